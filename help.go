@@ -0,0 +1,349 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// command is a subcommand as listed in the help and the man page
+type command struct {
+	name    string
+	summary string
+}
+
+// commands lists the subcommands in the order the help shows them
+var commands = []command{
+	{"generate", "Generate videos; the same as the top-level -p, -template, and -f flags"},
+	{"remix", "Change a completed Sora video with a new prompt"},
+	{"storyboard", "Generate a video per scene of a script or storyboard, optionally stitched together"},
+	{"compare", "Generate two variants of a prompt side by side"},
+	{"run", "Run a workflow file of generation and post-processing steps"},
+	{"list", "List videos on the service"},
+	{"status", "Show the request quota and jobs rendering now"},
+	{"download", "Download a video, waiting for it if it is still rendering"},
+	{"delete", "Delete videos from the service"},
+	{"history", "List past jobs from the local ledger (history export, history tree)"},
+	{"templates", "List the prompt templates in templates.toml and the project config"},
+	{"cost", "Report estimated spend per month"},
+	{"stats", "Summarize jobs, success rates, and render times"},
+	{"mcp", "Serve video generation to AI agents over the Model Context Protocol"},
+	{"help", "Show help for video-gen or one of its commands"},
+	{"man", "Print the man page"},
+}
+
+// flagGroup is a heading the help lists related flags under
+type flagGroup struct {
+	title string
+	flags []string
+}
+
+// flagGroups orders the flags in the help; flags of a subcommand that are in
+// no group are listed first, under "Flags"
+var flagGroups = []flagGroup{
+	{"Modes", []string{"p", "template", "f", "resume", "remix", "revise", "count", "concurrency", "json"}},
+	{"Video", []string{"m", "t", "s", "r", "crop-anchor", "continue-from", "ref-prompt", "style", "negative"}},
+	{"Prompt and review", []string{"var", "vars", "enhance-prompt", "strict", "auto-review", "review-attempts"}},
+	{"Output", []string{"o", "name-template", "thumbnail", "spritesheet", "postprocess", "on-complete", "keep-remote"}},
+	{"Providers and accounts", []string{"provider", "fallback", "fallback-model", "api-key", "base-url", "org", "project", "profile", "config", "force"}},
+	{"Waiting for jobs", []string{"poll-interval", "max-polls", "timeout", "webhook-port"}},
+	{"Debugging and testing", []string{"d", "debug-log", "record", "replay", "simulate"}},
+}
+
+// helpNotes explain how the top-level flags interact
+var helpNotes = []string{
+	"Without -p, -template, -f, or -resume, video-gen opens the interactive TUI and the flags set its starting choices. Any of those four runs without the TUI and exits when the videos are saved.",
+	"-remix and -revise change the video or prompt of a past job and need -p. -count applies to a single prompt, not to -f, -remix, or -resume.",
+	"Flags override the environment, which overrides the config file. Settings not given anywhere use the defaults shown.",
+	"-json prints one JSON event per line on stdout and moves progress to stderr.",
+}
+
+// helpExamples are shown in the help and the man page, each with what it does
+var helpExamples = [][2]string{
+	{"video-gen", "Open the TUI"},
+	{`video-gen -p "A sunset over the ocean" -s portrait -t 8`, "Generate one 8-second portrait video"},
+	{`video-gen -p "A cat playing with yarn" -m sora-pro -r cat.jpg -o ~/Videos`, "Start from a reference image"},
+	{"video-gen -f prompts.txt -concurrency 3", "Generate every prompt in a file, three at a time"},
+	{`video-gen -p "Neon city at night" -count 4`, "Generate four takes of one prompt"},
+	{"video-gen -resume video_68d7...", "Download a job that was interrupted"},
+	{"video-gen help generate", "Show the flags of the generate command"},
+}
+
+// helpEnvironment lists the environment variables video-gen reads
+var helpEnvironment = [][2]string{
+	{"OPENAI_API_KEY", "OpenAI API key, used when -api-key is not given"},
+	{"OPENAI_BASE_URL", "OpenAI-compatible API base URL"},
+	{"OPENAI_ORG_ID", "OpenAI organization to bill"},
+	{"OPENAI_PROJECT_ID", "OpenAI project to bill"},
+	{"AZURE_OPENAI_API_KEY", "API key for Azure OpenAI, when the config has an [azure] table"},
+	{"XDG_CONFIG_HOME", "Where the config file and its directory are (default ~/.config; ~/Library/Application Support on macOS, %AppData% on Windows)"},
+	{"XDG_DATA_HOME", "Where the job ledger is kept (default ~/.local/share; ~/Library/Application Support on macOS, %LocalAppData% on Windows)"},
+}
+
+// helpFiles lists the files video-gen reads and writes
+var helpFiles = [][2]string{
+	{"~/.config/telemetryos-video-gen.toml", "Config file (see example.toml), unless -config names another"},
+	{"~/.config/telemetryos-video-gen/templates.toml", "Named prompt templates"},
+	{"~/.config/telemetryos-video-gen/styles.toml", "User style presets"},
+	{"~/.config/telemetryos-video-gen/wildcards/", "Wildcard lists for __name__ in prompts"},
+	{".video-gen.toml", "Project config, in the working directory or a parent, overriding the config file"},
+	{"~/.local/share/video-gen/history.json", "Ledger of every job"},
+}
+
+// helpExitCodes lists the exit codes of non-interactive runs
+var helpExitCodes = [][2]string{
+	{"0", "Success"},
+	{"1", "Any other failure"},
+	{"2", "Invalid flags, settings, templates, or input files"},
+	{"3", "Missing or rejected API key"},
+	{"4", "Prompt or video refused by moderation"},
+	{"5", "The job did not finish in time"},
+	{"6", "The job finished but the video could not be saved"},
+	{"7", "The job would pass a budget limit"},
+	{"130", "Interrupted while jobs were rendering"},
+}
+
+// helpFlag is a flag as the help lists it, with its aliases
+type helpFlag struct {
+	names    []string
+	arg      string
+	usage    string
+	defValue string
+}
+
+// helpSection is a titled list of flags
+type helpSection struct {
+	title string
+	flags []helpFlag
+}
+
+// flagSections groups the flags of fs for the help. Flags whose usage is
+// "Alias for -x" are listed with -x rather than on their own.
+func flagSections(fs *flag.FlagSet) []helpSection {
+	aliases := make(map[string][]string)
+	fs.VisitAll(func(f *flag.Flag) {
+		if target, ok := strings.CutPrefix(f.Usage, "Alias for -"); ok {
+			aliases[target] = append(aliases[target], f.Name)
+		}
+	})
+
+	grouped := make(map[string]bool)
+	for _, group := range flagGroups {
+		for _, name := range group.flags {
+			grouped[name] = true
+		}
+	}
+
+	describe := func(f *flag.Flag) helpFlag {
+		arg, usage := flag.UnquoteUsage(f)
+		hf := helpFlag{names: append([]string{f.Name}, aliases[f.Name]...), arg: arg, usage: usage}
+		switch f.DefValue {
+		case "", "0", "false", "0s":
+		default:
+			hf.defValue = f.DefValue
+		}
+		return hf
+	}
+
+	var sections []helpSection
+	var own []helpFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		if !grouped[f.Name] && !strings.HasPrefix(f.Usage, "Alias for -") {
+			own = append(own, describe(f))
+		}
+	})
+	if len(own) > 0 {
+		sections = append(sections, helpSection{"Flags", own})
+	}
+	for _, group := range flagGroups {
+		var flags []helpFlag
+		for _, name := range group.flags {
+			if f := fs.Lookup(name); f != nil {
+				flags = append(flags, describe(f))
+			}
+		}
+		if len(flags) > 0 {
+			sections = append(sections, helpSection{group.title, flags})
+		}
+	}
+	return sections
+}
+
+// term formats a flag's names and argument, e.g. "-f, -batch string"
+func (f helpFlag) term() string {
+	names := make([]string, len(f.names))
+	for i, name := range f.names {
+		names[i] = "-" + name
+	}
+	term := strings.Join(names, ", ")
+	if f.arg != "" {
+		term += " " + f.arg
+	}
+	return term
+}
+
+// printFlags writes the grouped flags of fs
+func printFlags(w io.Writer, fs *flag.FlagSet) {
+	for _, section := range flagSections(fs) {
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, f := range section.flags {
+			fmt.Fprintf(w, "  %s\n", f.term())
+			usage := f.usage
+			if f.defValue != "" {
+				usage += fmt.Sprintf(" (default %s)", f.defValue)
+			}
+			fmt.Fprintf(w, "      %s\n", usage)
+		}
+	}
+}
+
+// printPairs writes a titled two-column list
+func printPairs(w io.Writer, title string, pairs [][2]string) {
+	width := 0
+	for _, pair := range pairs {
+		width = max(width, len(pair[0]))
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, pair := range pairs {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, pair[0], pair[1])
+	}
+}
+
+// printUsage writes the top-level help: how video-gen is run, its commands,
+// its flags by group, and examples
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "video-gen generates videos with OpenAI Sora and Runway, in a TUI or from scripts.\n\n")
+	fmt.Fprintf(w, "Usage:\n")
+	fmt.Fprintf(w, "  video-gen [flags]                  Open the interactive TUI\n")
+	fmt.Fprintf(w, "  video-gen -p <prompt> [flags]      Generate a video and exit\n")
+	fmt.Fprintf(w, "  video-gen <command> [flags] [args]\n")
+	fmt.Fprintf(w, "  video-gen help <command>           Show a command's flags\n")
+	for _, note := range helpNotes {
+		fmt.Fprintf(w, "\n%s\n", wrap(note, 80))
+	}
+
+	var pairs [][2]string
+	for _, c := range commands {
+		pairs = append(pairs, [2]string{c.name, c.summary})
+	}
+	printPairs(w, "Commands", pairs)
+	printFlags(w, fs)
+	printPairs(w, "Examples", helpExamples)
+	printPairs(w, "Environment", helpEnvironment)
+	printPairs(w, "Files", helpFiles)
+}
+
+// wrap breaks text into lines of at most width characters at spaces
+func wrap(text string, width int) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
+// commandSummary returns the help summary of a subcommand
+func commandSummary(name string) string {
+	for _, c := range commands {
+		if c.name == name {
+			return c.summary
+		}
+	}
+	return ""
+}
+
+// runHelp prints the help of a subcommand, e.g. "help history export", by
+// running it with -h. "help" alone is handled in main, which defines the
+// top-level flags.
+func runHelp(args []string) {
+	if len(args) == 0 {
+		return
+	}
+	run, ok := subcommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q; run 'video-gen help' for the list\n", args[0])
+		os.Exit(2)
+	}
+	run(append(args[1:], "-h"))
+}
+
+// runMan prints a man page for video-gen, built from the same commands and
+// flags as the help
+func runMan(args []string) {
+	fs := newSubcommandFlags("man", "man > video-gen.1")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	writeManPage(os.Stdout, flag.CommandLine)
+}
+
+// writeManPage writes the man page in roff
+func writeManPage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, ".TH VIDEO-GEN 1 %q \"video-gen %s\" \"User Commands\"\n", time.Now().Format("2006-01-02"), version)
+	fmt.Fprintf(w, ".SH NAME\nvideo-gen \\- generate videos with OpenAI Sora and Runway\n")
+	fmt.Fprintf(w, ".SH SYNOPSIS\n.B video-gen\n[\\fIflags\\fR]\n.br\n.B video-gen\n\\fB\\-p\\fR \\fIprompt\\fR [\\fIflags\\fR]\n.br\n.B video-gen\n\\fIcommand\\fR [\\fIflags\\fR] [\\fIargs\\fR]\n")
+	fmt.Fprintf(w, ".SH DESCRIPTION\n")
+	for i, note := range helpNotes {
+		if i > 0 {
+			fmt.Fprintf(w, ".PP\n")
+		}
+		fmt.Fprintf(w, "%s\n", roff(note))
+	}
+
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, ".TP\n\\fB%s\\fR\n%s\n", roff(c.name), roff(c.summary))
+	}
+	fmt.Fprintf(w, ".PP\nRun \\fBvideo-gen help\\fR \\fIcommand\\fR for the flags of a command.\n")
+
+	fmt.Fprintf(w, ".SH OPTIONS\n")
+	for _, section := range flagSections(fs) {
+		fmt.Fprintf(w, ".SS %s\n", roff(section.title))
+		for _, f := range section.flags {
+			usage := f.usage
+			if f.defValue != "" {
+				usage += fmt.Sprintf(" (default %s)", f.defValue)
+			}
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR\n%s\n", roff(f.term()), roff(usage))
+		}
+	}
+
+	sections := []struct {
+		title string
+		pairs [][2]string
+	}{
+		{"ENVIRONMENT", helpEnvironment},
+		{"FILES", helpFiles},
+		{"EXIT STATUS", helpExitCodes},
+		{"EXAMPLES", helpExamples},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(w, ".SH %s\n", section.title)
+		for _, pair := range section.pairs {
+			fmt.Fprintf(w, ".TP\n\\fB%s\\fR\n%s\n", roff(pair[0]), roff(pair[1]))
+		}
+	}
+}
+
+// roff escapes text for a man page: hyphens and backslashes, and a leading
+// dot or quote that would start a request
+func roff(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
@@ -0,0 +1,380 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Model is a video model and what it can render. Menus and validation read
+// the models from Models, so supporting a new model, size, or duration is a
+// change to the capabilities table alone.
+type Model struct {
+	Name        string   // Model ID sent to the provider
+	Aliases     []string // Short names -m accepts
+	Provider    string   // "sora" or "runway"
+	Description string   // One line for menus
+	Sizes       []string // Resolutions it renders, the default first
+	Durations   []string // Clip lengths in seconds, shortest (the default) first
+}
+
+// capabilities lists the models of every provider, the default model of each provider first
+var capabilities = []Model{
+	{
+		Name:        "sora-2",
+		Aliases:     []string{"sora"},
+		Provider:    "sora",
+		Description: "Fast generation, good quality",
+		Sizes:       []string{"1280x720", "720x1280"},
+		Durations:   []string{"4", "8", "12"},
+	},
+	{
+		Name:        "sora-2-pro",
+		Aliases:     []string{"sora-pro"},
+		Provider:    "sora",
+		Description: "Superior quality, slower",
+		Sizes:       []string{"1280x720", "720x1280", "1792x1024", "1024x1792"},
+		Durations:   []string{"4", "8", "12"},
+	},
+	{
+		Name:        "gen3a_turbo",
+		Aliases:     []string{"gen3", "gen3-turbo"},
+		Provider:    "runway",
+		Description: "Runway Gen-3 Alpha Turbo",
+		Sizes:       []string{"1280x720", "720x1280"},
+		Durations:   []string{"5", "10"},
+	},
+	{
+		Name:        "gen4_turbo",
+		Aliases:     []string{"gen4", "gen4-turbo"},
+		Provider:    "runway",
+		Description: "Runway Gen-4 Turbo",
+		Sizes:       []string{"1280x720", "720x1280"},
+		Durations:   []string{"5", "10"},
+	},
+}
+
+// sizeLabels describes the sizes in menus
+var sizeLabels = map[string]string{
+	"1280x720":  "Landscape (HD)",
+	"720x1280":  "Portrait (HD)",
+	"1792x1024": "Landscape (Wide)",
+	"1024x1792": "Portrait (Wide)",
+}
+
+// sizeAliases are the names accepted in place of pixel sizes, in the order
+// they are listed
+var sizeAliases = []struct{ alias, size string }{
+	{"landscape", "1280x720"},
+	{"16:9", "1280x720"},
+	{"portrait", "720x1280"},
+	{"9:16", "720x1280"},
+	{"wide", "1792x1024"},
+	{"tall", "1024x1792"},
+}
+
+// Models returns the models of a provider, its default first
+func Models(provider string) []Model {
+	var models []Model
+	for _, model := range capabilities {
+		if model.Provider == provider {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// DefaultModel returns the model a provider uses when none is chosen
+func DefaultModel(provider string) Model {
+	return Models(provider)[0]
+}
+
+// LookupModel returns the model with the given ID or alias
+func LookupModel(name string) (Model, bool) {
+	for _, model := range capabilities {
+		if model.Name == name {
+			return model, true
+		}
+		for _, alias := range model.Aliases {
+			if alias == name {
+				return model, true
+			}
+		}
+	}
+	return Model{}, false
+}
+
+// ModelName expands a model alias such as "sora-pro" to the model's ID,
+// returning names it does not know unchanged
+func ModelName(name string) string {
+	if model, ok := LookupModel(name); ok {
+		return model.Name
+	}
+	return name
+}
+
+// ModelSizes returns the sizes a model renders; sora-2-pro adds the wide sizes.
+// Unknown models get the sizes of sora-2.
+func ModelSizes(model string) []string {
+	if m, ok := LookupModel(model); ok {
+		return m.Sizes
+	}
+	return DefaultModel("sora").Sizes
+}
+
+// ModelDurations returns the clip lengths a model renders, in seconds.
+// Unknown models get the durations of sora-2.
+func ModelDurations(model string) []string {
+	if m, ok := LookupModel(model); ok {
+		return m.Durations
+	}
+	return DefaultModel("sora").Durations
+}
+
+// SupportsDuration reports whether the model renders clips of seconds
+func (m Model) SupportsDuration(seconds string) bool {
+	return contains(m.Durations, seconds)
+}
+
+// SupportsSize reports whether the model renders size
+func (m Model) SupportsSize(size string) bool {
+	return contains(m.Sizes, size)
+}
+
+// CheckDuration returns an error naming the supported durations when the
+// model does not render clips of seconds
+func (m Model) CheckDuration(seconds string) error {
+	if m.SupportsDuration(seconds) {
+		return nil
+	}
+	return fmt.Errorf("invalid duration '%s'. %s supports %s", seconds, m.Name, quoteList(m.Durations))
+}
+
+// CheckModel returns an error for a model name the provider does not offer,
+// suggesting the name that was probably meant
+func CheckModel(name, provider string) error {
+	offered := Models(provider)
+	var names []string
+	for _, model := range offered {
+		names = append(names, model.Name)
+	}
+	if model, ok := LookupModel(name); ok {
+		if model.Provider == provider {
+			return nil
+		}
+		return fmt.Errorf("model '%s' is a %s model. %s supports %s", name, model.Provider, provider, quoteList(names))
+	}
+
+	message := fmt.Sprintf("unknown model '%s'", name)
+	candidates := names
+	for _, model := range offered {
+		candidates = append(candidates, model.Aliases...)
+	}
+	if suggestion := ClosestName(strings.ToLower(name), candidates); suggestion != "" {
+		return fmt.Errorf("%s. Did you mean '%s'? %s supports %s", message, suggestion, provider, quoteList(names))
+	}
+	return fmt.Errorf("%s. %s supports %s", message, provider, quoteList(names))
+}
+
+// CheckSize returns an error naming the supported sizes when the model does
+// not render size, with the size that was probably meant: 1280×720 and
+// 720p suggest 1280x720, and a size only a sibling model renders names it
+func (m Model) CheckSize(size string) error {
+	if m.SupportsSize(size) {
+		return nil
+	}
+	message := fmt.Sprintf("invalid size '%s'", size)
+	if sibling, ok := m.siblingWithSize(size); ok {
+		message = fmt.Sprintf("size '%s' needs %s", size, sibling.Name)
+	} else if suggestion := m.suggestSize(size); suggestion != "" {
+		return fmt.Errorf("%s. Did you mean '%s'? %s supports %s", message, suggestion, m.Name, quoteList(m.Sizes))
+	}
+	return fmt.Errorf("%s. %s supports %s", message, m.Name, quoteList(m.Sizes))
+}
+
+// siblingWithSize returns another model of the same provider that renders size
+func (m Model) siblingWithSize(size string) (Model, bool) {
+	for _, model := range Models(m.Provider) {
+		if model.Name != m.Name && model.SupportsSize(size) {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
+
+// suggestSize returns the supported size closest to a mistyped one, or ""
+// when nothing is close
+func (m Model) suggestSize(size string) string {
+	normalized := strings.ToLower(strings.TrimSpace(size))
+	normalized = strings.NewReplacer("×", "x", "*", "x", " ", "").Replace(normalized)
+	if m.SupportsSize(normalized) {
+		return normalized
+	}
+
+	// 720p names the shorter side of a landscape video
+	if lines, err := strconv.Atoi(strings.TrimSuffix(normalized, "p")); err == nil && strings.HasSuffix(normalized, "p") {
+		best, bestDistance := "", math.MaxInt
+		for _, candidate := range m.Sizes {
+			width, height, _ := parseSize(candidate)
+			if height > width {
+				continue
+			}
+			if distance := abs(height - lines); distance < bestDistance {
+				best, bestDistance = candidate, distance
+			}
+		}
+		return best
+	}
+
+	// Other pixel sizes get the supported size of the same orientation with the nearest shape
+	if width, height, err := parseSize(normalized); err == nil && width > 0 && height > 0 {
+		ratio := float64(width) / float64(height)
+		best, bestDistance := "", math.Inf(1)
+		for _, candidate := range m.Sizes {
+			w, h, _ := parseSize(candidate)
+			if (h > w) != (height > width) {
+				continue
+			}
+			if distance := math.Abs(float64(w)/float64(h) - ratio); distance < bestDistance {
+				best, bestDistance = candidate, distance
+			}
+		}
+		return best
+	}
+
+	return ClosestName(normalized, m.Sizes)
+}
+
+// ClosestName returns the candidate within two edits of name, or "", for
+// did-you-mean suggestions
+func ClosestName(name string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ClosestDuration returns the duration the model supports nearest to seconds,
+// the shorter one on a tie, or the model's default when seconds is not a number
+func (m Model) ClosestDuration(seconds string) string {
+	want, err := strconv.Atoi(seconds)
+	if err != nil {
+		return m.Durations[0]
+	}
+	best, bestDistance := m.Durations[0], math.MaxInt
+	for _, duration := range m.Durations {
+		n, _ := strconv.Atoi(duration)
+		distance := abs(n - want)
+		// Durations are listed shortest first, so ties keep the shorter one
+		if distance < bestDistance {
+			best, bestDistance = duration, distance
+		}
+	}
+	return best
+}
+
+// SizeLabel describes a size in menus, e.g. "Landscape (HD)"
+func SizeLabel(size string) string {
+	if label, ok := sizeLabels[size]; ok {
+		return label
+	}
+	width, height, err := parseSize(size)
+	if err != nil {
+		return ""
+	}
+	if height > width {
+		return "Portrait"
+	}
+	return "Landscape"
+}
+
+// ResolveSize expands a size alias such as "portrait" or "16:9" to its
+// pixel size, returning other sizes unchanged
+func ResolveSize(size string) string {
+	name := strings.ToLower(strings.TrimSpace(size))
+	for _, alias := range sizeAliases {
+		if alias.alias == name {
+			return alias.size
+		}
+	}
+	return size
+}
+
+// SizeAliases returns the aliases of a pixel size, e.g. "landscape" and
+// "16:9" for 1280x720
+func SizeAliases(size string) []string {
+	var aliases []string
+	for _, alias := range sizeAliases {
+		if alias.size == size {
+			aliases = append(aliases, alias.alias)
+		}
+	}
+	return aliases
+}
+
+// SizeAliasNames returns every size alias, in the order they are listed
+func SizeAliasNames() []string {
+	names := make([]string, len(sizeAliases))
+	for i, alias := range sizeAliases {
+		names[i] = alias.alias
+	}
+	return names
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteList formats values as 'a', 'b', and 'c'
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + value + "'"
+	}
+	switch len(quoted) {
+	case 1:
+		return quoted[0]
+	case 2:
+		return quoted[0] + " and " + quoted[1]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", and " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     string
+		provider string
+	}{
+		{"sora", "sora-2", "sora"},
+		{"sora-pro", "sora-2-pro", "sora"},
+		{"sora-2-pro", "sora-2-pro", "sora"},
+		{"gen4", "gen4_turbo", "runway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, ok := LookupModel(tt.name)
+			if !ok {
+				t.Fatalf("LookupModel(%q) found nothing", tt.name)
+			}
+			if model.Name != tt.want || model.Provider != tt.provider {
+				t.Errorf("LookupModel(%q) = %s (%s), want %s (%s)", tt.name, model.Name, model.Provider, tt.want, tt.provider)
+			}
+		})
+	}
+
+	if _, ok := LookupModel("sora-3"); ok {
+		t.Error("LookupModel(\"sora-3\") found a model")
+	}
+	if got := ModelName("sora-3"); got != "sora-3" {
+		t.Errorf("ModelName(\"sora-3\") = %q, want it unchanged", got)
+	}
+}
+
+func TestClosestDuration(t *testing.T) {
+	sora, runway := DefaultModel("sora"), DefaultModel("runway")
+	tests := []struct {
+		model   Model
+		seconds string
+		want    string
+	}{
+		{sora, "8", "8"},
+		{sora, "5", "4"},
+		{sora, "10", "8"},
+		{sora, "", "4"},
+		{runway, "4", "5"},
+		{runway, "8", "10"},
+		{runway, "12", "10"},
+	}
+	for _, tt := range tests {
+		if got := tt.model.ClosestDuration(tt.seconds); got != tt.want {
+			t.Errorf("%s ClosestDuration(%q) = %q, want %q", tt.model.Name, tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   string
+		size    string
+		wantErr string
+	}{
+		{"supported", "sora-2", "720x1280", ""},
+		{"unicode times", "sora-2", "1280×720", "Did you mean '1280x720'?"},
+		{"capital X", "sora-2", "720X1280", "Did you mean '720x1280'?"},
+		{"720p", "sora-2", "720p", "Did you mean '1280x720'?"},
+		{"1080p on pro", "sora-2-pro", "1080p", "Did you mean '1792x1024'?"},
+		{"same shape", "sora-2", "1920x1080", "Did you mean '1280x720'?"},
+		{"typo", "sora-2", "1280x72", "Did you mean '1280x720'?"},
+		{"pro only", "sora-2", "1792x1024", "needs sora-2-pro"},
+		{"nonsense", "sora-2", "huge", "sora-2 supports '1280x720' and '720x1280'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, _ := LookupModel(tt.model)
+			err := model.CheckSize(tt.size)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckSize(%q) error: %v", tt.size, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckSize(%q) error = %v, want one containing %q", tt.size, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		wantErr  string
+	}{
+		{"sora-pro", "sora", ""},
+		{"gen3", "runway", ""},
+		{"sora2", "sora", "Did you mean 'sora-2'?"},
+		{"Sora-Pro", "sora", "Did you mean 'sora-pro'?"},
+		{"gen4", "sora", "is a runway model"},
+		{"veo", "sora", "sora supports 'sora-2' and 'sora-2-pro'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckModel(tt.name, tt.provider)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckModel(%q) error: %v", tt.name, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckModel(%q) error = %v, want one containing %q", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveSize(t *testing.T) {
+	for size, want := range map[string]string{
+		"landscape": "1280x720",
+		"16:9":      "1280x720",
+		"Portrait":  "720x1280",
+		"9:16":      "720x1280",
+		"wide":      "1792x1024",
+		"tall":      "1024x1792",
+		"1280x720":  "1280x720",
+		"match":     "match",
+	} {
+		if got := ResolveSize(size); got != want {
+			t.Errorf("ResolveSize(%q) = %s, want %s", size, got, want)
+		}
+	}
+}
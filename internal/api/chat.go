@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	chatEndpoint = "/chat/completions"
+	enhanceModel = "gpt-4o-mini"
+)
+
+// enhanceSystemPrompt instructs the chat model to rewrite terse prompts into detailed video prompts
+const enhanceSystemPrompt = `You are an expert prompt engineer for text-to-video models such as OpenAI Sora.
+Rewrite the user's video idea into a single, vivid prompt that a video model can follow.
+Describe the subject and action, the setting, the shot type and framing, the camera movement,
+the lighting and time of day, and the overall mood or visual style.
+Keep every element of the original idea, do not add on-screen text, and do not exceed 120 words.
+Reply with the rewritten prompt only, without quotes, headings, or commentary.`
+
+// splitSystemPrompt instructs the chat model to break a script into shots
+const splitSystemPrompt = `You are a storyboard artist preparing a script for a text-to-video model.
+Split the user's script into an ordered list of scenes, one continuous shot per scene.
+Write each scene as a self-contained video prompt describing the subject, action, and setting,
+so it can be generated without reading the other scenes.
+Reply with a JSON object of the form {"scenes": ["...", "..."]} and nothing else.`
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// EnhancePrompt rewrites a terse prompt into a detailed video prompt using a chat model
+func (c *SoraClient) EnhancePrompt(prompt string) (string, error) {
+	req := chatRequest{
+		Model: c.deployment(enhanceModel),
+		Messages: []chatMessage{
+			{Role: "system", Content: enhanceSystemPrompt},
+			{Role: "user", Content: prompt},
+		},
+	}
+
+	var resp chatResponse
+	if err := c.postJSON(chatEndpoint, req, &resp); err != nil {
+		return "", fmt.Errorf("failed to enhance prompt: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("failed to enhance prompt: no response from %s", enhanceModel)
+	}
+
+	enhanced := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if enhanced == "" {
+		return "", fmt.Errorf("failed to enhance prompt: empty response from %s", enhanceModel)
+	}
+
+	return enhanced, nil
+}
+
+// SplitScript asks a chat model to split a script into per-scene video prompts
+func (c *SoraClient) SplitScript(script string) ([]string, error) {
+	req := chatRequest{
+		Model: c.deployment(enhanceModel),
+		Messages: []chatMessage{
+			{Role: "system", Content: splitSystemPrompt},
+			{Role: "user", Content: script},
+		},
+		ResponseFormat: &responseFormat{Type: "json_object"},
+	}
+
+	var resp chatResponse
+	if err := c.postJSON(chatEndpoint, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to split script: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("failed to split script: no response from %s", enhanceModel)
+	}
+
+	var result struct {
+		Scenes []string `json:"scenes"`
+	}
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse scenes: %w", err)
+	}
+
+	var scenes []string
+	for _, scene := range result.Scenes {
+		if scene = strings.TrimSpace(scene); scene != "" {
+			scenes = append(scenes, scene)
+		}
+	}
+	if len(scenes) == 0 {
+		return nil, fmt.Errorf("failed to split script: no scenes returned")
+	}
+
+	return scenes, nil
+}
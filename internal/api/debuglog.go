@@ -0,0 +1,297 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxDebugBody is the largest request or response body written to the debug log
+	maxDebugBody = 64 << 10
+	// debugLogEntries is how many recent entries DebugLog keeps for the TUI debug pane
+	debugLogEntries = 50
+	// redacted replaces credentials in the debug log
+	redacted = "[REDACTED]"
+)
+
+// secretHeaders are the request and response headers that carry credentials
+var secretHeaders = map[string]bool{
+	"Authorization": true,
+	"Api-Key":       true,
+	"X-Api-Key":     true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// DebugEntry is one API call in the debug log. Bodies are JSON as sent or
+// received, or a JSON string describing bodies that are not logged.
+type DebugEntry struct {
+	Time           time.Time         `json:"time"`
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	RequestHeader  map[string]string `json:"request_header,omitempty"`
+	RequestBody    json.RawMessage   `json:"request_body,omitempty"`
+	Status         int               `json:"status,omitempty"`
+	ResponseHeader map[string]string `json:"response_header,omitempty"`
+	ResponseBody   json.RawMessage   `json:"response_body,omitempty"`
+	DurationMS     int64             `json:"duration_ms"` // Time until the response was read or closed
+	Timing         CallTiming        `json:"timing"`
+	Error          string            `json:"error,omitempty"`
+}
+
+// DebugLog records every API call made through its transport as a line of
+// JSON, with credentials redacted, and keeps the latest entries in memory
+// along with timing statistics for the whole run
+type DebugLog struct {
+	mu      sync.Mutex
+	file    *os.File // nil keeps entries in memory only
+	secrets []string
+	recent  []DebugEntry
+	onEntry func(DebugEntry)
+	stats   callStats
+}
+
+// OpenDebugLog appends the log to the file at path, or keeps it in memory
+// only when path is empty
+func OpenDebugLog(path string) (*DebugLog, error) {
+	l := &DebugLog{}
+	if path == "" {
+		return l, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// Redact removes secrets, such as API keys, wherever they appear in logged
+// URLs and bodies. Credential headers are always redacted.
+func (l *DebugLog) Redact(secrets ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			l.secrets = append(l.secrets, secret)
+		}
+	}
+}
+
+// Recent returns up to n of the latest entries, oldest first
+func (l *DebugLog) Recent(n int) []DebugEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n > len(l.recent) {
+		n = len(l.recent)
+	}
+	return append([]DebugEntry(nil), l.recent[len(l.recent)-n:]...)
+}
+
+// OnEntry calls fn with each entry as it is logged
+func (l *DebugLog) OnEntry(fn func(DebugEntry)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onEntry = fn
+}
+
+// Close closes the log file
+func (l *DebugLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Transport returns a transport that logs each request sent with next, or
+// the default transport when next is nil
+func (l *DebugLog) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &debugTransport{log: l, next: next}
+}
+
+// add redacts entry, keeps it for Recent and the statistics, appends it to
+// the file, and passes it to the OnEntry callback
+func (l *DebugLog) add(entry DebugEntry) {
+	l.mu.Lock()
+
+	entry.URL = l.redact(entry.URL)
+	entry.RequestBody = json.RawMessage(l.redact(string(entry.RequestBody)))
+	entry.ResponseBody = json.RawMessage(l.redact(string(entry.ResponseBody)))
+	entry.Error = l.redact(entry.Error)
+
+	l.recent = append(l.recent, entry)
+	if len(l.recent) > debugLogEntries {
+		l.recent = l.recent[len(l.recent)-debugLogEntries:]
+	}
+	if l.file != nil {
+		// Logging must never break the request, so write errors are dropped
+		encoder := json.NewEncoder(l.file)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(entry)
+	}
+	l.stats.add(entry)
+	onEntry := l.onEntry
+	l.mu.Unlock()
+
+	if onEntry != nil {
+		onEntry(entry)
+	}
+}
+
+// redact replaces the registered secrets in text. Callers hold l.mu.
+func (l *DebugLog) redact(text string) string {
+	for _, secret := range l.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	return text
+}
+
+// debugTransport logs requests and responses to a DebugLog
+type debugTransport struct {
+	log  *DebugLog
+	next http.RoundTripper
+}
+
+// RoundTrip logs the call once its response has been read or closed, so the
+// entry includes the full timing
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	entry := DebugEntry{
+		Time:          time.Now(),
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: debugHeaders(req.Header),
+		RequestBody:   requestBody(req),
+	}
+	req, trace := traceRequest(req)
+	finish := func() {
+		entry.Timing = trace.finish()
+		entry.DurationMS = entry.Timing.Total.Milliseconds()
+		t.log.add(entry)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		entry.Error = err.Error()
+		finish()
+		return nil, err
+	}
+
+	entry.Status = resp.StatusCode
+	entry.ResponseHeader = debugHeaders(resp.Header)
+	entry.ResponseBody = responseBody(resp)
+	resp.Body = &tracedBody{ReadCloser: resp.Body, done: finish}
+	return resp, nil
+}
+
+// debugHeaders flattens headers for the log with credentials redacted
+func debugHeaders(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		if secretHeaders[http.CanonicalHeaderKey(name)] {
+			flat[name] = redacted
+			continue
+		}
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// requestBody returns a copy of the request body for the log without
+// consuming it. Streamed bodies, such as reference uploads, are described
+// instead of copied.
+func requestBody(req *http.Request) json.RawMessage {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	contentType := req.Header.Get("Content-Type")
+	if req.GetBody == nil || !isTextBody(contentType) || req.ContentLength > maxDebugBody {
+		return describeBody(contentType, req.ContentLength)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return describeBody(contentType, req.ContentLength)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return describeBody(contentType, req.ContentLength)
+	}
+	return debugBody(data, contentType)
+}
+
+// responseBody returns the start of the response body for the log and puts
+// what it read back in front of the rest, so the caller still sees all of it
+func responseBody(resp *http.Response) json.RawMessage {
+	contentType := resp.Header.Get("Content-Type")
+	if !isTextBody(contentType) || resp.ContentLength > maxDebugBody {
+		return describeBody(contentType, resp.ContentLength)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDebugBody+1))
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	if err != nil || len(data) > maxDebugBody {
+		return describeBody(contentType, resp.ContentLength)
+	}
+	return debugBody(data, contentType)
+}
+
+// readCloser reads from a reader and closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// isTextBody reports whether a body of contentType is worth logging
+func isTextBody(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "" || strings.HasSuffix(mediaType, "json") || strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// debugBody logs JSON bodies as they are, other text as a string, and
+// binary data as a description
+func debugBody(data []byte, contentType string) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return describeBody(contentType, int64(len(data)))
+	}
+	if json.Valid(data) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, data); err == nil {
+			return compact.Bytes()
+		}
+	}
+	encoded, _ := json.Marshal(string(data))
+	return encoded
+}
+
+// describeBody stands in for a body that is not logged
+func describeBody(contentType string, size int64) json.RawMessage {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = "binary"
+	}
+	description := fmt.Sprintf("[%s body]", mediaType)
+	if size > 0 {
+		description = fmt.Sprintf("[%s body, %d bytes]", mediaType, size)
+	}
+	encoded, _ := json.Marshal(description)
+	return encoded
+}
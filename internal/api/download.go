@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrIncompleteDownload is wrapped by the errors for downloads that are empty,
+// truncated, or not a complete MP4 file
+var ErrIncompleteDownload = errors.New("incomplete download")
+
+// ProgressFunc reports download or upload progress. total is -1 when the
+// server does not send a Content-Length.
+type ProgressFunc func(written, total int64)
+
+// progressReader calls fn after every read from r
+type progressReader struct {
+	r       io.Reader
+	written int64
+	total   int64
+	fn      ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.written += int64(n)
+		p.fn(p.written, p.total)
+	}
+	return n, err
+}
+
+// saveContent streams body to outputPath, creating the output directory if
+// needed and reporting progress when progress is not nil. An empty body, or
+// one shorter or longer than the total the server announced, is an error
+// and leaves no file behind.
+func saveContent(body io.Reader, total int64, outputPath string, progress ProgressFunc) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if progress != nil {
+		body = &progressReader{r: body, total: total, fn: progress}
+	}
+	written, err := io.Copy(out, body)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	switch {
+	case err != nil:
+		err = fmt.Errorf("failed to write video data: %w", err)
+	case written == 0:
+		err = fmt.Errorf("%w: the server sent no data", ErrIncompleteDownload)
+	case total >= 0 && written != total:
+		err = fmt.Errorf("%w: received %d of %d bytes", ErrIncompleteDownload, written, total)
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
+
+// saveVideo saves an MP4 like saveContent, then checks that it is a
+// complete MP4 file, removing it when it is not
+func saveVideo(body io.Reader, total int64, outputPath string, progress ProgressFunc) error {
+	if err := saveContent(body, total, outputPath, progress); err != nil {
+		return err
+	}
+	if err := ValidateMP4(outputPath); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrContentNotReady is wrapped by download errors for a finished job whose
+// video the provider does not serve yet
+var ErrContentNotReady = errors.New("video content not ready")
+
+// Reasons a key check fails, told apart so the fix can be named
+var (
+	ErrInvalidKey     = errors.New("the API key is invalid or has been revoked; create one at https://platform.openai.com/api-keys")
+	ErrNoSoraAccess   = errors.New("the API key works, but its project does not have access to Sora; check the project's model access and usage tier")
+	ErrOrgNotVerified = errors.New("the organization must be verified to use Sora; verify it at https://platform.openai.com/settings/organization/general")
+)
+
+// contentPolicyCodes are error codes OpenAI uses when a prompt, reference, or
+// output is refused by its safety systems
+var contentPolicyCodes = map[string]bool{
+	"moderation_blocked":       true,
+	"content_policy_violation": true,
+	"content_filter":           true,
+}
+
+// quotaCodes are error codes OpenAI uses when the account's quota or billing
+// limit is used up
+var quotaCodes = map[string]bool{
+	"insufficient_quota":         true,
+	"billing_hard_limit_reached": true,
+}
+
+// StatusCode returns the HTTP status of an API error, or 0 when err did not
+// come from an API response
+func StatusCode(err error) int {
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		return httpErr.statusCode
+	}
+	return 0
+}
+
+// IsTransient reports whether a request failed in a way that may not happen
+// again: a rate limit, a server error, or the network, rather than a refusal
+// of the request itself
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if status := StatusCode(err); status != 0 {
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// IsContentPolicy reports whether an API error is a content policy rejection
+func IsContentPolicy(err error) bool {
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return IsContentPolicyError(&ErrorObject{Code: httpErr.code, Type: httpErr.errorType, Message: httpErr.message})
+}
+
+// IsContentPolicyError reports whether a job's error describes a content policy rejection
+func IsContentPolicyError(e *ErrorObject) bool {
+	if e == nil {
+		return false
+	}
+	if contentPolicyCodes[e.Code] || contentPolicyCodes[e.Type] {
+		return true
+	}
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "content policy") || strings.Contains(message, "moderation") || strings.Contains(message, "safety system")
+}
+
+// IsQuotaOrTier reports whether an API error refused a job because of the
+// account rather than the request: a used-up quota or billing limit, a rate
+// limit, or a usage tier without access to the model
+func IsQuotaOrTier(err error) bool {
+	var httpErr *httpError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	if quotaCodes[httpErr.code] || quotaCodes[httpErr.errorType] {
+		return true
+	}
+	if httpErr.statusCode == http.StatusTooManyRequests || httpErr.statusCode == http.StatusForbidden {
+		return true
+	}
+	message := strings.ToLower(httpErr.message)
+	return strings.Contains(message, "quota") || strings.Contains(message, "usage tier")
+}
+
+// IsKeyError reports whether a key check failed because of the key, its
+// project, or its organization, rather than the network
+func IsKeyError(err error) bool {
+	return errors.Is(err, ErrInvalidKey) || errors.Is(err, ErrNoSoraAccess) || errors.Is(err, ErrOrgNotVerified)
+}
+
+// keyError wraps the response to a key check in the reason it failed, when
+// the response says
+func keyError(e *httpError) error {
+	message := strings.ToLower(e.message)
+	switch {
+	case e.statusCode == http.StatusUnauthorized || e.code == "invalid_api_key":
+		return fmt.Errorf("%w (%w)", ErrInvalidKey, e)
+	case strings.Contains(message, "must be verified") || strings.Contains(message, "organization verification"):
+		return fmt.Errorf("%w (%w)", ErrOrgNotVerified, e)
+	case e.statusCode == http.StatusForbidden || e.statusCode == http.StatusNotFound || e.code == "model_not_found" || strings.Contains(message, "does not have access"):
+		return fmt.Errorf("%w (%w)", ErrNoSoraAccess, e)
+	}
+	return e
+}
@@ -3,10 +3,31 @@ package api
 import (
 	"fmt"
 	"image"
+	"math"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+// videoReferenceTypes maps the video file extensions accepted as references to MIME types
+var videoReferenceTypes = map[string]string{
+	".mp4": "video/mp4",
+	".mov": "video/quicktime",
+}
+
+// videoReferenceType returns the MIME type of a video reference, or false for images
+func videoReferenceType(path string) (string, bool) {
+	contentType, ok := videoReferenceTypes[strings.ToLower(filepath.Ext(path))]
+	return contentType, ok
+}
+
+// IsVideoReference reports whether path is a video (mp4 or mov) rather than an image reference
+func IsVideoReference(path string) bool {
+	_, ok := videoReferenceType(path)
+	return ok
+}
+
 // parseSize parses a size string like "1280x720" into width and height
 func parseSize(size string) (int, int, error) {
 	parts := strings.Split(size, "x")
@@ -27,9 +48,137 @@ func parseSize(size string) (int, int, error) {
 	return width, height, nil
 }
 
+// CropAnchor positions the crop window when a reference image is cropped to
+// the video size. X and Y are the fractions (0-1) of the excess width and
+// height cut from the left and top, so {0.5, 0.5} keeps the center.
+type CropAnchor struct {
+	X, Y float64
+}
+
+// cropAnchors are the named crop anchors
+var cropAnchors = map[string]CropAnchor{
+	"center":       {0.5, 0.5},
+	"top":          {0.5, 0},
+	"bottom":       {0.5, 1},
+	"left":         {0, 0.5},
+	"right":        {1, 0.5},
+	"top-left":     {0, 0},
+	"top-right":    {1, 0},
+	"bottom-left":  {0, 1},
+	"bottom-right": {1, 1},
+}
+
+// ParseCropAnchor parses a crop anchor: center (the default when empty), top,
+// bottom, left, right, a corner such as top-left, or a custom "X,Y" offset of
+// fractions between 0 and 1
+func ParseCropAnchor(spec string) (CropAnchor, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" {
+		return cropAnchors["center"], nil
+	}
+	if anchor, ok := cropAnchors[spec]; ok {
+		return anchor, nil
+	}
+
+	parts := strings.Split(spec, ",")
+	if len(parts) == 2 {
+		x, errX := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		y, errY := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if errX == nil && errY == nil && x >= 0 && x <= 1 && y >= 0 && y <= 1 {
+			return CropAnchor{x, y}, nil
+		}
+	}
+	return CropAnchor{}, fmt.Errorf("invalid crop anchor %q (use center, top, bottom, left, right, top-left, top-right, bottom-left, bottom-right, or X,Y between 0 and 1)", spec)
+}
+
+// SizeMatchReference is the size (-s match) that picks the supported size
+// closest to the reference image's aspect ratio
+const SizeMatchReference = "match"
+
+// ReferenceCropAxis reports which edges of the reference image at path are
+// cropped to fit size: "x" for the sides, "y" for the top and bottom, or ""
+// when the aspect ratios match. Videos are never cropped.
+func ReferenceCropAxis(path, size string) (string, error) {
+	axis, _, err := ReferenceCrop(path, size)
+	return axis, err
+}
+
+// ReferenceCrop reports which edges of the reference image at path are
+// cropped to fit size, as ReferenceCropAxis does, and the fraction (0-1) of
+// the image's width or height that is cut away
+func ReferenceCrop(path, size string) (string, float64, error) {
+	if IsVideoReference(path) {
+		return "", 0, nil
+	}
+	width, height, err := parseSize(size)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid size format: %w", err)
+	}
+	source, err := referenceAspect(path)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Compare the scaled size with the target, allowing for rounding
+	target := float64(width) / float64(height)
+	switch {
+	case source > target*1.01:
+		return "x", 1 - target/source, nil
+	case source < target/1.01:
+		return "y", 1 - source/target, nil
+	}
+	return "", 0, nil
+}
+
+// MatchReferenceSize returns the size among sizes whose aspect ratio is
+// closest to that of the reference image at path, so the least is cropped
+func MatchReferenceSize(path string, sizes []string) (string, error) {
+	if IsVideoReference(path) {
+		return "", fmt.Errorf("-s %s needs an image reference; video references are not resized", SizeMatchReference)
+	}
+	source, err := referenceAspect(path)
+	if err != nil {
+		return "", err
+	}
+
+	best, bestDistance := "", math.Inf(1)
+	for _, size := range sizes {
+		width, height, err := parseSize(size)
+		if err != nil {
+			continue
+		}
+		// Ratios are compared on a log scale so 2:1 and 1:2 are equally far from 1:1
+		if distance := math.Abs(math.Log(source * float64(height) / float64(width))); distance < bestDistance {
+			best, bestDistance = size, distance
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no size to match the reference image against")
+	}
+	return best, nil
+}
+
+// referenceAspect returns the width-to-height ratio of the image at path
+func referenceAspect(path string) (float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open reference file: %w", err)
+	}
+	defer file.Close()
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Height == 0 {
+		return 0, fmt.Errorf("reference image has no height")
+	}
+	return float64(cfg.Width) / float64(cfg.Height), nil
+}
+
 // resizeAndCropToFill resizes and crops an image to fill the target dimensions
-// using a "cover" strategy (scales to cover the entire target, cropping excess)
-func resizeAndCropToFill(src image.Image, targetWidth, targetHeight int) image.Image {
+// using a "cover" strategy (scales to cover the entire target, cropping excess
+// at the anchor's position)
+func resizeAndCropToFill(src image.Image, targetWidth, targetHeight int, anchor CropAnchor) image.Image {
 	srcBounds := src.Bounds()
 	srcWidth := srcBounds.Dx()
 	srcHeight := srcBounds.Dy()
@@ -51,9 +200,9 @@ func resizeAndCropToFill(src image.Image, targetWidth, targetHeight int) image.I
 	// Resize using nearest neighbor (fast, simple)
 	scaled := resizeImage(src, scaledWidth, scaledHeight)
 
-	// Calculate crop offsets to center the image
-	cropX := (scaledWidth - targetWidth) / 2
-	cropY := (scaledHeight - targetHeight) / 2
+	// Calculate crop offsets from the anchor
+	cropX := int(float64(scaledWidth-targetWidth) * anchor.X)
+	cropY := int(float64(scaledHeight-targetHeight) * anchor.Y)
 
 	// Crop to target dimensions
 	cropped := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	imageGenerationEndpoint = "/images/generations"
+	imageGenerationModel    = "gpt-image-1"
+)
+
+type imageGenerationRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Size   string `json:"size"`
+	N      int    `json:"n"`
+}
+
+type imageGenerationResponse struct {
+	Data []struct {
+		B64JSON string `json:"b64_json"`
+	} `json:"data"`
+}
+
+// GenerateReferenceImage creates a PNG image for use as a video reference.
+// The image model only supports a few sizes, so the closest orientation to
+// videoSize is requested; the reference resize step then crops it to the
+// exact video dimensions.
+func (c *SoraClient) GenerateReferenceImage(prompt, videoSize string) ([]byte, error) {
+	width, height, err := parseSize(videoSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size format: %w", err)
+	}
+
+	imageSize := "1024x1024"
+	if width > height {
+		imageSize = "1536x1024"
+	} else if height > width {
+		imageSize = "1024x1536"
+	}
+
+	req := imageGenerationRequest{
+		Model:  c.deployment(imageGenerationModel),
+		Prompt: prompt,
+		Size:   imageSize,
+		N:      1,
+	}
+
+	var resp imageGenerationResponse
+	if err := c.postJSON(imageGenerationEndpoint, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to generate reference image: %w", err)
+	}
+
+	if len(resp.Data) == 0 || resp.Data[0].B64JSON == "" {
+		return nil, fmt.Errorf("failed to generate reference image: no image returned")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode reference image: %w", err)
+	}
+
+	return data, nil
+}
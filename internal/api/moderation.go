@@ -0,0 +1,63 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	moderationEndpoint = "/moderations"
+	moderationModel    = "omni-moderation-latest"
+)
+
+type moderationRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type moderationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// ModerationResult reports whether a prompt is likely to be rejected by content moderation
+type ModerationResult struct {
+	Flagged    bool
+	Categories []string // Flagged category names, sorted
+}
+
+// String describes the flagged categories for display
+func (r *ModerationResult) String() string {
+	if !r.Flagged {
+		return "not flagged"
+	}
+	if len(r.Categories) == 0 {
+		return "flagged"
+	}
+	return "flagged for " + strings.Join(r.Categories, ", ")
+}
+
+// ModeratePrompt checks a prompt against the moderation endpoint before it is submitted to Sora
+func (c *SoraClient) ModeratePrompt(prompt string) (*ModerationResult, error) {
+	var resp moderationResponse
+	if err := c.postJSON(moderationEndpoint, moderationRequest{Model: c.deployment(moderationModel), Input: prompt}, &resp); err != nil {
+		return nil, fmt.Errorf("moderation check failed: %w", err)
+	}
+
+	if len(resp.Results) == 0 {
+		return nil, fmt.Errorf("moderation check failed: empty response")
+	}
+
+	result := &ModerationResult{Flagged: resp.Results[0].Flagged}
+	for category, flagged := range resp.Results[0].Categories {
+		if flagged {
+			result.Categories = append(result.Categories, category)
+		}
+	}
+	sort.Strings(result.Categories)
+
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// encodeMJPEG wraps JPEG frames of width x height pixels in an MP4 container
+// as a Motion JPEG track played at fps frames per second. It needs no video
+// encoder, and the result plays in QuickTime, VLC, and ffmpeg.
+func encodeMJPEG(frames [][]byte, width, height, fps int) []byte {
+	ftyp := mp4Box("ftyp", []byte("isom"), be32(0x200), []byte("isomiso2mp41"))
+
+	var data []byte
+	sizes := make([]byte, 0, 4*len(frames))
+	for _, frame := range frames {
+		data = append(data, frame...)
+		sizes = append(sizes, be32(uint32(len(frame)))...)
+	}
+	mdat := mp4Box("mdat", data)
+	// The only chunk starts right after the mdat header
+	chunkOffset := uint32(len(ftyp) + 8)
+
+	count := uint32(len(frames))
+	duration := count * 1000 / uint32(fps) // In the movie's millisecond timescale
+	matrix := be32(0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000)
+
+	mvhd := mp4FullBox("mvhd", 0, 0,
+		be32(0, 0, 1000, duration, 0x00010000),
+		be16(0x0100, 0), be32(0, 0), matrix, make([]byte, 24), be32(2))
+	tkhd := mp4FullBox("tkhd", 0, 3,
+		be32(0, 0, 1, 0, duration, 0, 0),
+		be16(0, 0, 0, 0), matrix, be32(uint32(width)<<16, uint32(height)<<16))
+	mdhd := mp4FullBox("mdhd", 0, 0, be32(0, 0, uint32(fps), count), be16(0x55C4, 0)) // Language "und"
+	hdlr := mp4FullBox("hdlr", 0, 0, be32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00"))
+
+	compressor := make([]byte, 32)
+	compressor[0] = byte(copy(compressor[1:], "Photo - JPEG"))
+	sampleEntry := mp4Box("jpeg",
+		make([]byte, 6), be16(1), // Reserved, data reference index
+		make([]byte, 16), be16(uint16(width), uint16(height)),
+		be32(0x00480000, 0x00480000, 0), be16(1), // 72 dpi, one frame per sample
+		compressor, be16(0x0018, 0xFFFF))
+
+	stbl := mp4Box("stbl",
+		mp4FullBox("stsd", 0, 0, be32(1), sampleEntry),
+		mp4FullBox("stts", 0, 0, be32(1, count, 1)),
+		mp4FullBox("stsc", 0, 0, be32(1, 1, count, 1)),
+		mp4FullBox("stsz", 0, 0, be32(0, count), sizes),
+		mp4FullBox("stco", 0, 0, be32(1, chunkOffset)))
+	minf := mp4Box("minf",
+		mp4FullBox("vmhd", 0, 1, be16(0, 0, 0, 0)),
+		mp4Box("dinf", mp4FullBox("dref", 0, 0, be32(1), mp4FullBox("url ", 0, 1))),
+		stbl)
+	moov := mp4Box("moov", mvhd, mp4Box("trak", tkhd, mp4Box("mdia", mdhd, hdlr, minf)))
+
+	video := make([]byte, 0, len(ftyp)+len(mdat)+len(moov))
+	video = append(video, ftyp...)
+	video = append(video, mdat...)
+	return append(video, moov...)
+}
+
+// ValidateMP4 checks that the file at path is a whole MP4: it starts with an
+// ftyp box, has a moov box, and its top-level boxes fill it exactly. A
+// truncated download cuts the last box short or loses the moov box, which is
+// often written last. Errors wrap ErrIncompleteDownload.
+func ValidateMP4(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to check video: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to check video: %w", err)
+	}
+	size := info.Size()
+
+	var first string
+	hasMoov := false
+	header := make([]byte, 16)
+	for offset := int64(0); offset < size; {
+		if size-offset < 8 {
+			return fmt.Errorf("%w: %d stray bytes at the end of the file", ErrIncompleteDownload, size-offset)
+		}
+		if _, err := f.ReadAt(header[:8], offset); err != nil {
+			return fmt.Errorf("failed to check video: %w", err)
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		switch boxSize {
+		case 0: // The box runs to the end of the file
+			boxSize = size - offset
+		case 1: // A 64-bit size follows the type
+			if size-offset < 16 {
+				return fmt.Errorf("%w: the %q box is cut off", ErrIncompleteDownload, kind)
+			}
+			if _, err := f.ReadAt(header[8:16], offset+8); err != nil {
+				return fmt.Errorf("failed to check video: %w", err)
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+
+		if first == "" {
+			first = kind
+			if kind != "ftyp" {
+				return fmt.Errorf("%w: not an MP4 file (it starts with %q, not an ftyp box)", ErrIncompleteDownload, kind)
+			}
+		}
+		if boxSize < 8 {
+			return fmt.Errorf("%w: invalid size %d of the %q box", ErrIncompleteDownload, boxSize, kind)
+		}
+		if offset+boxSize > size {
+			return fmt.Errorf("%w: the %q box needs %d bytes but the file ends after %d", ErrIncompleteDownload, kind, boxSize, size-offset)
+		}
+		hasMoov = hasMoov || kind == "moov"
+		offset += boxSize
+	}
+
+	if first == "" {
+		return fmt.Errorf("%w: the file is empty", ErrIncompleteDownload)
+	}
+	if !hasMoov {
+		return fmt.Errorf("%w: the file has no moov box", ErrIncompleteDownload)
+	}
+	return nil
+}
+
+// mp4Box returns an MP4 box of the given four-character type
+func mp4Box(kind string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	box := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(box, uint32(size))
+	copy(box[4:], kind)
+	for _, p := range payload {
+		box = append(box, p...)
+	}
+	return box
+}
+
+// mp4FullBox returns an MP4 box with a version and flags header
+func mp4FullBox(kind string, version byte, flags uint32, payload ...[]byte) []byte {
+	header := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return mp4Box(kind, append([][]byte{header}, payload...)...)
+}
+
+// be32 encodes values as consecutive big-endian 32-bit integers
+func be32(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
+
+// be16 encodes values as consecutive big-endian 16-bit integers
+func be16(values ...uint16) []byte {
+	b := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(b[2*i:], v)
+	}
+	return b
+}
@@ -0,0 +1,50 @@
+package api
+
+import "strconv"
+
+// pricePerSecond is the list price in USD per second of video for each model.
+// sora-2-pro is billed higher above 720p, see proHighResPrice.
+var pricePerSecond = map[string]float64{
+	"sora-2":      0.10,
+	"sora-2-pro":  0.30,
+	"gen3a_turbo": 0.05,
+	"gen4_turbo":  0.05,
+}
+
+// proHighResPrice is the sora-2-pro price per second for the 1792x1024 and 1024x1792 sizes
+const proHighResPrice = 0.50
+
+// EstimateCost returns the estimated price in USD of a video, and false when
+// the model's price or the duration is unknown
+func EstimateCost(model, size, seconds string) (float64, bool) {
+	price, ok := pricePerSecond[model]
+	if !ok {
+		return 0, false
+	}
+	secs, err := strconv.Atoi(seconds)
+	if err != nil {
+		return 0, false
+	}
+	if model == "sora-2-pro" && (size == "1792x1024" || size == "1024x1792") {
+		price = proHighResPrice
+	}
+	return price * float64(secs), true
+}
+
+// EstimateJobCost prices a job by the settings of its request, taking any
+// the request did not carry (remixes, resumed jobs) from the job's status
+func EstimateJobCost(req CreateVideoRequest, resp *VideoResponse) (float64, bool) {
+	model, size, seconds := req.Model, req.Size, req.Seconds
+	if resp != nil {
+		if model == "" {
+			model = resp.Model
+		}
+		if size == "" {
+			size = resp.Size
+		}
+		if seconds == "" {
+			seconds = resp.Seconds
+		}
+	}
+	return EstimateCost(model, size, seconds)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/backoff"
+)
+
+// defaultRateLimitWait is used when a 429 response says nothing about when to retry
+const defaultRateLimitWait = 20 * time.Second
+
+// rateLimitHeaders are the OpenAI headers describing the current limits, in debug output order
+var rateLimitHeaders = []string{
+	"x-ratelimit-limit-requests",
+	"x-ratelimit-remaining-requests",
+	"x-ratelimit-reset-requests",
+	"x-ratelimit-limit-tokens",
+	"x-ratelimit-remaining-tokens",
+	"x-ratelimit-reset-tokens",
+}
+
+// rateLimiter pauses every request to a service after any of them is rate
+// limited, so concurrent jobs back off together instead of each hammering
+// the API until it gets its own 429
+type rateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// openAILimiter is shared by every SoraClient in the process unless it is isolated
+var openAILimiter = &rateLimiter{}
+
+// wait blocks until the current backoff (if any) has passed or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	delay := time.Until(l.until)
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff holds all requests for d, extending (never shortening) any current backoff
+func (l *rateLimiter) backoff(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// retryAfter returns how long a 429 response asks the client to wait, from
+// retry-after-ms, Retry-After (seconds or HTTP date), or the request limit reset
+func retryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if value := h.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			if d := time.Until(at); d > 0 {
+				return d
+			}
+		}
+	}
+	if reset, err := time.ParseDuration(h.Get("x-ratelimit-reset-requests")); err == nil && reset > 0 {
+		return reset
+	}
+	// Jittered so clients limited at the same moment do not all retry together
+	return backoff.Jitter(defaultRateLimitWait, 0.2)
+}
+
+// formatRateLimits summarizes the rate-limit headers of a response, or returns
+// an empty string when there are none
+func formatRateLimits(h http.Header) string {
+	var parts []string
+	for _, name := range rateLimitHeaders {
+		if value := h.Get(name); value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", strings.TrimPrefix(name, "x-ratelimit-"), value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isRateLimited reports whether err is a 429 response
+func isRateLimited(err error) bool {
+	return StatusCode(err) == http.StatusTooManyRequests
+}
+
+// do sends an API request, waiting out any shared rate-limit backoff first.
+// A 429 response starts a backoff for every request sharing the client's
+// limiter, which is every request from this process unless it is isolated.
+func (c *SoraClient) do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.status.observeHeaders(resp.Header)
+	if c.debug && c.debugLog != nil {
+		if limits := formatRateLimits(resp.Header); limits != "" {
+			c.debugLog(fmt.Sprintf("RATE LIMITS: %s", limits))
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfter(resp.Header)
+		c.limiter.backoff(wait)
+		c.status.observePause(time.Now().Add(wait))
+		if c.debug && c.debugLog != nil {
+			c.debugLog(fmt.Sprintf("RATE LIMITED: pausing all requests for %s", wait.Round(time.Millisecond)))
+		}
+	}
+
+	return resp, nil
+}
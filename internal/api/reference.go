@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/naming"
+)
+
+// maxReferenceDownload caps reference images fetched from a URL
+const maxReferenceDownload = 20 << 20
+
+// referenceFetchTimeout bounds fetching a reference image from a URL
+const referenceFetchTimeout = time.Minute
+
+// referenceImageTypes maps the image types accepted from a URL to the
+// extension the fetched file is saved with
+var referenceImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// IsReferenceURL reports whether a reference is an http(s) or data: URL
+// rather than a local path
+func IsReferenceURL(ref string) bool {
+	lower := strings.ToLower(ref)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "data:")
+}
+
+// FetchReference saves a reference image given as an http(s) or data: URL
+// in dir as reference_TIMESTAMP.jpg or .png and returns the file's path, so
+// it goes through the same resizing as a local reference. Only JPEG and PNG
+// images of up to 20 MB are accepted. transport carries the request, nil for
+// the default transport.
+func FetchReference(ref, dir string, transport http.RoundTripper) (string, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(strings.ToLower(ref), "data:") {
+		data, err = decodeDataURL(ref)
+	} else {
+		data, err = downloadReference(ref, transport)
+	}
+	if err != nil {
+		return "", err
+	}
+	return SaveReferenceImage(data, dir, "reference", describeReference(ref))
+}
+
+// SaveReferenceImage saves image data in dir as PREFIX_TIMESTAMP.jpg or
+// .png, after checking that it is a JPEG or PNG image; source names where
+// the data came from in errors. The path of the file is returned.
+func SaveReferenceImage(data []byte, dir, prefix, source string) (string, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := referenceImageTypes[contentType]
+	if !ok {
+		return "", fmt.Errorf("reference %s is %s, not a JPEG or PNG image", source, contentType)
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("reference %s is not a valid image: %w", source, err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	path := naming.Unique(filepath.Join(dir, fmt.Sprintf("%s_%s%s", prefix, time.Now().Format("20060102_150405"), ext)))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save reference image: %w", err)
+	}
+	return path, nil
+}
+
+// downloadReference fetches an http(s) reference, refusing bodies over maxReferenceDownload
+func downloadReference(ref string, transport http.RoundTripper) ([]byte, error) {
+	client := &http.Client{Transport: transport, Timeout: referenceFetchTimeout}
+	resp, err := client.Get(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reference image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch reference image %s: %s", describeReference(ref), resp.Status)
+	}
+	if resp.ContentLength > maxReferenceDownload {
+		return nil, fmt.Errorf("reference image %s is %.1f MB, over the %d MB limit", describeReference(ref), float64(resp.ContentLength)/1e6, maxReferenceDownload>>20)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReferenceDownload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reference image: %w", err)
+	}
+	if len(data) > maxReferenceDownload {
+		return nil, fmt.Errorf("reference image %s is over the %d MB limit", describeReference(ref), maxReferenceDownload>>20)
+	}
+	return data, nil
+}
+
+// decodeDataURL returns the bytes of a data: URL, base64 or percent-encoded
+func decodeDataURL(ref string) ([]byte, error) {
+	meta, payload, ok := strings.Cut(ref[len("data:"):], ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data URL reference: missing ','")
+	}
+
+	var data []byte
+	var err error
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			// Some encoders leave out the padding
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		}
+	} else {
+		var text string
+		text, err = url.PathUnescape(payload)
+		data = []byte(text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URL reference: %w", err)
+	}
+	if len(data) > maxReferenceDownload {
+		return nil, fmt.Errorf("data URL reference is over the %d MB limit", maxReferenceDownload>>20)
+	}
+	return data, nil
+}
+
+// describeReference shortens a reference URL for messages; data URLs can be megabytes long
+func describeReference(ref string) string {
+	if strings.HasPrefix(strings.ToLower(ref), "data:") {
+		meta, _, _ := strings.Cut(ref, ",")
+		return meta + ",..."
+	}
+	return ref
+}
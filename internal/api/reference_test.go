@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPNG returns an encoded 32x18 PNG
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 32, 18))); err != nil {
+		t.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+func TestFetchReference(t *testing.T) {
+	pngData := testPNG(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/frame.png":
+			w.Write(pngData)
+		case "/notes.txt":
+			w.Write([]byte("not an image"))
+		case "/huge.png":
+			w.Header().Set("Content-Length", "30000000")
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData)
+	tests := []struct {
+		name    string
+		ref     string
+		wantErr string
+	}{
+		{"http image", server.URL + "/frame.png", ""},
+		{"data URL", dataURL, ""},
+		{"unpadded data URL", strings.TrimRight(dataURL, "="), ""},
+		{"not an image", server.URL + "/notes.txt", "not a JPEG or PNG image"},
+		{"missing", server.URL + "/missing.png", "404"},
+		{"too large", server.URL + "/huge.png", "over the 20 MB limit"},
+		{"malformed data URL", "data:image/png;base64", "missing ','"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !IsReferenceURL(tt.ref) {
+				t.Fatalf("IsReferenceURL(%q) = false", tt.ref)
+			}
+			path, err := FetchReference(tt.ref, t.TempDir(), nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FetchReference() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchReference() error: %v", err)
+			}
+			if filepath.Ext(path) != ".png" {
+				t.Errorf("saved as %s, want a .png", path)
+			}
+			if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, pngData) {
+				t.Errorf("saved file differs from the image (err %v)", err)
+			}
+		})
+	}
+}
+
+func TestIsReferenceURL(t *testing.T) {
+	for ref, want := range map[string]bool{
+		"https://cdn.example.com/frame.jpg": true,
+		"HTTP://cdn.example.com/frame.jpg":  true,
+		"data:image/jpeg;base64,/9j/":       true,
+		"frames/frame.jpg":                  false,
+		"~/Pictures/http.png":               false,
+	} {
+		if got := IsReferenceURL(ref); got != want {
+			t.Errorf("IsReferenceURL(%q) = %t, want %t", ref, got, want)
+		}
+	}
+}
+
+func TestMatchReferenceSize(t *testing.T) {
+	dir := t.TempDir()
+	writePNG := func(name string, width, height int) string {
+		var encoded bytes.Buffer
+		if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, encoded.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		model string
+		want  string
+	}{
+		{"landscape", writePNG("wide.png", 64, 36), "sora-2", "1280x720"},
+		{"portrait", writePNG("tall.png", 36, 64), "sora-2", "720x1280"},
+		{"square prefers landscape", writePNG("square.png", 50, 50), "sora-2", "1280x720"},
+		{"16:10 on pro", writePNG("sixteen-ten.png", 70, 40), "sora-2-pro", "1792x1024"},
+		{"10:16 on pro", writePNG("ten-sixteen.png", 40, 70), "sora-2-pro", "1024x1792"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MatchReferenceSize(tt.path, ModelSizes(tt.model))
+			if err != nil {
+				t.Fatalf("MatchReferenceSize() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MatchReferenceSize() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MatchReferenceSize(filepath.Join(dir, "clip.mp4"), ModelSizes("sora-2")); err == nil {
+		t.Error("MatchReferenceSize() of a video reference succeeded, want an error")
+	}
+}
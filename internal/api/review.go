@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const reviewModel = "gpt-4o-mini"
+
+// reviewSystemPrompt instructs the vision model to judge frames against acceptance criteria
+const reviewSystemPrompt = `You review AI-generated videos before they are delivered.
+You are shown frames sampled in order from one video, the prompt it was generated from,
+and the acceptance criteria. Decide whether the video meets every criterion.
+If it does not, give short, concrete feedback that could be added to the prompt to fix it.
+Reply with a JSON object of the form {"pass": true|false, "feedback": "..."} and nothing else.`
+
+// ReviewResult is a vision model's verdict on a generated video
+type ReviewResult struct {
+	Pass     bool   `json:"pass"`
+	Feedback string `json:"feedback"`
+}
+
+// visionMessage is a chat message whose content may mix text and images
+type visionMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type visionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []visionMessage `json:"messages"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// ReviewFrames asks a vision model whether JPEG frames from a video meet the criteria
+func (c *SoraClient) ReviewFrames(frames [][]byte, prompt, criteria string) (*ReviewResult, error) {
+	parts := []contentPart{{
+		Type: "text",
+		Text: fmt.Sprintf("Prompt: %s\n\nAcceptance criteria: %s", prompt, criteria),
+	}}
+	for _, frame := range frames {
+		parts = append(parts, contentPart{
+			Type:     "image_url",
+			ImageURL: &imageURL{URL: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(frame)},
+		})
+	}
+
+	req := visionRequest{
+		Model: c.deployment(reviewModel),
+		Messages: []visionMessage{
+			{Role: "system", Content: reviewSystemPrompt},
+			{Role: "user", Content: parts},
+		},
+		ResponseFormat: &responseFormat{Type: "json_object"},
+	}
+
+	var resp chatResponse
+	if err := c.postJSON(chatEndpoint, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to review video: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("failed to review video: no response from %s", reviewModel)
+	}
+
+	var result ReviewResult
+	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse review: %w", err)
+	}
+	result.Feedback = strings.TrimSpace(result.Feedback)
+
+	return &result, nil
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	runwayBaseURL = "https://api.dev.runwayml.com/v1"
+	runwayVersion = "2024-11-06"
+)
+
+// VideoProvider is implemented by every video generation backend
+type VideoProvider interface {
+	Name() string
+	CreateVideo(req CreateVideoRequest) (*CreateVideoResponse, error)
+	GetVideo(videoID string) (*VideoResponse, error)
+	DownloadVideoContent(videoID, outputPath string, progress ProgressFunc) error
+	DeleteVideo(videoID string) error
+}
+
+// RunwayClient generates videos with Runway's image-to-video API
+type RunwayClient struct {
+	apiKey     string
+	httpClient *http.Client
+	debug      bool
+	debugLog   func(string)
+}
+
+type runwayCreateRequest struct {
+	Model       string `json:"model"`
+	PromptImage string `json:"promptImage"`
+	PromptText  string `json:"promptText,omitempty"`
+	Duration    int    `json:"duration"`
+	Ratio       string `json:"ratio"`
+}
+
+type runwayTask struct {
+	ID          string   `json:"id"`
+	Status      string   `json:"status"`
+	CreatedAt   string   `json:"createdAt"`
+	Progress    float64  `json:"progress"`
+	Output      []string `json:"output"`
+	Failure     string   `json:"failure"`
+	FailureCode string   `json:"failureCode"`
+}
+
+func NewRunwayClient(apiKey string, debug bool, debugLog func(string)) *RunwayClient {
+	return &RunwayClient{
+		apiKey:   apiKey,
+		debug:    debug,
+		debugLog: debugLog,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// Name returns the provider name used in flags, config, and output
+func (c *RunwayClient) Name() string {
+	return "runway"
+}
+
+// SetTransport replaces the HTTP transport used for all API calls
+func (c *RunwayClient) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// runwayModel maps user-friendly model names to Runway model IDs
+func runwayModel(model string) string {
+	if model == "" {
+		return DefaultModel("runway").Name
+	}
+	return ModelName(model)
+}
+
+// runwayRatio maps a Sora-style size to the closest ratio the Runway model supports
+func runwayRatio(model string, width, height int) string {
+	portrait := height > width
+	if model == "gen3a_turbo" {
+		if portrait {
+			return "768:1280"
+		}
+		return "1280:768"
+	}
+	if portrait {
+		return "720:1280"
+	}
+	return "1280:720"
+}
+
+// runwayDuration maps a requested duration to Runway's 5 or 10 second clips
+func runwayDuration(seconds string) int {
+	switch seconds {
+	case "", "4", "5":
+		return 5
+	}
+	return 10
+}
+
+// CreateVideo starts an image-to-video task. Runway requires a reference
+// image, which is resized to the requested size and sent as a data URI.
+func (c *RunwayClient) CreateVideo(req CreateVideoRequest) (*CreateVideoResponse, error) {
+	if req.InputReference == "" {
+		return nil, fmt.Errorf("runway requires a reference image (use -r or -ref-prompt)")
+	}
+	if IsVideoReference(req.InputReference) {
+		return nil, fmt.Errorf("runway only accepts image references, not videos")
+	}
+
+	width, height, err := parseSize(req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size format: %w", err)
+	}
+
+	anchor, err := ParseCropAnchor(req.CropAnchor)
+	if err != nil {
+		return nil, err
+	}
+	promptImage, err := encodeReferenceDataURI(req.InputReference, width, height, anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	model := runwayModel(req.Model)
+	body := runwayCreateRequest{
+		Model:       model,
+		PromptImage: promptImage,
+		PromptText:  req.Prompt,
+		Duration:    runwayDuration(req.Seconds),
+		Ratio:       runwayRatio(model, width, height),
+	}
+
+	var task runwayTask
+	if err := c.do("POST", "/image_to_video", body, &task); err != nil {
+		return nil, err
+	}
+
+	return &CreateVideoResponse{ID: task.ID, Status: runwayStatus(task.Status)}, nil
+}
+
+// GetVideo retrieves a Runway task, translated into the Sora response shape
+func (c *RunwayClient) GetVideo(videoID string) (*VideoResponse, error) {
+	task, err := c.getTask(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &VideoResponse{
+		ID:       task.ID,
+		Status:   runwayStatus(task.Status),
+		Progress: int(task.Progress * 100),
+		Object:   "video",
+	}
+	if resp.Status == "completed" {
+		resp.Progress = 100
+	}
+	if task.Failure != "" {
+		resp.Error = &ErrorObject{Message: task.Failure, Code: task.FailureCode}
+	}
+	if createdAt, err := time.Parse(time.RFC3339, task.CreatedAt); err == nil {
+		resp.CreatedAt = createdAt.Unix()
+	}
+
+	return resp, nil
+}
+
+// DownloadVideoContent downloads the first output of a completed task
+func (c *RunwayClient) DownloadVideoContent(videoID, outputPath string, progress ProgressFunc) error {
+	task, err := c.getTask(videoID)
+	if err != nil {
+		return err
+	}
+	if len(task.Output) == 0 {
+		return fmt.Errorf("%w (task status %s)", ErrContentNotReady, task.Status)
+	}
+
+	// Output URLs are pre-signed and must not receive the API key
+	resp, err := c.httpClient.Get(task.Output[0])
+	if err != nil {
+		return fmt.Errorf("failed to download video content: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download video content (status %d)", resp.StatusCode)
+	}
+
+	return saveVideo(resp.Body, resp.ContentLength, outputPath, progress)
+}
+
+// DeleteVideo cancels a running task or deletes a finished one
+func (c *RunwayClient) DeleteVideo(videoID string) error {
+	return c.do("DELETE", "/tasks/"+videoID, nil, nil)
+}
+
+func (c *RunwayClient) getTask(taskID string) (*runwayTask, error) {
+	var task runwayTask
+	if err := c.do("GET", "/tasks/"+taskID, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// runwayStatus maps Runway task states onto the Sora statuses used throughout the app
+func runwayStatus(status string) string {
+	switch status {
+	case "PENDING", "THROTTLED":
+		return "queued"
+	case "RUNNING":
+		return "in_progress"
+	case "SUCCEEDED":
+		return "completed"
+	case "FAILED", "CANCELLED":
+		return "failed"
+	}
+	return status
+}
+
+// do sends a request to the Runway API and decodes the JSON response into result
+func (c *RunwayClient) do(method, endpoint string, payload interface{}, result interface{}) error {
+	url := runwayBaseURL + endpoint
+
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("X-Runway-Version", runwayVersion)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// Debug log request (the reference image data URI is omitted)
+	if c.debug && c.debugLog != nil {
+		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
+			"method": method,
+			"url":    url,
+		}, "", "  ")
+		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Debug log response
+	if c.debug && c.debugLog != nil {
+		var prettyJSON bytes.Buffer
+		if json.Indent(&prettyJSON, body, "", "  ") == nil {
+			c.debugLog(fmt.Sprintf("RESPONSE [%d]:\n%s", resp.StatusCode, prettyJSON.String()))
+		} else {
+			c.debugLog(fmt.Sprintf("RESPONSE [%d]:\n%s", resp.StatusCode, string(body)))
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return &httpError{statusCode: resp.StatusCode, message: apiErr.Error}
+		}
+		return &httpError{statusCode: resp.StatusCode, message: string(body)}
+	}
+
+	if result != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, result); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// encodeReferenceDataURI resizes a reference image to the target size, cropping
+// at anchor, and encodes it as a JPEG data URI
+func encodeReferenceDataURI(path string, width, height int, anchor CropAnchor) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open reference file: %w", err)
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img = resizeAndCropToFill(img, width, height, anchor)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return "", fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,369 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayPollInterval is the wait between status checks of a replayed job.
+// Recorded responses are served at once, so waiting out the real poll
+// intervals would only slow replays down.
+const ReplayPollInterval = 250 * time.Millisecond
+
+// Interaction is a single recorded HTTP exchange with the API
+type Interaction struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header,omitempty"`
+	Body       string              `json:"body,omitempty"`
+	BodyFile   string              `json:"body_file,omitempty"`   // Fixture file holding a binary body such as video content, relative to the session file
+	BodyBase64 string              `json:"body_base64,omitempty"` // Binary body inlined by older recordings
+}
+
+// Session is the on-disk format of sessions recorded before interactions
+// were appended one per line; -replay still reads it
+type Session struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// NewSessionTransport returns a transport that records to recordPath or replays
+// from replayPath. Recorded requests are sent with next, or the default
+// transport when next is nil. It returns next when neither path is set.
+func NewSessionTransport(recordPath, replayPath string, next http.RoundTripper) (http.RoundTripper, error) {
+	if replayPath != "" && recordPath != "" {
+		return nil, fmt.Errorf("cannot record and replay at the same time")
+	}
+	if replayPath != "" {
+		rt, err := newReplayTransport(replayPath)
+		if err != nil {
+			return nil, err
+		}
+		return rt, nil
+	}
+	if recordPath != "" {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return newRecordTransport(recordPath, next)
+	}
+	return next, nil
+}
+
+// fixtureDir returns the directory binary bodies of a session are saved in,
+// e.g. session.fixtures/ next to session.json
+func fixtureDir(sessionPath string) string {
+	return strings.TrimSuffix(sessionPath, filepath.Ext(sessionPath)) + ".fixtures"
+}
+
+// recordTransport forwards requests to the network and appends each
+// exchange to the session file, one JSON object per line, once its body has
+// been read. Text bodies are stored inline; binary ones are streamed to a
+// fixture file as the caller reads them, so videos are never held in memory.
+// Each exchange is on disk as soon as it completes, so a crash keeps what
+// was captured.
+type recordTransport struct {
+	path     string
+	next     http.RoundTripper
+	mu       sync.Mutex
+	fixtures int // Fixture files written so far
+}
+
+// newRecordTransport starts a new session at path, replacing any earlier
+// recording and its fixtures
+func newRecordTransport(path string, next http.RoundTripper) (*recordTransport, error) {
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return nil, fmt.Errorf("failed to create session file: %w", err)
+	}
+	if err := os.RemoveAll(fixtureDir(path)); err != nil {
+		return nil, fmt.Errorf("failed to clear session fixtures: %w", err)
+	}
+	return &recordTransport{path: path, next: next}, nil
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	}
+
+	if isTextContent(resp.Header.Get("Content-Type")) {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response for recording: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		interaction.Body = string(body)
+		if err := t.append(interaction); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+
+	name, file, err := t.createFixture(resp.Header.Get("Content-Type"))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	interaction.BodyFile = name
+	resp.Body = &recordingBody{body: resp.Body, fixture: file, done: func() error { return t.append(interaction) }}
+	return resp, nil
+}
+
+// createFixture creates the next fixture file for a binary body, named after
+// its position in the session and its content type
+func (t *recordTransport) createFixture(contentType string) (string, *os.File, error) {
+	t.mu.Lock()
+	t.fixtures++
+	n := t.fixtures
+	t.mu.Unlock()
+
+	dir := fixtureDir(t.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create session fixtures: %w", err)
+	}
+	name := fmt.Sprintf("%04d%s", n, fixtureExtension(contentType))
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create session fixture: %w", err)
+	}
+	return filepath.Join(filepath.Base(dir), name), file, nil
+}
+
+// append adds an interaction to the end of the session file
+func (t *recordTransport) append(interaction Interaction) error {
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	file, err := os.OpenFile(t.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
+
+// recordingBody copies a response body into its fixture file as it is read,
+// and records the exchange when the body is closed. A body closed before its
+// end is recorded as far as it was read, as the caller saw it.
+type recordingBody struct {
+	body    io.ReadCloser
+	fixture *os.File
+	done    func() error
+	err     error // First failure to write the fixture
+	closed  bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && b.err == nil {
+		if _, writeErr := b.fixture.Write(p[:n]); writeErr != nil {
+			b.err = fmt.Errorf("failed to write session fixture: %w", writeErr)
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	err := b.body.Close()
+	if closeErr := b.fixture.Close(); b.err == nil && closeErr != nil {
+		b.err = fmt.Errorf("failed to write session fixture: %w", closeErr)
+	}
+	if b.err != nil {
+		return b.err
+	}
+	if doneErr := b.done(); doneErr != nil {
+		return doneErr
+	}
+	return err
+}
+
+// isTextContent reports whether a response body of this content type is
+// stored inline in the session rather than in a fixture file
+func isTextContent(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml")
+}
+
+// fixtureExtension returns the file extension of a fixture, so recorded
+// videos and images can be opened directly
+func fixtureExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "video/mp4":
+		return ".mp4"
+	case "image/webp":
+		return ".webp"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	}
+	return ".bin"
+}
+
+// replayTransport serves recorded responses without touching the network.
+// Interactions are matched by method and URL in recorded order; once a
+// request's recordings are used up the last one is repeated, which keeps
+// status polling working when the replay polls more often than the recording.
+type replayTransport struct {
+	dir          string // Directory of the session file, which fixture paths are relative to
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+func newReplayTransport(path string) (*replayTransport, error) {
+	interactions, err := readSession(path)
+	if err != nil {
+		return nil, err
+	}
+	return &replayTransport{
+		dir:          filepath.Dir(path),
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}, nil
+}
+
+// readSession reads the interactions of a session file: one JSON object per
+// line, or a single Session object from older recordings
+func readSession(path string) ([]Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	defer file.Close()
+
+	var interactions []Interaction
+	dec := json.NewDecoder(file)
+	for {
+		var value struct {
+			Interaction
+			Interactions []Interaction `json:"interactions"`
+		}
+		err := dec.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			return interactions, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode session file: %w", err)
+		}
+		if value.Interactions != nil {
+			interactions = append(interactions, value.Interactions...)
+		} else {
+			interactions = append(interactions, value.Interaction)
+		}
+	}
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	url := req.URL.String()
+	last := -1
+	match := -1
+	for i, interaction := range t.interactions {
+		if interaction.Method != req.Method || interaction.URL != url {
+			continue
+		}
+		last = i
+		if !t.used[i] {
+			match = i
+			break
+		}
+	}
+	if match == -1 {
+		match = last
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, url)
+	}
+	t.used[match] = true
+
+	interaction := t.interactions[match]
+	body, length, err := t.body(interaction)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(interaction.Header).Clone(),
+		Body:          body,
+		ContentLength: length,
+		Request:       req,
+	}, nil
+}
+
+// body returns the recorded body of an interaction and its length, streaming
+// fixture files from disk
+func (t *replayTransport) body(interaction Interaction) (io.ReadCloser, int64, error) {
+	switch {
+	case interaction.BodyFile != "":
+		file, err := os.Open(filepath.Join(t.dir, interaction.BodyFile))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open session fixture: %w", err)
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, fmt.Errorf("failed to open session fixture: %w", err)
+		}
+		return file, info.Size(), nil
+	case interaction.BodyBase64 != "":
+		decoded, err := base64.StdEncoding.DecodeString(interaction.BodyBase64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode recorded body: %w", err)
+		}
+		return io.NopCloser(bytes.NewReader(decoded)), int64(len(decoded)), nil
+	}
+	return io.NopCloser(strings.NewReader(interaction.Body)), int64(len(interaction.Body)), nil
+}
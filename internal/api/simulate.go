@@ -0,0 +1,452 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SimulateReject is the prompt marker that makes the simulated backend
+	// refuse a prompt, to show content policy errors
+	SimulateReject = "#reject"
+	// SimulateQuota is the prompt marker that makes the simulated backend
+	// refuse Pro jobs as beyond the account's usage tier, to show -fallback-model
+	SimulateQuota = "#quota"
+
+	simulatedQueueTime = 2 * time.Second // Before a simulated job starts rendering
+	simulatedRateLimit = 50              // Requests per minute reported in the rate-limit headers
+	simulatedFPS       = 6
+	simulatedQuality   = 60
+)
+
+// simulatedLatency is how long the simulated backend takes to answer each kind of call
+var simulatedLatency = map[string]time.Duration{
+	"create":  800 * time.Millisecond,
+	"content": 500 * time.Millisecond,
+	"default": 150 * time.Millisecond,
+}
+
+// simulatedTransport is a fake Sora backend for -simulate. Jobs queue
+// briefly, render with rising progress for a time that grows with their
+// duration and model, and produce a sample video: a color sweep picked from
+// the prompt with a bar that fills over the clip. Requests are matched by
+// path only, so any base URL works.
+type simulatedTransport struct {
+	mu    sync.Mutex
+	jobs  map[string]*simulatedJob
+	order []string // Job IDs, oldest first
+	next  int
+
+	window   time.Time // Start of the current rate-limit minute
+	requests int       // Requests made in the window
+}
+
+// simulatedJob is a job of the simulated backend
+type simulatedJob struct {
+	video    VideoResponse // As submitted; status and progress follow from the clock
+	created  time.Time
+	render   time.Duration
+	rejected bool
+	frames   [][]byte // JPEG frames of the sample video, rendered on first download
+	width    int
+	height   int
+	content  []byte // The frames as an MP4
+}
+
+// NewSimulatedTransport returns a transport that answers video API calls
+// from a built-in fake backend instead of the network
+func NewSimulatedTransport() http.RoundTripper {
+	return &simulatedTransport{jobs: make(map[string]*simulatedJob)}
+}
+
+func (t *simulatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := req.URL.Path
+	call := "default"
+	switch {
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/videos"):
+		call = "create"
+	case strings.HasSuffix(path, "/content"):
+		call = "content"
+	}
+	select {
+	case <-time.After(simulatedLatency[call]):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	resp, err := t.route(req)
+	if resp != nil {
+		t.rateLimit(resp.Header)
+	}
+	return resp, err
+}
+
+// route answers a request with the handler for its endpoint
+func (t *simulatedTransport) route(req *http.Request) (*http.Response, error) {
+	path := req.URL.Path
+	switch {
+	case strings.HasSuffix(path, "/moderations"):
+		return t.moderate(req)
+	case !strings.Contains(path, "/videos"):
+		return simulatedError(req, http.StatusNotImplemented, "this endpoint is not available in simulation mode")
+	}
+
+	rest := strings.Trim(path[strings.LastIndex(path, "/videos")+len("/videos"):], "/")
+	parts := strings.Split(rest, "/")
+	switch {
+	case rest == "" && req.Method == http.MethodPost:
+		return t.create(req)
+	case rest == "" && req.Method == http.MethodGet:
+		return t.list(req)
+	case len(parts) == 2 && parts[1] == "remix" && req.Method == http.MethodPost:
+		return t.remix(req, parts[0])
+	case len(parts) == 2 && parts[1] == "content" && req.Method == http.MethodGet:
+		return t.content(req, parts[0])
+	case len(parts) == 1 && req.Method == http.MethodGet:
+		return t.get(req, parts[0])
+	case len(parts) == 1 && req.Method == http.MethodDelete:
+		return t.delete(req, parts[0])
+	}
+	return simulatedError(req, http.StatusNotFound, fmt.Sprintf("no simulated endpoint for %s %s", req.Method, path))
+}
+
+// rateLimit counts a request against a per-minute quota and reports it in
+// OpenAI's rate-limit headers. The quota is never enforced.
+func (t *simulatedTransport) rateLimit(h http.Header) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if now.Sub(t.window) >= time.Minute {
+		t.window, t.requests = now, 0
+	}
+	t.requests++
+	h.Set("x-ratelimit-limit-requests", strconv.Itoa(simulatedRateLimit))
+	h.Set("x-ratelimit-remaining-requests", strconv.Itoa(max(simulatedRateLimit-t.requests, 0)))
+	h.Set("x-ratelimit-reset-requests", t.window.Add(time.Minute).Sub(now).Round(time.Millisecond).String())
+}
+
+// add registers a new job and returns its create response
+func (t *simulatedTransport) add(video VideoResponse) *CreateVideoResponse {
+	if video.Model == "" {
+		video.Model = "sora-2"
+	}
+	if video.Seconds == "" {
+		video.Seconds = "4"
+	}
+	if video.Size == "" {
+		video.Size = "720x1280"
+	}
+
+	// Rendering takes 1.5s per second of video, twice that for Pro models
+	seconds, _ := strconv.Atoi(video.Seconds)
+	render := time.Duration(seconds) * 1500 * time.Millisecond
+	if strings.Contains(video.Model, "pro") {
+		render *= 2
+	}
+
+	t.next++
+	video.ID = fmt.Sprintf("video_sim_%04d", t.next)
+	video.Object = "video"
+	video.CreatedAt = time.Now().Unix()
+	t.jobs[video.ID] = &simulatedJob{
+		video:    video,
+		created:  time.Now(),
+		render:   render,
+		rejected: strings.Contains(video.Prompt, SimulateReject),
+	}
+	t.order = append(t.order, video.ID)
+	return &CreateVideoResponse{ID: video.ID, Status: "queued", Object: "video"}
+}
+
+func (t *simulatedTransport) create(req *http.Request) (*http.Response, error) {
+	if err := req.ParseMultipartForm(32 << 20); err != nil {
+		return simulatedError(req, http.StatusBadRequest, "invalid form: "+err.Error())
+	}
+	video := VideoResponse{
+		Prompt:  req.FormValue("prompt"),
+		Model:   req.FormValue("model"),
+		Seconds: req.FormValue("seconds"),
+		Size:    req.FormValue("size"),
+	}
+	if video.Prompt == "" {
+		return simulatedError(req, http.StatusBadRequest, "prompt is required")
+	}
+	if video.Size != "" {
+		if _, _, err := parseSize(video.Size); err != nil {
+			return simulatedError(req, http.StatusBadRequest, "invalid size: "+err.Error())
+		}
+	}
+	if strings.Contains(video.Prompt, SimulateQuota) && strings.Contains(video.Model, "pro") {
+		return simulatedError(req, http.StatusForbidden, fmt.Sprintf("Your organization's usage tier does not include %s", video.Model))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return simulatedJSON(req, http.StatusOK, t.add(video))
+}
+
+func (t *simulatedTransport) remix(req *http.Request, sourceID string) (*http.Response, error) {
+	var body struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Prompt == "" {
+		return simulatedError(req, http.StatusBadRequest, "prompt is required")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	source, ok := t.jobs[sourceID]
+	if !ok {
+		return simulatedError(req, http.StatusNotFound, "video not found")
+	}
+	if source.status(time.Now()).Status != "completed" {
+		return simulatedError(req, http.StatusBadRequest, "only completed videos can be remixed")
+	}
+	video := source.video
+	video.Prompt = body.Prompt
+	video.RemixedFromVideoID = sourceID
+	return simulatedJSON(req, http.StatusOK, t.add(video))
+}
+
+func (t *simulatedTransport) get(req *http.Request, videoID string) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[videoID]
+	if !ok {
+		return simulatedError(req, http.StatusNotFound, "video not found")
+	}
+	return simulatedJSON(req, http.StatusOK, job.status(time.Now()))
+}
+
+func (t *simulatedTransport) list(req *http.Request) (*http.Response, error) {
+	limit, err := strconv.Atoi(req.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	after := req.URL.Query().Get("after")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := ListVideosResponse{Object: "list", Data: []VideoResponse{}}
+	now := time.Now()
+	skipping := after != ""
+	for i := len(t.order) - 1; i >= 0; i-- {
+		id := t.order[i]
+		if skipping {
+			skipping = id != after
+			continue
+		}
+		if len(list.Data) == limit {
+			list.HasMore = true
+			break
+		}
+		list.Data = append(list.Data, t.jobs[id].status(now))
+	}
+	if len(list.Data) > 0 {
+		list.FirstID = list.Data[0].ID
+		list.LastID = list.Data[len(list.Data)-1].ID
+	}
+	return simulatedJSON(req, http.StatusOK, list)
+}
+
+func (t *simulatedTransport) content(req *http.Request, videoID string) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[videoID]
+	if !ok {
+		return simulatedError(req, http.StatusNotFound, "video not found")
+	}
+	if job.status(time.Now()).Status != "completed" {
+		return simulatedError(req, http.StatusNotFound, "video is not ready yet")
+	}
+
+	if job.frames == nil {
+		var err error
+		job.frames, job.width, job.height, err = renderSimulatedFrames(job.video)
+		if err != nil {
+			return simulatedError(req, http.StatusInternalServerError, err.Error())
+		}
+		job.content = encodeMJPEG(job.frames, job.width, job.height, simulatedFPS)
+	}
+	// Previews are a frame from the middle of the clip, sent as JPEG
+	if req.URL.Query().Get("variant") != "" {
+		return simulatedBody(req, http.StatusOK, "image/jpeg", job.frames[len(job.frames)/2]), nil
+	}
+	return simulatedBody(req, http.StatusOK, "video/mp4", job.content), nil
+}
+
+func (t *simulatedTransport) delete(req *http.Request, videoID string) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.jobs[videoID]; !ok {
+		return simulatedError(req, http.StatusNotFound, "video not found")
+	}
+	delete(t.jobs, videoID)
+	for i, id := range t.order {
+		if id == videoID {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+	return simulatedJSON(req, http.StatusOK, map[string]interface{}{"id": videoID, "object": "video.deleted", "deleted": true})
+}
+
+func (t *simulatedTransport) moderate(req *http.Request) (*http.Response, error) {
+	var body moderationRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return simulatedError(req, http.StatusBadRequest, "invalid moderation request")
+	}
+	flagged := strings.Contains(body.Input, SimulateReject)
+	return simulatedJSON(req, http.StatusOK, map[string]interface{}{
+		"results": []interface{}{map[string]interface{}{
+			"flagged":    flagged,
+			"categories": map[string]bool{"violence": flagged},
+		}},
+	})
+}
+
+// status returns the job as the service would report it at now
+func (j *simulatedJob) status(now time.Time) VideoResponse {
+	video := j.video
+	elapsed := now.Sub(j.created)
+	switch {
+	case elapsed < simulatedQueueTime:
+		video.Status = "queued"
+	case j.rejected:
+		video.Status = "failed"
+		video.Error = &ErrorObject{Code: "moderation_blocked", Message: "Your request was blocked by our moderation system."}
+	case elapsed < simulatedQueueTime+j.render:
+		video.Status = "in_progress"
+		video.Progress = int(100 * (elapsed - simulatedQueueTime) / j.render)
+	default:
+		video.Status = "completed"
+		video.Progress = 100
+		video.CompletedAt = j.created.Add(simulatedQueueTime + j.render).Unix()
+		video.ExpiresAt = j.created.Add(24 * time.Hour).Unix()
+	}
+	return video
+}
+
+// renderSimulatedFrames draws the frames of a job's sample video as JPEG
+// images: a sweep of colors picked from the prompt that drifts over the clip,
+// with a bar along the bottom that fills as it plays
+func renderSimulatedFrames(video VideoResponse) ([][]byte, int, int, error) {
+	width, height, err := parseSize(video.Size)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	seconds, err := strconv.Atoi(video.Seconds)
+	if err != nil || seconds <= 0 {
+		seconds = 4
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(video.Prompt))
+	hue := float64(hash.Sum32() % 360)
+
+	count := seconds * simulatedFPS
+	bar := height / 40
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	frames := make([][]byte, count)
+	for f := range frames {
+		played := float64(f) / float64(count)
+
+		// Colors only change across the frame, so draw one row and copy it down
+		row := img.Pix[:4*width]
+		for x := 0; x < width; x++ {
+			c := hsv(hue+60*float64(x)/float64(width)+90*played, 0.55, 0.85)
+			copy(row[4*x:], []byte{c.R, c.G, c.B, 255})
+		}
+		for y := 1; y < height-bar; y++ {
+			copy(img.Pix[y*img.Stride:], row)
+		}
+		filled := int(played * float64(width))
+		for y := height - bar; y < height; y++ {
+			for x := 0; x < width; x++ {
+				shade := byte(40)
+				if x < filled {
+					shade = 240
+				}
+				copy(img.Pix[y*img.Stride+4*x:], []byte{shade, shade, shade, 255})
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: simulatedQuality}); err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to encode frame: %w", err)
+		}
+		frames[f] = buf.Bytes()
+	}
+	return frames, width, height, nil
+}
+
+// hsv converts a hue in degrees, saturation, and value to a color
+func hsv(h, s, v float64) color.RGBA {
+	h = math.Mod(h, 360) / 60
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = c, x
+	case 1:
+		r, g = x, c
+	case 2:
+		g, b = c, x
+	case 3:
+		g, b = x, c
+	case 4:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := v - c
+	return color.RGBA{R: uint8(255 * (r + m)), G: uint8(255 * (g + m)), B: uint8(255 * (b + m)), A: 255}
+}
+
+// simulatedJSON answers a request with v encoded as JSON
+func simulatedJSON(req *http.Request, status int, v interface{}) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode simulated response: %w", err)
+	}
+	return simulatedBody(req, status, "application/json", body), nil
+}
+
+// simulatedError answers a request with an API error
+func simulatedError(req *http.Request, status int, message string) (*http.Response, error) {
+	var body APIError
+	body.Error.Message = message
+	body.Error.Type = "invalid_request_error"
+	return simulatedJSON(req, status, body)
+}
+
+// simulatedBody answers a request with body
+func simulatedBody(req *http.Request, status int, contentType string, body []byte) *http.Response {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -14,26 +15,53 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/telemetry/video-gen/internal/backoff"
 )
 
 const (
-	baseURL        = "https://api.openai.com/v1"
-	createEndpoint = "/videos"
+	// DefaultBaseURL is the OpenAI API endpoint used unless SetBaseURL points elsewhere
+	DefaultBaseURL = "https://api.openai.com/v1"
+	// defaultAzureAPIVersion is the Azure OpenAI api-version used when none is configured
+	defaultAzureAPIVersion = "preview"
+	createEndpoint         = "/videos"
 )
 
+// createBackoff spaces out CreateVideo retries after server and network errors
+var createBackoff = backoff.Policy{Base: 2 * time.Second, Max: 10 * time.Second, Jitter: 0.2}
+
+// Doer sends HTTP requests. *http.Client implements it; SetHTTPClient takes
+// any other, e.g. a fake in tests or an instrumented client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 type SoraClient struct {
-	apiKey     string
-	httpClient *http.Client
-	debug      bool
-	debugLog   func(string)
+	apiKey          string
+	baseURL         string
+	azureAPIVersion string            // Set for Azure OpenAI, which authenticates with an api-key header
+	deployments     map[string]string // Azure deployment names by model
+	organization    string            // Sent as OpenAI-Organization when set
+	project         string            // Sent as OpenAI-Project when set
+	httpClient      Doer
+	ctx             context.Context // Bounds every request, nil for none (see WithContext)
+	debug           bool
+	debugLog        func(string)
+	limiter         *rateLimiter   // 429 backoff, shared by every client in the process unless isolated
+	status          *serviceStatus // Quota and jobs seen, shared like limiter
 }
 
 type CreateVideoRequest struct {
-	Prompt         string `json:"prompt"`
-	Model          string `json:"model,omitempty"`
-	Seconds        string `json:"seconds,omitempty"`
-	Size           string `json:"size,omitempty"`
-	InputReference string `json:"-"` // File path, handled separately
+	Prompt         string       `json:"prompt"`
+	Model          string       `json:"model,omitempty"`
+	Seconds        string       `json:"seconds,omitempty"`
+	Size           string       `json:"size,omitempty"`
+	InputReference string       `json:"-"` // File path, handled separately
+	CropAnchor     string       `json:"-"` // Part of InputReference kept when cropping (see ParseCropAnchor)
+	UploadProgress ProgressFunc `json:"-"` // Reports the upload of InputReference, optional
+	Negative       string       `json:"-"` // Exclusions already folded into Prompt, kept for the ledger and manifest
+	Parent         string       `json:"-"` // Past job whose prompt this one revises, recorded in the ledger
+	FallbackFrom   string       `json:"-"` // Model that refused the job before it was resubmitted with Model, kept for the ledger and manifest
 }
 
 type CreateVideoResponse struct {
@@ -61,11 +89,15 @@ type VideoResponse struct {
 	Size               string       `json:"size,omitempty"`
 	Object             string       `json:"object,omitempty"`
 	RemixedFromVideoID string       `json:"remixed_from_video_id,omitempty"`
+	Prompt             string       `json:"prompt,omitempty"`
 }
 
 type ListVideosResponse struct {
-	Data   []VideoResponse `json:"data"`
-	Object string          `json:"object"`
+	Data    []VideoResponse `json:"data"`
+	Object  string          `json:"object"`
+	FirstID string          `json:"first_id,omitempty"`
+	LastID  string          `json:"last_id,omitempty"`
+	HasMore bool            `json:"has_more"`
 }
 
 type APIError struct {
@@ -79,26 +111,151 @@ type APIError struct {
 func NewClient(apiKey string, debug bool, debugLog func(string)) *SoraClient {
 	return &SoraClient{
 		apiKey:   apiKey,
+		baseURL:  DefaultBaseURL,
 		debug:    debug,
 		debugLog: debugLog,
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 		},
+		limiter: openAILimiter,
+		status:  openAIStatus,
 	}
 }
 
-// CreateVideo initiates video generation with the Sora API with retry logic
+// Isolate gives the client its own 429 backoff and service status instead of
+// the ones shared by every client in the process, for programs whose clients
+// use different accounts
+func (c *SoraClient) Isolate() {
+	c.limiter = &rateLimiter{}
+	c.status = &serviceStatus{jobs: map[string]string{}}
+}
+
+// Name returns the provider name used in flags, config, and output
+func (c *SoraClient) Name() string {
+	return "sora"
+}
+
+// SetBaseURL points the client at an OpenAI-compatible API such as a gateway
+// proxy, e.g. "https://gateway.example.com/openai/v1". An empty url keeps the
+// current one.
+func (c *SoraClient) SetBaseURL(url string) {
+	if url != "" {
+		c.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// SetAzure switches the client to Azure OpenAI: requests go to the v1 API of
+// the resource at endpoint (e.g. "https://myresource.openai.azure.com") with an
+// api-key header and the api-version parameter, and model names are sent as
+// the deployment names mapped in deployments (unmapped names are sent as is)
+func (c *SoraClient) SetAzure(endpoint, apiVersion string, deployments map[string]string) {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/openai/v1") {
+		endpoint += "/openai/v1"
+	}
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+	c.baseURL = endpoint
+	c.azureAPIVersion = apiVersion
+	c.deployments = deployments
+}
+
+// SetOrganization bills requests to an OpenAI organization and project by
+// sending the OpenAI-Organization and OpenAI-Project headers. Empty values
+// leave the header off, so the key's default applies.
+func (c *SoraClient) SetOrganization(organization, project string) {
+	c.organization = organization
+	c.project = project
+}
+
+// deployment returns the name a model is sent as: its Azure deployment when
+// one is mapped, otherwise the model itself
+func (c *SoraClient) deployment(model string) string {
+	if name, ok := c.deployments[model]; ok {
+		return name
+	}
+	return model
+}
+
+// WithContext returns a copy of the client whose requests, retries, and
+// rate-limit waits are cancelled with ctx. The copy shares the original's
+// HTTP client and settings.
+func (c *SoraClient) WithContext(ctx context.Context) *SoraClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// context returns the context requests are made with
+func (c *SoraClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// newRequest creates an API request with the client's authentication: a
+// bearer token, or for Azure an api-key header and the api-version parameter,
+// plus the organization and project headers
+func (c *SoraClient) newRequest(method, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(c.context(), method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.organization != "" {
+		req.Header.Set("OpenAI-Organization", c.organization)
+	}
+	if c.project != "" {
+		req.Header.Set("OpenAI-Project", c.project)
+	}
+	if c.azureAPIVersion == "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		return req, nil
+	}
+
+	req.Header.Set("api-key", c.apiKey)
+	query := req.URL.Query()
+	query.Set("api-version", c.azureAPIVersion)
+	req.URL.RawQuery = query.Encode()
+	return req, nil
+}
+
+// SetHTTPClient replaces the HTTP client that sends all API calls
+func (c *SoraClient) SetHTTPClient(client Doer) {
+	c.httpClient = client
+}
+
+// SetTransport replaces the HTTP transport used for all API calls. An
+// *http.Client given to SetHTTPClient is copied rather than changed, as its
+// owner may share it; any other client is left as it is.
+func (c *SoraClient) SetTransport(rt http.RoundTripper) {
+	if client, ok := c.httpClient.(*http.Client); ok {
+		copied := *client
+		copied.Transport = rt
+		c.httpClient = &copied
+	}
+}
+
+// Transport returns the HTTP transport set with SetTransport, or nil for the default
+func (c *SoraClient) Transport() http.RoundTripper {
+	if client, ok := c.httpClient.(*http.Client); ok {
+		return client.Transport
+	}
+	return nil
+}
+
+// CreateVideo initiates video generation with the Sora API with retry logic.
+// Rate-limited attempts wait for the server's Retry-After and are retried up
+// to maxRateLimitRetries times without using up the regular retries.
 func (c *SoraClient) CreateVideo(req CreateVideoRequest) (*CreateVideoResponse, error) {
 	maxRetries := 3
+	maxRateLimitRetries := 5
 	var lastErr error
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff: 2s, 4s, 8s
-			waitTime := time.Duration(1<<uint(attempt)) * time.Second
-			time.Sleep(waitTime)
-		}
-
+	attempt, rateLimited, tries := 0, 0, 0
+	for attempt < maxRetries {
+		tries++
 		result, err := c.createVideoAttempt(req)
 		if err == nil {
 			return result, nil
@@ -106,130 +263,214 @@ func (c *SoraClient) CreateVideo(req CreateVideoRequest) (*CreateVideoResponse,
 
 		lastErr = err
 
+		// The shared backoff set by do() delays the next attempt
+		if isRateLimited(err) {
+			rateLimited++
+			if rateLimited > maxRateLimitRetries {
+				break
+			}
+			continue
+		}
+
 		// Don't retry on authentication or validation errors
 		if isClientError(err) {
 			break
 		}
+
+		attempt++
+		if attempt < maxRetries {
+			select {
+			case <-time.After(createBackoff.Delay(attempt)):
+			case <-c.context().Done():
+				return nil, c.context().Err()
+			}
+		}
 	}
 
-	return nil, fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+	return nil, fmt.Errorf("failed after %d attempts: %w", tries, lastErr)
 }
 
-func (c *SoraClient) createVideoAttempt(req CreateVideoRequest) (*CreateVideoResponse, error) {
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
+// referenceFile is an input_reference file ready to stream into a request
+type referenceFile struct {
+	filename    string
+	contentType string
+	body        io.Reader
+	size        int64
+	file        *os.File // Open video file, nil for images
+}
 
+// Close releases the open video file, if any
+func (r *referenceFile) Close() {
+	if r.file != nil {
+		r.file.Close()
+	}
+}
+
+// openReference prepares the input reference at path. Images are resized and
+// cropped to size at the crop anchor and re-encoded in memory; videos are read
+// from disk as they are while the request is sent.
+func openReference(path, size, cropAnchor string) (*referenceFile, error) {
+	anchor, err := ParseCropAnchor(cropAnchor)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open reference file: %w", err)
+	}
+
+	filename := filepath.Base(path)
+	if contentType, ok := videoReferenceType(path); ok {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read reference file: %w", err)
+		}
+		return &referenceFile{filename: filename, contentType: contentType, body: file, size: info.Size(), file: file}, nil
+	}
+	defer file.Close()
+
+	// Decode image
+	img, format, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Parse target dimensions from size string (e.g., "1280x720")
+	targetWidth, targetHeight, err := parseSize(size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size format: %w", err)
+	}
+
+	// Resize and crop image to match target dimensions
+	img = resizeAndCropToFill(img, targetWidth, targetHeight, anchor)
+
+	// Detect MIME type from format
+	contentType := "application/octet-stream"
+	switch format {
+	case "jpeg":
+		contentType = "image/jpeg"
+	case "png":
+		contentType = "image/png"
+	case "gif":
+		contentType = "image/gif"
+	}
+
+	// Encode resized image
+	var encoded bytes.Buffer
+	if format == "png" {
+		if err := png.Encode(&encoded, img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG: %w", err)
+		}
+	} else {
+		// Default to JPEG for other formats
+		if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 95}); err != nil {
+			return nil, fmt.Errorf("failed to encode JPEG: %w", err)
+		}
+	}
+	return &referenceFile{filename: filename, contentType: contentType, body: &encoded, size: int64(encoded.Len())}, nil
+}
+
+// writeCreateForm writes the multipart form of a create request, streaming
+// the reference (if any) into it, and closes writer
+func writeCreateForm(writer *multipart.Writer, req CreateVideoRequest, ref *referenceFile) error {
 	// Add text fields
 	if err := writer.WriteField("prompt", req.Prompt); err != nil {
-		return nil, fmt.Errorf("failed to write prompt: %w", err)
+		return fmt.Errorf("failed to write prompt: %w", err)
 	}
 
 	if req.Model != "" {
 		if err := writer.WriteField("model", req.Model); err != nil {
-			return nil, fmt.Errorf("failed to write model: %w", err)
+			return fmt.Errorf("failed to write model: %w", err)
 		}
 	}
 
 	if req.Seconds != "" {
 		if err := writer.WriteField("seconds", req.Seconds); err != nil {
-			return nil, fmt.Errorf("failed to write seconds: %w", err)
+			return fmt.Errorf("failed to write seconds: %w", err)
 		}
 	}
 
 	if req.Size != "" {
 		if err := writer.WriteField("size", req.Size); err != nil {
-			return nil, fmt.Errorf("failed to write size: %w", err)
+			return fmt.Errorf("failed to write size: %w", err)
 		}
 	}
 
 	// Add reference file if provided
-	if req.InputReference != "" {
-		file, err := os.Open(req.InputReference)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open reference file: %w", err)
-		}
-		defer file.Close()
-
-		// Decode image
-		img, format, err := image.Decode(file)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode image: %w", err)
-		}
-
-		// Parse target dimensions from size string (e.g., "1280x720")
-		targetWidth, targetHeight, err := parseSize(req.Size)
-		if err != nil {
-			return nil, fmt.Errorf("invalid size format: %w", err)
-		}
-
-		// Resize and crop image to match target dimensions
-		img = resizeAndCropToFill(img, targetWidth, targetHeight)
-
-		// Detect MIME type from format
-		filename := filepath.Base(req.InputReference)
-		contentType := "application/octet-stream"
-		switch format {
-		case "jpeg":
-			contentType = "image/jpeg"
-		case "png":
-			contentType = "image/png"
-		case "gif":
-			contentType = "image/gif"
-		}
-
+	if ref != nil {
 		// Create form file with proper Content-Type header
 		h := make(map[string][]string)
-		h["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="input_reference"; filename="%s"`, filename)}
-		h["Content-Type"] = []string{contentType}
+		h["Content-Disposition"] = []string{fmt.Sprintf(`form-data; name="input_reference"; filename="%s"`, ref.filename)}
+		h["Content-Type"] = []string{ref.contentType}
 		part, err := writer.CreatePart(h)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create form file: %w", err)
+			return fmt.Errorf("failed to create form file: %w", err)
 		}
 
-		// Encode resized image to part
-		if format == "png" {
-			if err := png.Encode(part, img); err != nil {
-				return nil, fmt.Errorf("failed to encode PNG: %w", err)
-			}
-		} else {
-			// Default to JPEG for other formats
-			if err := jpeg.Encode(part, img, &jpeg.Options{Quality: 95}); err != nil {
-				return nil, fmt.Errorf("failed to encode JPEG: %w", err)
-			}
+		body := ref.body
+		if req.UploadProgress != nil {
+			body = &progressReader{r: body, total: ref.size, fn: req.UploadProgress}
+		}
+		if _, err := io.Copy(part, body); err != nil {
+			return fmt.Errorf("failed to write reference file: %w", err)
 		}
 	}
 
 	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close writer: %w", err)
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
+
+func (c *SoraClient) createVideoAttempt(req CreateVideoRequest) (*CreateVideoResponse, error) {
+	// Prepare the reference first so a bad file fails before anything is sent
+	var ref *referenceFile
+	if req.InputReference != "" {
+		var err error
+		ref, err = openReference(req.InputReference, req.Size, req.CropAnchor)
+		if err != nil {
+			return nil, err
+		}
+		defer ref.Close()
 	}
 
+	req.Model = c.deployment(req.Model)
+
+	// Stream the form into the request instead of building it in memory
+	body, pipeWriter := io.Pipe()
+	defer body.Close()
+	writer := multipart.NewWriter(pipeWriter)
+	go func() {
+		pipeWriter.CloseWithError(writeCreateForm(writer, req, ref))
+	}()
+
 	// Create HTTP request
-	httpReq, err := http.NewRequest("POST", baseURL+createEndpoint, &body)
+	httpReq, err := c.newRequest("POST", c.baseURL+createEndpoint, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// Debug log request
 	if c.debug && c.debugLog != nil {
 		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
 			"method":  "POST",
-			"url":     baseURL + createEndpoint,
+			"url":     c.baseURL + createEndpoint,
 			"headers": map[string]string{"Content-Type": writer.FormDataContentType()},
 			"body": map[string]string{
-				"prompt": req.Prompt,
-				"model":  req.Model,
+				"prompt":  req.Prompt,
+				"model":   req.Model,
 				"seconds": req.Seconds,
-				"size": req.Size,
+				"size":    req.Size,
 			},
 		}, "", "  ")
 		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
 	}
 
 	// Execute request
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -264,6 +505,7 @@ func (c *SoraClient) createVideoAttempt(req CreateVideoRequest) (*CreateVideoRes
 				statusCode: resp.StatusCode,
 				message:    errMsg,
 				errorType:  apiErr.Error.Type,
+				code:       apiErr.Error.Code,
 			}
 		}
 		return nil, &httpError{
@@ -276,6 +518,7 @@ func (c *SoraClient) createVideoAttempt(req CreateVideoRequest) (*CreateVideoRes
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.status.observeJob(result.ID, result.Status)
 
 	return &result, nil
 }
@@ -284,6 +527,7 @@ type httpError struct {
 	statusCode int
 	message    string
 	errorType  string
+	code       string
 }
 
 func (e *httpError) Error() string {
@@ -295,23 +539,99 @@ func (e *httpError) Error() string {
 
 func isClientError(err error) bool {
 	if httpErr, ok := err.(*httpError); ok {
-		// 4xx errors are client errors - don't retry
-		return httpErr.statusCode >= 400 && httpErr.statusCode < 500
+		// 4xx errors are client errors - don't retry (except 429, which is handled separately)
+		return httpErr.statusCode >= 400 && httpErr.statusCode < 500 && httpErr.statusCode != http.StatusTooManyRequests
 	}
 	return false
 }
 
-// ListVideos retrieves a list of video jobs
+// postJSON sends a JSON request body to an API endpoint and decodes the JSON response into result
+func (c *SoraClient) postJSON(endpoint string, payload interface{}, result interface{}) error {
+	url := c.baseURL + endpoint
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := c.newRequest("POST", url, bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Debug log request
+	if c.debug && c.debugLog != nil {
+		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
+			"method": "POST",
+			"url":    url,
+			"body":   json.RawMessage(reqBody),
+		}, "", "  ")
+		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Debug log response
+	if c.debug && c.debugLog != nil {
+		var prettyJSON bytes.Buffer
+		if json.Indent(&prettyJSON, body, "", "  ") == nil {
+			c.debugLog(fmt.Sprintf("RESPONSE [%d]:\n%s", resp.StatusCode, prettyJSON.String()))
+		} else {
+			c.debugLog(fmt.Sprintf("RESPONSE [%d]:\n%s", resp.StatusCode, string(body)))
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var apiErr APIError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return &httpError{
+				statusCode: resp.StatusCode,
+				message:    apiErr.Error.Message,
+				errorType:  apiErr.Error.Type,
+				code:       apiErr.Error.Code,
+			}
+		}
+		return &httpError{
+			statusCode: resp.StatusCode,
+			message:    string(body),
+		}
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return nil
+}
+
+// ListVideos retrieves a list of video jobs, newest first
 func (c *SoraClient) ListVideos(limit int) (*ListVideosResponse, error) {
-	url := fmt.Sprintf("%s%s?limit=%d&order=desc", baseURL, createEndpoint, limit)
+	return c.ListVideosAfter(limit, "")
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// ListVideosAfter retrieves the page of video jobs that follows the job with
+// ID after (the previous page's LastID), or the first page when after is empty
+func (c *SoraClient) ListVideosAfter(limit int, after string) (*ListVideosResponse, error) {
+	url := fmt.Sprintf("%s%s?limit=%d&order=desc", c.baseURL, createEndpoint, limit)
+	if after != "" {
+		url += "&after=" + after
+	}
+
+	req, err := c.newRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
 	// Debug log request
 	if c.debug && c.debugLog != nil {
 		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
@@ -321,7 +641,7 @@ func (c *SoraClient) ListVideos(limit int) (*ListVideosResponse, error) {
 		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -350,21 +670,55 @@ func (c *SoraClient) ListVideos(limit int) (*ListVideosResponse, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	for _, video := range result.Data {
+		c.status.observeJob(video.ID, video.Status)
+	}
 
 	return &result, nil
 }
 
+// CheckKey makes a cheap authenticated request, listing one video, to tell
+// whether the client's key can generate videos before a job is submitted.
+// When the response says why the key was refused, the error wraps
+// ErrInvalidKey, ErrNoSoraAccess, or ErrOrgNotVerified.
+func (c *SoraClient) CheckKey() error {
+	url := fmt.Sprintf("%s%s?limit=1", c.baseURL, createEndpoint)
+	req, err := c.newRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	e := &httpError{statusCode: resp.StatusCode, message: string(body)}
+	var apiErr APIError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		e.message, e.errorType, e.code = apiErr.Error.Message, apiErr.Error.Type, apiErr.Error.Code
+	}
+	return keyError(e)
+}
+
 // GetVideo retrieves the status and URL of a video generation job
 func (c *SoraClient) GetVideo(videoID string) (*VideoResponse, error) {
-	url := fmt.Sprintf("%s%s/%s", baseURL, createEndpoint, videoID)
+	url := fmt.Sprintf("%s%s/%s", c.baseURL, createEndpoint, videoID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := c.newRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
 	// Debug log request
 	if c.debug && c.debugLog != nil {
 		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
@@ -374,7 +728,7 @@ func (c *SoraClient) GetVideo(videoID string) (*VideoResponse, error) {
 		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -395,21 +749,37 @@ func (c *SoraClient) GetVideo(videoID string) (*VideoResponse, error) {
 		}
 	}
 
+	// A typed error lets callers tell a rate limit or outage, worth polling
+	// through, from a job that does not exist
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		var apiErr APIError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, &httpError{
+				statusCode: resp.StatusCode,
+				message:    apiErr.Error.Message,
+				errorType:  apiErr.Error.Type,
+				code:       apiErr.Error.Code,
+			}
+		}
+		return nil, &httpError{statusCode: resp.StatusCode, message: string(body)}
 	}
 
 	var result VideoResponse
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
+	c.status.observeJob(result.ID, result.Status)
 
 	return &result, nil
 }
 
 // DownloadVideo downloads the video from the provided URL to the specified path
 func (c *SoraClient) DownloadVideo(videoURL, outputPath string) error {
-	resp, err := c.httpClient.Get(videoURL)
+	req, err := http.NewRequestWithContext(c.context(), http.MethodGet, videoURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download video: %w", err)
 	}
@@ -419,35 +789,28 @@ func (c *SoraClient) DownloadVideo(videoURL, outputPath string) error {
 		return fmt.Errorf("failed to download video (status %d)", resp.StatusCode)
 	}
 
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	out, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer out.Close()
+	return saveVideo(resp.Body, resp.ContentLength, outputPath, nil)
+}
 
-	if _, err := io.Copy(out, resp.Body); err != nil {
-		return fmt.Errorf("failed to write video data: %w", err)
+// RemixVideo creates a new video from a completed one, changed as described by prompt
+func (c *SoraClient) RemixVideo(videoID, prompt string) (*CreateVideoResponse, error) {
+	var resp CreateVideoResponse
+	if err := c.postJSON(createEndpoint+"/"+videoID+"/remix", map[string]string{"prompt": prompt}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to remix video: %w", err)
 	}
-
-	return nil
+	c.status.observeJob(resp.ID, resp.Status)
+	return &resp, nil
 }
 
 // DeleteVideo deletes a video job
 func (c *SoraClient) DeleteVideo(videoID string) error {
-	url := fmt.Sprintf("%s%s/%s", baseURL, createEndpoint, videoID)
+	url := fmt.Sprintf("%s%s/%s", c.baseURL, createEndpoint, videoID)
 
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := c.newRequest("DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
 	// Debug log request
 	if c.debug && c.debugLog != nil {
 		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
@@ -457,7 +820,7 @@ func (c *SoraClient) DeleteVideo(videoID string) error {
 		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -481,21 +844,58 @@ func (c *SoraClient) DeleteVideo(videoID string) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
 	}
+	c.status.observeJob(videoID, "")
 
 	return nil
 }
 
+// CancelVideo stops a queued or in-progress job. The videos API has no
+// separate cancel endpoint, so the job is deleted, which also stops it
+// using quota.
+func (c *SoraClient) CancelVideo(videoID string) error {
+	if err := c.DeleteVideo(videoID); err != nil {
+		return fmt.Errorf("failed to cancel video: %w", err)
+	}
+	return nil
+}
+
+// Preview variants served by the /content endpoint alongside the video
+const (
+	VariantThumbnail   = "thumbnail"   // Still image (WebP)
+	VariantSpritesheet = "spritesheet" // Grid of frames (JPEG)
+)
+
+// VariantExtension returns the file extension for a content variant
+func VariantExtension(variant string) string {
+	if variant == VariantThumbnail {
+		return ".webp"
+	}
+	return ".jpg"
+}
+
 // DownloadVideoContent downloads the video content directly from the /content endpoint
-func (c *SoraClient) DownloadVideoContent(videoID, outputPath string) error {
-	url := fmt.Sprintf("%s%s/%s/content", baseURL, createEndpoint, videoID)
+func (c *SoraClient) DownloadVideoContent(videoID, outputPath string, progress ProgressFunc) error {
+	return c.downloadContent(videoID, "", outputPath, progress)
+}
+
+// DownloadVideoVariant downloads a preview asset of a completed video, such as
+// VariantThumbnail or VariantSpritesheet
+func (c *SoraClient) DownloadVideoVariant(videoID, variant, outputPath string) error {
+	return c.downloadContent(videoID, variant, outputPath, nil)
+}
+
+// downloadContent downloads the video, or the given variant of it, from the /content endpoint
+func (c *SoraClient) downloadContent(videoID, variant, outputPath string, progress ProgressFunc) error {
+	url := fmt.Sprintf("%s%s/%s/content", c.baseURL, createEndpoint, videoID)
+	if variant != "" {
+		url += "?variant=" + variant
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := c.newRequest("GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
 	// Debug log request
 	if c.debug && c.debugLog != nil {
 		reqJSON, _ := json.MarshalIndent(map[string]interface{}{
@@ -505,7 +905,7 @@ func (c *SoraClient) DownloadVideoContent(videoID, outputPath string) error {
 		c.debugLog(fmt.Sprintf("REQUEST:\n%s", string(reqJSON)))
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download video content: %w", err)
 	}
@@ -521,23 +921,16 @@ func (c *SoraClient) DownloadVideoContent(videoID, outputPath string) error {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to download video content (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
-	}
-
-	out, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		httpErr := &httpError{statusCode: resp.StatusCode, message: string(body)}
+		var apiErr APIError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			httpErr.message, httpErr.errorType, httpErr.code = apiErr.Error.Message, apiErr.Error.Type, apiErr.Error.Code
+		}
+		return fmt.Errorf("failed to download video content: %w", httpErr)
 	}
-	defer out.Close()
 
-	if _, err := io.Copy(out, resp.Body); err != nil {
-		return fmt.Errorf("failed to write video data: %w", err)
+	if variant != "" {
+		return saveContent(resp.Body, resp.ContentLength, outputPath, progress)
 	}
-
-	return nil
+	return saveVideo(resp.Body, resp.ContentLength, outputPath, progress)
 }
@@ -0,0 +1,536 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/backoff"
+)
+
+// newTestClient returns a client for server with its own rate-limit backoff,
+// so a 429 in one test never delays another, and fast CreateVideo retries
+func newTestClient(t *testing.T, server *httptest.Server) *SoraClient {
+	t.Helper()
+	saved := createBackoff
+	createBackoff = backoff.Policy{Base: time.Millisecond, Max: 5 * time.Millisecond}
+	t.Cleanup(func() {
+		createBackoff = saved
+	})
+
+	client := NewClient("test-key", false, nil)
+	client.Isolate()
+	client.SetBaseURL(server.URL)
+	return client
+}
+
+// writeAPIError writes an OpenAI error response
+func writeAPIError(w http.ResponseWriter, status int, code, errorType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	var body APIError
+	body.Error.Code, body.Error.Type, body.Error.Message = code, errorType, message
+	json.NewEncoder(w).Encode(body)
+}
+
+// testMP4 returns a complete two-frame MP4
+func testMP4(t *testing.T) []byte {
+	t.Helper()
+	var frame bytes.Buffer
+	if err := jpeg.Encode(&frame, image.NewRGBA(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return encodeMJPEG([][]byte{frame.Bytes(), frame.Bytes()}, 16, 16, 2)
+}
+
+func TestCreateVideo(t *testing.T) {
+	reference := filepath.Join(t.TempDir(), "reference.png")
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 400, 400))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reference, encoded.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/videos" {
+			t.Errorf("request = %s %s, want POST /videos", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want the bearer token", got)
+		}
+		if got := r.Header.Get("OpenAI-Organization"); got != "org-1" {
+			t.Errorf("OpenAI-Organization = %q, want org-1", got)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse multipart body: %v", err)
+		}
+		fields := map[string]string{"prompt": "a red kite", "model": "sora-2", "seconds": "8", "size": "1280x720"}
+		for name, expected := range fields {
+			if got := r.FormValue(name); got != expected {
+				t.Errorf("field %s = %q, want %q", name, got, expected)
+			}
+		}
+
+		file, header, err := r.FormFile("input_reference")
+		if err != nil {
+			t.Fatalf("missing input_reference: %v", err)
+		}
+		defer file.Close()
+		if header.Filename != "reference.png" || header.Header.Get("Content-Type") != "image/png" {
+			t.Errorf("input_reference = %s (%s), want reference.png (image/png)", header.Filename, header.Header.Get("Content-Type"))
+		}
+		img, err := png.Decode(file)
+		if err != nil {
+			t.Fatalf("failed to decode input_reference: %v", err)
+		}
+		if size := img.Bounds().Size(); size.X != 1280 || size.Y != 720 {
+			t.Errorf("input_reference is %dx%d, want it cropped to 1280x720", size.X, size.Y)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"video_1","status":"queued","object":"video"}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetOrganization("org-1", "")
+	var uploaded int64
+	resp, err := client.CreateVideo(CreateVideoRequest{
+		Prompt:         "a red kite",
+		Model:          "sora-2",
+		Seconds:        "8",
+		Size:           "1280x720",
+		InputReference: reference,
+		UploadProgress: func(written, total int64) { uploaded = written },
+	})
+	if err != nil {
+		t.Fatalf("CreateVideo() = %v", err)
+	}
+	if resp.ID != "video_1" || resp.Status != "queued" {
+		t.Errorf("CreateVideo() = %+v, want video_1 queued", resp)
+	}
+	if uploaded == 0 {
+		t.Error("expected upload progress to be reported")
+	}
+}
+
+func TestCreateVideoAzure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/v1/videos" {
+			t.Errorf("path = %s, want /openai/v1/videos", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "preview" {
+			t.Errorf("api-version = %q, want preview", got)
+		}
+		if got := r.Header.Get("api-key"); got != "test-key" {
+			t.Errorf("api-key = %q, want the key", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none for Azure", got)
+		}
+		if got := r.FormValue("model"); got != "my-sora" {
+			t.Errorf("model = %q, want the deployment name", got)
+		}
+		fmt.Fprint(w, `{"id":"video_1","status":"queued"}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetAzure(server.URL, "", map[string]string{"sora-2": "my-sora"})
+	if _, err := client.CreateVideo(CreateVideoRequest{Prompt: "a kite", Model: "sora-2"}); err != nil {
+		t.Fatalf("CreateVideo() = %v", err)
+	}
+}
+
+func TestGetVideo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/videos/video_1" {
+			t.Errorf("request = %s %s, want GET /videos/video_1", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"id":"video_1","status":"in_progress","progress":40,"model":"sora-2","size":"1280x720"}`)
+	}))
+	defer server.Close()
+
+	video, err := newTestClient(t, server).GetVideo("video_1")
+	if err != nil {
+		t.Fatalf("GetVideo() = %v", err)
+	}
+	if video.Status != "in_progress" || video.Progress != 40 || video.Size != "1280x720" {
+		t.Errorf("GetVideo() = %+v", video)
+	}
+}
+
+func TestListVideosPagination(t *testing.T) {
+	videos := []string{"video_5", "video_4", "video_3", "video_2", "video_1"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("order") != "desc" {
+			t.Errorf("order = %q, want desc", query.Get("order"))
+		}
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		start := 0
+		if after := query.Get("after"); after != "" {
+			for i, id := range videos {
+				if id == after {
+					start = i + 1
+				}
+			}
+		}
+		end := start + limit
+		if end > len(videos) {
+			end = len(videos)
+		}
+		page := ListVideosResponse{Object: "list", HasMore: end < len(videos)}
+		for _, id := range videos[start:end] {
+			page.Data = append(page.Data, VideoResponse{ID: id, Status: "completed"})
+		}
+		page.FirstID, page.LastID = page.Data[0].ID, page.Data[len(page.Data)-1].ID
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	var listed []string
+	after := ""
+	for pages := 0; ; pages++ {
+		if pages > len(videos) {
+			t.Fatal("pagination did not end")
+		}
+		page, err := client.ListVideosAfter(2, after)
+		if err != nil {
+			t.Fatalf("ListVideosAfter(2, %q) = %v", after, err)
+		}
+		for _, video := range page.Data {
+			listed = append(listed, video.ID)
+		}
+		if !page.HasMore {
+			break
+		}
+		after = page.LastID
+	}
+	if strings.Join(listed, ",") != strings.Join(videos, ",") {
+		t.Errorf("listed %v, want %v", listed, videos)
+	}
+}
+
+func TestDownloadVideoContent(t *testing.T) {
+	video := testMP4(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/videos/video_1/content":
+			if variant := r.URL.Query().Get("variant"); variant != "" {
+				fmt.Fprint(w, "thumbnail")
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(video)))
+			w.Write(video)
+		case "/videos/truncated/content":
+			w.Header().Set("Content-Length", strconv.Itoa(len(video)))
+			w.Write(video[:len(video)/2])
+		case "/videos/not_a_video/content":
+			fmt.Fprint(w, "not an mp4")
+		default:
+			writeAPIError(w, http.StatusNotFound, "", "invalid_request_error", "Video not found")
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	dir := t.TempDir()
+
+	t.Run("video with progress", func(t *testing.T) {
+		output := filepath.Join(dir, "nested", "video.mp4")
+		var written, total int64
+		if err := client.DownloadVideoContent("video_1", output, func(w, t int64) { written, total = w, t }); err != nil {
+			t.Fatalf("DownloadVideoContent() = %v", err)
+		}
+		if written != int64(len(video)) || total != int64(len(video)) {
+			t.Errorf("progress ended at %d of %d, want %d of %d", written, total, len(video), len(video))
+		}
+		saved, err := os.ReadFile(output)
+		if err != nil || !bytes.Equal(saved, video) {
+			t.Errorf("saved video differs from the served one (%v)", err)
+		}
+	})
+
+	t.Run("variant", func(t *testing.T) {
+		output := filepath.Join(dir, "thumbnail.webp")
+		if err := client.DownloadVideoVariant("video_1", VariantThumbnail, output); err != nil {
+			t.Fatalf("DownloadVideoVariant() = %v", err)
+		}
+		if saved, _ := os.ReadFile(output); string(saved) != "thumbnail" {
+			t.Errorf("saved variant = %q", saved)
+		}
+	})
+
+	failures := []struct {
+		name    string
+		videoID string
+		cause   error // Expected to be wrapped by the error, nil for none
+		status  int
+	}{
+		// The HTTP client itself notices a body shorter than its Content-Length
+		{"truncated", "truncated", io.ErrUnexpectedEOF, 0},
+		{"not an mp4", "not_a_video", ErrIncompleteDownload, 0},
+		{"not found", "missing", nil, http.StatusNotFound},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(dir, tt.videoID+".mp4")
+			err := client.DownloadVideoContent(tt.videoID, output, nil)
+			if err == nil {
+				t.Fatal("DownloadVideoContent() = nil, want an error")
+			}
+			if tt.cause != nil && !errors.Is(err, tt.cause) {
+				t.Errorf("DownloadVideoContent() = %v, want it to wrap %v", err, tt.cause)
+			}
+			if got := StatusCode(err); got != tt.status {
+				t.Errorf("StatusCode(%v) = %d, want %d", err, got, tt.status)
+			}
+			if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+				t.Errorf("expected no file to be left at %s", output)
+			}
+		})
+	}
+}
+
+func TestDeleteVideo(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("method = %s, want DELETE", r.Method)
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/videos/")
+		if id == "missing" {
+			writeAPIError(w, http.StatusNotFound, "", "invalid_request_error", "Video not found")
+			return
+		}
+		deleted = append(deleted, id)
+		fmt.Fprintf(w, `{"id":%q,"object":"video.deleted","deleted":true}`, id)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if err := client.DeleteVideo("video_1"); err != nil {
+		t.Fatalf("DeleteVideo() = %v", err)
+	}
+	if err := client.CancelVideo("video_2"); err != nil {
+		t.Fatalf("CancelVideo() = %v", err)
+	}
+	if strings.Join(deleted, ",") != "video_1,video_2" {
+		t.Errorf("deleted %v, want video_1 and video_2", deleted)
+	}
+	if err := client.DeleteVideo("missing"); err == nil {
+		t.Error("DeleteVideo() of a missing video = nil, want an error")
+	}
+}
+
+func TestCreateVideoErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		code          string
+		errorType     string
+		message       string
+		contentPolicy bool
+		quota         bool
+		mentions      string
+	}{
+		{"invalid key", http.StatusUnauthorized, "invalid_api_key", "invalid_request_error", "Incorrect API key provided", false, false, "Incorrect API key"},
+		{"no access", http.StatusForbidden, "", "invalid_request_error", "You do not have access to sora-2", false, true, "do not have access"},
+		{"billing limit", http.StatusBadRequest, "billing_hard_limit_reached", "invalid_request_error", "Billing hard limit has been reached", false, true, "Billing hard limit"},
+		{"moderation", http.StatusBadRequest, "moderation_blocked", "invalid_request_error", "Your request was blocked by our moderation system.", true, false, "moderation"},
+		{"content policy message", http.StatusBadRequest, "", "invalid_request_error", "This prompt violates our content policy", true, false, "content policy"},
+		{"reference size", http.StatusBadRequest, "", "invalid_request_error", "Inpaint image must match the requested width and height", false, false, "Hint: Your reference image must be exactly 1280x720"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				writeAPIError(w, tt.status, tt.code, tt.errorType, tt.message)
+			}))
+			defer server.Close()
+
+			_, err := newTestClient(t, server).CreateVideo(CreateVideoRequest{Prompt: "a kite", Size: "1280x720"})
+			if err == nil {
+				t.Fatal("CreateVideo() = nil, want an error")
+			}
+			if requests != 1 {
+				t.Errorf("sent %d requests, want 1 (client errors are not retried)", requests)
+			}
+			if got := StatusCode(err); got != tt.status {
+				t.Errorf("StatusCode() = %d, want %d", got, tt.status)
+			}
+			if got := IsContentPolicy(err); got != tt.contentPolicy {
+				t.Errorf("IsContentPolicy() = %t, want %t", got, tt.contentPolicy)
+			}
+			if got := IsQuotaOrTier(err); got != tt.quota {
+				t.Errorf("IsQuotaOrTier() = %t, want %t", got, tt.quota)
+			}
+			if !strings.Contains(err.Error(), tt.mentions) {
+				t.Errorf("error %q does not mention %q", err, tt.mentions)
+			}
+		})
+	}
+
+	if got := StatusCode(errors.New("connection refused")); got != 0 {
+		t.Errorf("StatusCode() of a network error = %d, want 0", got)
+	}
+}
+
+func TestCreateVideoRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  []int // Status of each response; the last one repeats
+		header   http.Header
+		requests int
+		status   int // Expected StatusCode of the final error, 0 for success
+		minGap   time.Duration
+	}{
+		{"rate limited then created", []int{429, 200}, http.Header{"Retry-After-Ms": {"50"}}, 2, 0, 50 * time.Millisecond},
+		{"retry-after seconds", []int{429, 200}, http.Header{"Retry-After": {"0.05"}}, 2, 0, 50 * time.Millisecond},
+		{"server errors then created", []int{500, 503, 200}, nil, 3, 0, 0},
+		{"server errors exhaust retries", []int{502}, nil, 3, http.StatusBadGateway, 0},
+		{"rate limits exhaust retries", []int{429}, http.Header{"Retry-After-Ms": {"1"}}, 6, http.StatusTooManyRequests, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var times []time.Time
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(io.Discard, r.Body)
+				mu.Lock()
+				times = append(times, time.Now())
+				n := len(times)
+				mu.Unlock()
+
+				status := tt.replies[len(tt.replies)-1]
+				if n <= len(tt.replies) {
+					status = tt.replies[n-1]
+				}
+				if status == http.StatusOK {
+					fmt.Fprint(w, `{"id":"video_1","status":"queued"}`)
+					return
+				}
+				for name, values := range tt.header {
+					w.Header()[name] = values
+				}
+				writeAPIError(w, status, "", "server_error", http.StatusText(status))
+			}))
+			defer server.Close()
+
+			resp, err := newTestClient(t, server).CreateVideo(CreateVideoRequest{Prompt: "a kite"})
+			if len(times) != tt.requests {
+				t.Errorf("sent %d requests, want %d", len(times), tt.requests)
+			}
+			if tt.status == 0 {
+				if err != nil || resp.ID != "video_1" {
+					t.Fatalf("CreateVideo() = %v, %v; want video_1", resp, err)
+				}
+			} else if got := StatusCode(err); got != tt.status {
+				t.Fatalf("StatusCode(%v) = %d, want %d", err, got, tt.status)
+			}
+			if tt.minGap > 0 && len(times) > 1 {
+				if gap := times[1].Sub(times[0]); gap < tt.minGap {
+					t.Errorf("retried after %s, want at least the %s Retry-After", gap, tt.minGap)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{"milliseconds", http.Header{"Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"request reset", http.Header{"X-Ratelimit-Reset-Requests": {"6s"}}, 6 * time.Second},
+		{"milliseconds first", http.Header{"Retry-After-Ms": {"200"}, "Retry-After": {"9"}}, 200 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header); got != tt.expected {
+				t.Errorf("retryAfter() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	// Without any header the default wait is jittered by 20%
+	if got := retryAfter(http.Header{}); got < 16*time.Second || got > 24*time.Second {
+		t.Errorf("retryAfter() without headers = %s, want about %s", got, defaultRateLimitWait)
+	}
+}
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		code    string
+		message string
+		want    error
+	}{
+		{"valid", http.StatusOK, "", "", nil},
+		{"invalid key", http.StatusUnauthorized, "invalid_api_key", "Incorrect API key provided", ErrInvalidKey},
+		{"no Sora access", http.StatusForbidden, "", "Your project does not have access to sora-2", ErrNoSoraAccess},
+		{"unverified org", http.StatusForbidden, "", "Your organization must be verified to use the model sora-2", ErrOrgNotVerified},
+		{"outage", http.StatusInternalServerError, "", "The server had an error", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "GET" || r.Header.Get("Authorization") != "Bearer test-key" {
+					t.Errorf("got %s with %q, want an authenticated GET", r.Method, r.Header.Get("Authorization"))
+				}
+				if tt.status == http.StatusOK {
+					w.Write([]byte(`{"data":[]}`))
+					return
+				}
+				writeAPIError(w, tt.status, tt.code, "invalid_request_error", tt.message)
+			}))
+			defer server.Close()
+
+			err := newTestClient(t, server).CheckKey()
+			if tt.status == http.StatusOK {
+				if err != nil {
+					t.Errorf("CheckKey() error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("CheckKey() = nil, want an error")
+			}
+			if tt.want == nil {
+				if IsKeyError(err) {
+					t.Errorf("CheckKey() = %v, want an error that is not about the key", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("CheckKey() = %v, want %v", err, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("CheckKey() = %v, want the API's message %q", err, tt.message)
+			}
+		})
+	}
+}
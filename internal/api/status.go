@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimits is the request quota OpenAI last reported in the
+// x-ratelimit-*-requests headers of a response
+type RateLimits struct {
+	Limit       int           // Requests allowed per window
+	Remaining   int           // Requests left in the window
+	Reset       time.Duration // Until the window resets, as of Updated
+	Updated     time.Time     // When a response last carried the headers, zero when none has
+	PausedUntil time.Time     // End of the pause after a 429, zero when never rate limited
+}
+
+// Known reports whether any response has carried the rate-limit headers
+func (r RateLimits) Known() bool {
+	return !r.Updated.IsZero()
+}
+
+// Paused returns how much longer requests are held after a 429, or 0
+func (r RateLimits) Paused() time.Duration {
+	if d := time.Until(r.PausedUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// String summarizes the limits, e.g. "48/50 requests left, resets in 1.2s"
+func (r RateLimits) String() string {
+	if !r.Known() {
+		return "rate limits not reported yet"
+	}
+	s := fmt.Sprintf("%d/%d requests left", r.Remaining, r.Limit)
+	if left := r.Reset - time.Since(r.Updated); left > 0 && r.Remaining < r.Limit {
+		s += fmt.Sprintf(", resets in %s", left.Round(100*time.Millisecond))
+	}
+	if paused := r.Paused(); paused > 0 {
+		s += fmt.Sprintf(", rate limited for %s", paused.Round(time.Second))
+	}
+	return s
+}
+
+// serviceStatus is what the responses of this process have shown about the
+// account: its request quota and the jobs rendering on it
+type serviceStatus struct {
+	mu     sync.Mutex
+	limits RateLimits
+	jobs   map[string]string // Status of every job seen, by ID
+}
+
+// openAIStatus is shared by every SoraClient in the process unless it is
+// isolated, as the quota is per account rather than per client
+var openAIStatus = &serviceStatus{jobs: map[string]string{}}
+
+// observeHeaders records the request quota of a response, if it carries one
+func (s *serviceStatus) observeHeaders(h http.Header) {
+	limit, err := strconv.Atoi(h.Get("x-ratelimit-limit-requests"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(h.Get("x-ratelimit-remaining-requests"))
+	if err != nil {
+		return
+	}
+	reset, _ := time.ParseDuration(h.Get("x-ratelimit-reset-requests"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits.Limit, s.limits.Remaining, s.limits.Reset = limit, remaining, reset
+	s.limits.Updated = time.Now()
+}
+
+// observePause records a 429 pause ending at until
+func (s *serviceStatus) observePause(until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if until.After(s.limits.PausedUntil) {
+		s.limits.PausedUntil = until
+	}
+}
+
+// observeJob records the latest status of a job; an empty status forgets it
+func (s *serviceStatus) observeJob(id, status string) {
+	if id == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == "" {
+		delete(s.jobs, id)
+		return
+	}
+	s.jobs[id] = status
+}
+
+// CurrentRateLimits returns the OpenAI request quota as last reported
+func CurrentRateLimits() RateLimits {
+	openAIStatus.mu.Lock()
+	defer openAIStatus.mu.Unlock()
+	return openAIStatus.limits
+}
+
+// ActiveJobs counts the jobs seen queued or rendering on the service, from
+// the responses to creating, checking, and listing videos in this process
+func ActiveJobs() (queued, inProgress int) {
+	openAIStatus.mu.Lock()
+	defer openAIStatus.mu.Unlock()
+	for _, status := range openAIStatus.jobs {
+		switch status {
+		case "queued":
+			queued++
+		case "in_progress":
+			inProgress++
+		}
+	}
+	return queued, inProgress
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CallTiming breaks an API call's duration down by phase. Phases that did
+// not happen, such as DNS and connecting on a reused connection, are zero.
+type CallTiming struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration // From the request being sent to the first response byte
+	Total   time.Duration // From the start of the call until its response was read or closed
+	Reused  bool          // The call reused a kept-alive connection
+}
+
+// MarshalJSON writes the phases in milliseconds
+func (t CallTiming) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return float64(d.Microseconds()) / 1000
+	}
+	return json.Marshal(struct {
+		DNS     float64 `json:"dns_ms"`
+		Connect float64 `json:"connect_ms"`
+		TLS     float64 `json:"tls_ms"`
+		TTFB    float64 `json:"ttfb_ms"`
+		Total   float64 `json:"total_ms"`
+		Reused  bool    `json:"reused_connection"`
+	}{ms(t.DNS), ms(t.Connect), ms(t.TLS), ms(t.TTFB), ms(t.Total), t.Reused})
+}
+
+// String lists the phases that happened, e.g. "dns 3ms, connect 41ms, tls 88ms, ttfb 20.4s, total 20.5s"
+func (t CallTiming) String() string {
+	var parts []string
+	for _, phase := range []struct {
+		name     string
+		duration time.Duration
+	}{{"dns", t.DNS}, {"connect", t.Connect}, {"tls", t.TLS}, {"ttfb", t.TTFB}, {"total", t.Total}} {
+		if phase.duration > 0 || phase.name == "total" {
+			parts = append(parts, fmt.Sprintf("%s %s", phase.name, roundDuration(phase.duration)))
+		}
+	}
+	if t.Reused {
+		parts = append(parts, "reused connection")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// roundDuration keeps durations readable: hundredths of a millisecond for
+// local lookups and connections, whole milliseconds below a second, and
+// tenths of a second above
+func roundDuration(d time.Duration) time.Duration {
+	if d < 10*time.Millisecond {
+		return d.Round(10 * time.Microsecond)
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(100 * time.Millisecond)
+}
+
+// callTrace collects the phase timestamps of one call. The httptrace hooks
+// can run on the transport's dialing goroutines, hence the lock.
+type callTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	timing       CallTiming
+}
+
+// traceRequest returns req with hooks that time each phase of the call
+func traceRequest(req *http.Request) (*http.Request, *callTrace) {
+	t := &callTrace{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mark(func() { t.timing.DNS = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			t.mark(func() {
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			t.mark(func() { t.timing.Connect = time.Since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			t.mark(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mark(func() { t.timing.TLS = time.Since(t.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mark(func() { t.timing.Reused = info.Reused })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mark(func() { t.wroteRequest = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			t.mark(func() {
+				if !t.wroteRequest.IsZero() {
+					t.timing.TTFB = time.Since(t.wroteRequest)
+				}
+			})
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+// mark records a phase under the lock
+func (t *callTrace) mark(record func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record()
+}
+
+// finish ends the call and returns its timing
+func (t *callTrace) finish() CallTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timing.Total = time.Since(t.start)
+	return t.timing
+}
+
+// tracedBody calls done once the response body has been read to the end or
+// closed, which is when the call is complete
+type tracedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// phaseStats accumulates one phase across calls
+type phaseStats struct {
+	count int
+	sum   time.Duration
+	max   time.Duration
+}
+
+func (p *phaseStats) add(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	p.count++
+	p.sum += d
+	if d > p.max {
+		p.max = d
+	}
+}
+
+// callStats accumulates timing for every call of a run
+type callStats struct {
+	calls   int
+	phases  [5]phaseStats // dns, connect, tls, ttfb, total
+	slowest DebugEntry
+}
+
+func (s *callStats) add(entry DebugEntry) {
+	s.calls++
+	for i, d := range []time.Duration{entry.Timing.DNS, entry.Timing.Connect, entry.Timing.TLS, entry.Timing.TTFB, entry.Timing.Total} {
+		s.phases[i].add(d)
+	}
+	if entry.Timing.Total > s.slowest.Timing.Total {
+		s.slowest = entry
+	}
+}
+
+// Summary describes the timing of every call logged so far, phase by phase
+// with the slowest call, or returns "" when there were none
+func (l *DebugLog) Summary() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := l.stats
+	if s.calls == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	if s.calls == 1 {
+		sb.WriteString("API call timing (1 call):\n")
+	} else {
+		fmt.Fprintf(&sb, "API call timing (%d calls):\n", s.calls)
+	}
+	for i, name := range []string{"dns", "connect", "tls", "ttfb", "total"} {
+		phase := s.phases[i]
+		if phase.count == 0 {
+			continue
+		}
+		avg := phase.sum / time.Duration(phase.count)
+		fmt.Fprintf(&sb, "  %-8s avg %-8s max %-8s (%d of %d)\n", name, roundDuration(avg), roundDuration(phase.max), phase.count, s.calls)
+	}
+	fmt.Fprintf(&sb, "  slowest: %s %s (%s)\n", s.slowest.Method, s.slowest.URL, s.slowest.Timing)
+	return sb.String()
+}
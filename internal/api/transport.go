@@ -0,0 +1,24 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// NewProxyTransport returns a transport that sends every request through
+// proxyURL (http, https, or socks5). It returns nil when proxyURL is empty;
+// the default transport then honors HTTPS_PROXY, HTTP_PROXY, and NO_PROXY.
+func NewProxyTransport(proxyURL string) (http.RoundTripper, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", proxyURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	return transport, nil
+}
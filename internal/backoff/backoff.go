@@ -0,0 +1,51 @@
+// Package backoff spaces out retries and status checks: each wait grows
+// exponentially up to a cap and is randomized, so many clients retrying at
+// once spread out instead of hitting the API in lockstep.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// defaultFactor is the growth per attempt when a Policy does not set one
+const defaultFactor = 2
+
+// Policy describes a series of waits
+type Policy struct {
+	Base   time.Duration // Wait before the first retry
+	Max    time.Duration // Cap on any wait, before jitter; 0 for none
+	Factor float64       // Growth per attempt; 0 for 2
+	Jitter float64       // Fraction each wait is randomized by in either direction, e.g. 0.2 for ±20%
+}
+
+// Step returns the wait before retry number attempt (1 for the first retry)
+// without jitter, for display
+func (p Policy) Step(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	factor := p.Factor
+	if factor <= 0 {
+		factor = defaultFactor
+	}
+	wait := float64(p.Base) * math.Pow(factor, float64(attempt-1))
+	if p.Max > 0 && wait > float64(p.Max) {
+		return p.Max
+	}
+	return time.Duration(wait)
+}
+
+// Delay returns the wait before retry number attempt (1 for the first retry)
+func (p Policy) Delay(attempt int) time.Duration {
+	return Jitter(p.Step(attempt), p.Jitter)
+}
+
+// Jitter randomizes d by up to fraction of it in either direction
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+}
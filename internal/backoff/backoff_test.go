@@ -0,0 +1,68 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry waits the base", Policy{Base: 2 * time.Second}, 1, 2 * time.Second},
+		{"doubles by default", Policy{Base: 2 * time.Second}, 3, 8 * time.Second},
+		{"grows by the factor", Policy{Base: 10 * time.Second, Factor: 1.5}, 3, 22500 * time.Millisecond},
+		{"capped", Policy{Base: 10 * time.Second, Max: 30 * time.Second, Factor: 1.5}, 4, 30 * time.Second},
+		{"no cap", Policy{Base: time.Second}, 11, 1024 * time.Second},
+		{"attempt below 1 is the first", Policy{Base: 5 * time.Second}, 0, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Step(tt.attempt); got != tt.want {
+				t.Errorf("Step(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		low     time.Duration
+		high    time.Duration
+	}{
+		{"no jitter", Policy{Base: 5 * time.Second}, 2, 10 * time.Second, 10 * time.Second},
+		{"±20%", Policy{Base: 10 * time.Second, Jitter: 0.2}, 1, 8 * time.Second, 12 * time.Second},
+		{"jitter applies after the cap", Policy{Base: 10 * time.Second, Max: 30 * time.Second, Factor: 1.5, Jitter: 0.2}, 9, 24 * time.Second, 36 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spread := false
+			first := tt.policy.Delay(tt.attempt)
+			for i := 0; i < 200; i++ {
+				got := tt.policy.Delay(tt.attempt)
+				if got < tt.low || got > tt.high {
+					t.Fatalf("Delay(%d) = %s, want between %s and %s", tt.attempt, got, tt.low, tt.high)
+				}
+				spread = spread || got != first
+			}
+			if want := tt.low != tt.high; spread != want {
+				t.Errorf("Delay(%d) randomized = %t, want %t", tt.attempt, spread, want)
+			}
+		})
+	}
+}
+
+func TestJitterEdgeCases(t *testing.T) {
+	if got := Jitter(0, 0.5); got != 0 {
+		t.Errorf("Jitter(0, 0.5) = %s, want 0", got)
+	}
+	if got := Jitter(time.Second, -1); got != time.Second {
+		t.Errorf("Jitter(1s, -1) = %s, want 1s", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Package budget keeps estimated spend within the monthly and per-run limits
+// set in the config. Soft budgets only warn; hard limits refuse jobs unless
+// they are forced.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrOverLimit is wrapped by the error for a job that would pass a hard limit
+var ErrOverLimit = errors.New("over budget")
+
+// Budget is the spending limits in USD. Zero means no limit.
+type Budget struct {
+	Monthly      float64 // Warn when a job takes the month's spend past this
+	MonthlyLimit float64 // Refuse jobs that take the month's spend past this
+	Session      float64 // Warn when a job takes the run's spend past this
+	SessionLimit float64 // Refuse jobs that take the run's spend past this
+}
+
+// Set reports whether any limit is configured
+func (b Budget) Set() bool {
+	return b.Monthly > 0 || b.MonthlyLimit > 0 || b.Session > 0 || b.SessionLimit > 0
+}
+
+// Tracker counts the spend of one run against a budget. It is safe for
+// concurrent jobs.
+type Tracker struct {
+	budget Budget
+	month  float64 // Spent this month before the run
+
+	mu      sync.Mutex
+	session float64 // Committed by the jobs the run submitted
+}
+
+// NewTracker returns a tracker for a run starting with monthToDate already
+// spent this month
+func NewTracker(b Budget, monthToDate float64) *Tracker {
+	return &Tracker{budget: b, month: monthToDate}
+}
+
+// Check describes what submitting a job of the given cost would do to the
+// budget: a warning when it passes a soft budget, and an error wrapping
+// ErrOverLimit when it passes a hard limit. Both are empty when it fits.
+func (t *Tracker) Check(cost float64) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.check(cost)
+}
+
+func (t *Tracker) check(cost float64) (string, error) {
+	session := t.session + cost
+	month := t.month + session
+
+	if limit := t.budget.SessionLimit; limit > 0 && session > limit {
+		return "", fmt.Errorf("%w: this job ($%.2f) would bring this run's estimated spend to $%.2f, past the session_limit of $%.2f", ErrOverLimit, cost, session, limit)
+	}
+	if limit := t.budget.MonthlyLimit; limit > 0 && month > limit {
+		return "", fmt.Errorf("%w: this job ($%.2f) would bring this month's estimated spend to $%.2f, past the monthly_limit of $%.2f", ErrOverLimit, cost, month, limit)
+	}
+	if budget := t.budget.Session; budget > 0 && session > budget {
+		return fmt.Sprintf("this job ($%.2f) brings this run's estimated spend to $%.2f, past the session_budget of $%.2f", cost, session, budget), nil
+	}
+	if budget := t.budget.Monthly; budget > 0 && month > budget {
+		return fmt.Sprintf("this job ($%.2f) brings this month's estimated spend to $%.2f, past the monthly_budget of $%.2f", cost, month, budget), nil
+	}
+	return "", nil
+}
+
+// Reserve commits the cost of a job about to be submitted, returning any
+// warning. A job past a hard limit is refused with an error and not
+// committed, unless force is set, in which case the error becomes a warning.
+func (t *Tracker) Reserve(cost float64, force bool) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	warning, err := t.check(cost)
+	if err != nil {
+		if !force {
+			return "", err
+		}
+		warning = err.Error() + " (forced)"
+	}
+	t.session += cost
+	return warning, nil
+}
+
+// Release returns the cost of a job that was not billed, e.g. because it was
+// rejected or failed
+func (t *Tracker) Release(cost float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.session -= cost
+	if t.session < 0 {
+		t.session = 0
+	}
+}
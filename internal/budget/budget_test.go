@@ -0,0 +1,98 @@
+package budget
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		budget   Budget
+		month    float64   // Spent this month before the run
+		reserved []float64 // Reserved before the job
+		cost     float64
+		force    bool
+		warning  string // Substring of the warning; "" for none
+		over     bool   // Refused with ErrOverLimit
+	}{
+		{"no limits", Budget{}, 100, nil, 5, false, "", false},
+		{"up to the session limit", Budget{SessionLimit: 2}, 0, []float64{1.2}, 0.8, false, "", false},
+		{"past the session limit", Budget{SessionLimit: 2}, 0, []float64{1.2}, 0.81, false, "", true},
+		{"up to the monthly limit", Budget{MonthlyLimit: 10}, 9, nil, 1, false, "", false},
+		{"past the monthly limit", Budget{MonthlyLimit: 10}, 9, []float64{0.5}, 0.6, false, "", true},
+		{"forced past a limit", Budget{SessionLimit: 1}, 0, nil, 1.2, true, "past the session_limit of $1.00 (forced)", false},
+		{"past the session budget", Budget{Session: 1}, 0, []float64{0.8}, 0.4, false, "past the session_budget of $1.00", false},
+		{"past the monthly budget", Budget{Monthly: 10}, 9.5, nil, 0.8, false, "past the monthly_budget of $10.00", false},
+		{"limit before budget", Budget{Session: 1, SessionLimit: 1.5}, 0, nil, 2, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(tt.budget, tt.month)
+			for _, cost := range tt.reserved {
+				if _, err := tracker.Reserve(cost, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			warning, err := tracker.Reserve(tt.cost, tt.force)
+			if tt.over {
+				if !errors.Is(err, ErrOverLimit) {
+					t.Fatalf("Reserve() error = %v, want ErrOverLimit", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Reserve() error: %v", err)
+			}
+			if (tt.warning == "") != (warning == "") || !strings.Contains(warning, tt.warning) {
+				t.Errorf("Reserve() warning = %q, want %q", warning, tt.warning)
+			}
+		})
+	}
+}
+
+func TestReleaseAtTheLimit(t *testing.T) {
+	tracker := NewTracker(Budget{SessionLimit: 1}, 0)
+	if _, err := tracker.Reserve(1, false); err != nil {
+		t.Fatalf("Reserve() up to the limit: %v", err)
+	}
+	// A refused job is not committed, so it takes no room
+	if _, err := tracker.Reserve(0.5, false); !errors.Is(err, ErrOverLimit) {
+		t.Fatalf("Reserve() past the limit error = %v, want ErrOverLimit", err)
+	}
+
+	// A job that was not billed gives its room back
+	tracker.Release(0.5)
+	if _, err := tracker.Reserve(0.5, false); err != nil {
+		t.Errorf("Reserve() after Release: %v", err)
+	}
+
+	// Releasing more than was reserved does not create credit
+	tracker.Release(5)
+	if _, err := tracker.Check(1.01); !errors.Is(err, ErrOverLimit) {
+		t.Errorf("Check() after releasing everything error = %v, want ErrOverLimit", err)
+	}
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	tracker := NewTracker(Budget{SessionLimit: 1}, 0)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tracker.Reserve(0.25, false); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 4 {
+		t.Errorf("%d jobs of $0.25 reserved under a $1.00 limit, want 4", accepted)
+	}
+}
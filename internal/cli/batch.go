@@ -0,0 +1,422 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/history"
+	"github.com/telemetry/video-gen/internal/naming"
+	"github.com/telemetry/video-gen/internal/queue"
+)
+
+// BatchItem is one video in a batch file. Empty fields fall back to the
+// command-line flags and config.
+type BatchItem struct {
+	Prompt    string `json:"prompt"`
+	Model     string `json:"model,omitempty"`
+	Size      string `json:"size,omitempty"`
+	Duration  string `json:"duration,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Filename  string `json:"filename,omitempty"`
+	Priority  string `json:"priority,omitempty"` // urgent, normal, or batch; urgent items start first
+
+	MaxAttempts int `json:"max_attempts,omitempty"` // Tries when the video fails, overriding retry_attempts
+}
+
+// batchResult records the outcome of one batch item for the summary table
+type batchResult struct {
+	item       BatchItem
+	outputPath string
+	err        error
+	elapsed    time.Duration
+	attempts   int
+}
+
+// loadBatch reads batch items from a JSON array, a JSON Lines file with an
+// item a line (such as the dead-letter file), a CSV file with a header row, or
+// a text file with one prompt per line (blank lines and # comments are skipped)
+func loadBatch(path string) ([]BatchItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+
+	var items []BatchItem
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("failed to decode batch file: %w", err)
+		}
+	case ".jsonl":
+		for n, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			var item BatchItem
+			if err := json.Unmarshal([]byte(line), &item); err != nil {
+				return nil, fmt.Errorf("failed to decode line %d of the batch file: %w", n+1, err)
+			}
+			items = append(items, item)
+		}
+	case ".csv":
+		items, err = parseBatchCSV(string(data))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			items = append(items, BatchItem{Prompt: line})
+		}
+	}
+
+	for i, item := range items {
+		if strings.TrimSpace(item.Prompt) == "" {
+			return nil, fmt.Errorf("batch item %d: 'prompt' is required", i+1)
+		}
+		if item.Filename != "" {
+			if items[i].Filename, err = batchFilename(item.Filename); err != nil {
+				return nil, fmt.Errorf("batch item %d: %w", i+1, err)
+			}
+		}
+		if _, err := queue.ParsePriority(item.Priority); err != nil {
+			return nil, fmt.Errorf("batch item %d: %w", i+1, err)
+		}
+		if item.MaxAttempts < 0 {
+			return nil, fmt.Errorf("batch item %d: invalid max_attempts %d", i+1, item.MaxAttempts)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no prompts found in %s", path)
+	}
+
+	return items, nil
+}
+
+// batchFilename checks a filename given in a batch file, which must name a
+// file in the output directory rather than a path that could leave it, and
+// makes it safe like a rendered name template
+func batchFilename(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("filename %q must be a file name, not a path; videos are saved in the output directory (-o)", name)
+	}
+	name = naming.Sanitize(name)
+	if name == "" {
+		return "", fmt.Errorf("filename is empty once unsafe characters are removed")
+	}
+	if filepath.Ext(name) == "" {
+		name += ".mp4"
+	}
+	return name, nil
+}
+
+// parseBatchCSV maps CSV columns to batch item fields by their header names
+func parseBatchCSV(data string) ([]BatchItem, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["prompt"]; !ok {
+		return nil, fmt.Errorf("batch CSV needs a 'prompt' column")
+	}
+
+	var items []BatchItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch CSV: %w", err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		item := BatchItem{
+			Prompt:    field("prompt"),
+			Model:     field("model"),
+			Size:      field("size"),
+			Duration:  field("duration"),
+			Reference: field("reference"),
+			Filename:  field("filename"),
+			Priority:  field("priority"),
+		}
+		if attempts := field("max_attempts"); attempts != "" {
+			if item.MaxAttempts, err = strconv.Atoi(attempts); err != nil {
+				return nil, fmt.Errorf("batch CSV row %d: invalid max_attempts %q", len(items)+1, attempts)
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// runBatch generates every video in the batch file in order, retrying
+// failures as the retry policy allows and continuing past the rest, and
+// prints a summary table at the end
+func runBatch(opts Options) error {
+	items, err := loadBatch(opts.BatchFile)
+	if err != nil {
+		return withExitCode(ExitValidation, err)
+	}
+
+	cfg, client, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if err := provider.setUpload(opts, cfg); err != nil {
+		return err
+	}
+
+	// A generated or extracted reference is shared by every item without its own reference
+	if opts.RefPrompt != "" || opts.ContinueFrom != "" {
+		s, err := resolveSettings(opts, cfg)
+		if err != nil {
+			return err
+		}
+		if err := generateReference(client, opts, s); err != nil {
+			return err
+		}
+		opts.ReferenceImage = s.referenceImage
+		opts.RefPrompt = ""
+		opts.ContinueFrom = ""
+	}
+
+	concurrency := clampConcurrency(opts.Concurrency, len(items))
+	if concurrency > 1 {
+		stdout.Printf("Batch: %d videos, %d at a time\n\n", len(items), concurrency)
+	} else {
+		stdout.Printf("Batch: %d videos\n\n", len(items))
+	}
+
+	// Items start by priority; names and the summary keep the file's order
+	priorities := make([]queue.Priority, len(items))
+	for i, item := range items {
+		priorities[i], _ = queue.ParsePriority(item.Priority)
+	}
+	order := queue.Order(priorities)
+
+	timestamp := time.Now().Format("20060102_150405")
+	results := make([]batchResult, len(items))
+	runConcurrently(len(items), concurrency, func(n int) {
+		i := order[n]
+		item := items[i]
+		itemOpts := opts
+		itemOpts.console = stdout.withJob(i + 1)
+		if concurrency > 1 {
+			itemOpts.console = itemOpts.console.withPrefix(fmt.Sprintf("[%d] ", i+1))
+		} else {
+			stdout.Printf("=== Video %d/%d ===\n\n", i+1, len(items))
+		}
+		out := itemOpts.out()
+
+		policy := provider.retry
+		if item.MaxAttempts > 0 {
+			policy.MaxAttempts = item.MaxAttempts
+		}
+
+		start := time.Now()
+		results[i] = batchResult{item: item}
+		for {
+			results[i].attempts++
+			results[i].outputPath, results[i].err = generateBatchItem(client, cfg, provider, itemOpts, item, i+1, fmt.Sprintf("batch_%s_%02d.mp4", timestamp, i+1))
+			if results[i].err == nil {
+				break
+			}
+			out.Warnf("Error: %v\n", results[i].err)
+			out.Event("error", map[string]interface{}{"error": results[i].err.Error()})
+			wait, retry := retryBatchItem(policy, results[i].attempts, results[i].err)
+			if !retry {
+				provider.deadLetter(out, item, results[i])
+				break
+			}
+			out.Printf("Retrying in %s (attempt %d/%d)...\n\n", wait.Round(time.Second), results[i].attempts+1, policy.MaxAttempts)
+			out.Event("retrying", map[string]interface{}{"attempt": results[i].attempts + 1, "wait_seconds": wait.Seconds()})
+			time.Sleep(wait)
+		}
+		results[i].elapsed = time.Since(start)
+		out.Println()
+	})
+
+	return printBatchSummary(results, provider.ledger)
+}
+
+// retryBatchItem reports whether an item whose attempt-th try failed with
+// err is tried again under policy, and after how long. A failed download is
+// not: the video was rendered and billed, and -resume fetches it.
+func retryBatchItem(policy queue.RetryPolicy, attempt int, err error) (time.Duration, bool) {
+	if ExitCode(err) == ExitDownload {
+		return 0, false
+	}
+	return policy.Retry(attempt, err)
+}
+
+// deadLetter adds an item that failed for good to the dead-letter file,
+// warning instead of failing the batch
+func (p *providers) deadLetter(out *console, item BatchItem, result batchResult) {
+	if !p.ledger {
+		return
+	}
+	err := history.AddDeadLetter(history.DeadLetter{
+		Prompt:    item.Prompt,
+		Model:     item.Model,
+		Size:      item.Size,
+		Duration:  item.Duration,
+		Reference: item.Reference,
+		Filename:  item.Filename,
+		Priority:  item.Priority,
+		Error:     result.err.Error(),
+		Class:     string(queue.Classify(result.err)),
+		Attempts:  result.attempts,
+	})
+	if err != nil {
+		out.Warnf("Warning: failed to update the dead-letter file: %v\n", err)
+	}
+}
+
+// clampConcurrency limits the number of parallel jobs to between 1 and count
+func clampConcurrency(concurrency, count int) int {
+	if concurrency > count {
+		concurrency = count
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return concurrency
+}
+
+// runConcurrently calls fn for every index below count, running up to limit calls at once
+func runConcurrently(count, limit int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// generateBatchItem applies an item's overrides to the options and generates its
+// video. index is the item's 1-based position, used by name templates.
+func generateBatchItem(client *api.SoraClient, cfg *config.Config, provider *providers, opts Options, item BatchItem, index int, defaultFilename string) (string, error) {
+	if item.Model != "" {
+		opts.Model = item.Model
+	}
+	if item.Size != "" {
+		opts.Size = item.Size
+	}
+	if item.Duration != "" {
+		opts.Duration = item.Duration
+	}
+	if item.Reference != "" {
+		opts.ReferenceImage = item.Reference
+	}
+
+	s, err := resolveSettings(opts, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	promptText, err := preparePrompt(client, cfg, opts, s, item.Prompt)
+	if err != nil {
+		return "", err
+	}
+
+	req := api.CreateVideoRequest{
+		Prompt:         promptText,
+		Model:          s.model,
+		InputReference: s.referenceImage,
+		CropAnchor:     s.cropAnchor,
+		Seconds:        s.duration,
+		Size:           s.size,
+		Negative:       s.negative,
+	}
+
+	// A filename given in the prompts file wins over the name template. It was
+	// checked by loadBatch, and execute adds a -2 style suffix when it is taken.
+	filename := item.Filename
+	if filename == "" {
+		filename, err = outputName(opts, cfg, defaultFilename, provider.primary.Name(), req, index)
+		if err != nil {
+			return "", err
+		}
+	}
+	return generateReviewed(client, provider, opts, req, filepath.Join(s.outputDir, filename))
+}
+
+// printBatchSummary prints one row per item and returns an error if any
+// failed, pointing at the dead-letter file they were added to when deadLetters
+func printBatchSummary(results []batchResult, deadLetters bool) error {
+	stdout.Println("Summary:")
+	stdout.Printf("  %-3s  %-6s  %-8s  %s\n", "#", "Status", "Time", "Output")
+
+	failed := 0
+	for i, result := range results {
+		status := "✓ done"
+		output := result.outputPath
+		if result.err != nil {
+			failed++
+			status = "✗ fail"
+			output = result.err.Error()
+		}
+		stdout.Printf("  %-3d  %-6s  %-8s  %s\n", i+1, status, result.elapsed.Round(time.Second), output)
+		stdout.Printf("  %-3s  %-6s  %-8s  %s\n", "", "", "", truncate(result.item.Prompt, 70))
+	}
+
+	stdout.Println()
+	stdout.Printf("%d of %d videos generated\n", len(results)-failed, len(results))
+	stdout.Event("summary", map[string]interface{}{"generated": len(results) - failed, "failed": failed})
+	if failed > 0 && deadLetters {
+		if path, err := history.DeadLetterPath(); err == nil {
+			stdout.Printf("Failed videos were added to %s; run them again with -f\n", path)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d videos failed", failed, len(results))
+	}
+	return nil
+}
+
+// truncate shortens text to at most max runes, marking the cut with an ellipsis
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
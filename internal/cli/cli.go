@@ -1,67 +1,556 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/budget"
+	"github.com/telemetry/video-gen/internal/clipboard"
 	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/engine"
+	"github.com/telemetry/video-gen/internal/ffmpeg"
+	"github.com/telemetry/video-gen/internal/history"
+	"github.com/telemetry/video-gen/internal/hook"
+	"github.com/telemetry/video-gen/internal/manifest"
+	"github.com/telemetry/video-gen/internal/naming"
+	"github.com/telemetry/video-gen/internal/poll"
+	"github.com/telemetry/video-gen/internal/prompt"
+	"github.com/telemetry/video-gen/internal/queue"
+	"github.com/telemetry/video-gen/internal/upload"
+	"github.com/telemetry/video-gen/internal/webhook"
 )
 
 type Options struct {
-	Debug          bool
-	Prompt         string
-	Model          string
-	ReferenceImage string
-	Duration       string
-	Size           string
-	OutputDir      string
+	Debug            bool
+	Prompt           string
+	Model            string
+	ReferenceImage   string
+	Duration         string
+	Size             string
+	OutputDir        string
+	RecordPath       string
+	ReplayPath       string
+	Simulate         bool // Use the built-in fake Sora backend instead of the API
+	Force            bool // Submit jobs past the budget's hard limits
+	EnhancePrompt    bool
+	Strict           bool
+	Vars             map[string]string
+	VarsFile         string
+	RefPrompt        string
+	Negative         string
+	Style            string
+	Provider         string
+	FallbackProvider string
+	FallbackModel    string // Model retried with when the account's quota or tier refuses a job, e.g. "sora-2"
+	AutoReview       string // Acceptance criteria checked by a vision model after download
+	ReviewAttempts   int
+	BatchFile        string // Prompts file for batch mode (text, JSON, or CSV)
+	RemixID          string // Completed Sora video to remix with Prompt
+	ResumeID         string // Existing job to poll and download instead of creating one
+	Revise           string // Past job whose prompt this run revises, linked as its next version
+	WebhookPort      int    // Port for receiving OpenAI webhook events, 0 to poll
+	Concurrency      int    // Jobs generated in parallel in batch and storyboard modes
+	Count            int    // Variations of the prompt generated at once, saved with _v1.._vN suffixes; below 2 generates one
+	JSON             bool   // Emit newline-delimited JSON events on stdout instead of human text
+	APIKey           string // OpenAI API key overriding OPENAI_API_KEY and the config file
+	Thumbnail        bool   // Also download the thumbnail of each video
+	Spritesheet      bool   // Also download the spritesheet of each video
+	NameTemplate     string // Output filename template, e.g. "{date}_{model}_{prompt:40}_{id}.mp4"
+	KeepRemote       bool   // Keep videos on the service after download, overriding the retention config
+	OnComplete       string // Shell command run after each download, overriding on_complete in the config
+	Template         string // Named prompt template from templates.toml, filled from Vars instead of Prompt
+	PostProcess      string // ffmpeg post-processing spec, e.g. "codec=h265,watermark=logo.png,gif"
+	ContinueFrom     string // Local video whose last frame becomes the reference image
+	CropAnchor       string // Part of the reference image kept when cropping, e.g. "top" or "0.5,0.2"
+	BaseURL          string // OpenAI-compatible API endpoint overriding OPENAI_BASE_URL and api_base_url
+	Organization     string // OpenAI organization overriding OPENAI_ORG_ID and organization
+	Project          string // OpenAI project overriding OPENAI_PROJECT_ID and project
+	Profile          string // Config profile applied over the top-level settings
+	ConfigPath       string // Config file to use instead of the default one
+	DebugLog         string // File the API calls are logged to as JSON lines
+
+	PollInterval time.Duration // Fixed wait between status checks, overriding poll_interval; 0 polls adaptively
+	MaxPolls     int           // Status checks before giving up, overriding poll_max_attempts
+	Timeout      time.Duration // Limit on waiting for each job, overriding poll_timeout
+
+	console *console // Progress output; set per job when jobs run concurrently
 }
 
 // RunNonInteractive runs the video generation in non-interactive mode
 func RunNonInteractive(opts Options) error {
-	// Load config
-	cfg, err := config.Load()
+	stdout.json = opts.JSON
+	defer printCallSummary()
+	err := runNonInteractive(opts)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		stdout.Event("error", map[string]interface{}{"error": err.Error(), "exit_code": ExitCode(err)})
 	}
+	return err
+}
 
-	// Check API key
-	if cfg.OpenAIAPIKey == "" {
-		return fmt.Errorf("OpenAI API key not found. Please run interactively first or set key in config")
+// runNonInteractive dispatches to the generation mode selected by opts
+func runNonInteractive(opts Options) error {
+	if opts.AutoReview != "" && !ffmpeg.Available() {
+		return withExitCode(ExitValidation, fmt.Errorf("--auto-review requires ffmpeg on PATH"))
 	}
 
-	// Set defaults from config
-	model := opts.Model
-	if model == "" {
-		if cfg.Model != "" {
-			model = cfg.Model
-		} else {
-			model = "sora-2"
+	if opts.Template != "" {
+		if opts.Prompt != "" {
+			return withExitCode(ExitValidation, fmt.Errorf("use either -p or -template, not both"))
 		}
-	} else {
-		// Normalize model name
-		if model == "sora" {
-			model = "sora-2"
-		} else if model == "sora-pro" {
-			model = "sora-2-pro"
+		text, err := templatePrompt(opts)
+		if err != nil {
+			return err
 		}
+		opts.Prompt = text
 	}
 
-	duration := opts.Duration
-	if duration == "" {
-		if cfg.Duration != "" {
+	if opts.Revise != "" {
+		if opts.BatchFile != "" || opts.RemixID != "" || opts.ResumeID != "" {
+			return withExitCode(ExitValidation, fmt.Errorf("-revise applies to a single prompt, not -f, -remix, or -resume"))
+		}
+		if _, err := history.Find(opts.Revise); err != nil {
+			return withExitCode(ExitValidation, err)
+		}
+	}
+
+	if opts.Count > 1 && (opts.BatchFile != "" || opts.RemixID != "" || opts.ResumeID != "") {
+		return withExitCode(ExitValidation, fmt.Errorf("-count applies to a single prompt, not -f, -remix, or -resume"))
+	}
+
+	if opts.BatchFile != "" {
+		return runBatch(opts)
+	}
+	if opts.RemixID != "" {
+		return runRemix(opts)
+	}
+	if opts.ResumeID != "" {
+		return runResume(opts)
+	}
+
+	cfg, client, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if err := provider.setUpload(opts, cfg); err != nil {
+		return err
+	}
+
+	s, err := resolveSettings(opts, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := generateReference(client, opts, s); err != nil {
+		return err
+	}
+
+	promptText, err := preparePrompt(client, cfg, opts, s, opts.Prompt)
+	if err != nil {
+		return err
+	}
+
+	req := api.CreateVideoRequest{
+		Prompt:         promptText,
+		Model:          s.model,
+		InputReference: s.referenceImage,
+		CropAnchor:     s.cropAnchor,
+		Seconds:        s.duration,
+		Size:           s.size,
+		Negative:       s.negative,
+		Parent:         opts.Revise,
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	filename, err := outputName(opts, cfg, fmt.Sprintf("%s_video_%s.mp4", provider.primary.Name(), timestamp), provider.primary.Name(), req, 0)
+	if err != nil {
+		return err
+	}
+
+	if opts.Count > 1 {
+		return runVariations(client, provider, opts, req, filepath.Join(s.outputDir, filename))
+	}
+	_, err = generateReviewed(client, provider, opts, req, filepath.Join(s.outputDir, filename))
+	return err
+}
+
+// runRemix remixes an existing Sora video with a new prompt. The remix keeps
+// the original's model, duration, and size.
+func runRemix(opts Options) error {
+	cfg, client, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if err := provider.setUpload(opts, cfg); err != nil {
+		return err
+	}
+
+	s, err := resolveSettings(opts, cfg)
+	if err != nil {
+		return err
+	}
+
+	promptText, err := preparePrompt(client, cfg, opts, s, opts.Prompt)
+	if err != nil {
+		return err
+	}
+
+	req := api.CreateVideoRequest{Prompt: promptText, Negative: s.negative}
+	filename, err := outputName(opts, cfg, fmt.Sprintf("sora_remix_%s.mp4", time.Now().Format("20060102_150405")), client.Name(), req, 0)
+	if err != nil {
+		return err
+	}
+
+	stdout.Printf("Creating remix job...\n")
+	stdout.Printf("  Source: %s\n", opts.RemixID)
+	stdout.Printf("  Prompt: %s\n", promptText)
+	stdout.Println()
+
+	job := engine.Job{Request: req, RemixOf: opts.RemixID}
+	_, err = execute(stdout, provider, client, job, filepath.Join(s.outputDir, filename))
+	return err
+}
+
+// runResume polls and downloads a job created by an earlier run, e.g. one
+// that was interrupted before the video was saved
+func runResume(opts Options) error {
+	cfg, _, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if err := provider.setUpload(opts, cfg); err != nil {
+		return err
+	}
+
+	s, err := resolveSettings(opts, cfg)
+	if err != nil {
+		return err
+	}
+
+	stdout.Printf("Resuming job: %s\n", opts.ResumeID)
+	stdout.Printf("  Provider: %s\n", provider.primary.Name())
+	stdout.Println()
+
+	req := api.CreateVideoRequest{Prompt: opts.Prompt}
+	filename, err := outputName(opts, cfg, fmt.Sprintf("%s_video_%s.mp4", provider.primary.Name(), time.Now().Format("20060102_150405")), provider.primary.Name(), req, 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = awaitJob(stdout, provider, provider.primary, opts.ResumeID, req, filepath.Join(s.outputDir, filename))
+	return err
+}
+
+// newClient loads the config and creates the OpenAI client, used for prompt
+// helpers, and the providers that generate the videos
+func newClient(opts Options) (*config.Config, *api.SoraClient, *providers, error) {
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := cfg.UseProfile(opts.Profile); err != nil {
+		return nil, nil, nil, withExitCode(ExitValidation, err)
+	}
+	// Only the directory this run saves to is checked, so one profile's
+	// unmounted drive does not stop another profile or -o from working
+	if dir := outputDestination(opts, cfg); dir != "" && !upload.IsRemote(dir) {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			opts.out().Warnf("Warning: output directory %s does not exist; it is created when the first video is saved\n", dir)
+		}
+	}
+
+	// Create API client
+	client := api.NewClient(cfg.APIKey(opts.APIKey), opts.Debug, debugLogger(opts))
+	if cfg.UsesAzure() {
+		client.SetAzure(cfg.Azure.Endpoint, cfg.Azure.APIVersion, cfg.Azure.Deployments)
+	}
+	client.SetBaseURL(cfg.BaseURL(opts.BaseURL))
+	client.SetOrganization(cfg.OrganizationID(opts.Organization), cfg.ProjectID(opts.Project))
+
+	// Send requests through the configured proxy, or the simulated backend, recording or replaying them if requested
+	proxy, err := api.NewProxyTransport(cfg.Proxy)
+	if err != nil {
+		return nil, nil, nil, withExitCode(ExitValidation, err)
+	}
+	if opts.Simulate {
+		if opts.ReplayPath != "" {
+			return nil, nil, nil, withExitCode(ExitValidation, fmt.Errorf("cannot simulate and replay at the same time"))
+		}
+		proxy = api.NewSimulatedTransport()
+	}
+	transport, err := api.NewSessionTransport(opts.RecordPath, opts.ReplayPath, proxy)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Log and time every call for -debug-log and -d
+	if opts.DebugLog != "" || opts.Debug {
+		debugLog, err := api.OpenDebugLog(opts.DebugLog)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		debugLog.Redact(cfg.APIKey(opts.APIKey), cfg.RunwayAPIKey)
+		if opts.Debug {
+			debugLog.OnEntry(func(entry api.DebugEntry) {
+				opts.out().Println(fmt.Sprintf("TIMING %s %s: %s", entry.Method, entry.URL, entry.Timing))
+			})
+			callLog = debugLog
+		}
+		transport = debugLog.Transport(transport)
+	}
+	if transport != nil {
+		client.SetTransport(transport)
+	}
+
+	// Replayed and simulated jobs are not real, so keep them out of the history
+	p := &providers{ledger: opts.ReplayPath == "" && !opts.Simulate, profile: cfg.ActiveProfile()}
+	if opts.ConfigPath != "" {
+		// Resume commands may be run from another directory
+		if p.configPath, err = filepath.Abs(opts.ConfigPath); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if opts.Thumbnail {
+		p.variants = append(p.variants, api.VariantThumbnail)
+	}
+	if opts.Spritesheet {
+		p.variants = append(p.variants, api.VariantSpritesheet)
+	}
+	p.primary, err = newProvider(resolveProvider(opts, cfg), cfg, opts, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fallbackName := opts.FallbackProvider
+	if fallbackName == "" {
+		fallbackName = cfg.FallbackProvider
+	}
+	if fallbackName != "" && fallbackName != p.primary.Name() {
+		p.fallback, err = newProvider(fallbackName, cfg, opts, client)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("fallback provider: %w", err)
+		}
+	}
+
+	p.fallbackModel = opts.FallbackModel
+	if p.fallbackModel == "" {
+		p.fallbackModel = cfg.FallbackModel
+	}
+	p.fallbackModel = api.ModelName(p.fallbackModel)
+
+	policy, age, err := cfg.RetentionPolicy()
+	if err != nil {
+		return nil, nil, nil, withExitCode(ExitValidation, err)
+	}
+	if opts.KeepRemote {
+		policy = config.RetentionKeep
+	}
+	p.keepRemote = policy != config.RetentionDelete
+
+	pollInterval := opts.PollInterval
+	if pollInterval == 0 && opts.ReplayPath != "" {
+		pollInterval = api.ReplayPollInterval
+	}
+	p.poll, err = cfg.PollStrategy(pollInterval, opts.Timeout, opts.MaxPolls)
+	if err != nil {
+		return nil, nil, nil, withExitCode(ExitValidation, err)
+	}
+
+	p.retry, err = cfg.RetryPolicy()
+	if err != nil {
+		return nil, nil, nil, withExitCode(ExitValidation, err)
+	}
+
+	limits, err := cfg.Budget()
+	if err != nil {
+		return nil, nil, nil, withExitCode(ExitValidation, err)
+	}
+	var monthToDate float64
+	if limits.Set() {
+		if monthToDate, err = history.MonthSpend(time.Now()); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read this month's spend from history: %w", err)
+		}
+	}
+	p.budget = budget.NewTracker(limits, monthToDate)
+	p.force = opts.Force
+	p.sidecar = cfg.SidecarManifest
+
+	p.onComplete = opts.OnComplete
+	if p.onComplete == "" {
+		p.onComplete = cfg.OnComplete
+	}
+
+	spec := opts.PostProcess
+	if spec == "" {
+		spec = cfg.PostProcess
+	}
+	if spec != "" {
+		if !ffmpeg.Available() {
+			return nil, nil, nil, withExitCode(ExitValidation, fmt.Errorf("--postprocess requires ffmpeg on PATH"))
+		}
+		p.postprocess, err = ffmpeg.ParsePipeline(spec)
+		if err != nil {
+			return nil, nil, nil, withExitCode(ExitValidation, fmt.Errorf("invalid post-processing spec: %w", err))
+		}
+	}
+	if policy == config.RetentionDeleteAfterDays && p.ledger {
+		p.deleteExpired(opts.out(), age)
+	}
+
+	// Receive completion events instead of polling frequently
+	if opts.WebhookPort > 0 {
+		p.events, err = webhook.Listen(opts.WebhookPort, cfg.WebhookSecret)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if cfg.WebhookSecret == "" {
+			fmt.Fprintf(os.Stderr, "Warning: webhook_secret is not set; listening on %s only, as webhook signatures cannot be verified\n", p.events.Addr())
+		}
+	}
+
+	return cfg, client, p, nil
+}
+
+// callLog times the API calls of a -d run for the summary printed when it ends
+var callLog *api.DebugLog
+
+// printCallSummary prints the API call timing of a -d run
+func printCallSummary() {
+	if callLog == nil {
+		return
+	}
+	if summary := callLog.Summary(); summary != "" {
+		stdout.Printf("\n%s", summary)
+	}
+}
+
+// debugLogger returns the callback API clients use to print debug output
+func debugLogger(opts Options) func(string) {
+	return func(entry string) {
+		if opts.Debug {
+			opts.out().Println(entry)
+		}
+	}
+}
+
+// newProvider creates the named video provider, sharing the OpenAI client's
+// session transport so recordings capture every provider's traffic
+func newProvider(name string, cfg *config.Config, opts Options, client *api.SoraClient) (api.VideoProvider, error) {
+	// API keys are not needed when replaying a recorded session or simulating
+	replaying := opts.ReplayPath != "" || opts.Simulate
+
+	switch name {
+	case "sora":
+		if cfg.APIKey(opts.APIKey) == "" && !replaying {
+			return nil, withExitCode(ExitAuth, fmt.Errorf("OpenAI API key not found. Set OPENAI_API_KEY, pass -api-key, or run interactively first to save it in config"))
+		}
+		// A key passed with -api-key has not been checked like a saved one,
+		// so a bad one is caught here rather than by the first create call
+		if opts.APIKey != "" && !replaying && !cfg.UsesAzure() {
+			if err := client.CheckKey(); api.IsKeyError(err) {
+				return nil, withExitCode(ExitAuth, err)
+			}
+		}
+		return client, nil
+	case "runway":
+		if opts.Simulate {
+			return nil, withExitCode(ExitValidation, fmt.Errorf("-simulate only supports the sora provider"))
+		}
+		if cfg.RunwayAPIKey == "" && !replaying {
+			return nil, withExitCode(ExitAuth, fmt.Errorf("Runway API key not found. Please set runway_api_key in config"))
+		}
+		runway := api.NewRunwayClient(cfg.RunwayAPIKey, opts.Debug, debugLogger(opts))
+		if transport := client.Transport(); transport != nil {
+			runway.SetTransport(transport)
+		}
+		return runway, nil
+	}
+	return nil, fmt.Errorf("unknown provider '%s'. Supported providers are: 'sora' and 'runway'", name)
+}
+
+// resolveProvider returns the video provider selected by flag or config, defaulting to Sora
+func resolveProvider(opts Options, cfg *config.Config) string {
+	if opts.Provider != "" {
+		return opts.Provider
+	}
+	if cfg.Provider != "" {
+		return cfg.Provider
+	}
+	return "sora"
+}
+
+// settings holds generation parameters resolved from flags, config, and defaults
+type settings struct {
+	model          string
+	duration       string
+	size           string
+	outputDir      string
+	referenceImage string
+	cropAnchor     string
+	styleDirective string
+	negative       string // Exclusions folded into every prompt
+}
+
+// resolveSettings applies config values and defaults to any options not given on the command line
+func resolveSettings(opts Options, cfg *config.Config) (*settings, error) {
+	// A style preset supplies defaults between the flags and the config
+	var style prompt.Style
+	if opts.Style != "" {
+		stylesPath, err := config.StylesPath()
+		if err != nil {
+			return nil, err
+		}
+		preset, err := prompt.LoadStyle(opts.Style, stylesPath)
+		if err != nil {
+			return nil, withExitCode(ExitValidation, err)
+		}
+		style = *preset
+		if opts.Size == "" {
+			opts.Size = style.Size
+		}
+		if opts.Duration == "" {
+			opts.Duration = style.Duration
+		}
+	}
+
+	provider := resolveProvider(opts, cfg)
+	model, duration := api.ModelName(opts.Model), opts.Duration
+	// Config model and duration are Sora defaults, so only flags apply to Runway
+	if provider != "runway" {
+		if model == "" {
+			model = cfg.Model
+		}
+		if duration == "" {
 			duration = cfg.Duration
-		} else {
-			duration = "4"
 		}
 	}
-	// Validate duration (must be 4, 8, or 12)
-	if duration != "4" && duration != "8" && duration != "12" {
-		return fmt.Errorf("invalid duration '%s'. Supported values are: '4', '8', and '12'", duration)
+	// Typos fail here rather than as an API error once the job is submitted
+	if model != "" && len(api.Models(provider)) > 0 {
+		if err := api.CheckModel(model, provider); err != nil {
+			return nil, withExitCode(ExitValidation, err)
+		}
+	}
+	capabilities, ok := api.LookupModel(model)
+	if !ok {
+		capabilities = api.DefaultModel(provider)
+	}
+	if model == "" {
+		model = capabilities.Name
+	}
+	if duration == "" {
+		duration = capabilities.Durations[0]
+	}
+	if err := capabilities.CheckDuration(duration); err != nil {
+		return nil, withExitCode(ExitValidation, err)
 	}
 
 	size := opts.Size
@@ -69,17 +558,20 @@ func RunNonInteractive(opts Options) error {
 		if cfg.Size != "" {
 			size = cfg.Size
 		} else {
-			size = "1280x720"
+			size = capabilities.Sizes[0]
 		}
 	}
+	size = api.ResolveSize(size)
 
-	outputDir := opts.OutputDir
+	outputDir := outputDestination(opts, cfg)
 	if outputDir == "" {
-		if cfg.OutputDir != "" {
-			outputDir = cfg.OutputDir
-		} else {
-			homeDir, _ := os.UserHomeDir()
-			outputDir = filepath.Join(homeDir, "Desktop")
+		homeDir, _ := os.UserHomeDir()
+		outputDir = filepath.Join(homeDir, "Desktop")
+	} else if upload.IsRemote(outputDir) {
+		// Videos bound for a bucket are saved locally until they are uploaded
+		var err error
+		if outputDir, err = stagingDir(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -92,148 +584,916 @@ func RunNonInteractive(opts Options) error {
 		}
 	}
 
-	// Create debug callback
-	debugCallback := func(entry string) {
-		if opts.Debug {
-			fmt.Println(entry)
+	if api.IsReferenceURL(referenceImage) || clipboard.IsReference(referenceImage) {
+		var err error
+		if referenceImage, err = fetchReference(opts.out(), cfg, referenceImage, outputDir); err != nil {
+			return nil, withExitCode(ExitValidation, err)
 		}
 	}
 
-	// Create API client
-	client := api.NewClient(cfg.OpenAIAPIKey, opts.Debug, debugCallback)
-
-	// Step 1: Create video
-	fmt.Printf("Creating video generation job...\n")
-	fmt.Printf("  Prompt: %s\n", opts.Prompt)
-	fmt.Printf("  Model: %s\n", model)
-	fmt.Printf("  Duration: %ss\n", duration)
-	fmt.Printf("  Size: %s\n", size)
+	matched := size == api.SizeMatchReference
+	if matched && referenceImage == "" {
+		if opts.Size == api.SizeMatchReference {
+			return nil, withExitCode(ExitValidation, fmt.Errorf("-s %s needs a reference image (-r)", api.SizeMatchReference))
+		}
+		// A config default of "match" only applies when there is a reference
+		size, matched = "1280x720", false
+	}
+	if matched {
+		var err error
+		if size, err = api.MatchReferenceSize(referenceImage, api.ModelSizes(model)); err != nil {
+			return nil, withExitCode(ExitValidation, err)
+		}
+		opts.out().Printf("Size %s matches the reference image\n", size)
+	}
+	if err := capabilities.CheckSize(size); err != nil {
+		return nil, withExitCode(ExitValidation, err)
+	}
 	if referenceImage != "" {
-		fmt.Printf("  Reference: %s\n", referenceImage)
+		warnCrop(opts.out(), referenceImage, size, matched)
+	}
+
+	if _, err := api.ParseCropAnchor(opts.CropAnchor); err != nil {
+		return nil, withExitCode(ExitValidation, err)
+	}
+
+	negative := opts.Negative
+	if negative == "" {
+		negative = cfg.NegativePrompt
+	}
+
+	return &settings{
+		model:          model,
+		duration:       duration,
+		size:           size,
+		outputDir:      outputDir,
+		referenceImage: referenceImage,
+		cropAnchor:     opts.CropAnchor,
+		styleDirective: style.Directive,
+		negative:       negative,
+	}, nil
+}
+
+// significantCrop is the share of a reference image's width or height that
+// can be cropped away before a warning is printed
+const significantCrop = 0.1
+
+// warnCrop warns when fitting the reference image to size crops away much of
+// it, suggesting -s match unless the size was already matched
+func warnCrop(out *console, referenceImage, size string, matched bool) {
+	axis, fraction, err := api.ReferenceCrop(referenceImage, size)
+	// Unreadable images are reported when the job is submitted
+	if err != nil || fraction < significantCrop {
+		return
+	}
+	dimension := "width"
+	if axis == "y" {
+		dimension = "height"
+	}
+	hint := "; -s match picks the closest size"
+	if matched {
+		hint = ""
+	}
+	out.Warnf("Warning: %s crops %.0f%% of the reference image's %s%s\n", size, fraction*100, dimension, hint)
+}
+
+// fetchedReferences maps reference URLs, and the clipboard, to the files
+// they were saved to, so batch items and scenes sharing one fetch it once per run
+var fetchedReferences = struct {
+	sync.Mutex
+	paths map[string]string
+}{paths: map[string]string{}}
+
+// fetchReference saves a reference given as an http(s) or data: URL, fetched
+// through the configured proxy, or the clipboard image (-r clipboard) to the
+// output directory and returns the file's path
+func fetchReference(out *console, cfg *config.Config, ref, outputDir string) (string, error) {
+	fetchedReferences.Lock()
+	defer fetchedReferences.Unlock()
+	if path, ok := fetchedReferences.paths[ref]; ok {
+		return path, nil
+	}
+
+	var path string
+	if clipboard.IsReference(ref) {
+		data, err := clipboard.PasteImage()
+		if err != nil {
+			return "", err
+		}
+		if path, err = api.SaveReferenceImage(data, outputDir, "clipboard", "from the clipboard"); err != nil {
+			return "", err
+		}
+	} else {
+		transport, err := api.NewProxyTransport(cfg.Proxy)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(strings.ToLower(ref), "data:") {
+			out.Printf("Fetching reference image...\n")
+			out.Printf("  URL: %s\n", ref)
+		}
+		if path, err = api.FetchReference(ref, outputDir, transport); err != nil {
+			return "", err
+		}
 	}
-	fmt.Println()
+	out.Printf("✓ Reference image saved: %s\n\n", path)
+	fetchedReferences.paths[ref] = path
+	return path, nil
+}
 
-	createReq := api.CreateVideoRequest{
-		Prompt:         opts.Prompt,
-		Model:          model,
-		InputReference: referenceImage,
-		Seconds:        duration,
-		Size:           size,
+// generateReference creates the reference image from a text prompt when
+// -ref-prompt is set, saving it to the output directory for reuse
+func generateReference(client *api.SoraClient, opts Options, s *settings) error {
+	if opts.ContinueFrom != "" {
+		if s.referenceImage != "" || opts.RefPrompt != "" {
+			return withExitCode(ExitValidation, fmt.Errorf("-continue-from cannot be combined with -r or -ref-prompt"))
+		}
+		return continueFrom(opts.ContinueFrom, s)
+	}
+	if opts.RefPrompt == "" {
+		return nil
+	}
+	if s.referenceImage != "" {
+		return fmt.Errorf("use either a reference image or a reference prompt, not both")
 	}
 
-	createResp, err := client.CreateVideo(createReq)
+	stdout.Printf("Generating reference image...\n")
+	stdout.Printf("  Prompt: %s\n", opts.RefPrompt)
+
+	data, err := client.GenerateReferenceImage(opts.RefPrompt, s.size)
 	if err != nil {
-		return fmt.Errorf("failed to create video: %w", err)
+		return err
 	}
 
-	fmt.Printf("✓ Video job created: %s\n", createResp.ID)
-	fmt.Println()
+	if err := os.MkdirAll(s.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	path := filepath.Join(s.outputDir, fmt.Sprintf("reference_%s.png", time.Now().Format("20060102_150405")))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save reference image: %w", err)
+	}
 
-	// Step 2: Poll for completion
-	videoID := createResp.ID
-	pollAttempts := 0
-	maxAttempts := 200
-	startTime := time.Now()
+	stdout.Printf("✓ Reference image saved: %s\n\n", path)
+	s.referenceImage = path
+	return nil
+}
 
-	fmt.Println("Polling for completion...")
-	fmt.Println("(This may take several minutes)")
-	fmt.Println()
-
-	for pollAttempts < maxAttempts {
-		pollAttempts++
-		elapsed := int(time.Since(startTime).Seconds())
-
-		// Determine poll interval: 10s for first 2 minutes, 30s thereafter
-		var pollInterval time.Duration
-		if pollAttempts == 1 {
-			// First check is immediate
-			pollInterval = 0
-		} else if elapsed < 120 {
-			pollInterval = 10 * time.Second
-		} else {
-			pollInterval = 30 * time.Second
+// continueFrom extracts the last frame of video into the output directory and
+// uses it as the reference image, so the next clip picks up where video ends
+func continueFrom(video string, s *settings) error {
+	if strings.HasPrefix(video, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			video = filepath.Join(homeDir, video[2:])
 		}
+	}
+	if _, err := os.Stat(video); err != nil {
+		return withExitCode(ExitValidation, fmt.Errorf("-continue-from: %w", err))
+	}
+	if !ffmpeg.Available() {
+		return withExitCode(ExitValidation, fmt.Errorf("-continue-from requires ffmpeg on PATH"))
+	}
 
-		if pollInterval > 0 {
-			time.Sleep(pollInterval)
+	if err := os.MkdirAll(s.outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(video), filepath.Ext(video))
+	path := filepath.Join(s.outputDir, fmt.Sprintf("%s_lastframe_%s.png", name, time.Now().Format("20060102_150405")))
+
+	stdout.Printf("Extracting last frame of %s...\n", video)
+	if err := ffmpeg.LastFrame(video, path); err != nil {
+		return fmt.Errorf("failed to extract last frame: %w", err)
+	}
+
+	stdout.Printf("✓ Continuing from frame: %s\n\n", path)
+	s.referenceImage = path
+	return nil
+}
+
+// loadConfig loads the config file selected by -config, or the default one,
+// with the project-local config applied
+func loadConfig(opts Options) (*config.Config, error) {
+	if opts.ConfigPath != "" {
+		config.SetPath(opts.ConfigPath)
+		if !config.Exists() {
+			return nil, withExitCode(ExitValidation, fmt.Errorf("config file %s does not exist", opts.ConfigPath))
 		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, withExitCode(ExitValidation, fmt.Errorf("failed to load config: %w", err))
+	}
+	return cfg, nil
+}
+
+// templatePrompt fills the -template prompt template with the -var and -vars values
+func templatePrompt(opts Options) (string, error) {
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return "", err
+	}
+	templates, err := cfg.Templates()
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := prompt.FindTemplate(templates, opts.Template, cfg.TemplateSources())
+	if err != nil {
+		return "", withExitCode(ExitValidation, err)
+	}
+	vars, err := prompt.LoadVars(opts.VarsFile, opts.Vars)
+	if err != nil {
+		return "", withExitCode(ExitValidation, err)
+	}
+	text, err := tmpl.Fill(vars)
+	if err != nil {
+		return "", withExitCode(ExitValidation, fmt.Errorf("template '%s': %w", opts.Template, err))
+	}
+	return text, nil
+}
+
+// preparePrompt expands template variables and wildcards, optionally enhances the
+// prompt, and runs the pre-flight moderation check when moderates allows it
+func preparePrompt(client *api.SoraClient, cfg *config.Config, opts Options, s *settings, text string) (string, error) {
+	out := opts.out()
 
-		resp, err := client.GetVideo(videoID)
+	// Expand template variables in the prompt
+	vars, err := prompt.LoadVars(opts.VarsFile, opts.Vars)
+	if err != nil {
+		return "", withExitCode(ExitValidation, err)
+	}
+	promptText, err := prompt.Render(text, vars)
+	if err != nil {
+		return "", withExitCode(ExitValidation, err)
+	}
+
+	// Resolve {a|b} alternatives and __name__ wildcards into one random variant
+	wildcardsDir, err := cfg.WildcardsDirPath()
+	if err != nil {
+		return "", err
+	}
+	promptText, err = prompt.Expand(promptText, wildcardsDir)
+	if err != nil {
+		return "", withExitCode(ExitValidation, err)
+	}
+
+	promptText = prompt.WithStyle(promptText, s.styleDirective)
+
+	// Optionally enrich the prompt with a chat model before generation
+	if opts.EnhancePrompt {
+		out.Printf("Enhancing prompt...\n")
+		enhanced, err := client.EnhancePrompt(promptText)
 		if err != nil {
-			return fmt.Errorf("failed to get video status: %w", err)
+			return "", err
+		}
+		out.Printf("  Original: %s\n", promptText)
+		out.Printf("  Enhanced: %s\n", enhanced)
+		out.Println()
+		promptText = enhanced
+	}
+
+	// Fold exclusions in after enhancement so the rewrite cannot drop them
+	promptText = prompt.WithNegative(promptText, s.negative)
+
+	// Local lint warnings for issues that otherwise surface as cryptic API failures
+	for _, warning := range prompt.Lint(promptText, s.model) {
+		out.Warnf("Warning: %s\n", warning)
+	}
+
+	// Pre-flight moderation check, so a rejected prompt fails now instead of minutes into polling
+	if !moderates(opts, cfg) {
+		return promptText, nil
+	}
+	moderation, err := client.ModeratePrompt(promptText)
+	if err != nil {
+		out.Warnf("Warning: %v\n", err)
+	} else if moderation.Flagged {
+		if opts.Strict {
+			return "", withExitCode(ExitContentPolicy, fmt.Errorf("prompt blocked by pre-flight moderation (%s)", moderation))
+		}
+		out.Warnf("Warning: prompt may be rejected by content moderation (%s)\n\n", moderation)
+	}
+
+	return promptText, nil
+}
+
+// moderates reports whether prompts get the pre-flight moderation check. It
+// needs an OpenAI key, which a Runway-only setup may not have, and replayed
+// and simulated runs make no real requests to check with.
+func moderates(opts Options, cfg *config.Config) bool {
+	return opts.ReplayPath == "" && !opts.Simulate && cfg.APIKey(opts.APIKey) != ""
+}
+
+// providers holds the primary video provider, an optional fallback, and the
+// webhook receiver when -webhook-port is set
+type providers struct {
+	primary       api.VideoProvider
+	fallback      api.VideoProvider
+	fallbackModel string // Model the primary provider retries with on quota or tier errors, empty for none
+	events        *webhook.Server
+	ledger        bool              // Record jobs in the local history ledger
+	variants      []string          // Preview assets downloaded with every video
+	keepRemote    bool              // Leave videos on the service after download
+	onComplete    string            // Hook command run after each download
+	postprocess   *ffmpeg.Pipeline  // Applied to every download, nil for none
+	poll          poll.Strategy     // How jobs are polled and how long they are waited for
+	budget        *budget.Tracker   // Estimated spend of the run against the configured budget
+	force         bool              // Submit jobs past the budget's hard limits
+	sidecar       bool              // Write a JSON manifest next to each saved video
+	uploader      *upload.Uploader  // Uploads saved videos to an s3:// or gs:// output directory, nil to keep them local
+	profile       string            // Config profile in use, for resume commands
+	retry         queue.RetryPolicy // How failed batch items are tried again
+	configPath    string            // Absolute path of the -config file, empty for the default one
+}
+
+// close stops the webhook receiver, releases the uploader, and removes its
+// staging directory once the run is over
+func (p *providers) close() {
+	if p.events != nil {
+		p.events.Close()
+	}
+	if p.uploader != nil {
+		p.uploader.Close()
+	}
+	removeStaging()
+}
+
+// record updates a job in the history ledger, warning instead of failing the generation
+func (p *providers) record(out *console, id string, update func(e *history.Entry)) {
+	if !p.ledger {
+		return
+	}
+	if err := history.Record(id, update); err != nil {
+		out.Warnf("Warning: failed to update history: %v\n", err)
+	}
+}
+
+// runHook runs the on_complete hook for a downloaded video, warning instead
+// of failing the generation since the video is already saved
+func (p *providers) runHook(out *console, vars hook.Vars) {
+	if p.onComplete == "" {
+		return
+	}
+	out.Printf("Running on_complete hook...\n")
+	output, err := hook.Run(p.onComplete, vars)
+	if err != nil {
+		out.Warnf("Warning: %v\n", err)
+		return
+	}
+	if output != "" {
+		out.Printf("%s\n", output)
+	}
+}
+
+// byName returns the primary or fallback provider with the given name, or nil
+func (p *providers) byName(name string) api.VideoProvider {
+	for _, client := range []api.VideoProvider{p.primary, p.fallback} {
+		if client != nil && client.Name() == name {
+			return client
+		}
+	}
+	return nil
+}
+
+// deleteExpired deletes videos this tool kept on the service once they are
+// older than age (retention = "delete-after-days"). Videos created by other
+// tools or teammates are never touched, since only the ledger is consulted.
+func (p *providers) deleteExpired(out *console, age time.Duration) {
+	expired, err := history.Expired(age)
+	if err != nil {
+		out.Warnf("Warning: failed to check retention: %v\n", err)
+		return
+	}
+
+	for _, entry := range expired {
+		// Videos from a provider this run does not use wait for a run that does
+		client := p.byName(entry.Provider)
+		if client == nil {
+			continue
 		}
 
-		elapsed = int(time.Since(startTime).Seconds())
-		progressStr := ""
-		if resp.Progress > 0 {
-			progressStr = fmt.Sprintf(" (%d%% complete)", resp.Progress)
+		if err := client.DeleteVideo(entry.ID); err != nil {
+			out.Warnf("Warning: failed to delete expired video %s: %v\n", entry.ID, err)
+			continue
 		}
+		p.record(out, entry.ID, func(e *history.Entry) {
+			e.KeptRemote = false
+		})
+	}
+}
 
-		fmt.Printf("[%ds] Status: %s%s (attempt %d/%d)\n", elapsed, resp.Status, progressStr, pollAttempts, maxAttempts)
+// rejectedError marks failures where the provider refused or failed the job,
+// as opposed to local or download errors, so only these trigger a fallback
+type rejectedError struct {
+	err error
+}
 
-		// Only download when status is "completed"
-		if resp.Status == "completed" {
-			fmt.Println()
-			fmt.Printf("✓ Video generation completed!\n")
-			fmt.Println()
+func (e *rejectedError) Error() string { return e.err.Error() }
+func (e *rejectedError) Unwrap() error { return e.err }
 
-			// Step 3: Download video content directly
-			timestamp := time.Now().Format("20060102_150405")
-			filename := fmt.Sprintf("sora_video_%s.mp4", timestamp)
-			outputPath := filepath.Join(outputDir, filename)
+// generateVideo generates a video with the primary provider, retrying once with
+// the fallback model if the account's quota or tier refuses the job, then with
+// the fallback provider if the primary rejects it. It returns the path the
+// video was saved to.
+func generateVideo(out *console, p *providers, req api.CreateVideoRequest, outputPath string) (string, error) {
+	path, err := runJob(out, p, p.primary, req, outputPath)
 
-			fmt.Printf("Downloading video to: %s\n", outputPath)
+	if err != nil && p.fallbackModel != "" && req.Model != p.fallbackModel && api.IsQuotaOrTier(err) {
+		out.Warnf("Warning: %s refused %s: %v\n", p.primary.Name(), req.Model, err)
+		out.Printf("\nFalling back to %s...\n\n", p.fallbackModel)
+		req.FallbackFrom, req.Model = req.Model, p.fallbackModel
+		path, err = runJob(out, p, p.primary, req, outputPath)
+	}
 
-			// Retry download with 10s intervals (up to 12 attempts = 2 minutes)
-			maxDownloadRetries := 12
-			var downloadErr error
-			for downloadAttempt := 0; downloadAttempt < maxDownloadRetries; downloadAttempt++ {
-				if downloadAttempt > 0 {
-					fmt.Printf("  Retrying download (attempt %d/%d)...\n", downloadAttempt+1, maxDownloadRetries)
-					time.Sleep(10 * time.Second)
-				}
+	var rejected *rejectedError
+	if err == nil || p.fallback == nil || !errors.As(err, &rejected) {
+		return path, err
+	}
 
-				downloadErr = client.DownloadVideoContent(videoID, outputPath)
-				if downloadErr == nil {
-					break // Success!
-				}
+	out.Warnf("Warning: %s rejected the job: %v\n", p.primary.Name(), err)
+	out.Printf("\nFalling back to %s...\n\n", p.fallback.Name())
 
-				// Check if it's a 404 (not ready yet) - if so, retry
-				if !strings.Contains(downloadErr.Error(), "404") && !strings.Contains(downloadErr.Error(), "not ready") {
-					// Other errors, fail immediately
-					return fmt.Errorf("failed to download video: %w", downloadErr)
-				}
+	return runJob(out, p, p.fallback, translateRequest(req, p.fallback.Name()), outputPath)
+}
+
+// translateRequest maps a request's model and duration onto the closest values
+// the target provider supports; prompt, size, and reference carry over unchanged
+func translateRequest(req api.CreateVideoRequest, provider string) api.CreateVideoRequest {
+	if len(api.Models(provider)) == 0 {
+		return req
+	}
+	model := api.DefaultModel(provider)
+	req.Model = model.Name
+	req.Seconds = model.ClosestDuration(req.Seconds)
+	return req
+}
+
+// runJob creates a video job, polls it until completion, downloads it to
+// outputPath, and deletes it from the service
+func runJob(out *console, p *providers, client api.VideoProvider, req api.CreateVideoRequest, outputPath string) (string, error) {
+	// Step 1: Create video
+	out.Printf("Creating video generation job...\n")
+	out.Printf("  Provider: %s\n", client.Name())
+	out.Printf("  Prompt: %s\n", req.Prompt)
+	out.Printf("  Model: %s\n", req.Model)
+	out.Printf("  Duration: %ss\n", req.Seconds)
+	out.Printf("  Size: %s\n", req.Size)
+	if req.InputReference != "" {
+		out.Printf("  Reference: %s\n", req.InputReference)
+	}
+	out.Println()
+
+	return execute(out, p, client, engine.Job{Request: req}, outputPath)
+}
+
+// awaitJob polls an existing job until completion, downloads it to outputPath,
+// and deletes it from the service. req describes the job as far as it is known.
+func awaitJob(out *console, p *providers, client api.VideoProvider, videoID string, req api.CreateVideoRequest, outputPath string) (string, error) {
+	return execute(out, p, client, engine.Job{Request: req, VideoID: videoID}, outputPath)
+}
+
+// execute runs a job with the engine, printing its progress and recording it
+// in the ledger. A {id} placeholder in outputPath (see -name-template) is
+// replaced by the job ID, a -2 style suffix keeps it from overwriting another
+// video, and the resulting path is returned.
+func execute(out *console, p *providers, client api.VideoProvider, job engine.Job, outputPath string) (string, error) {
+	req := job.Request
+	startTime := time.Now()
+	var saveMu sync.Mutex
+	var savePath string
+	job.Output = func(videoID string) string {
+		saveMu.Lock()
+		defer saveMu.Unlock()
+		if savePath == "" {
+			savePath = naming.Unique(naming.FillID(outputPath, videoID))
+		}
+		return savePath
+	}
+	job.Finish = func(path string, resp *api.VideoResponse) (string, error) {
+		return p.finish(out, client, req, path, resp, startTime)
+	}
+
+	// Past jobs with the same settings give an ETA before the service reports progress
+	var typical time.Duration
+	if p.ledger && req.Model != "" {
+		typical = history.TypicalDuration(req.Model, req.Size, req.Seconds)
+	}
+
+	// Interrupting the run records the job so it can be resumed: the handler
+	// is in place before the job is submitted, and the job is tracked as soon
+	// as the create request returns its ID
+	watchInterrupts()
+	var untrack func()
+	defer func() {
+		if untrack != nil {
+			untrack()
+		}
+	}()
+	if job.VideoID != "" {
+		untrack = trackJob(p, client, job.VideoID, req, job.Output(job.VideoID))
+	} else {
+		created := trackCreate()
+		defer created()
+		job.Submitted = func(videoID string) {
+			untrack = trackJob(p, client, videoID, req, job.Output(videoID))
+			created()
+		}
+	}
+	started := func() {
+		if p.events != nil && client.Name() == "sora" {
+			out.Printf("Waiting for webhook on %s (polling every %s as a fallback)...\n", p.events.Addr(), engine.WebhookPollInterval)
+		} else {
+			out.Println("Polling for completion...")
+		}
+		out.Println("(This may take several minutes)")
+		out.Println()
+	}
+	if job.VideoID != "" {
+		started()
+	}
+
+	// A new job counts toward the budget from the moment it is submitted
+	var reserved float64
+	if job.VideoID == "" {
+		if cost, ok := api.EstimateJobCost(req, nil); ok {
+			warning, err := p.budget.Reserve(cost, p.force)
+			if err != nil {
+				return "", withExitCode(ExitBudget, fmt.Errorf("%w (use -force to submit anyway)", err))
+			}
+			if warning != "" {
+				out.Warnf("Warning: %s\n", warning)
+			}
+			reserved = cost
+		}
+	}
+
+	uploaded := uploadProgress(out, req.InputReference)
+	var downloaded api.ProgressFunc
+
+	eng := &engine.Engine{Client: client, Poll: p.poll, KeepRemote: p.keepRemote, Webhooks: p.events}
+	events := make(chan engine.Event)
+	go eng.Run(context.Background(), job, events)
+
+	for event := range events {
+		switch event := event.(type) {
+		case engine.Uploading:
+			uploaded(event.Written, event.Total)
+
+		case engine.Created:
+			if job.RemixOf != "" {
+				out.Printf("✓ Remix job created: %s\n", event.VideoID)
+				out.Println()
+				out.Event("created", map[string]interface{}{
+					"video_id": event.VideoID,
+					"provider": client.Name(),
+					"prompt":   req.Prompt,
+					"remix_of": job.RemixOf,
+				})
+			} else {
+				out.Printf("✓ Video job created: %s\n", event.VideoID)
+				out.Println()
+				out.Event("created", map[string]interface{}{
+					"video_id": event.VideoID,
+					"provider": client.Name(),
+					"prompt":   req.Prompt,
+					"model":    req.Model,
+					"size":     req.Size,
+					"duration": req.Seconds,
+				})
 			}
+			p.record(out, event.VideoID, func(e *history.Entry) {
+				e.Provider = client.Name()
+				e.Prompt = req.Prompt
+				e.Model = req.Model
+				e.Size = req.Size
+				e.Duration = req.Seconds
+				e.NegativePrompt = req.Negative
+				e.RemixOf = job.RemixOf
+				e.Parent = req.Parent
+				e.FallbackFrom = req.FallbackFrom
+				e.Status = event.Status
+			})
+			started()
 
-			if downloadErr != nil {
-				return fmt.Errorf("video content not available after %d attempts (2 minutes): %w", maxDownloadRetries, downloadErr)
+		case engine.Polled:
+			resp := event.Response
+			elapsed := int(event.Elapsed.Seconds())
+			progressStr := ""
+			if resp.Progress > 0 {
+				progressStr = fmt.Sprintf(" (%d%% complete)", resp.Progress)
 			}
 
-			fmt.Println()
-			fmt.Printf("✓ Video saved successfully!\n")
-			fmt.Printf("  Location: %s\n", outputPath)
+			remaining := history.Remaining(typical, event.Elapsed, resp.Progress)
+			etaStr := ""
+			if remaining > 0 && resp.Status != "completed" {
+				etaStr = fmt.Sprintf(", ETA ~%s", remaining.Round(time.Second))
+			}
+
+			out.Printf("[%ds] Status: %s%s%s (attempt %d/%d)\n", elapsed, resp.Status, progressStr, etaStr, event.Attempt, p.poll.Attempts())
+			out.Event("progress", map[string]interface{}{
+				"video_id": event.VideoID,
+				"status":   resp.Status,
+				"progress": resp.Progress,
+				"elapsed":  elapsed,
+				"eta":      int(remaining.Seconds()),
+			})
 
-			// Delete the video from the service after successful download
-			fmt.Println()
-			fmt.Printf("Deleting video from service...\n")
-			if err := client.DeleteVideo(videoID); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to delete video from service: %v\n", err)
+			if resp.Status == "completed" {
+				out.Println()
+				out.Printf("✓ Video generation completed!\n")
+				out.Println()
+				out.Printf("Downloading video to: %s\n", job.Output(event.VideoID))
+				downloaded = downloadProgress(out, event.VideoID)
+			}
+
+		case engine.RetryingStatus:
+			out.Warnf("  Status check %d/%d failed (%v); retrying in %s\n", event.Attempt, p.poll.Attempts(), event.Err, event.Wait.Round(time.Second))
+
+		case engine.RetryingDownload:
+			out.Printf("  Retrying download in %s (attempt %d/%d)...\n", event.Wait.Round(time.Second), event.Attempt, poll.ContentRetries)
+
+		case engine.Downloading:
+			downloaded(event.Written, event.Total)
+
+		case engine.Kept:
+			out.Println()
+			out.Printf("Keeping video on service\n")
+			p.record(out, event.VideoID, func(e *history.Entry) {
+				e.KeptRemote = true
+			})
+
+		case engine.Deleted:
+			out.Println()
+			if event.Err != nil {
+				out.Warnf("Warning: failed to delete video from service: %v\n", event.Err)
 			} else {
-				fmt.Printf("✓ Video deleted from service\n")
+				out.Printf("✓ Video deleted from service\n")
+			}
+
+		case engine.Done:
+			// Jobs that were refused or failed are not billed
+			if event.Stage == engine.StageCreate || (event.Response != nil && event.Response.Status == "failed") {
+				p.budget.Release(reserved)
 			}
+			return event.Path, jobError(out, p, client, event)
+		}
+	}
+	return "", errors.New("job ended without a result")
+}
 
-			return nil
+// jobError classifies how a job ended: failures where the provider refused
+// or failed the job are rejected, so they can fall back to another provider,
+// and get the matching exit code
+func jobError(out *console, p *providers, client api.VideoProvider, done engine.Done) error {
+	if done.Err == nil {
+		return nil
+	}
+	switch done.Stage {
+	case engine.StageCreate:
+		err := fmt.Errorf("failed to create video: %w", done.Err)
+		if rejectedAtCreate(done.Err) {
+			return &rejectedError{err}
 		}
+		return err
+	case engine.StageDownload:
+		return withExitCode(ExitDownload, done.Err)
+	}
 
-		if resp.Status == "failed" {
-			errMsg := "Video generation failed"
-			if resp.Error != nil && resp.Error.Message != "" {
-				errMsg += ": " + resp.Error.Message
-			}
-			return fmt.Errorf(errMsg)
+	if resp := done.Response; resp != nil && resp.Status == "failed" {
+		p.record(out, done.VideoID, func(e *history.Entry) {
+			e.Provider = client.Name()
+			e.Status = "failed"
+			e.Error = done.Err.Error()
+		})
+		out.Event("failed", map[string]interface{}{"video_id": done.VideoID, "error": done.Err.Error()})
+		if api.IsContentPolicyError(resp.Error) {
+			return &rejectedError{withExitCode(ExitContentPolicy, done.Err)}
+		}
+		return &rejectedError{done.Err}
+	}
+	if errors.Is(done.Err, poll.ErrTimeout) {
+		return &rejectedError{withExitCode(ExitTimeout, done.Err)}
+	}
+	return done.Err
+}
+
+// rejectedAtCreate reports whether a create error is worth retrying with
+// the fallback provider: a content policy refusal, or a provider that is
+// rate limiting or failing. Other errors, such as an invalid key or a bad
+// reference file, would fail the fallback in the same way.
+func rejectedAtCreate(err error) bool {
+	if api.IsContentPolicy(err) {
+		return true
+	}
+	status := api.StatusCode(err)
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// finish post-processes a saved video, records it in the ledger, fetches its
+// preview assets, uploads them all to an s3:// or gs:// output directory,
+// reports it, and runs the on_complete hook. It returns the path or URL the
+// video ended up at, or the upload error.
+func (p *providers) finish(out *console, client api.VideoProvider, req api.CreateVideoRequest, outputPath string, resp *api.VideoResponse, start time.Time) (string, error) {
+	videoID := resp.ID
+
+	// Post-processing problems leave the downloaded video in place
+	var gif string
+	if p.postprocess != nil {
+		out.Printf("Post-processing...\n")
+		var err error
+		outputPath, gif, err = p.postprocess.Apply(outputPath)
+		if err != nil {
+			out.Warnf("Warning: post-processing failed: %v\n", err)
+		}
+	}
+
+	checksum, fileSize, err := history.Checksum(outputPath)
+	if err != nil {
+		out.Warnf("Warning: %v\n", err)
+	}
+
+	var manifestPath string
+	if p.sidecar {
+		if manifestPath, err = manifest.Write(outputPath, client.Name(), req, resp, start); err != nil {
+			out.Warnf("Warning: %v\n", err)
+		}
+	}
+
+	previews := downloadVariants(out, client, videoID, outputPath, p.variants)
+
+	// A failed upload fails the job, which stays on the service to resume
+	var urls map[string]string
+	location := outputPath
+	if p.uploader != nil {
+		out.Printf("Uploading to %s...\n", p.uploader.Destination())
+		files := []string{outputPath, manifestPath, gif}
+		for _, variant := range p.variants {
+			files = append(files, previews[variant])
+		}
+		if urls, err = p.uploadFiles(out, files...); err != nil {
+			return "", fmt.Errorf("%w (the job was left on the service; resume it with -resume %s)", err, videoID)
+		}
+		location = urls[outputPath]
+	}
+	p.record(out, videoID, func(e *history.Entry) {
+		e.Provider = client.Name()
+		e.Status = "downloaded"
+		e.OutputPath = location
+		e.SHA256, e.Bytes = checksum, fileSize
+		e.SetGenerationTime(resp.CreatedAt, resp.CompletedAt)
+		e.SetCost(resp)
+	})
+
+	out.Println()
+	out.Printf("✓ Video saved successfully!\n")
+	out.Printf("  Location: %s\n", location)
+	if checksum != "" {
+		out.Printf("  SHA-256: %s\n", checksum)
+	}
+	for _, variant := range p.variants {
+		if path, ok := previews[variant]; ok {
+			out.Printf("  %s: %s\n", strings.ToUpper(variant[:1])+variant[1:], located(urls, path))
+		}
+	}
+	if gif != "" {
+		out.Printf("  GIF preview: %s\n", located(urls, gif))
+	}
+	if manifestPath != "" {
+		out.Printf("  Manifest: %s\n", located(urls, manifestPath))
+	}
+	out.Printf("  Provider: %s\n", client.Name())
+	if req.Prompt != "" {
+		out.Printf("  Prompt: %s\n", req.Prompt)
+	}
+	fields := resultFields(client, videoID, req, resp, location, start)
+	for variant, path := range previews {
+		fields[variant] = located(urls, path)
+	}
+	if gif != "" {
+		fields["gif"] = located(urls, gif)
+	}
+	if checksum != "" {
+		fields["sha256"] = checksum
+		fields["bytes"] = fileSize
+	}
+	if manifestPath != "" {
+		fields["manifest"] = located(urls, manifestPath)
+	}
+	out.Event("result", fields)
+	p.runHook(out, hook.Vars{
+		Path:     location,
+		ID:       videoID,
+		Prompt:   req.Prompt,
+		Model:    req.Model,
+		Provider: client.Name(),
+		Size:     req.Size,
+		Duration: req.Seconds,
+	})
+	return location, nil
+}
+
+// downloadVariants saves the requested preview assets next to the video as
+// NAME_thumbnail.webp and NAME_spritesheet.jpg, returning their paths by variant.
+// Failures only warn, since the video itself was saved.
+func downloadVariants(out *console, client api.VideoProvider, videoID, outputPath string, variants []string) map[string]string {
+	if len(variants) == 0 {
+		return nil
+	}
+	sora, ok := client.(*api.SoraClient)
+	if !ok {
+		out.Warnf("Warning: the %s provider does not offer thumbnails or spritesheets\n", client.Name())
+		return nil
+	}
+
+	paths := make(map[string]string)
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	for _, variant := range variants {
+		path := base + "_" + variant + api.VariantExtension(variant)
+		if err := sora.DownloadVideoVariant(videoID, variant, path); err != nil {
+			out.Warnf("Warning: failed to download %s: %v\n", variant, err)
+			continue
+		}
+		paths[variant] = path
+	}
+	return paths
+}
+
+// minUploadProgress is the smallest reference upload that reports progress;
+// smaller files are sent before a report would be useful
+const minUploadProgress = 1 << 20
+
+// downloadProgress returns a callback that prints download progress in 10% steps.
+// Nothing is printed when the size of the video is unknown.
+func downloadProgress(out *console, videoID string) api.ProgressFunc {
+	return transferProgress(func(percent int, written, total int64) {
+		out.Printf("  Downloaded %d%% (%.1f / %.1f MB)\n", percent, float64(written)/1e6, float64(total)/1e6)
+		out.Event("download", map[string]interface{}{
+			"video_id":    videoID,
+			"percent":     percent,
+			"bytes":       written,
+			"total_bytes": total,
+		})
+	})
+}
+
+// uploadProgress returns a callback that prints the upload progress of a
+// reference file of at least minUploadProgress bytes in 10% steps
+func uploadProgress(out *console, reference string) api.ProgressFunc {
+	return transferProgress(func(percent int, written, total int64) {
+		if total < minUploadProgress {
+			return
 		}
+		out.Printf("  Uploaded %d%% (%.1f / %.1f MB)\n", percent, float64(written)/1e6, float64(total)/1e6)
+		out.Event("upload", map[string]interface{}{
+			"reference":   reference,
+			"percent":     percent,
+			"bytes":       written,
+			"total_bytes": total,
+		})
+	})
+}
 
+// transferProgress returns a progress callback that calls report at every
+// 10% step. Transfers of unknown size are not reported.
+func transferProgress(report func(percent int, written, total int64)) api.ProgressFunc {
+	next := 10
+	return func(written, total int64) {
+		if total <= 0 {
+			return
+		}
+		percent := int(written * 100 / total)
+		if percent < next {
+			return
+		}
+		next = percent/10*10 + 10
+		report(percent, written, total)
 	}
+}
 
-	return fmt.Errorf("timeout waiting for video generation")
+// resultFields describes a saved video for the JSON "result" event, filling in
+// details the request did not carry (remixes, resumed jobs) from the job status
+func resultFields(client api.VideoProvider, videoID string, req api.CreateVideoRequest, resp *api.VideoResponse, outputPath string, start time.Time) map[string]interface{} {
+	model, size, seconds := req.Model, req.Size, req.Seconds
+	if model == "" {
+		model = resp.Model
+	}
+	if size == "" {
+		size = resp.Size
+	}
+	if seconds == "" {
+		seconds = resp.Seconds
+	}
+
+	fields := map[string]interface{}{
+		"video_id": videoID,
+		"provider": client.Name(),
+		"path":     outputPath,
+		"prompt":   req.Prompt,
+		"model":    model,
+		"size":     size,
+		"duration": seconds,
+		"elapsed":  int(time.Since(start).Seconds()),
+	}
+	if cost, ok := api.EstimateJobCost(req, resp); ok {
+		fields["cost_estimate_usd"] = cost
+	}
+	return fields
 }
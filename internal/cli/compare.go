@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/ffmpeg"
+)
+
+// CompareOptions holds options for the compare command
+type CompareOptions struct {
+	Options
+	PromptA   string
+	PromptB   string
+	Providers []string // Exactly two providers, or empty to use the default for both
+}
+
+// compareSide is one half of an A/B comparison
+type compareSide struct {
+	label    string
+	prompt   string
+	provider string
+}
+
+// RunCompare generates two variants that differ by prompt, provider, or both,
+// and combines them into a single side-by-side comparison video
+func RunCompare(opts CompareOptions) error {
+	defer printCallSummary()
+	if !ffmpeg.Available() {
+		return withExitCode(ExitValidation, fmt.Errorf("compare requires ffmpeg on PATH"))
+	}
+
+	sides, err := compareSides(opts)
+	if err != nil {
+		return err
+	}
+
+	// Side A's provider is the primary so its API key is checked up front
+	opts.Provider = sides[0].provider
+	cfg, client, p, err := newClient(opts.Options)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+	if err := localOutputOnly("compare", opts.Options, cfg); err != nil {
+		return err
+	}
+	for i := range sides {
+		if sides[i].provider == "" {
+			sides[i].provider = resolveProvider(opts.Options, cfg)
+		}
+	}
+
+	s, err := resolveSettings(opts.Options, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Both sides share one reference so only the compared variable differs
+	if err := generateReference(client, opts.Options, s); err != nil {
+		return err
+	}
+
+	baseReq := api.CreateVideoRequest{
+		Model:          s.model,
+		InputReference: s.referenceImage,
+		CropAnchor:     s.cropAnchor,
+		Seconds:        s.duration,
+		Size:           s.size,
+		Negative:       s.negative,
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	var clips []string
+	var preparedPrompt string
+	for i, side := range sides {
+		fmt.Printf("=== Variant %s (%s) ===\n\n", side.label, side.provider)
+
+		provider, err := newProvider(side.provider, cfg, opts.Options, client)
+		if err != nil {
+			return fmt.Errorf("variant %s: %w", side.label, err)
+		}
+
+		req := baseReq
+		if side.provider != sides[0].provider {
+			req = translateRequest(req, side.provider)
+		}
+
+		// Identical prompts are prepared once so wildcards resolve the same way on both sides
+		if i == 0 || side.prompt != sides[0].prompt {
+			sideSettings := *s
+			sideSettings.model = req.Model
+			preparedPrompt, err = preparePrompt(client, cfg, opts.Options, &sideSettings, side.prompt)
+			if err != nil {
+				return fmt.Errorf("variant %s: %w", side.label, err)
+			}
+		}
+		req.Prompt = preparedPrompt
+
+		outputPath, err := generateVideo(stdout, &providers{primary: provider}, req, filepath.Join(s.outputDir, fmt.Sprintf("compare_%s_%s.mp4", timestamp, side.label)))
+		if err != nil {
+			return fmt.Errorf("variant %s: %w", side.label, err)
+		}
+
+		clips = append(clips, outputPath)
+		fmt.Println()
+	}
+
+	outputPath := filepath.Join(s.outputDir, fmt.Sprintf("compare_%s.mp4", timestamp))
+	fmt.Printf("Combining variants side by side...\n")
+	if err := ffmpeg.SideBySide(clips[0], clips[1], outputPath, 720); err != nil {
+		return fmt.Errorf("failed to combine variants: %w", err)
+	}
+
+	fmt.Printf("✓ Comparison saved: %s\n", outputPath)
+	for i, side := range sides {
+		fmt.Printf("  %s (%s): %s\n", side.label, side.provider, side.prompt)
+		fmt.Printf("    %s\n", clips[i])
+	}
+
+	return nil
+}
+
+// compareSides works out the prompt and provider for each variant
+func compareSides(opts CompareOptions) ([]compareSide, error) {
+	promptA := opts.PromptA
+	if promptA == "" {
+		promptA = opts.Prompt
+	}
+	promptB := opts.PromptB
+	if promptB == "" {
+		promptB = promptA
+	}
+	if promptA == "" {
+		return nil, fmt.Errorf("a prompt is required (-p, or -prompt-a and -prompt-b)")
+	}
+
+	var providerA, providerB string
+	switch len(opts.Providers) {
+	case 0:
+		// Resolved from config once it is loaded
+		providerA, providerB = opts.Provider, opts.Provider
+	case 2:
+		providerA, providerB = opts.Providers[0], opts.Providers[1]
+	default:
+		return nil, fmt.Errorf("-providers takes exactly two providers (e.g. 'sora,runway')")
+	}
+
+	if promptA == promptB && providerA == providerB {
+		return nil, fmt.Errorf("nothing to compare: give different prompts with -prompt-a/-prompt-b or two providers with -providers")
+	}
+
+	return []compareSide{
+		{label: "a", prompt: promptA, provider: providerA},
+		{label: "b", prompt: promptB, provider: providerB},
+	}, nil
+}
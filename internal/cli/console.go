@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// console prints progress for one job. Jobs that run concurrently each get a
+// console with a label prefix, and whole lines are written under a shared lock
+// so their output does not interleave mid-line.
+//
+// In JSON mode stdout carries only newline-delimited JSON events, and the
+// human-readable progress moves to stderr.
+type console struct {
+	prefix string
+	job    int // 1-based job number included in JSON events, 0 when running a single job
+	json   bool
+	to     io.Writer // Where progress is written instead of stdout, e.g. stderr when stdout carries a protocol
+	mu     *sync.Mutex
+}
+
+// stdout is the console for jobs that run one at a time
+var stdout = &console{mu: &sync.Mutex{}}
+
+// withPrefix returns a console that labels every line with prefix
+func (c *console) withPrefix(prefix string) *console {
+	return &console{prefix: prefix, job: c.job, json: c.json, to: c.to, mu: c.mu}
+}
+
+// withJob returns a console whose JSON events carry the given job number
+func (c *console) withJob(job int) *console {
+	return &console{prefix: c.prefix, job: job, json: c.json, to: c.to, mu: c.mu}
+}
+
+func (c *console) write(w io.Writer, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.prefix == "" {
+		fmt.Fprint(w, text)
+		return
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			fmt.Fprint(w, c.prefix+line)
+		}
+	}
+}
+
+// progress returns where human-readable progress is written
+func (c *console) progress() io.Writer {
+	if c.to != nil {
+		return c.to
+	}
+	if c.json {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Printf writes formatted progress to stdout
+func (c *console) Printf(format string, args ...interface{}) {
+	c.write(c.progress(), fmt.Sprintf(format, args...))
+}
+
+// Println writes a line of progress to stdout
+func (c *console) Println(args ...interface{}) {
+	c.write(c.progress(), fmt.Sprintln(args...))
+}
+
+// Event writes a JSON event line to stdout in JSON mode, and does nothing otherwise
+func (c *console) Event(event string, fields map[string]interface{}) {
+	if !c.json {
+		return
+	}
+
+	line := map[string]interface{}{
+		"event": event,
+		"time":  time.Now().UTC().Format(time.RFC3339),
+	}
+	if c.job > 0 {
+		line["job"] = c.job
+	}
+	for key, value := range fields {
+		line[key] = value
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintln(os.Stdout, string(data))
+}
+
+// Warnf writes a formatted warning to stderr
+func (c *console) Warnf(format string, args ...interface{}) {
+	c.write(os.Stderr, fmt.Sprintf(format, args...))
+}
+
+// out returns the console for a run's progress output
+func (opts Options) out() *console {
+	if opts.console != nil {
+		return opts.console
+	}
+	return stdout
+}
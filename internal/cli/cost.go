@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// CostOptions configures the cost subcommand
+type CostOptions struct {
+	Month string // Month to report as YYYY-MM, empty for the current month
+	All   bool   // Report every recorded job instead of one month
+}
+
+// RunCost reports the estimated spend on the jobs created in a month, per job
+// and per model, from the local ledger
+func RunCost(opts CostOptions) error {
+	start, end, label, err := costPeriod(opts)
+	if err != nil {
+		return withExitCode(ExitValidation, err)
+	}
+
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	type modelTotal struct {
+		videos int
+		cost   float64
+	}
+	totals := map[string]*modelTotal{}
+	var jobs []history.Entry
+	var total float64
+	for _, entry := range entries {
+		if !opts.All && (entry.CreatedAt.Before(start) || !entry.CreatedAt.Before(end)) {
+			continue
+		}
+		cost, ok := entry.EstimatedCost()
+		if !ok {
+			continue
+		}
+		entry.Cost = cost
+		jobs = append(jobs, entry)
+		total += cost
+		if totals[entry.Model] == nil {
+			totals[entry.Model] = &modelTotal{}
+		}
+		totals[entry.Model].videos++
+		totals[entry.Model].cost += cost
+	}
+
+	fmt.Printf("Estimated spend for %s (list prices)\n\n", label)
+	if len(jobs) == 0 {
+		fmt.Println("No billable jobs recorded in this period")
+		return nil
+	}
+
+	fmt.Printf("%-12s  %-11s  %-12s  %-9s  %4s  %8s  %s\n", "Created", "Status", "Model", "Size", "Dur", "Cost", "ID")
+	for _, entry := range jobs {
+		fmt.Printf("%-12s  %-11s  %-12s  %-9s  %3ss  %8s  %s\n",
+			entry.CreatedAt.Local().Format("Jan 2 15:04"), entry.Status, entry.Model, entry.Size, entry.Duration, formatUSD(entry.Cost), entry.ID)
+	}
+
+	models := make([]string, 0, len(totals))
+	for model := range totals {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	fmt.Println()
+	for _, model := range models {
+		fmt.Printf("%-12s  %3d video(s)  %8s\n", model, totals[model].videos, formatUSD(totals[model].cost))
+	}
+	fmt.Printf("%-12s  %3d video(s)  %8s\n", "Total", len(jobs), formatUSD(total))
+	return nil
+}
+
+// costPeriod returns the time range and title of the report
+func costPeriod(opts CostOptions) (time.Time, time.Time, string, error) {
+	if opts.All {
+		return time.Time{}, time.Time{}, "all recorded jobs", nil
+	}
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if opts.Month != "" {
+		var err error
+		start, err = time.ParseInLocation("2006-01", opts.Month, time.Local)
+		if err != nil {
+			return start, start, "", fmt.Errorf("invalid month %q (use YYYY-MM, e.g. %s)", opts.Month, now.Format("2006-01"))
+		}
+	}
+	return start, start.AddDate(0, 1, 0), start.Format("January 2006"), nil
+}
+
+// formatUSD formats an amount in US dollars
+func formatUSD(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/telemetry/video-gen/internal/api"
+)
+
+// Exit codes for non-interactive runs, so automation can tell failure classes
+// apart and decide whether to retry or alert a human
+const (
+	ExitFailure       = 1   // Anything not covered below
+	ExitValidation    = 2   // Invalid flags, settings, templates, or input files
+	ExitAuth          = 3   // Missing or rejected API key
+	ExitContentPolicy = 4   // Prompt or video refused by moderation
+	ExitTimeout       = 5   // The job did not finish in time
+	ExitDownload      = 6   // The job finished but the video could not be saved
+	ExitBudget        = 7   // The job would pass a budget hard limit (monthly_limit, session_limit)
+	ExitInterrupted   = 130 // Stopped with Ctrl+C or SIGTERM while jobs were rendering
+)
+
+// exitError tags an error with the exit code it should produce
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode tags err with an exit code, returning nil for a nil error
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by this package
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	if api.IsContentPolicy(err) {
+		return ExitContentPolicy
+	}
+	switch api.StatusCode(err) {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ExitAuth
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ExitValidation
+	}
+	return ExitFailure
+}
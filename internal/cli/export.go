@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// ExportOptions configures history export
+type ExportOptions struct {
+	Format string // "csv" (default) or "json"
+	Since  string // Only export jobs created within this age, e.g. 90d, 12w, or 36h; empty for all
+}
+
+// exportColumns are the CSV columns of history export, in order
+var exportColumns = []string{"created_at", "id", "provider", "status", "model", "size", "duration", "cost_usd", "generation_seconds", "prompt", "output_path", "error"}
+
+// RunHistoryExport writes the jobs in the local ledger to stdout as CSV or
+// JSON, oldest first, for spreadsheets and reporting tools. Costs are the
+// same estimates the cost subcommand reports.
+func RunHistoryExport(opts ExportOptions) error {
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return withExitCode(ExitValidation, fmt.Errorf("unsupported export format %q (use csv or json)", opts.Format))
+	}
+	var cutoff time.Time
+	if opts.Since != "" {
+		age, err := parseAge(opts.Since)
+		if err != nil {
+			return withExitCode(ExitValidation, err)
+		}
+		cutoff = time.Now().Add(-age)
+	}
+
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+	var jobs []history.Entry
+	// The ledger loads newest first
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if cost, ok := entry.EstimatedCost(); ok {
+			entry.Cost = cost
+		}
+		jobs = append(jobs, entry)
+	}
+
+	if format == "json" {
+		return exportJSON(os.Stdout, jobs)
+	}
+	return exportCSV(os.Stdout, jobs)
+}
+
+// exportJSON writes jobs as a JSON array of ledger entries
+func exportJSON(w io.Writer, jobs []history.Entry) error {
+	if jobs == nil {
+		jobs = []history.Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(jobs); err != nil {
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	return nil
+}
+
+// exportCSV writes jobs as CSV with a header row
+func exportCSV(w io.Writer, jobs []history.Entry) error {
+	out := csv.NewWriter(w)
+	out.Write(exportColumns)
+	for _, entry := range jobs {
+		cost := ""
+		if entry.Cost > 0 {
+			cost = strconv.FormatFloat(entry.Cost, 'f', 2, 64)
+		}
+		seconds := ""
+		if entry.GenerationSeconds > 0 {
+			seconds = strconv.Itoa(entry.GenerationSeconds)
+		}
+		out.Write([]string{
+			entry.CreatedAt.UTC().Format(time.RFC3339), entry.ID, entry.Provider, entry.Status,
+			entry.Model, entry.Size, entry.Duration, cost, seconds,
+			entry.Prompt, entry.OutputPath, entry.Error,
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	return nil
+}
+
+// parseAge parses how far back a report reaches: a number of days (90d) or
+// weeks (12w), or any Go duration such as 36h
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	if unit, ok := units[s[len(s)-1:]]; ok {
+		if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid age %q (use days like 90d, weeks like 12w, or hours like 36h)", s)
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// HistoryOptions configures the history subcommand
+type HistoryOptions struct {
+	Options
+	Status   string // Only list jobs with this status
+	Query    string // Only list jobs whose prompt contains this text
+	Limit    int    // Maximum number of jobs listed, 0 for all
+	Download string // Job to download again instead of listing
+}
+
+// RunHistory lists past jobs from the local ledger, or downloads one of them again
+func RunHistory(opts HistoryOptions) error {
+	if opts.Download != "" {
+		entry, err := history.Find(opts.Download)
+		if err != nil {
+			return err
+		}
+
+		// Poll through the provider that created the job
+		opts.ResumeID = entry.ID
+		opts.Provider = entry.Provider
+		opts.Prompt = entry.Prompt
+		if err := runResume(opts.Options); err != nil {
+			if entry.Status == "downloaded" {
+				return fmt.Errorf("%w (the video was deleted from the service after it was saved to %s)", err, entry.OutputPath)
+			}
+			return err
+		}
+		return nil
+	}
+
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	var matched []history.Entry
+	query := strings.ToLower(opts.Query)
+	for _, entry := range entries {
+		if opts.Status != "" && entry.Status != opts.Status {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(entry.Prompt), query) {
+			continue
+		}
+		matched = append(matched, entry)
+		if opts.Limit > 0 && len(matched) == opts.Limit {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		fmt.Println("No matching jobs in history")
+		return nil
+	}
+
+	fmt.Printf("%-12s  %-11s  %-8s  %-12s  %s\n", "Created", "Status", "Provider", "Model", "ID")
+	for _, entry := range matched {
+		fmt.Printf("%-12s  %-11s  %-8s  %-12s  %s\n",
+			entry.CreatedAt.Local().Format("Jan 2 15:04"), entry.Status, entry.Provider, entry.Model, entry.ID)
+		if entry.Prompt != "" {
+			fmt.Printf("%-12s  %s\n", "", truncate(entry.Prompt, 70))
+		}
+		if entry.OutputPath != "" {
+			fmt.Printf("%-12s  → %s\n", "", entry.OutputPath)
+		}
+		if entry.Error != "" {
+			fmt.Printf("%-12s  ✗ %s\n", "", entry.Error)
+		}
+	}
+	return nil
+}
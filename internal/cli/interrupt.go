@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// runningJob is a job being polled, kept so an interrupted run can record it
+type runningJob struct {
+	entry     history.Entry
+	outputDir string
+	ledger    bool
+}
+
+// running holds the jobs execute is polling, by ID, and the number of
+// create requests still waiting for their job ID
+var running = struct {
+	sync.Mutex
+	jobs     map[string]runningJob
+	creating int
+	watch    sync.Once
+}{jobs: make(map[string]runningJob)}
+
+// createGrace is how long an interrupted run waits for a create request in
+// flight, so the job it starts can be recorded instead of lost
+const createGrace = 10 * time.Second
+
+// watchInterrupts starts watching for Ctrl+C and SIGTERM, once. execute
+// calls it before submitting anything, so no job is created unwatched.
+func watchInterrupts() {
+	running.watch.Do(func() {
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			interrupted(signals)
+		}()
+	})
+}
+
+// trackCreate registers a create request in flight until the returned
+// function is called, which trackJob's caller does once the job has an ID
+func trackCreate() func() {
+	running.Lock()
+	defer running.Unlock()
+	running.creating++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			running.Lock()
+			defer running.Unlock()
+			running.creating--
+		})
+	}
+}
+
+// trackJob registers a job being polled until the returned function is
+// called. Call it as soon as the job has an ID, with watchInterrupts
+// already running.
+func trackJob(p *providers, client api.VideoProvider, videoID string, req api.CreateVideoRequest, outputPath string) func() {
+	// A resumed job should be uploaded where this one would have been
+	outputDir := filepath.Dir(outputPath)
+	if p.uploader != nil {
+		outputDir = p.uploader.Destination().String()
+	}
+
+	running.Lock()
+	defer running.Unlock()
+	running.jobs[videoID] = runningJob{
+		entry: history.Entry{
+			ID:       videoID,
+			Provider: client.Name(),
+			Prompt:   req.Prompt,
+			Model:    req.Model,
+			Size:     req.Size,
+			Duration: req.Seconds,
+			Profile:  p.profile,
+			Config:   p.configPath,
+		},
+		outputDir: outputDir,
+		ledger:    p.ledger,
+	}
+	return func() {
+		running.Lock()
+		defer running.Unlock()
+		delete(running.jobs, videoID)
+	}
+}
+
+// interrupted records the jobs still being polled as interrupted, prints how
+// to resume them, and exits. The jobs keep rendering on the service. A job
+// being submitted is waited for, up to createGrace or another signal.
+func interrupted(signals <-chan os.Signal) {
+	awaitCreates(signals)
+	running.Lock()
+	var commands []string
+	for _, job := range running.jobs {
+		if job.ledger {
+			// The process is exiting, so there is no one left to warn
+			_ = history.MarkInterrupted(job.entry)
+		}
+		commands = append(commands, history.ResumeCommand(job.entry, job.outputDir))
+	}
+	sort.Strings(commands)
+
+	PrintResumeCommands(commands)
+	removeStaging()
+	os.Exit(ExitInterrupted)
+}
+
+// awaitCreates waits until no create request is in flight, another signal
+// arrives, or createGrace passes
+func awaitCreates(signals <-chan os.Signal) {
+	creating := func() bool {
+		running.Lock()
+		defer running.Unlock()
+		return running.creating > 0
+	}
+	if !creating() {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "\nWaiting for the job being submitted, to record it for resuming (Ctrl+C again to quit now)...")
+	deadline := time.After(createGrace)
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for creating() {
+		select {
+		case <-signals:
+			return
+		case <-deadline:
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// PrintResumeCommands tells the user how to pick up jobs that were still
+// rendering when the program was stopped
+func PrintResumeCommands(commands []string) {
+	if len(commands) == 0 {
+		return
+	}
+	if len(commands) == 1 {
+		fmt.Fprintln(os.Stderr, "\nInterrupted while a job was still rendering on the service. Resume it with:")
+	} else {
+		fmt.Fprintf(os.Stderr, "\nInterrupted while %d jobs were still rendering on the service. Resume them with:\n", len(commands))
+	}
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", command)
+	}
+}
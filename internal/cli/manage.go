@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunList prints the most recent videos stored on the service
+func RunList(opts Options, limit int) error {
+	defer printCallSummary()
+	_, client, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if provider.primary.Name() != "sora" {
+		return fmt.Errorf("listing videos is not supported by the %s provider", provider.primary.Name())
+	}
+
+	resp, err := client.ListVideos(limit)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Data) == 0 {
+		fmt.Println("No videos found")
+		return nil
+	}
+
+	fmt.Printf("%-12s  %-15s  %-10s  %-4s  %-9s  %s\n", "Created", "Status", "Model", "Secs", "Size", "ID")
+	for _, video := range resp.Data {
+		status := video.Status
+		if video.Status == "in_progress" && video.Progress > 0 {
+			status = fmt.Sprintf("%s %d%%", status, video.Progress)
+		}
+		fmt.Printf("%-12s  %-15s  %-10s  %-4s  %-9s  %s\n",
+			time.Unix(video.CreatedAt, 0).Format("Jan 2 15:04"), status, video.Model, video.Seconds, video.Size, video.ID)
+	}
+	return nil
+}
+
+// RunDelete deletes videos from the service, continuing past failures
+func RunDelete(opts Options, ids []string) error {
+	defer printCallSummary()
+	_, _, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+
+	failed := 0
+	for _, id := range ids {
+		if err := provider.primary.DeleteVideo(id); err != nil {
+			failed++
+			fmt.Printf("✗ %s: %v\n", id, err)
+			continue
+		}
+		fmt.Printf("✓ Deleted %s\n", id)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d videos could not be deleted", failed, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/history"
+	"github.com/telemetry/video-gen/internal/mcp"
+)
+
+// mcpServer holds what the MCP tools share for the life of the server: the
+// config, clients, and ledger settings, resolved once from the flags the
+// server was started with
+type mcpServer struct {
+	opts     Options
+	out      *console
+	cfg      *config.Config
+	client   *api.SoraClient
+	provider *providers
+}
+
+// RunMCP serves video generation to agents as a Model Context Protocol server
+// on stdin and stdout. The generation flags act as defaults for every job.
+func RunMCP(opts Options, version string) error {
+	// stdout carries only protocol messages; progress and warnings go to stderr
+	out := &console{to: os.Stderr, mu: &sync.Mutex{}}
+	opts.console = out
+
+	cfg, client, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if err := provider.setUpload(opts, cfg); err != nil {
+		return err
+	}
+
+	s := &mcpServer{opts: opts, out: out, cfg: cfg, client: client, provider: provider}
+	server := mcp.NewServer("video-gen", version,
+		mcp.Tool{
+			Name:        "create_video",
+			Description: "Start generating a video from a text prompt. Returns the job ID at once; rendering takes minutes, so check it with get_status and save it with download_video.",
+			InputSchema: objectSchema([]string{"prompt"}, map[string]interface{}{
+				"prompt":          stringProperty("What the video shows"),
+				"model":           stringProperty("sora-2 or sora-2-pro (default from the server's flags or config)"),
+				"seconds":         stringProperty("Duration: 4, 8, or 12 for Sora; 5 or 10 for Runway"),
+				"size":            stringProperty("Resolution, e.g. 1280x720 or 720x1280, or an alias: landscape, portrait, wide, tall, 16:9, or 9:16"),
+				"reference_image": stringProperty("Local path of an image or video the video starts from, or an https:// or data: image URL"),
+				"negative":        stringProperty("Things to keep out of the video, e.g. 'text, logos'"),
+			}),
+			Handler: s.createVideo,
+		},
+		mcp.Tool{
+			Name:        "get_status",
+			Description: "Get the status and progress of a video job: queued, in_progress, completed, or failed.",
+			InputSchema: objectSchema([]string{"video_id"}, map[string]interface{}{
+				"video_id": stringProperty("Job ID returned by create_video"),
+			}),
+			Handler: s.getStatus,
+		},
+		mcp.Tool{
+			Name:        "download_video",
+			Description: "Save a completed video to disk and return its path. Fails while the job is still rendering.",
+			InputSchema: objectSchema([]string{"video_id"}, map[string]interface{}{
+				"video_id":    stringProperty("Job ID returned by create_video"),
+				"output_path": stringProperty("File to save the video as (default: the server's output directory and name template)"),
+			}),
+			Handler: s.downloadVideo,
+		},
+	)
+
+	fmt.Fprintf(os.Stderr, "video-gen MCP server ready (provider: %s)\n", provider.primary.Name())
+	return server.Serve(os.Stdin, os.Stdout)
+}
+
+// objectSchema returns the JSON Schema of a tool's arguments object
+func objectSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// stringProperty returns the JSON Schema of a string argument
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+// toolJSON formats a tool result for the model
+func toolJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// createVideo submits a job with the call's settings over the server's
+// defaults, going through the same prompt checks and budget as the CLI
+func (s *mcpServer) createVideo(raw json.RawMessage) (string, error) {
+	var args struct {
+		Prompt         string `json:"prompt"`
+		Model          string `json:"model"`
+		Seconds        string `json:"seconds"`
+		Size           string `json:"size"`
+		ReferenceImage string `json:"reference_image"`
+		Negative       string `json:"negative"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Prompt == "" {
+		return "", fmt.Errorf("prompt is required")
+	}
+
+	opts := s.opts
+	if args.Model != "" {
+		opts.Model = args.Model
+	}
+	if args.Seconds != "" {
+		opts.Duration = args.Seconds
+	}
+	if args.Size != "" {
+		opts.Size = args.Size
+	}
+	if args.ReferenceImage != "" {
+		opts.ReferenceImage = args.ReferenceImage
+	}
+	if args.Negative != "" {
+		opts.Negative = args.Negative
+	}
+	settings, err := resolveSettings(opts, s.cfg)
+	if err != nil {
+		return "", err
+	}
+	promptText, err := preparePrompt(s.client, s.cfg, opts, settings, args.Prompt)
+	if err != nil {
+		return "", err
+	}
+
+	req := api.CreateVideoRequest{
+		Prompt:         promptText,
+		Model:          settings.model,
+		InputReference: settings.referenceImage,
+		CropAnchor:     settings.cropAnchor,
+		Seconds:        settings.duration,
+		Size:           settings.size,
+		Negative:       settings.negative,
+	}
+
+	p := s.provider
+	cost, estimated := api.EstimateJobCost(req, nil)
+	if estimated {
+		warning, err := p.budget.Reserve(cost, p.force)
+		if err != nil {
+			return "", err
+		}
+		if warning != "" {
+			s.out.Warnf("Warning: %s\n", warning)
+		}
+	}
+
+	resp, err := p.primary.CreateVideo(req)
+	if err != nil && p.fallbackModel != "" && req.Model != p.fallbackModel && api.IsQuotaOrTier(err) {
+		s.out.Warnf("Warning: %s refused %s, falling back to %s: %v\n", p.primary.Name(), req.Model, p.fallbackModel, err)
+		req.FallbackFrom, req.Model = req.Model, p.fallbackModel
+		resp, err = p.primary.CreateVideo(req)
+	}
+	if err != nil {
+		p.budget.Release(cost)
+		return "", fmt.Errorf("failed to create video: %w", err)
+	}
+	p.record(s.out, resp.ID, func(e *history.Entry) {
+		e.Provider = p.primary.Name()
+		e.Prompt = req.Prompt
+		e.Model = req.Model
+		e.Size = req.Size
+		e.Duration = req.Seconds
+		e.NegativePrompt = req.Negative
+		e.FallbackFrom = req.FallbackFrom
+		e.Status = resp.Status
+	})
+	s.out.Printf("✓ Video job created: %s\n", resp.ID)
+
+	result := map[string]interface{}{
+		"video_id": resp.ID,
+		"status":   resp.Status,
+		"provider": p.primary.Name(),
+		"prompt":   req.Prompt,
+		"model":    req.Model,
+		"size":     req.Size,
+		"seconds":  req.Seconds,
+	}
+	if req.FallbackFrom != "" {
+		result["fallback_from"] = req.FallbackFrom
+	}
+	if estimated {
+		result["cost_estimate_usd"] = cost
+	}
+	return toolJSON(result)
+}
+
+// getStatus reports a job as the service sees it, with the path it was saved
+// to when this tool already downloaded it
+func (s *mcpServer) getStatus(raw json.RawMessage) (string, error) {
+	videoID, err := videoIDArgument(raw)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.provider.primary.GetVideo(videoID)
+	if err != nil {
+		return "", err
+	}
+
+	result := map[string]interface{}{
+		"video_id": resp.ID,
+		"status":   resp.Status,
+		"progress": resp.Progress,
+		"model":    resp.Model,
+		"size":     resp.Size,
+		"seconds":  resp.Seconds,
+	}
+	if resp.Error != nil {
+		result["error"] = resp.Error.Message
+	}
+	if entry, err := history.Find(videoID); err == nil && entry.OutputPath != "" {
+		result["output_path"] = entry.OutputPath
+	}
+	return toolJSON(result)
+}
+
+// downloadVideo saves a completed job like download does: post-processed,
+// recorded in the ledger, uploaded, and removed from the service per the
+// retention config
+func (s *mcpServer) downloadVideo(raw json.RawMessage) (string, error) {
+	videoID, err := videoIDArgument(raw)
+	if err != nil {
+		return "", err
+	}
+	var args struct {
+		OutputPath string `json:"output_path"`
+	}
+	json.Unmarshal(raw, &args)
+
+	p := s.provider
+	resp, err := p.primary.GetVideo(videoID)
+	if err != nil {
+		return "", err
+	}
+	switch resp.Status {
+	case "completed":
+	case "failed":
+		if resp.Error != nil {
+			return "", fmt.Errorf("video %s failed: %s", videoID, resp.Error.Message)
+		}
+		return "", fmt.Errorf("video %s failed", videoID)
+	default:
+		return "", fmt.Errorf("video %s is %s (%d%% complete); call get_status until it is completed", videoID, resp.Status, resp.Progress)
+	}
+
+	// The ledger knows the prompt and settings of jobs created here
+	req := api.CreateVideoRequest{Model: resp.Model, Size: resp.Size, Seconds: resp.Seconds}
+	if entry, err := history.Find(videoID); err == nil {
+		req.Prompt, req.Negative = entry.Prompt, entry.NegativePrompt
+	}
+
+	outputPath := args.OutputPath
+	if outputPath == "" {
+		settings, err := resolveSettings(s.opts, s.cfg)
+		if err != nil {
+			return "", err
+		}
+		filename, err := outputName(s.opts, s.cfg, fmt.Sprintf("%s_video_%s.mp4", p.primary.Name(), time.Now().Format("20060102_150405")), p.primary.Name(), req, 0)
+		if err != nil {
+			return "", err
+		}
+		outputPath = filepath.Join(settings.outputDir, filename)
+	}
+
+	location, err := awaitJob(s.out, p, p.primary, videoID, req, outputPath)
+	if err != nil {
+		return "", err
+	}
+	return toolJSON(map[string]interface{}{"video_id": videoID, "path": location})
+}
+
+// videoIDArgument reads the video_id argument of a tool call
+func videoIDArgument(raw json.RawMessage) (string, error) {
+	var args struct {
+		VideoID string `json:"video_id"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.VideoID == "" {
+		return "", fmt.Errorf("video_id is required")
+	}
+	return args.VideoID, nil
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/naming"
+)
+
+// outputName returns the filename for a generated video: the -name-template
+// (or name_template config) rendered for the job, or defaultName when no
+// template is set. {id} is left in place for execute to fill in once the job
+// has been created. index numbers batch items and is 0 otherwise.
+func outputName(opts Options, cfg *config.Config, defaultName, provider string, req api.CreateVideoRequest, index int) (string, error) {
+	template := opts.NameTemplate
+	if template == "" {
+		template = cfg.NameTemplate
+	}
+	name, err := naming.Render(template, defaultName, naming.Job{
+		Provider: provider,
+		Prompt:   req.Prompt,
+		Model:    req.Model,
+		Size:     req.Size,
+		Seconds:  req.Seconds,
+		Index:    index,
+	})
+	if err != nil {
+		return "", withExitCode(ExitValidation, err)
+	}
+	return name, nil
+}
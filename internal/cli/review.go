@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/ffmpeg"
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// reviewFrameCount is the number of frames sampled across a video for review
+const reviewFrameCount = 3
+
+// generateReviewed generates a video and, when -auto-review is set, checks it
+// with a vision model, regenerating with the reviewer's feedback folded into
+// the prompt until it passes or the attempts run out. Rejected attempts are
+// kept next to the final video, whose path is returned.
+func generateReviewed(client *api.SoraClient, p *providers, opts Options, req api.CreateVideoRequest, outputPath string) (string, error) {
+	out := opts.out()
+	if opts.AutoReview == "" {
+		return generateVideo(out, p, req, outputPath)
+	}
+
+	maxAttempts := opts.ReviewAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	basePrompt := req.Prompt
+	ext := filepath.Ext(outputPath)
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		path := outputPath
+		if attempt > 1 {
+			path = fmt.Sprintf("%s_retry%d%s", strings.TrimSuffix(outputPath, ext), attempt-1, ext)
+		}
+
+		path, err := generateVideo(out, p, req, path)
+		if err != nil {
+			return "", err
+		}
+
+		out.Println()
+		out.Printf("Reviewing video (attempt %d/%d)...\n", attempt, maxAttempts)
+		review, err := reviewVideo(client, req, path, opts.AutoReview)
+		if err != nil {
+			// The review is advisory; keep the video rather than failing the run
+			out.Warnf("Warning: %v\n", err)
+			return path, nil
+		}
+
+		if review.Pass {
+			out.Printf("✓ Review passed: %s\n", path)
+			return path, nil
+		}
+
+		out.Printf("✗ Review failed: %s\n", review.Feedback)
+		if attempt < maxAttempts {
+			req.Prompt = fmt.Sprintf("%s Reviewer notes: %s", basePrompt, review.Feedback)
+			// The retry is the next version of the rejected attempt's prompt
+			if p.ledger {
+				req.Parent = savedJob(path)
+			}
+			out.Printf("\nRegenerating with feedback...\n\n")
+		}
+	}
+
+	return "", fmt.Errorf("video did not pass review after %d attempts", maxAttempts)
+}
+
+// savedJob returns the ID of the ledger job whose video was saved at path,
+// empty when there is none
+func savedJob(path string) string {
+	entries, err := history.Load()
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.OutputPath == path {
+			return entry.ID
+		}
+	}
+	return ""
+}
+
+// reviewVideo samples frames from the downloaded video and sends them to the vision model
+func reviewVideo(client *api.SoraClient, req api.CreateVideoRequest, path, criteria string) (*api.ReviewResult, error) {
+	seconds, err := strconv.ParseFloat(req.Seconds, 64)
+	if err != nil || seconds <= 0 {
+		seconds = 4
+	}
+
+	dir, err := os.MkdirTemp("", "video-gen-review-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create frame directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Sample the middle of each equal slice of the video
+	var frames [][]byte
+	for i := 0; i < reviewFrameCount; i++ {
+		at := seconds * (float64(i) + 0.5) / reviewFrameCount
+		framePath := filepath.Join(dir, fmt.Sprintf("frame%d.jpg", i))
+		if err := ffmpeg.Poster(path, framePath, at); err != nil {
+			return nil, fmt.Errorf("failed to extract review frame: %w", err)
+		}
+		data, err := os.ReadFile(framePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read review frame: %w", err)
+		}
+		frames = append(frames, data)
+	}
+
+	return client.ReviewFrames(frames, req.Prompt, criteria)
+}
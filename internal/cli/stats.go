@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// StatsOptions configures the stats subcommand
+type StatsOptions struct {
+	Since string // Only count jobs created within this age, e.g. 30d (default 30d)
+	All   bool   // Count every recorded job
+	By    string // Bucket jobs by "day" or "week"; empty picks days for up to a month
+}
+
+// statsBarWidth is the length of the bar of the busiest day or week
+const statsBarWidth = 30
+
+// statsTopPrompts is how many of the most generated prompts are listed
+const statsTopPrompts = 5
+
+// RunStats summarizes the local ledger: jobs per day or week, how many
+// succeeded, how long renders took per model and size, and the prompts
+// generated most often
+func RunStats(opts StatsOptions) error {
+	label := "all recorded jobs"
+	var cutoff time.Time
+	if !opts.All {
+		since := opts.Since
+		if since == "" {
+			since = "30d"
+		}
+		age, err := parseAge(since)
+		if err != nil {
+			return withExitCode(ExitValidation, err)
+		}
+		cutoff = time.Now().Add(-age)
+		label = fmt.Sprintf("jobs since %s (%s)", cutoff.Local().Format("Jan 2, 2006"), since)
+	}
+	by := opts.By
+	if by == "" {
+		by = "week"
+		if !opts.All && time.Since(cutoff) <= 31*24*time.Hour {
+			by = "day"
+		}
+	}
+	if by != "day" && by != "week" {
+		return withExitCode(ExitValidation, fmt.Errorf("invalid -by %q (use day or week)", opts.By))
+	}
+
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+	var jobs []history.Entry
+	for _, entry := range entries {
+		if !entry.CreatedAt.Before(cutoff) {
+			jobs = append(jobs, entry)
+		}
+	}
+
+	fmt.Printf("Usage statistics for %s\n\n", label)
+	if len(jobs) == 0 {
+		fmt.Println("No jobs recorded in this period")
+		return nil
+	}
+
+	printOutcomes(jobs)
+	printPeriods(jobs, by)
+	printRenderTimes(jobs)
+	printTopPrompts(jobs)
+	return nil
+}
+
+// printOutcomes prints how many jobs were saved, failed, cancelled, or are
+// still unfinished. The success rate counts finished jobs only.
+func printOutcomes(jobs []history.Entry) {
+	var downloaded, failed, cancelled int
+	for _, entry := range jobs {
+		switch entry.Status {
+		case "downloaded":
+			downloaded++
+		case "failed":
+			failed++
+		case "cancelled":
+			cancelled++
+		}
+	}
+	unfinished := len(jobs) - downloaded - failed - cancelled
+
+	fmt.Printf("%d job(s): %d downloaded, %d failed, %d cancelled, %d unfinished\n", len(jobs), downloaded, failed, cancelled, unfinished)
+	if finished := downloaded + failed + cancelled; finished > 0 {
+		fmt.Printf("Success rate: %.0f%% of finished jobs (failure rate %.0f%%)\n",
+			100*float64(downloaded)/float64(finished), 100*float64(failed)/float64(finished))
+	}
+}
+
+// printPeriods prints a bar chart of the jobs created per day or week, oldest first
+func printPeriods(jobs []history.Entry, by string) {
+	counts := map[time.Time]int{}
+	first := time.Now()
+	busiest := 0
+	for _, entry := range jobs {
+		start := periodStart(entry.CreatedAt.Local(), by)
+		counts[start]++
+		if counts[start] > busiest {
+			busiest = counts[start]
+		}
+		if start.Before(first) {
+			first = start
+		}
+	}
+
+	title, step := "Jobs per day", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	if by == "week" {
+		title, step = "Jobs per week (starting Monday)", func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	}
+	fmt.Printf("\n%s:\n", title)
+	// Every period up to now is listed, so quiet days show as gaps
+	for start := first; !start.After(time.Now()); start = step(start) {
+		count := counts[start]
+		bar := strings.Repeat("█", (count*statsBarWidth+busiest-1)/busiest)
+		fmt.Println(strings.TrimRight(fmt.Sprintf("  %-10s  %4d  %s", start.Format("Mon Jan 2"), count, bar), " "))
+	}
+}
+
+// periodStart returns the local midnight starting t's day, or the Monday starting its week
+func periodStart(t time.Time, by string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if by == "week" {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
+
+// printRenderTimes prints the average and median render time of the jobs
+// that recorded one, per model and size, so a slowing service stands out
+func printRenderTimes(jobs []history.Entry) {
+	type settings struct{ model, size string }
+	times := map[settings][]int{}
+	for _, entry := range jobs {
+		if entry.GenerationSeconds > 0 {
+			key := settings{entry.Model, entry.Size}
+			times[key] = append(times[key], entry.GenerationSeconds)
+		}
+	}
+	if len(times) == 0 {
+		return
+	}
+
+	keys := make([]settings, 0, len(times))
+	for key := range times {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].model != keys[j].model {
+			return keys[i].model < keys[j].model
+		}
+		return keys[i].size < keys[j].size
+	})
+
+	fmt.Printf("\nRender time by model and size:\n")
+	fmt.Printf("  %-12s  %-9s  %5s  %8s  %8s\n", "Model", "Size", "Jobs", "Average", "Median")
+	for _, key := range keys {
+		seconds := times[key]
+		sort.Ints(seconds)
+		total := 0
+		for _, s := range seconds {
+			total += s
+		}
+		average := time.Duration(total/len(seconds)) * time.Second
+		median := time.Duration(seconds[len(seconds)/2]) * time.Second
+		fmt.Printf("  %-12s  %-9s  %5d  %8s  %8s\n", key.model, key.size, len(seconds), average, median)
+	}
+}
+
+// printTopPrompts prints the prompts generated most often, ignoring case and
+// surrounding whitespace, and how many of their jobs were saved
+func printTopPrompts(jobs []history.Entry) {
+	type promptCount struct {
+		text       string
+		jobs       int
+		downloaded int
+	}
+	counts := map[string]*promptCount{}
+	for _, entry := range jobs {
+		text := strings.TrimSpace(entry.Prompt)
+		if text == "" {
+			continue
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(text), " "))
+		if counts[key] == nil {
+			counts[key] = &promptCount{text: text}
+		}
+		counts[key].jobs++
+		if entry.Status == "downloaded" {
+			counts[key].downloaded++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	top := make([]*promptCount, 0, len(counts))
+	for _, count := range counts {
+		top = append(top, count)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].jobs != top[j].jobs {
+			return top[i].jobs > top[j].jobs
+		}
+		return top[i].text < top[j].text
+	})
+	if len(top) > statsTopPrompts {
+		top = top[:statsTopPrompts]
+	}
+
+	fmt.Printf("\nTop prompts:\n")
+	for _, count := range top {
+		fmt.Printf("  %3d× (%d saved)  %s\n", count.jobs, count.downloaded, truncate(count.text, 60))
+	}
+}
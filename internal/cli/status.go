@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+)
+
+// statusListLimit is how many of the newest jobs status checks for ones still rendering
+const statusListLimit = 100
+
+// RunStatus reports the account's request quota and the jobs rendering on
+// it, and whether a new job would have to wait
+func RunStatus(opts Options) error {
+	defer printCallSummary()
+	cfg, client, provider, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if provider.primary.Name() != "sora" {
+		return fmt.Errorf("status is not supported by the %s provider", provider.primary.Name())
+	}
+
+	// Listing the newest jobs finds the ones rendering, and its response carries the quota
+	if _, err := client.ListVideos(statusListLimit); err != nil {
+		return err
+	}
+	limits := api.CurrentRateLimits()
+	queued, inProgress := api.ActiveJobs()
+	active := queued + inProgress
+
+	fmt.Printf("Requests:  %s\n", limits)
+	jobs := fmt.Sprintf("%d queued, %d rendering", queued, inProgress)
+	if cfg.ConcurrentJobs > 0 {
+		jobs += fmt.Sprintf(" (%d at once)", cfg.ConcurrentJobs)
+	}
+	fmt.Printf("Jobs:      %s\n\n", jobs)
+
+	switch {
+	case limits.Paused() > 0:
+		fmt.Printf("Requests are rate limited; a new job is submitted in %s\n", limits.Paused().Round(time.Second))
+	case limits.Known() && limits.Remaining == 0:
+		fmt.Println("The request quota is used up; a new job is submitted once it resets")
+	case cfg.ConcurrentJobs > 0 && active >= cfg.ConcurrentJobs:
+		fmt.Printf("All %d job slots are busy; a new job will queue behind them\n", cfg.ConcurrentJobs)
+	case active > 0 && cfg.ConcurrentJobs == 0:
+		fmt.Printf("%d job(s) already running; a new job may queue behind them (set concurrent_jobs to know)\n", active)
+	default:
+		fmt.Println("A new job should start rendering right away")
+	}
+	return nil
+}
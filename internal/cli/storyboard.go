@@ -0,0 +1,327 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/ffmpeg"
+	"github.com/telemetry/video-gen/internal/prompt"
+	"gopkg.in/yaml.v3"
+)
+
+// StoryboardOptions holds options for the storyboard command
+type StoryboardOptions struct {
+	Options
+	ScriptPath   string
+	Directive    string // Style directive appended to every scene for a consistent look
+	SplitWithLLM bool
+	Stitch       bool
+	Chain        bool // Start each scene from the last frame of the previous one
+}
+
+// Shot is one clip of a storyboard. Empty settings fall back to the flags and config.
+type Shot struct {
+	Prompt    string `yaml:"prompt"`
+	Model     string `yaml:"model,omitempty"`
+	Size      string `yaml:"size,omitempty"`
+	Duration  string `yaml:"duration,omitempty"`
+	Reference string `yaml:"reference,omitempty"`
+}
+
+// storyboardFile is a structured storyboard in YAML or JSON
+type storyboardFile struct {
+	Directive string `yaml:"directive"`
+	Shots     []Shot `yaml:"shots"`
+}
+
+// RunStoryboard splits a script into scenes (or reads the shots of a YAML/JSON
+// storyboard), generates a clip per scene, and optionally stitches the clips
+// into a single video
+func RunStoryboard(opts StoryboardOptions) error {
+	defer printCallSummary()
+	script, err := os.ReadFile(opts.ScriptPath)
+	if err != nil {
+		return fmt.Errorf("failed to read script: %w", err)
+	}
+
+	if opts.Stitch && !ffmpeg.Available() {
+		return withExitCode(ExitValidation, fmt.Errorf("--stitch requires ffmpeg on PATH"))
+	}
+	if opts.Chain && !ffmpeg.Available() {
+		return withExitCode(ExitValidation, fmt.Errorf("-chain requires ffmpeg on PATH"))
+	}
+
+	// Structured storyboards list their shots; Markdown scripts are split into scenes
+	var shots []Shot
+	directive := opts.Directive
+	structured := isStructuredStoryboard(opts.ScriptPath)
+	if structured {
+		if opts.SplitWithLLM {
+			return withExitCode(ExitValidation, fmt.Errorf("-llm only applies to Markdown scripts"))
+		}
+		file, err := parseStoryboard(script)
+		if err != nil {
+			return withExitCode(ExitValidation, fmt.Errorf("failed to parse storyboard %s: %w", opts.ScriptPath, err))
+		}
+		shots = file.Shots
+		if directive == "" {
+			directive = file.Directive
+		}
+	}
+
+	cfg, client, provider, err := newClient(opts.Options)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+	if err := localOutputOnly("storyboard", opts.Options, cfg); err != nil {
+		return err
+	}
+
+	s, err := resolveSettings(opts.Options, cfg)
+	if err != nil {
+		return err
+	}
+
+	// One generated reference anchors the style of every scene
+	if err := generateReference(client, opts.Options, s); err != nil {
+		return err
+	}
+	opts.ReferenceImage = s.referenceImage
+	opts.RefPrompt = ""
+	opts.ContinueFrom = ""
+
+	if !structured {
+		var scenes []string
+		if opts.SplitWithLLM {
+			fmt.Printf("Splitting script into scenes...\n")
+			scenes, err = client.SplitScript(string(script))
+			if err != nil {
+				return err
+			}
+		} else {
+			scenes = splitScript(string(script))
+		}
+		for _, scene := range scenes {
+			shots = append(shots, Shot{Prompt: scene})
+		}
+	}
+	if len(shots) == 0 {
+		return fmt.Errorf("no scenes found in %s", opts.ScriptPath)
+	}
+
+	// Resolve every shot's settings up front so a bad shot fails before any generation
+	shotSettings := make([]*settings, len(shots))
+	for i, shot := range shots {
+		if strings.TrimSpace(shot.Prompt) == "" {
+			return withExitCode(ExitValidation, fmt.Errorf("shot %d has no prompt", i+1))
+		}
+		shotSettings[i], err = resolveSettings(shotOptions(opts.Options, shot), cfg)
+		if err != nil {
+			return fmt.Errorf("shot %d: %w", i+1, err)
+		}
+	}
+
+	fmt.Printf("Storyboard: %d scenes\n", len(shots))
+	for i, shot := range shots {
+		fmt.Printf("  %d. [%ss, %s] %s\n", i+1, shotSettings[i].duration, shotSettings[i].size, shot.Prompt)
+	}
+	fmt.Println()
+
+	timestamp := time.Now().Format("20060102_150405")
+	concurrency := clampConcurrency(opts.Concurrency, len(shots))
+	if opts.Chain && concurrency > 1 {
+		// Each scene needs the previous clip before it can start
+		fmt.Printf("Warning: -chain generates scenes one at a time; ignoring -concurrency\n\n")
+		concurrency = 1
+	}
+	clips := make([]string, len(shots))
+	errs := make([]error, len(shots))
+
+	// Once a scene fails, scenes that have not started yet are skipped
+	var mu sync.Mutex
+	failed := false
+
+	runConcurrently(len(shots), concurrency, func(i int) {
+		mu.Lock()
+		skip := failed
+		mu.Unlock()
+		if skip {
+			return
+		}
+
+		sceneOpts := opts.Options
+		if concurrency > 1 {
+			sceneOpts.console = stdout.withPrefix(fmt.Sprintf("[scene %d] ", i+1))
+		} else {
+			fmt.Printf("=== Scene %d/%d ===\n\n", i+1, len(shots))
+		}
+
+		// Chained scenes start from the last frame of the previous clip unless
+		// the shot sets its own reference
+		if opts.Chain && i > 0 && shots[i].Reference == "" {
+			frame := strings.TrimSuffix(clips[i-1], filepath.Ext(clips[i-1])) + "_lastframe.png"
+			if errs[i] = ffmpeg.LastFrame(clips[i-1], frame); errs[i] != nil {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+				return
+			}
+			shotSettings[i].referenceImage = frame
+		}
+
+		clips[i], errs[i] = generateScene(client, cfg, provider, sceneOpts, shotSettings[i], prompt.WithStyle(shots[i].Prompt, directive), fmt.Sprintf("storyboard_%s_scene%02d.mp4", timestamp, i+1))
+		if errs[i] != nil {
+			mu.Lock()
+			failed = true
+			mu.Unlock()
+			return
+		}
+		sceneOpts.out().Println()
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("scene %d: %w", i+1, err)
+		}
+	}
+
+	if opts.Stitch {
+		outputPath := filepath.Join(s.outputDir, fmt.Sprintf("storyboard_%s.mp4", timestamp))
+		fmt.Printf("Stitching %d clips...\n", len(clips))
+		if err := stitchClips(clips, shotSettings, outputPath); err != nil {
+			return fmt.Errorf("failed to stitch clips: %w", err)
+		}
+		fmt.Printf("✓ Storyboard saved: %s\n", outputPath)
+	}
+
+	return nil
+}
+
+// isStructuredStoryboard reports whether path is a YAML or JSON storyboard rather than a Markdown script
+func isStructuredStoryboard(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// parseStoryboard reads a YAML or JSON storyboard (JSON is valid YAML)
+func parseStoryboard(data []byte) (*storyboardFile, error) {
+	var file storyboardFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Shots) == 0 {
+		return nil, fmt.Errorf("no shots defined")
+	}
+	return &file, nil
+}
+
+// shotOptions applies a shot's own settings on top of the storyboard options
+func shotOptions(opts Options, shot Shot) Options {
+	if shot.Model != "" {
+		opts.Model = shot.Model
+	}
+	if shot.Size != "" {
+		opts.Size = shot.Size
+	}
+	if shot.Duration != "" {
+		opts.Duration = shot.Duration
+	}
+	if shot.Reference != "" {
+		opts.ReferenceImage = shot.Reference
+	}
+	return opts
+}
+
+// stitchClips concatenates the clips, copying the streams when every shot has
+// the same size and re-encoding to the first shot's size when they differ
+func stitchClips(clips []string, shotSettings []*settings, outputPath string) error {
+	size := shotSettings[0].size
+	for _, s := range shotSettings[1:] {
+		if s.size != size {
+			var width, height int
+			if _, err := fmt.Sscanf(size, "%dx%d", &width, &height); err != nil {
+				return fmt.Errorf("invalid size %q: %w", size, err)
+			}
+			fmt.Printf("  Shots have different sizes; re-encoding to %s\n", size)
+			return ffmpeg.ConcatScaled(clips, outputPath, width, height)
+		}
+	}
+	return ffmpeg.Concat(clips, outputPath)
+}
+
+// generateScene prepares one scene's prompt and generates its clip
+func generateScene(client *api.SoraClient, cfg *config.Config, provider *providers, opts Options, s *settings, scene, filename string) (string, error) {
+	promptText, err := preparePrompt(client, cfg, opts, s, scene)
+	if err != nil {
+		return "", err
+	}
+
+	return generateVideo(opts.out(), provider, api.CreateVideoRequest{
+		Prompt:         promptText,
+		Model:          s.model,
+		InputReference: s.referenceImage,
+		CropAnchor:     s.cropAnchor,
+		Seconds:        s.duration,
+		Size:           s.size,
+		Negative:       s.negative,
+	}, filepath.Join(s.outputDir, filename))
+}
+
+// splitScript splits a Markdown script into scene prompts. Sections under
+// headings become scenes (headings without body text are skipped, which drops
+// titles); scripts without usable sections are split on blank lines.
+func splitScript(script string) []string {
+	var scenes []string
+	var body []string
+	sawHeading := false
+
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(body, " ")); text != "" {
+			scenes = append(scenes, text)
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			sawHeading = true
+			flush()
+			continue
+		}
+		if sawHeading && line != "" {
+			body = append(body, line)
+		}
+	}
+	flush()
+
+	if len(scenes) > 0 {
+		return scenes
+	}
+
+	// No sections with body text: treat each paragraph as a scene
+	for _, paragraph := range strings.Split(strings.ReplaceAll(script, "\r\n", "\n"), "\n\n") {
+		var lines []string
+		for _, line := range strings.Split(paragraph, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			scenes = append(scenes, strings.Join(lines, " "))
+		}
+	}
+
+	return scenes
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/telemetry/video-gen/internal/prompt"
+)
+
+// RunTemplates lists the prompt templates defined in templates.toml and the
+// project-local config with their placeholders
+func RunTemplates() error {
+	cfg, err := loadConfig(Options{})
+	if err != nil {
+		return err
+	}
+	templates, err := cfg.Templates()
+	if err != nil {
+		return err
+	}
+
+	if len(templates) == 0 {
+		fmt.Printf("No templates found; define them in %s\n", cfg.TemplateSources())
+		return nil
+	}
+
+	for _, name := range prompt.TemplateNames(templates) {
+		tmpl := templates[name]
+		fmt.Printf("%s\n", name)
+		if tmpl.Description != "" {
+			fmt.Printf("  %s\n", tmpl.Description)
+		}
+		fmt.Printf("  Prompt: %s\n", tmpl.Prompt)
+		if placeholders := tmpl.Placeholders(); len(placeholders) > 0 {
+			vars := make([]string, len(placeholders))
+			for i, placeholder := range placeholders {
+				vars[i] = placeholder
+				if value, ok := tmpl.Defaults[placeholder]; ok {
+					vars[i] += "=" + value
+				}
+			}
+			fmt.Printf("  Vars: %s\n", strings.Join(vars, ", "))
+		}
+		fmt.Println()
+	}
+	return nil
+}
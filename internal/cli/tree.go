@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// lineage is the ledger arranged for history tree
+type lineage struct {
+	children map[string][]*history.Entry // Jobs revised or remixed from each job, oldest first
+	target   string                      // The job asked about, marked in the tree
+	printed  map[string]bool             // Guards against cycles in a hand-edited ledger
+}
+
+// RunHistoryTree prints the iteration chain a job belongs to: the first
+// prompt, each edited version regenerated from it, and remixes, with the
+// output each one produced
+func RunHistoryTree(id string) error {
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	byID := map[string]*history.Entry{}
+	for i := range entries {
+		byID[entries[i].ID] = &entries[i]
+	}
+	job := byID[id]
+	if job == nil {
+		return withExitCode(ExitValidation, fmt.Errorf("job %s not found in history", id))
+	}
+
+	t := &lineage{children: map[string][]*history.Entry{}, target: id, printed: map[string]bool{}}
+	// Entries load newest first, so walk them backwards to list children in order
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := &entries[i]
+		if from := derivedFrom(*entry); byID[from] != nil {
+			t.children[from] = append(t.children[from], entry)
+		}
+	}
+
+	// Climb to the first job of the chain
+	root := job
+	seen := map[string]bool{root.ID: true}
+	for {
+		parent := byID[derivedFrom(*root)]
+		if parent == nil || seen[parent.ID] {
+			break
+		}
+		seen[parent.ID] = true
+		root = parent
+	}
+
+	t.print(root, "v1", 1, "", "", "")
+	return nil
+}
+
+// derivedFrom returns the job a job was remixed or revised from, empty for
+// one started from scratch
+func derivedFrom(e history.Entry) string {
+	if e.RemixOf != "" {
+		return e.RemixOf
+	}
+	return e.Parent
+}
+
+// print writes a job and, below it, the jobs derived from it. A revision
+// with an edited prompt is the next version; one that reran the same prompt
+// keeps its parent's version, and a remix keeps its source's.
+func (t *lineage) print(e *history.Entry, label string, version int, indent, branch, cont string) {
+	t.printed[e.ID] = true
+
+	marker := ""
+	if e.ID == t.target {
+		marker = "  ←"
+	}
+	fmt.Printf("%s%s%-10s  %-11s  %-12s  %s%s\n", indent, branch, label, e.Status, e.CreatedAt.Local().Format("Jan 2 15:04"), e.ID, marker)
+	detail := indent + cont + "            "
+	if e.Prompt != "" {
+		fmt.Printf("%s%s\n", detail, truncate(e.Prompt, 70))
+	}
+	if e.OutputPath != "" {
+		fmt.Printf("%s→ %s\n", detail, e.OutputPath)
+	}
+	if e.Error != "" {
+		fmt.Printf("%s✗ %s\n", detail, e.Error)
+	}
+
+	var children []*history.Entry
+	for _, child := range t.children[e.ID] {
+		if !t.printed[child.ID] {
+			children = append(children, child)
+		}
+	}
+	for i, child := range children {
+		childLabel, childVersion := fmt.Sprintf("v%d", version+1), version+1
+		switch {
+		case child.RemixOf == e.ID:
+			childLabel, childVersion = "remix", version
+		case child.Prompt == e.Prompt:
+			childLabel, childVersion = fmt.Sprintf("v%d rerun", version), version
+		}
+		childBranch, childCont := "├─ ", "│  "
+		if i == len(children)-1 {
+			childBranch, childCont = "└─ ", "   "
+		}
+		t.print(child, childLabel, childVersion, indent+cont, childBranch, childCont)
+	}
+}
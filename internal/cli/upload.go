@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/upload"
+)
+
+// staging is the directory videos bound for an s3:// or gs:// destination are
+// saved in until they are uploaded, created on first use and removed when the
+// run ends
+var staging struct {
+	sync.Mutex
+	dir string
+}
+
+// stagingDir returns the run's staging directory
+func stagingDir() (string, error) {
+	staging.Lock()
+	defer staging.Unlock()
+	if staging.dir == "" {
+		dir, err := upload.StagingDir()
+		if err != nil {
+			return "", err
+		}
+		staging.dir = dir
+	}
+	return staging.dir, nil
+}
+
+// removeStaging deletes the staging directory with anything left in it, such
+// as a video whose upload failed; that job stays on the service to resume
+func removeStaging() {
+	staging.Lock()
+	defer staging.Unlock()
+	if staging.dir != "" {
+		_ = os.RemoveAll(staging.dir)
+		staging.dir = ""
+	}
+}
+
+// outputDestination returns the output directory given with -o or in the
+// config, empty for the default
+func outputDestination(opts Options, cfg *config.Config) string {
+	if opts.OutputDir != "" {
+		return opts.OutputDir
+	}
+	return cfg.OutputDir
+}
+
+// setUpload prepares the upload of every saved video when the output
+// directory is an s3:// or gs:// destination
+func (p *providers) setUpload(opts Options, cfg *config.Config) error {
+	dir := outputDestination(opts, cfg)
+	if !upload.IsRemote(dir) {
+		return nil
+	}
+	dest, err := upload.ParseDestination(dir)
+	if err != nil {
+		return withExitCode(ExitValidation, err)
+	}
+	if opts.AutoReview != "" {
+		return withExitCode(ExitValidation, fmt.Errorf("--auto-review needs a local output directory, not %s", dest))
+	}
+	// Uploads go through the configured proxy, but never the simulated or replayed backend
+	transport, err := api.NewProxyTransport(cfg.Proxy)
+	if err != nil {
+		return withExitCode(ExitValidation, err)
+	}
+	p.uploader, err = upload.New(dest, cfg.UploadOptions(), transport)
+	if err != nil {
+		return withExitCode(ExitAuth, err)
+	}
+	return nil
+}
+
+// localOutputOnly refuses s3:// and gs:// output directories for commands
+// that do not upload what they save
+func localOutputOnly(command string, opts Options, cfg *config.Config) error {
+	if dir := outputDestination(opts, cfg); upload.IsRemote(dir) {
+		return withExitCode(ExitValidation, fmt.Errorf("%s does not support uploading to %s; use a local output directory", command, dir))
+	}
+	return nil
+}
+
+// uploadFiles uploads a saved video and the files saved next to it, skipping
+// empty paths, and returns their URLs by local path. Each file is deleted
+// once it is uploaded, so staged videos do not pile up on the local disk;
+// nothing is uploaded after the first failure.
+func (p *providers) uploadFiles(out *console, paths ...string) (map[string]string, error) {
+	urls := map[string]string{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		url, err := p.uploader.Upload(path)
+		if err != nil {
+			return nil, err
+		}
+		urls[path] = url
+		if err := os.Remove(path); err != nil {
+			out.Warnf("Warning: failed to remove uploaded file: %v\n", err)
+		}
+	}
+	return urls, nil
+}
+
+// located returns the URL a file was uploaded to, or its path when it was not
+func located(urls map[string]string, path string) string {
+	if url, ok := urls[path]; ok {
+		return url
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/naming"
+)
+
+// variationResult is the outcome of one take of a -count run
+type variationResult struct {
+	outputPath string
+	err        error
+	elapsed    time.Duration
+}
+
+// runVariations generates opts.Count takes of the same request at once,
+// saving take N as outputPath with a _vN suffix, and prints every file at the
+// end so the best take can be picked. The prompt is prepared once, so
+// wildcards resolve the same way in every take.
+func runVariations(client *api.SoraClient, provider *providers, opts Options, req api.CreateVideoRequest, outputPath string) error {
+	count := opts.Count
+	concurrency := count
+	if opts.Concurrency > 1 {
+		concurrency = clampConcurrency(opts.Concurrency, count)
+	}
+	stdout.Printf("Generating %d variations, %d at a time\n\n", count, concurrency)
+
+	results := make([]variationResult, count)
+	runConcurrently(count, concurrency, func(i int) {
+		takeOpts := opts
+		takeOpts.console = stdout.withJob(i + 1).withPrefix(fmt.Sprintf("[v%d] ", i+1))
+		out := takeOpts.out()
+
+		start := time.Now()
+		results[i].outputPath, results[i].err = generateReviewed(client, provider, takeOpts, req, naming.Variation(outputPath, i+1))
+		results[i].elapsed = time.Since(start)
+		if results[i].err != nil {
+			out.Warnf("Error: %v\n", results[i].err)
+			out.Event("error", map[string]interface{}{"error": results[i].err.Error()})
+		}
+	})
+
+	return printVariationSummary(results, req.Prompt)
+}
+
+// printVariationSummary lists the file of every take and returns an error if any failed
+func printVariationSummary(results []variationResult, prompt string) error {
+	stdout.Println()
+	stdout.Printf("Variations of: %s\n", truncate(prompt, 70))
+	stdout.Printf("  %-4s  %-6s  %-8s  %s\n", "Take", "Status", "Time", "Output")
+
+	failed := 0
+	for i, result := range results {
+		status := "✓ done"
+		output := result.outputPath
+		if result.err != nil {
+			failed++
+			status = "✗ fail"
+			output = result.err.Error()
+		}
+		stdout.Printf("  %-4s  %-6s  %-8s  %s\n", fmt.Sprintf("v%d", i+1), status, result.elapsed.Round(time.Second), output)
+	}
+
+	stdout.Println()
+	stdout.Printf("%d of %d variations generated\n", len(results)-failed, len(results))
+	stdout.Event("summary", map[string]interface{}{"generated": len(results) - failed, "failed": failed})
+	if failed > 0 {
+		return fmt.Errorf("%d of %d variations failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,520 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/ffmpeg"
+	"github.com/telemetry/video-gen/internal/prompt"
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow is a multi-step production pipeline loaded from a YAML file
+type Workflow struct {
+	Name  string            `yaml:"name"`
+	Vars  map[string]string `yaml:"vars"`
+	Steps []WorkflowStep    `yaml:"steps"`
+}
+
+// WorkflowStep is a single pipeline step. Parameters in With and the If
+// condition are Go templates evaluated against the workflow state. Steps
+// without a condition only run while no earlier step has failed.
+type WorkflowStep struct {
+	Name string            `yaml:"name"`
+	Type string            `yaml:"type"`
+	If   string            `yaml:"if"`
+	With map[string]string `yaml:"with"`
+}
+
+// WorkflowOptions holds options for the run command
+type WorkflowOptions struct {
+	Options
+	WorkflowPath string
+}
+
+var workflowStepTypes = map[string]bool{
+	"generate": true,
+	"poster":   true,
+	"gif":      true,
+	"upload":   true,
+	"notify":   true,
+	"shell":    true,
+}
+
+// loadWorkflow reads and validates a workflow file
+func loadWorkflow(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	var wf Workflow
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+
+	if len(wf.Steps) == 0 {
+		return nil, fmt.Errorf("workflow has no steps")
+	}
+
+	names := make(map[string]bool)
+	for i := range wf.Steps {
+		step := &wf.Steps[i]
+		if !workflowStepTypes[step.Type] {
+			return nil, fmt.Errorf("step %d: unknown type '%s' (expected generate, poster, gif, upload, notify, or shell)", i+1, step.Type)
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("%s%d", step.Type, i+1)
+		}
+		if names[step.Name] {
+			return nil, fmt.Errorf("step %d: duplicate step name '%s'", i+1, step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	return &wf, nil
+}
+
+// RunWorkflow executes the steps of a workflow file in order
+func RunWorkflow(opts WorkflowOptions) error {
+	defer printCallSummary()
+	wf, err := loadWorkflow(opts.WorkflowPath)
+	if err != nil {
+		return err
+	}
+
+	cfg, client, provider, err := newClient(opts.Options)
+	if err != nil {
+		return err
+	}
+	defer provider.close()
+
+	// Variables from -var and -vars override those declared in the workflow
+	overrides, err := prompt.LoadVars(opts.VarsFile, opts.Vars)
+	if err != nil {
+		return err
+	}
+	vars := make(map[string]string)
+	for key, value := range wf.Vars {
+		vars[key] = value
+	}
+	for key, value := range overrides {
+		vars[key] = value
+	}
+
+	if wf.Name != "" {
+		fmt.Printf("Workflow: %s\n\n", wf.Name)
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	steps := make(map[string]map[string]string)
+	previous := ""
+	failed := false
+	var firstErr error
+
+	for i, step := range wf.Steps {
+		data := map[string]interface{}{
+			"vars":     vars,
+			"steps":    steps,
+			"failed":   failed,
+			"previous": previous,
+		}
+
+		run := !failed
+		if step.If != "" {
+			cond, err := renderWorkflowValue(step.If, data)
+			if err != nil {
+				return fmt.Errorf("step '%s': invalid condition: %w", step.Name, err)
+			}
+			run = strings.TrimSpace(cond) == "true"
+		}
+		if !run {
+			fmt.Printf("=== Step %d/%d: %s (%s) skipped ===\n\n", i+1, len(wf.Steps), step.Name, step.Type)
+			steps[step.Name] = map[string]string{"status": "skipped", "output": ""}
+			continue
+		}
+
+		params := make(map[string]string)
+		for key, value := range step.With {
+			rendered, err := renderWorkflowValue(value, data)
+			if err != nil {
+				return fmt.Errorf("step '%s': invalid parameter '%s': %w", step.Name, key, err)
+			}
+			params[key] = rendered
+		}
+
+		fmt.Printf("=== Step %d/%d: %s (%s) ===\n\n", i+1, len(wf.Steps), step.Name, step.Type)
+
+		runner := workflowRunner{
+			client:    client,
+			provider:  provider,
+			cfg:       cfg,
+			opts:      opts.Options,
+			timestamp: timestamp,
+			steps:     steps,
+			previous:  previous,
+		}
+		output, err := runner.run(step, params)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Step '%s' failed: %v\n\n", step.Name, err)
+			steps[step.Name] = map[string]string{"status": "failed", "output": ""}
+			failed = true
+			if firstErr == nil {
+				firstErr = fmt.Errorf("step '%s': %w", step.Name, err)
+			}
+			continue
+		}
+
+		if output != "" {
+			fmt.Printf("✓ Step '%s' output: %s\n\n", step.Name, output)
+			previous = output
+		} else {
+			fmt.Printf("✓ Step '%s' done\n\n", step.Name)
+		}
+		steps[step.Name] = map[string]string{"status": "success", "output": output}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("workflow failed: %w", firstErr)
+	}
+
+	fmt.Printf("✓ Workflow completed\n")
+	return nil
+}
+
+// renderWorkflowValue evaluates a Go template against the workflow state
+func renderWorkflowValue(text string, data interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("workflow").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// workflowRunner executes individual steps with access to earlier step results
+type workflowRunner struct {
+	client    *api.SoraClient
+	provider  *providers
+	cfg       *config.Config
+	opts      Options
+	timestamp string
+	steps     map[string]map[string]string
+	previous  string
+}
+
+func (r *workflowRunner) run(step WorkflowStep, params map[string]string) (string, error) {
+	switch step.Type {
+	case "generate":
+		return r.generate(step, params)
+	case "poster":
+		return r.poster(params)
+	case "gif":
+		return r.gif(params)
+	case "upload":
+		return r.upload(params)
+	case "notify":
+		return "", r.notify(params)
+	case "shell":
+		return "", r.shell(params)
+	}
+	return "", fmt.Errorf("unknown step type '%s'", step.Type)
+}
+
+// input resolves the file a step operates on: a step name, a literal path, or
+// by default the output of the previous step
+func (r *workflowRunner) input(params map[string]string) (string, error) {
+	input := params["input"]
+	if input == "" {
+		input = r.previous
+	} else if result, ok := r.steps[input]; ok {
+		input = result["output"]
+	}
+	if input == "" {
+		return "", fmt.Errorf("no input file (set 'input' or run after a step that produces a file)")
+	}
+	return input, nil
+}
+
+func (r *workflowRunner) generate(step WorkflowStep, params map[string]string) (string, error) {
+	if params["prompt"] == "" {
+		return "", fmt.Errorf("'prompt' is required")
+	}
+
+	// Step parameters override command-line flags, which override config defaults
+	opts := r.opts
+	if params["model"] != "" {
+		opts.Model = params["model"]
+	}
+	if params["duration"] != "" {
+		opts.Duration = params["duration"]
+	}
+	if params["size"] != "" {
+		opts.Size = params["size"]
+	}
+	if params["reference"] != "" {
+		opts.ReferenceImage = params["reference"]
+	}
+	if params["ref_prompt"] != "" {
+		opts.RefPrompt = params["ref_prompt"]
+	}
+	if params["crop_anchor"] != "" {
+		opts.CropAnchor = params["crop_anchor"]
+	}
+	if params["output_dir"] != "" {
+		opts.OutputDir = params["output_dir"]
+	}
+	if params["negative"] != "" {
+		opts.Negative = params["negative"]
+	}
+	if params["style"] != "" {
+		opts.Style = params["style"]
+	}
+
+	if err := localOutputOnly("workflow", opts, r.cfg); err != nil {
+		return "", err
+	}
+	s, err := resolveSettings(opts, r.cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if err := generateReference(r.client, opts, s); err != nil {
+		return "", err
+	}
+
+	promptText, err := preparePrompt(r.client, r.cfg, opts, s, params["prompt"])
+	if err != nil {
+		return "", err
+	}
+
+	filename := params["filename"]
+	if filename == "" {
+		filename = fmt.Sprintf("workflow_%s_%s.mp4", r.timestamp, step.Name)
+	}
+	return generateVideo(stdout, r.provider, api.CreateVideoRequest{
+		Prompt:         promptText,
+		Model:          s.model,
+		InputReference: s.referenceImage,
+		CropAnchor:     s.cropAnchor,
+		Seconds:        s.duration,
+		Size:           s.size,
+		Negative:       s.negative,
+	}, filepath.Join(s.outputDir, filename))
+}
+
+func (r *workflowRunner) poster(params map[string]string) (string, error) {
+	input, err := r.input(params)
+	if err != nil {
+		return "", err
+	}
+
+	at := 1.0
+	if params["at"] != "" {
+		at, err = strconv.ParseFloat(params["at"], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid 'at' seconds: %w", err)
+		}
+	}
+
+	output := params["output"]
+	if output == "" {
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + ".jpg"
+	}
+
+	if err := ffmpeg.Poster(input, output, at); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+func (r *workflowRunner) gif(params map[string]string) (string, error) {
+	input, err := r.input(params)
+	if err != nil {
+		return "", err
+	}
+
+	width, err := intParam(params, "width", 480)
+	if err != nil {
+		return "", err
+	}
+	fps, err := intParam(params, "fps", 12)
+	if err != nil {
+		return "", err
+	}
+
+	output := params["output"]
+	if output == "" {
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + ".gif"
+	}
+
+	if err := ffmpeg.GIF(input, output, width, fps); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+// upload sends a file to a URL with HTTP PUT (e.g. a presigned S3 URL) or copies it into a directory
+func (r *workflowRunner) upload(params map[string]string) (string, error) {
+	input, err := r.input(params)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case params["url"] != "":
+		file, err := os.Open(input)
+		if err != nil {
+			return "", fmt.Errorf("failed to open upload file: %w", err)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return "", fmt.Errorf("failed to stat upload file: %w", err)
+		}
+
+		req, err := http.NewRequest("PUT", params["url"], file)
+		if err != nil {
+			return "", fmt.Errorf("failed to create upload request: %w", err)
+		}
+		req.ContentLength = info.Size()
+		if contentType := mime.TypeByExtension(filepath.Ext(input)); contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to upload: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(resp.Body)
+			return "", fmt.Errorf("upload failed (status %d): %s", resp.StatusCode, string(body))
+		}
+
+		// Drop the query string so presigned credentials are not echoed
+		return strings.SplitN(params["url"], "?", 2)[0], nil
+
+	case params["dir"] != "":
+		output := filepath.Join(params["dir"], filepath.Base(input))
+		if err := copyFile(input, output); err != nil {
+			return "", err
+		}
+		return output, nil
+	}
+
+	return "", fmt.Errorf("upload needs a 'url' or 'dir' parameter")
+}
+
+// notify posts a message to a Slack-compatible incoming webhook
+func (r *workflowRunner) notify(params map[string]string) error {
+	if params["webhook"] == "" {
+		return fmt.Errorf("'webhook' is required")
+	}
+
+	message := params["message"]
+	if message == "" {
+		message = "Workflow step completed: " + r.previous
+	}
+
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	resp, err := http.Post(params["webhook"], "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("notification failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// shell runs an arbitrary command, for integrations without a built-in step
+func (r *workflowRunner) shell(params map[string]string) error {
+	if params["run"] == "" {
+		return fmt.Errorf("'run' is required")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", params["run"])
+	} else {
+		cmd = exec.Command("sh", "-c", params["run"])
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
+func intParam(params map[string]string, key string, fallback int) (int, error) {
+	if params[key] == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(params[key])
+	if err != nil {
+		return 0, fmt.Errorf("invalid '%s': %w", key, err)
+	}
+	return value, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy to %s: %w", dst, err)
+	}
+	// A failed close can mean the copy never reached the disk
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to copy to %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package clipboard copies text to and pastes images from the system
+// clipboard through the platform's clipboard commands
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// tool is a command that writes its standard input to the system clipboard
+type tool struct {
+	name string
+	args []string
+}
+
+// copyTools returns the clipboard commands to try on this platform, in order
+func copyTools() []tool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []tool{{name: "pbcopy"}}
+	case "windows":
+		return []tool{{name: "clip.exe"}}
+	}
+
+	var tools []tool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, tool{name: "wl-copy"})
+	}
+	return append(tools,
+		tool{name: "xclip", args: []string{"-selection", "clipboard"}},
+		tool{name: "xsel", args: []string{"--clipboard", "--input"}},
+		tool{name: "wl-copy"},
+	)
+}
+
+// CopyText puts text on the system clipboard using the first available
+// clipboard command (pbcopy, wl-copy, xclip, xsel, or clip.exe)
+func CopyText(text string) error {
+	for _, t := range copyTools() {
+		if _, err := exec.LookPath(t.name); err != nil {
+			continue
+		}
+
+		cmd := exec.Command(t.name, t.args...)
+		cmd.Stdin = strings.NewReader(text)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", t.name, err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard command found; install wl-clipboard, xclip, or xsel")
+}
+
+// Reference is the -r value that takes the reference image from the clipboard
+const Reference = "clipboard"
+
+// IsReference reports whether a reference names the clipboard rather than a file
+func IsReference(ref string) bool {
+	return strings.EqualFold(strings.TrimSpace(ref), Reference)
+}
+
+// PasteImage returns the image on the system clipboard as PNG or JPEG data,
+// read with pngpaste or osascript on macOS, wl-paste or xclip on Linux, and
+// PowerShell on Windows
+func PasteImage() ([]byte, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("pngpaste"); err == nil {
+			return pasteCommand(tool{name: "pngpaste", args: []string{"-"}})
+		}
+		return pasteToFile(func(path string) *exec.Cmd {
+			return exec.Command("osascript",
+				"-e", fmt.Sprintf("set f to open for access POSIX file %q with write permission", path),
+				"-e", "write (the clipboard as «class PNGf») to f",
+				"-e", "close access f")
+		})
+	case "windows":
+		return pasteToFile(func(path string) *exec.Cmd {
+			script := fmt.Sprintf("Add-Type -AssemblyName System.Windows.Forms; $img = [System.Windows.Forms.Clipboard]::GetImage(); "+
+				"if ($img -eq $null) { exit 1 }; $img.Save('%s', [System.Drawing.Imaging.ImageFormat]::Png)", strings.ReplaceAll(path, "'", "''"))
+			return exec.Command("powershell.exe", "-NoProfile", "-STA", "-Command", script)
+		})
+	}
+
+	var tools []tool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, tool{name: "wl-paste", args: []string{"--no-newline", "--type", "image/png"}})
+	}
+	tools = append(tools, tool{name: "xclip", args: []string{"-selection", "clipboard", "-target", "image/png", "-out"}})
+	for _, t := range tools {
+		if _, err := exec.LookPath(t.name); err == nil {
+			return pasteCommand(t)
+		}
+	}
+	return nil, fmt.Errorf("no clipboard command found; install wl-clipboard or xclip")
+}
+
+// pasteCommand returns what a command that prints the clipboard image writes
+func pasteCommand(t tool) ([]byte, error) {
+	cmd := exec.Command(t.name, t.args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil || len(data) == 0 {
+		return nil, errNoImage(t.name, stderr.String())
+	}
+	return data, nil
+}
+
+// pasteToFile runs a command that saves the clipboard image to a file and returns its contents
+func pasteToFile(command func(path string) *exec.Cmd) ([]byte, error) {
+	file, err := os.CreateTemp("", "video-gen-clipboard-*.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clipboard file: %w", err)
+	}
+	path := file.Name()
+	file.Close()
+	defer os.Remove(path)
+
+	cmd := command(path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errNoImage(cmd.Path, stderr.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, errNoImage(cmd.Path, "")
+	}
+	return data, nil
+}
+
+// errNoImage reports a clipboard that holds no image, with the command's explanation if any
+func errNoImage(name, stderr string) error {
+	if detail := strings.TrimSpace(stderr); detail != "" {
+		return fmt.Errorf("no image on the clipboard (%s: %s)", name, detail)
+	}
+	return fmt.Errorf("no image on the clipboard")
+}
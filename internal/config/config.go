@@ -4,56 +4,454 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/telemetry/video-gen/internal/budget"
+	"github.com/telemetry/video-gen/internal/poll"
+	"github.com/telemetry/video-gen/internal/queue"
+	"github.com/telemetry/video-gen/internal/upload"
 )
 
+// Retention policies for videos on the service after they are downloaded
+const (
+	RetentionDelete          = "delete"            // Delete right after download (default)
+	RetentionKeep            = "keep"              // Never delete
+	RetentionDeleteAfterDays = "delete-after-days" // Delete once retention_days have passed
+)
+
+// defaultRetentionDays applies to delete-after-days when retention_days is not set
+const defaultRetentionDays = 7
+
 type Config struct {
-	OpenAIAPIKey string `toml:"openai_api_key"`
-	OutputDir    string `toml:"output_dir"`
-	Model        string `toml:"model"`
-	Duration     string `toml:"duration"`
-	Size         string `toml:"size"`
-	LastPrompt   string `toml:"last_prompt"`
+	Version          int    `toml:"version"` // Layout of the file, migrated on load when older than CurrentVersion
+	OpenAIAPIKey     string `toml:"openai_api_key"`
+	OutputDir        string `toml:"output_dir"`
+	Model            string `toml:"model"`
+	Duration         string `toml:"duration"`
+	Size             string `toml:"size"`
+	LastPrompt       string `toml:"last_prompt"`
+	WildcardsDir     string `toml:"wildcards_dir"`
+	NegativePrompt   string `toml:"negative_prompt"`
+	Provider         string `toml:"provider"`
+	RunwayAPIKey     string `toml:"runway_api_key"`
+	FallbackProvider string `toml:"fallback_provider"`
+	FallbackModel    string `toml:"fallback_model"` // Model retried with when the account's quota or tier refuses a job
+	WebhookSecret    string `toml:"webhook_secret"`
+	NameTemplate     string `toml:"name_template"`
+	Retention        string `toml:"retention"`
+	RetentionDays    int    `toml:"retention_days"`
+	OnComplete       string `toml:"on_complete"`  // Shell command run after each download
+	PostProcess      string `toml:"postprocess"`  // ffmpeg post-processing spec applied to CLI downloads
+	APIBaseURL       string `toml:"api_base_url"` // OpenAI-compatible API endpoint, e.g. a gateway proxy
+	Proxy            string `toml:"proxy"`        // HTTP(S) or SOCKS5 proxy for all API requests
+	Organization     string `toml:"organization"` // OpenAI organization ID billed for requests
+	Project          string `toml:"project"`      // OpenAI project ID billed for requests
+
+	PollInterval    string `toml:"poll_interval"`     // Fixed wait between status checks, e.g. "15s"; empty polls adaptively
+	PollMaxAttempts int    `toml:"poll_max_attempts"` // Status checks before giving up (default 200)
+	PollTimeout     string `toml:"poll_timeout"`      // Limit on waiting for a job, e.g. "30m"; empty for none
+
+	RetryAttempts int      `toml:"retry_attempts"` // Tries per queued job, the first included (default 1: no retries)
+	RetryBackoff  string   `toml:"retry_backoff"`  // Wait before the first retry, doubling up to 10m, e.g. "1m" (default 30s)
+	RetryOn       []string `toml:"retry_on"`       // Error classes retried: transient, failed, moderation, timeout (default transient and failed)
+
+	MonthlyBudget float64 `toml:"monthly_budget"` // Warn when a job takes the month's estimated spend past this (USD)
+	MonthlyLimit  float64 `toml:"monthly_limit"`  // Refuse jobs that take the month's estimated spend past this, unless -force
+	SessionBudget float64 `toml:"session_budget"` // Warn when a job takes a run's estimated spend past this
+	SessionLimit  float64 `toml:"session_limit"`  // Refuse jobs that take a run's estimated spend past this, unless -force
+
+	ConcurrentJobs  int  `toml:"concurrent_jobs"`  // Jobs the account renders at once, to tell when new jobs will queue; 0 when unknown
+	SidecarManifest bool `toml:"sidecar_manifest"` // Write VIDEO.json with the job's request, status, and timings next to each video
+
+	Azure    *AzureConfig        `toml:"azure,omitempty"`    // Azure OpenAI settings; nil for the OpenAI API
+	S3       *S3Config           `toml:"s3,omitempty"`       // Credentials for s3:// output destinations
+	GCS      *GCSConfig          `toml:"gcs,omitempty"`      // Credentials for gs:// output destinations
+	Profiles map[string]*Profile `toml:"profiles,omitempty"` // Named settings selected with -profile
+
+	profile string  // Profile in use, empty for the top-level settings
+	base    Profile // Top-level settings the profile replaced
+	applied Profile // Settings as the profile left them, to find changes on save
+
+	local     *Local   // Project-local config applied over the settings, nil outside a project
+	localPath string   // File local was read from
+	localBase []string // Settings the local config replaced, restored on save
+}
+
+// AzureConfig selects Azure OpenAI instead of the OpenAI API when Endpoint is set
+type AzureConfig struct {
+	Endpoint    string            `toml:"endpoint"`    // Resource endpoint, e.g. https://myresource.openai.azure.com
+	APIKey      string            `toml:"api_key"`     // Resource key, sent in the api-key header
+	APIVersion  string            `toml:"api_version"` // api-version query parameter (default "preview")
+	Deployments map[string]string `toml:"deployments"` // Deployment names by model, e.g. "sora-2" = "my-sora"
+}
+
+// S3Config holds the credentials for uploading to s3:// output destinations.
+// Empty fields are left to the AWS SDK: the AWS_* environment variables, the
+// shared ~/.aws files, then the instance or task role.
+type S3Config struct {
+	Region          string `toml:"region"`            // Bucket region (default AWS_REGION, then us-east-1)
+	Profile         string `toml:"profile"`           // Named profile in ~/.aws/config
+	AccessKeyID     string `toml:"access_key_id"`     // Static credentials, used instead of the SDK's
+	SecretAccessKey string `toml:"secret_access_key"` // Paired with access_key_id
+	SessionToken    string `toml:"session_token"`     // For temporary static credentials
+	Endpoint        string `toml:"endpoint"`          // S3-compatible endpoint, e.g. for MinIO or Cloudflare R2
+}
+
+// GCSConfig holds the credentials for uploading to gs:// output destinations
+type GCSConfig struct {
+	CredentialsFile string `toml:"credentials_file"` // Service account key (default GOOGLE_APPLICATION_CREDENTIALS, then gcloud's, then the instance's)
+	Endpoint        string `toml:"endpoint"`         // Storage API endpoint, e.g. for an emulator
+}
+
+// UploadOptions returns the credentials for s3:// and gs:// output destinations
+func (c *Config) UploadOptions() upload.Options {
+	var opts upload.Options
+	if c.S3 != nil {
+		opts.S3 = upload.S3Options{
+			Region:          c.S3.Region,
+			Profile:         c.S3.Profile,
+			AccessKeyID:     c.S3.AccessKeyID,
+			SecretAccessKey: c.S3.SecretAccessKey,
+			SessionToken:    c.S3.SessionToken,
+			Endpoint:        c.S3.Endpoint,
+		}
+	}
+	if c.GCS != nil {
+		opts.GCS = upload.GCSOptions{
+			CredentialsFile: c.GCS.CredentialsFile,
+			Endpoint:        c.GCS.Endpoint,
+		}
+	}
+	return opts
+}
+
+// Names of the config file and of the directory of user files that
+// accompany it, both in the user config directory
+const (
+	fileName = "telemetryos-video-gen.toml"
+	dirName  = "telemetryos-video-gen"
+)
+
+// overridePath is the config file given with -config, replacing the default
+var overridePath string
+
+// SetPath makes path the config file that Load, Exists, and Save use, as
+// -config does. An empty path restores the default location.
+func SetPath(path string) {
+	overridePath = path
+}
+
+// Path returns the config file in use: the one given to SetPath, else
+// telemetryos-video-gen.toml in the user config directory (see configHome)
+func Path() (string, error) {
+	if overridePath != "" {
+		return overridePath, nil
+	}
+	home, err := configHome()
+	if err != nil {
+		return "", err
+	}
+	return legacy(filepath.Join(home, fileName), fileName), nil
+}
+
+// Dir returns the directory for user files that accompany the config file,
+// such as wildcard lists (telemetryos-video-gen in the user config directory).
+// It stays the same when -config picks another config file.
+func Dir() (string, error) {
+	home, err := configHome()
+	if err != nil {
+		return "", err
+	}
+	return legacy(filepath.Join(home, dirName), dirName), nil
 }
 
-func getConfigPath() (string, error) {
+// configHome returns the user config directory: $XDG_CONFIG_HOME when set,
+// else ~/.config on Linux, ~/Library/Application Support on macOS, and
+// %AppData% on Windows
+func configHome() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	return os.UserConfigDir()
+}
+
+// legacy returns the file or directory name in ~/.config, where earlier
+// versions kept it on every platform, when it is there and not at current,
+// so existing setups keep working until they are moved
+func legacy(current, name string) string {
 	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return current
+	}
+	old := filepath.Join(homeDir, ".config", name)
+	if old == current {
+		return current
+	}
+	if _, err := os.Stat(current); err == nil {
+		return current
+	}
+	if _, err := os.Stat(old); err == nil {
+		return old
+	}
+	return current
+}
+
+// WildcardsDirPath returns the directory holding __name__ wildcard files,
+// defaulting to the wildcards folder in Dir()
+func (c *Config) WildcardsDirPath() (string, error) {
+	if c.WildcardsDir != "" {
+		return c.WildcardsDir, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wildcards"), nil
+}
+
+// StylesPath returns the file holding user-defined style presets (styles.toml in Dir())
+func StylesPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "styles.toml"), nil
+}
+
+// TemplatesPath returns the file holding named prompt templates (templates.toml in Dir())
+func TemplatesPath() (string, error) {
+	dir, err := Dir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(homeDir, ".config", "telemetryos-video-gen.toml"), nil
+	return filepath.Join(dir, "templates.toml"), nil
+}
+
+// APIKey returns the OpenAI API key to use: override (the -api-key flag) when set,
+// then the active profile's key, then the OPENAI_API_KEY environment variable,
+// then the config file. In Azure mode AZURE_OPENAI_API_KEY and the [azure]
+// api_key are used instead. The config field is left untouched so overrides
+// are never saved.
+func (c *Config) APIKey(override string) string {
+	if override != "" {
+		return override
+	}
+	if c.UsesAzure() {
+		if key := os.Getenv("AZURE_OPENAI_API_KEY"); key != "" {
+			return key
+		}
+		return c.Azure.APIKey
+	}
+	if key := c.activeProfile().OpenAIAPIKey; key != "" {
+		return key
+	}
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		return key
+	}
+	return c.OpenAIAPIKey
+}
+
+// SetAPIKey stores key as the OpenAI API key, or the Azure key in Azure mode
+func (c *Config) SetAPIKey(key string) {
+	if c.UsesAzure() {
+		c.Azure.APIKey = key
+		return
+	}
+	c.OpenAIAPIKey = key
+}
+
+// UsesAzure reports whether the [azure] table selects Azure OpenAI
+func (c *Config) UsesAzure() bool {
+	return c.Azure != nil && c.Azure.Endpoint != ""
+}
+
+// BaseURL returns the OpenAI API endpoint to use: override (the -base-url
+// flag) when set, then the active profile's, then the OPENAI_BASE_URL
+// environment variable, then api_base_url from the config file. It is empty
+// for the default endpoint, and in Azure mode unless overridden, since the
+// [azure] endpoint is used instead.
+func (c *Config) BaseURL(override string) string {
+	if override != "" || c.UsesAzure() {
+		return override
+	}
+	if url := c.activeProfile().APIBaseURL; url != "" {
+		return url
+	}
+	if url := os.Getenv("OPENAI_BASE_URL"); url != "" {
+		return url
+	}
+	return c.APIBaseURL
+}
+
+// OrganizationID returns the OpenAI organization to bill: override (the -org
+// flag) when set, then the active profile's, then the OPENAI_ORG_ID
+// environment variable, then the config file. It is empty for the API key's
+// default organization.
+func (c *Config) OrganizationID(override string) string {
+	if override != "" {
+		return override
+	}
+	if org := c.activeProfile().Organization; org != "" {
+		return org
+	}
+	if org := os.Getenv("OPENAI_ORG_ID"); org != "" {
+		return org
+	}
+	return c.Organization
+}
+
+// ProjectID returns the OpenAI project to bill: override (the -project flag)
+// when set, then the active profile's, then the OPENAI_PROJECT_ID environment
+// variable, then the config file. It is empty for the API key's default
+// project.
+func (c *Config) ProjectID(override string) string {
+	if override != "" {
+		return override
+	}
+	if project := c.activeProfile().Project; project != "" {
+		return project
+	}
+	if project := os.Getenv("OPENAI_PROJECT_ID"); project != "" {
+		return project
+	}
+	return c.Project
+}
+
+// RetentionPolicy returns the validated retention policy and, for
+// delete-after-days, how long downloaded videos are kept on the service
+func (c *Config) RetentionPolicy() (string, time.Duration, error) {
+	days := c.RetentionDays
+	if days <= 0 {
+		days = defaultRetentionDays
+	}
+	age := time.Duration(days) * 24 * time.Hour
+
+	switch c.Retention {
+	case "", RetentionDelete:
+		return RetentionDelete, 0, nil
+	case RetentionKeep:
+		return RetentionKeep, 0, nil
+	case RetentionDeleteAfterDays:
+		return RetentionDeleteAfterDays, age, nil
+	default:
+		return "", 0, fmt.Errorf("invalid retention %q in config (use %q, %q, or %q)", c.Retention, RetentionKeep, RetentionDelete, RetentionDeleteAfterDays)
+	}
 }
 
-// Load reads the config file from ~/.config/telemetryos-video-gen.toml
+// PollStrategy returns how jobs are polled: the overrides from flags where
+// they are set, then poll_interval, poll_max_attempts, and poll_timeout
+func (c *Config) PollStrategy(interval, timeout time.Duration, maxAttempts int) (poll.Strategy, error) {
+	s := poll.Strategy{Interval: interval, MaxAttempts: maxAttempts, Timeout: timeout}
+	if s.Interval == 0 && c.PollInterval != "" {
+		d, err := time.ParseDuration(c.PollInterval)
+		if err != nil {
+			return s, fmt.Errorf("invalid poll_interval %q in config (use a duration such as \"15s\")", c.PollInterval)
+		}
+		s.Interval = d
+	}
+	if s.MaxAttempts == 0 {
+		s.MaxAttempts = c.PollMaxAttempts
+	}
+	if s.Timeout == 0 && c.PollTimeout != "" {
+		d, err := time.ParseDuration(c.PollTimeout)
+		if err != nil {
+			return s, fmt.Errorf("invalid poll_timeout %q in config (use a duration such as \"30m\")", c.PollTimeout)
+		}
+		s.Timeout = d
+	}
+	return s, s.Validate()
+}
+
+// RetryPolicy returns how queued jobs that failed are tried again, from
+// retry_attempts, retry_backoff, and retry_on
+func (c *Config) RetryPolicy() (queue.RetryPolicy, error) {
+	p := queue.RetryPolicy{MaxAttempts: c.RetryAttempts}
+	if p.MaxAttempts < 0 {
+		return p, fmt.Errorf("invalid retry_attempts %d in config", c.RetryAttempts)
+	}
+	if c.RetryBackoff != "" {
+		d, err := time.ParseDuration(c.RetryBackoff)
+		if err != nil || d <= 0 {
+			return p, fmt.Errorf("invalid retry_backoff %q in config (use a duration such as \"1m\")", c.RetryBackoff)
+		}
+		p.Backoff = queue.DefaultRetryBackoff
+		p.Backoff.Base, p.Backoff.Max = d, max(d, p.Backoff.Max)
+	}
+	for _, name := range c.RetryOn {
+		class, err := queue.ParseErrorClass(name)
+		if err != nil {
+			return p, fmt.Errorf("retry_on: %w", err)
+		}
+		p.On = append(p.On, class)
+	}
+	return p, nil
+}
+
+// Budget returns the spending limits, rejecting negative amounts
+func (c *Config) Budget() (budget.Budget, error) {
+	b := budget.Budget{
+		Monthly:      c.MonthlyBudget,
+		MonthlyLimit: c.MonthlyLimit,
+		Session:      c.SessionBudget,
+		SessionLimit: c.SessionLimit,
+	}
+	if b.Monthly < 0 || b.MonthlyLimit < 0 || b.Session < 0 || b.SessionLimit < 0 {
+		return b, fmt.Errorf("invalid budget in config: amounts must not be negative")
+	}
+	return b, nil
+}
+
+// Load reads the config file (see Path), migrating an older layout and
+// rejecting unknown keys and settings that cannot work, and applies the
+// project-local config of the working directory over it
 func Load() (*Config, error) {
-	configPath, err := getConfigPath()
+	configPath, err := Path()
 	if err != nil {
 		return nil, err
 	}
 
 	cfg := &Config{}
 
-	// If config doesn't exist, return empty config
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return cfg, nil
+	// If config doesn't exist, start from an empty config
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		md, err := toml.DecodeFile(configPath, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode config: %w", err)
+		}
+		if err := cfg.migrate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+		if err := problemsError(configPath, cfg.validate(md)); err != nil {
+			return nil, err
+		}
 	}
 
-	if _, err := toml.DecodeFile(configPath, cfg); err != nil {
-		return nil, fmt.Errorf("failed to decode config: %w", err)
+	if err := cfg.loadLocal(); err != nil {
+		return nil, err
 	}
-
 	return cfg, nil
 }
 
-// Save writes the config to ~/.config/telemetryos-video-gen.toml
+// Exists reports whether the config file has been written, telling a first
+// launch apart from later ones
+func Exists() bool {
+	configPath, err := Path()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(configPath)
+	return err == nil
+}
+
+// Save writes the config to its file (see Path)
 func Save(cfg *Config) error {
-	configPath, err := getConfigPath()
+	configPath, err := Path()
 	if err != nil {
 		return err
 	}
 
-	// Create .config directory if it doesn't exist
+	// Create the config directory if it doesn't exist
 	configDir := filepath.Dir(configPath)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
@@ -65,8 +463,10 @@ func Save(cfg *Config) error {
 	}
 	defer f.Close()
 
+	saved := cfg.forSave()
+	saved.Version = CurrentVersion
 	encoder := toml.NewEncoder(f)
-	if err := encoder.Encode(cfg); err != nil {
+	if err := encoder.Encode(saved); err != nil {
 		return fmt.Errorf("failed to encode config: %w", err)
 	}
 
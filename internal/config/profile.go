@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile names the top-level settings when picking a profile. A
+// profile defined with this name takes its place.
+const DefaultProfile = "default"
+
+// Profile is a named set of settings from a [profiles.NAME] table that
+// override the top-level ones, e.g. one per client account
+type Profile struct {
+	OpenAIAPIKey string `toml:"openai_api_key,omitempty"`
+	Organization string `toml:"organization,omitempty"`
+	Project      string `toml:"project,omitempty"`
+	APIBaseURL   string `toml:"api_base_url,omitempty"`
+	Provider     string `toml:"provider,omitempty"`
+	RunwayAPIKey string `toml:"runway_api_key,omitempty"`
+	OutputDir    string `toml:"output_dir,omitempty"`
+	Model        string `toml:"model,omitempty"`
+	Duration     string `toml:"duration,omitempty"`
+	Size         string `toml:"size,omitempty"`
+}
+
+// settings returns the profile's settings in the order of Config.profileSettings
+func (p *Profile) settings() []*string {
+	return []*string{
+		&p.OpenAIAPIKey, &p.Organization, &p.Project, &p.APIBaseURL, &p.Provider,
+		&p.RunwayAPIKey, &p.OutputDir, &p.Model, &p.Duration, &p.Size,
+	}
+}
+
+// profileSettings returns the top-level settings a profile can override
+func (c *Config) profileSettings() []*string {
+	return []*string{
+		&c.OpenAIAPIKey, &c.Organization, &c.Project, &c.APIBaseURL, &c.Provider,
+		&c.RunwayAPIKey, &c.OutputDir, &c.Model, &c.Duration, &c.Size,
+	}
+}
+
+// ProfileNames lists the defined profiles in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveProfile returns the name of the profile in use, or "" for the
+// top-level settings
+func (c *Config) ActiveProfile() string {
+	return c.profile
+}
+
+// activeProfile returns the profile in use, or an empty profile for the
+// top-level settings
+func (c *Config) activeProfile() *Profile {
+	if profile := c.Profiles[c.profile]; c.profile != "" && profile != nil {
+		return profile
+	}
+	return &Profile{}
+}
+
+// UseProfile applies the settings of the named profile over the top-level
+// ones. An empty name, or DefaultProfile when no profile has that name, keeps
+// the top-level settings. Save keeps writing the top-level settings as they
+// were and stores changes to the overridden ones in the profile.
+func (c *Config) UseProfile(name string) error {
+	if c.profile != "" {
+		return fmt.Errorf("profile '%s' is already in use", c.profile)
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if name == "" || name == DefaultProfile {
+			return nil
+		}
+		if len(c.Profiles) == 0 {
+			return fmt.Errorf("unknown profile '%s': no [profiles.%s] table in the config", name, name)
+		}
+		return fmt.Errorf("unknown profile '%s'. Available profiles: %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	if profile == nil {
+		profile = &Profile{}
+		c.Profiles[name] = profile
+	}
+
+	// The project-local config still overrides the profile
+	c.unapplyLocal()
+	base, applied := c.base.settings(), c.applied.settings()
+	for i, setting := range c.profileSettings() {
+		*base[i] = *setting
+		if value := *profile.settings()[i]; value != "" {
+			*setting = value
+		}
+		*applied[i] = *setting
+	}
+	c.profile = name
+	c.applyLocal()
+	return nil
+}
+
+// forSave returns the config as it should be written: settings from the
+// project-local config are left out, and with a profile in use, the
+// top-level settings keep their values from the file and settings changed
+// since the profile was applied are stored in the profile
+func (c *Config) forSave() *Config {
+	saved := *c
+	saved.unapplyLocal()
+	if c.profile == "" {
+		return &saved
+	}
+	overrides := c.Profiles[c.profile].settings()
+	base, applied := c.base.settings(), c.applied.settings()
+	for i, setting := range saved.profileSettings() {
+		if *setting != *applied[i] {
+			*overrides[i] = *setting
+			*applied[i] = *setting
+		}
+		*setting = *base[i]
+	}
+	return &saved
+}
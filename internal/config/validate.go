@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/upload"
+)
+
+// CurrentVersion is the layout of the config files this video-gen reads and
+// writes. Files without a version key predate versioning and are version 0.
+const CurrentVersion = 1
+
+// migrations bring a config from the version at their index to the next.
+// They run on every load of an older file; Save writes the result with the
+// current version.
+var migrations = []func(c *Config){
+	migrateUnversioned,
+}
+
+// migrateUnversioned updates a file from before versioning, which kept the
+// model and size as they were typed, aliases such as "sora-pro" and
+// "landscape" included, to the model IDs and pixel sizes
+func migrateUnversioned(c *Config) {
+	c.Model = api.ModelName(c.Model)
+	c.FallbackModel = api.ModelName(c.FallbackModel)
+	c.Size = api.ResolveSize(c.Size)
+	for _, profile := range c.Profiles {
+		if profile != nil {
+			profile.Model = api.ModelName(profile.Model)
+			profile.Size = api.ResolveSize(profile.Size)
+		}
+	}
+}
+
+// migrate brings a config loaded from an older file up to CurrentVersion
+func (c *Config) migrate() error {
+	if c.Version > CurrentVersion {
+		return fmt.Errorf("config version %d is newer than this video-gen reads (%d); update video-gen", c.Version, CurrentVersion)
+	}
+	for ; c.Version < CurrentVersion; c.Version++ {
+		migrations[c.Version](c)
+	}
+	return nil
+}
+
+// validate returns the problems with a loaded config, each naming the
+// setting and how to fix it: keys video-gen does not know, models and sizes
+// the provider does not offer, and output directories that cannot be one.
+// A directory that does not exist yet is not a problem: -o or a profile may
+// replace it, and saving the first video creates it.
+func (c *Config) validate(md toml.MetaData) []string {
+	var problems []string
+
+	undecoded := map[string]bool{}
+	for _, key := range md.Undecoded() {
+		undecoded[key.String()] = true
+	}
+	for _, key := range md.Undecoded() {
+		// Report an unknown table once, not once per key in it
+		if len(key) > 1 && undecoded[key[:len(key)-1].String()] {
+			continue
+		}
+		problems = append(problems, unknownSetting(key, reflect.TypeOf(Config{})))
+	}
+
+	top := Profile{Provider: c.Provider, Model: c.Model, Duration: c.Duration, Size: c.Size, OutputDir: c.OutputDir}
+	problems = append(problems, checkSettings("", top, Profile{})...)
+	if c.FallbackModel != "" {
+		if err := api.CheckModel(c.FallbackModel, providerOf(top)); err != nil {
+			problems = append(problems, "fallback_model: "+err.Error())
+		}
+	}
+	if c.FallbackProvider != "" && len(api.Models(c.FallbackProvider)) == 0 {
+		problems = append(problems, fmt.Sprintf("fallback_provider: unknown provider '%s'; use 'sora' or 'runway'", c.FallbackProvider))
+	}
+	for _, name := range c.ProfileNames() {
+		if profile := c.Profiles[name]; profile != nil {
+			problems = append(problems, checkSettings("profiles."+name+".", *profile, top)...)
+		}
+	}
+
+	if _, _, err := c.RetentionPolicy(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := c.PollStrategy(0, 0, 0); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := c.RetryPolicy(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := c.Budget(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// checkSettings returns the problems with the generation settings of the
+// top-level table or a profile. prefix names the table in messages, and
+// inherited holds the top-level settings a profile leaves unset.
+func checkSettings(prefix string, s, inherited Profile) []string {
+	var problems []string
+	add := func(setting, problem string) {
+		problems = append(problems, prefix+setting+": "+problem)
+	}
+
+	if s.Provider == "" {
+		s.Provider = inherited.Provider
+	} else if len(api.Models(s.Provider)) == 0 {
+		add("provider", fmt.Sprintf("unknown provider '%s'; use 'sora' or 'runway'", s.Provider))
+		return problems
+	}
+	provider := providerOf(s)
+
+	model := api.DefaultModel(provider)
+	if s.Model != "" {
+		if err := api.CheckModel(s.Model, provider); err != nil {
+			add("model", err.Error())
+		} else {
+			model, _ = api.LookupModel(s.Model)
+		}
+	} else if m, ok := api.LookupModel(inherited.Model); ok && m.Provider == provider {
+		model = m
+	}
+
+	// Sizes and durations are checked against every model of the provider,
+	// since -m can pick another model than the config's
+	if s.Size != "" && !providerRenders(provider, func(m api.Model) bool { return m.SupportsSize(api.ResolveSize(s.Size)) }) {
+		add("size", model.CheckSize(api.ResolveSize(s.Size)).Error())
+	}
+	if s.Duration != "" && !providerRenders(provider, func(m api.Model) bool { return m.SupportsDuration(s.Duration) }) {
+		add("duration", model.CheckDuration(s.Duration).Error())
+	}
+
+	if s.OutputDir != "" && !upload.IsRemote(s.OutputDir) {
+		info, err := os.Stat(s.OutputDir)
+		switch {
+		case strings.HasPrefix(s.OutputDir, "~"):
+			add("output_dir", fmt.Sprintf("'%s' starts with ~, which is not expanded; write the full path", s.OutputDir))
+		case os.IsNotExist(err):
+			// Created when the first video is saved
+		case err != nil:
+			add("output_dir", err.Error())
+		case !info.IsDir():
+			add("output_dir", fmt.Sprintf("%s is a file, not a directory", s.OutputDir))
+		}
+	}
+	return problems
+}
+
+// problemsError reports the problems found in the config file at path
+func problemsError(path string, problems []string) error {
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s: %s", path, problems[0])
+	}
+	return fmt.Errorf("%s has %d problems:\n  %s", path, len(problems), strings.Join(problems, "\n  "))
+}
+
+// providerOf returns the provider settings select, defaulting to Sora
+func providerOf(s Profile) string {
+	if s.Provider == "" {
+		return "sora"
+	}
+	return s.Provider
+}
+
+// providerRenders reports whether any model of the provider passes supports
+func providerRenders(provider string, supports func(api.Model) bool) bool {
+	for _, model := range api.Models(provider) {
+		if supports(model) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownSetting describes a key that a file decoded into root does not
+// have, suggesting the setting that was probably meant
+func unknownSetting(key toml.Key, root reflect.Type) string {
+	message := fmt.Sprintf("unknown setting '%s'", key)
+	name := key[len(key)-1]
+	if suggestion := api.ClosestName(strings.ToLower(name), settingNames(root, key[:len(key)-1])); suggestion != "" && suggestion != name {
+		return fmt.Sprintf("%s. Did you mean '%s'?", message, suggestion)
+	}
+	return message + "; remove it or check the spelling"
+}
+
+// settingNames returns the keys of the table at path in a config of type t,
+// e.g. the keys of a profile for ["profiles", "work"]
+func settingNames(t reflect.Type, path []string) []string {
+	for _, name := range path {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			// name is the map key, such as the profile name
+			t = t.Elem()
+		case reflect.Struct:
+			field, ok := fieldByKey(t, name)
+			if !ok {
+				return nil
+			}
+			t = field.Type
+		default:
+			return nil
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if key := tomlKey(t.Field(i)); key != "" {
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// fieldByKey returns the field of struct type t stored under key
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if tomlKey(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// tomlKey returns the key a field is stored under, or "" for fields that
+// are not stored
+func tomlKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	key, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	if key == "-" {
+		return ""
+	}
+	return key
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig makes data the config file of a temporary home directory
+func writeConfig(t *testing.T, data string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	path := filepath.Join(home, ".config", "telemetryos-video-gen.toml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMigratesUnversioned(t *testing.T) {
+	writeConfig(t, `
+model = "sora-pro"
+size = "Landscape"
+
+[profiles.client]
+size = "tall"
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+	}
+	if cfg.Model != "sora-2-pro" || cfg.Size != "1280x720" {
+		t.Errorf("model and size = %s, %s, want sora-2-pro, 1280x720", cfg.Model, cfg.Size)
+	}
+	if size := cfg.Profiles["client"].Size; size != "1024x1792" {
+		t.Errorf("profile size = %s, want 1024x1792", size)
+	}
+
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	path, _ := Path()
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "version = 1") {
+		t.Errorf("saved config has no version:\n%s", data)
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		want   []string // Substrings of the error; none for a valid config
+	}{
+		{"valid", `model = "sora-2"` + "\n" + `output_dir = "` + dir + `"`, nil},
+		{"wide size for another model", `model = "sora-2"` + "\n" + `size = "1792x1024"`, nil},
+		{"newer version", "version = 99", []string{"config version 99 is newer"}},
+		{"misspelled key", `ouput_dir = "/tmp"`, []string{"unknown setting 'ouput_dir'. Did you mean 'output_dir'?"}},
+		{"unknown table", "[telemetry]\nkey = 1", []string{"unknown setting 'telemetry';"}},
+		{"misspelled profile key", "[profiles.client]\nmodle = \"sora-2\"", []string{"unknown setting 'profiles.client.modle'. Did you mean 'model'?"}},
+		{"unknown model", `model = "sora2"`, []string{"model: unknown model 'sora2'. Did you mean 'sora-2'?"}},
+		{"malformed size", `size = "1280*720"`, []string{"size: invalid size '1280*720'. Did you mean '1280x720'?"}},
+		{"missing output dir", `output_dir = "` + filepath.Join(dir, "missing") + `"`, nil},
+		{"missing profile output dir", "[profiles.client]\noutput_dir = \"/mnt/unmounted\"", nil},
+		{"output dir with tilde", `output_dir = "~/videos"`, []string{"output_dir: '~/videos' starts with ~"}},
+		{"output dir is a file", `output_dir = "` + file + `"`, []string{"is a file, not a directory"}},
+		{"profile model", "[profiles.client]\nmodel = \"gen4_turbo\"", []string{"profiles.client.model: model 'gen4_turbo' is a runway model"}},
+		{"retry policy", "retry_attempts = 3\nretry_backoff = \"1m\"\nretry_on = [\"transient\", \"timeout\"]", nil},
+		{"bad retry backoff", `retry_backoff = "soon"`, []string{"invalid retry_backoff \"soon\""}},
+		{"unknown retry class", `retry_on = ["5xx"]`, []string{"retry_on: unknown error class '5xx'"}},
+		{"several problems", "model = \"x\"\nretention = \"forever\"", []string{"has 2 problems", "unknown model 'x'", "invalid retention"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfig(t, tt.config)
+			_, err := Load()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Load() error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Load() = nil error, want one")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error = %v, want one containing %q", err, want)
+				}
+			}
+		})
+	}
+}
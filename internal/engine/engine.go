@@ -0,0 +1,272 @@
+// Package engine runs a generation job from start to finish: it creates the
+// job, polls it until the service is done rendering, downloads the video, and
+// deletes it from the service. Each step is reported as a typed Event on a
+// channel, which the CLI prints and the TUI turns into messages, so both run
+// jobs the same way.
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/poll"
+	"github.com/telemetry/video-gen/internal/webhook"
+)
+
+// WebhookPollInterval is the safety-net poll interval while waiting for webhook events
+const WebhookPollInterval = 60 * time.Second
+
+// Engine runs jobs against one provider
+type Engine struct {
+	Client     api.VideoProvider
+	Poll       poll.Strategy   // How jobs are polled and how long they are waited for
+	KeepRemote bool            // Leave videos on the service after download
+	Webhooks   *webhook.Server // Ends waits early when OpenAI announces a Sora job finished; optional
+}
+
+// Remixer is a provider that can create a job from a finished video
+type Remixer interface {
+	RemixVideo(videoID, prompt string) (*api.CreateVideoResponse, error)
+}
+
+// Job is a video to generate, or an existing job to pick up
+type Job struct {
+	Request api.CreateVideoRequest // What to generate; for an existing job, whatever is known about it
+	VideoID string                 // An existing job to await instead of creating one
+	RemixOf string                 // Create the job by remixing this finished video with Request.Prompt
+	Started time.Time              // When the job started, for the poll timeout; zero for when polling starts
+
+	// Submitted, when set, is called with the job ID as soon as the create
+	// request returns, before the Created event is delivered, so the job can
+	// be recorded even when the process is stopped in between
+	Submitted func(videoID string)
+	// Output returns the path the video is saved to, given its job ID
+	Output func(videoID string) string
+	// Finish, when set, runs once the video is saved and before it is deleted
+	// from the service, e.g. to post-process it or fetch its thumbnail. It
+	// returns the path the video ended up at, or an error that fails the job
+	// and leaves the video on the service to download again.
+	Finish func(path string, resp *api.VideoResponse) (string, error)
+}
+
+// Run runs job, sending its events on events and closing the channel after
+// the final Done event. Cancelling ctx stops polling and drops any further
+// events; the job itself keeps rendering on the service.
+func (e *Engine) Run(ctx context.Context, job Job, events chan<- Event) {
+	defer close(events)
+	r := &run{Engine: e, ctx: ctx, events: events, job: job}
+	r.send(r.execute())
+}
+
+// run is the state of one Run
+type run struct {
+	*Engine
+	ctx    context.Context
+	events chan<- Event
+	job    Job
+}
+
+// send delivers an event unless the run has been cancelled
+func (r *run) send(event Event) {
+	select {
+	case r.events <- event:
+	case <-r.ctx.Done():
+	}
+}
+
+// sleep waits for d, returning the context's error if the run is cancelled first
+func (r *run) sleep(d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+}
+
+// execute runs the steps of the job and describes how it ended
+func (r *run) execute() Done {
+	videoID := r.job.VideoID
+	if videoID == "" {
+		resp, err := r.create()
+		if err != nil {
+			return Done{Stage: StageCreate, Err: err}
+		}
+		videoID = resp.ID
+		if r.job.Submitted != nil {
+			r.job.Submitted(videoID)
+		}
+		r.send(Created{VideoID: resp.ID, Status: resp.Status})
+	}
+
+	resp, err := r.await(videoID)
+	if err != nil {
+		return Done{VideoID: videoID, Response: resp, Stage: StagePoll, Err: err}
+	}
+
+	path, err := r.save(videoID, resp)
+	if err != nil {
+		return Done{VideoID: videoID, Response: resp, Stage: StageDownload, Err: err}
+	}
+	return Done{VideoID: videoID, Path: path, Response: resp}
+}
+
+// create submits the job, reporting the upload of a reference file
+func (r *run) create() (*api.CreateVideoResponse, error) {
+	req := r.job.Request
+	if r.job.RemixOf != "" {
+		remixer, ok := r.Client.(Remixer)
+		if !ok {
+			return nil, fmt.Errorf("the %s provider cannot remix videos", r.Client.Name())
+		}
+		return remixer.RemixVideo(r.job.RemixOf, req.Prompt)
+	}
+
+	if req.InputReference != "" && req.UploadProgress == nil {
+		req.UploadProgress = r.transfer(func(written, total int64) Event {
+			return Uploading{Reference: req.InputReference, Written: written, Total: total}
+		})
+	}
+	return r.Client.CreateVideo(req)
+}
+
+// await polls the job until it completes, fails, or runs out of checks or
+// time. A check that is rate limited or hits a server or network error is
+// retried with backoff rather than ending the job. The response of the last
+// check is returned with any error.
+func (r *run) await(videoID string) (*api.VideoResponse, error) {
+	started := r.job.Started
+	if started.IsZero() {
+		started = time.Now()
+	}
+
+	// Webhook events only cover Sora jobs; other providers keep polling
+	var wake <-chan webhook.Event
+	if r.Webhooks != nil && r.Client.Name() == "sora" {
+		var stop func()
+		wake, stop = r.Webhooks.Wait(videoID)
+		defer stop()
+	}
+
+	progress, failures := 0, 0
+	var last *api.VideoResponse
+	var retryWait time.Duration
+	for attempt := 1; ; attempt++ {
+		// The first check is immediate
+		if attempt > 1 {
+			wait := r.Poll.Wait(attempt-1, time.Since(started), progress)
+			if failures > 0 {
+				wait = retryWait
+			} else if wake != nil {
+				wait = r.Poll.Limit(WebhookPollInterval, time.Since(started))
+			}
+			// A webhook event ends the wait early; the status is then confirmed with the API
+			select {
+			case <-wake:
+				wake = nil
+			case <-time.After(wait):
+			case <-r.ctx.Done():
+				return nil, r.ctx.Err()
+			}
+		}
+
+		resp, err := r.Client.GetVideo(videoID)
+		if err != nil {
+			if !api.IsTransient(err) {
+				return last, fmt.Errorf("failed to get video status: %w", err)
+			}
+			if limitErr := r.Poll.Check(attempt, time.Since(started)); limitErr != nil {
+				return last, fmt.Errorf("%w; the last status check failed: %v", limitErr, err)
+			}
+			failures++
+			retryWait = r.Poll.Limit(poll.ErrorBackoff.Delay(failures), time.Since(started))
+			r.send(RetryingStatus{VideoID: videoID, Attempt: attempt, Wait: retryWait, Err: err})
+			continue
+		}
+		failures, last = 0, resp
+		progress = resp.Progress
+		r.send(Polled{VideoID: videoID, Response: resp, Attempt: attempt, Elapsed: time.Since(started)})
+
+		if resp.Status == "completed" {
+			return resp, nil
+		}
+		if err := poll.Failed(resp); err != nil {
+			return resp, err
+		}
+		if err := r.Poll.Check(attempt, time.Since(started)); err != nil {
+			return resp, err
+		}
+	}
+}
+
+// save downloads a finished video, retrying while the service does not serve
+// it yet, then deletes it from the service unless KeepRemote is set
+func (r *run) save(videoID string, resp *api.VideoResponse) (string, error) {
+	path := r.job.Output(videoID)
+	progress := r.transfer(func(written, total int64) Event {
+		return Downloading{VideoID: videoID, Written: written, Total: total}
+	})
+
+	var err error
+	for attempt := 1; attempt <= poll.ContentRetries; attempt++ {
+		if attempt > 1 {
+			wait := poll.ContentBackoff.Delay(attempt - 1)
+			r.send(RetryingDownload{VideoID: videoID, Attempt: attempt, Wait: wait})
+			if err := r.sleep(wait); err != nil {
+				return "", err
+			}
+		}
+
+		err = r.Client.DownloadVideoContent(videoID, path, progress)
+		if err == nil {
+			break
+		}
+		if !notReady(err) {
+			return "", fmt.Errorf("failed to download video: %w", err)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("video content not available after %d attempts: %w", poll.ContentRetries, err)
+	}
+	r.send(Downloaded{VideoID: videoID, Path: path})
+
+	if r.job.Finish != nil {
+		if path, err = r.job.Finish(path, resp); err != nil {
+			return "", err
+		}
+	}
+
+	if r.KeepRemote {
+		r.send(Kept{VideoID: videoID})
+	} else {
+		r.send(Deleted{VideoID: videoID, Err: r.Client.DeleteVideo(videoID)})
+	}
+	return path, nil
+}
+
+// transfer returns a progress callback that sends an event for each whole
+// percent transferred. Transfers of unknown size are not reported.
+func (r *run) transfer(event func(written, total int64) Event) api.ProgressFunc {
+	last := -1
+	return func(written, total int64) {
+		if total <= 0 {
+			return
+		}
+		percent := int(written * 100 / total)
+		if percent == last {
+			return
+		}
+		last = percent
+		r.send(event(written, total))
+	}
+}
+
+// notReady reports whether a download failed because the service does not
+// serve the finished video yet
+func notReady(err error) bool {
+	return api.StatusCode(err) == http.StatusNotFound || errors.Is(err, api.ErrContentNotReady)
+}
@@ -0,0 +1,316 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/backoff"
+	"github.com/telemetry/video-gen/internal/poll"
+)
+
+// statusServer answers each status check of a job with the next of
+// responses: an HTTP status for an error, or a job status for a 200
+func statusServer(t *testing.T, responses []interface{}) (*api.SoraClient, *int) {
+	t.Helper()
+	checks := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := responses[min(checks, len(responses)-1)]
+		checks++
+		switch response := response.(type) {
+		case int:
+			w.Header().Set("retry-after-ms", "1")
+			w.WriteHeader(response)
+			w.Write([]byte(`{"error": {"message": "upstream unavailable"}}`))
+		case string:
+			json.NewEncoder(w).Encode(api.VideoResponse{ID: "video_1", Status: response})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	saved := poll.ErrorBackoff
+	poll.ErrorBackoff = backoff.Policy{Base: time.Millisecond, Max: time.Millisecond}
+	t.Cleanup(func() { poll.ErrorBackoff = saved })
+
+	client := api.NewClient("test-key", false, nil)
+	client.Isolate()
+	client.SetBaseURL(server.URL)
+	return client, &checks
+}
+
+func TestAwaitRetriesStatusErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []interface{}
+		attempts  int
+		want      string // Substring of the error; "" for a completed job
+		retries   int    // RetryingStatus events
+	}{
+		{"server errors and rate limits", []interface{}{503, 429, "in_progress", "completed"}, 10, "", 2},
+		{"missing job", []interface{}{404, "completed"}, 10, "failed to get video status", 0},
+		{"errors count against the limit", []interface{}{500}, 3, "after 3 status checks", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := statusServer(t, tt.responses)
+			events := make(chan Event, 100)
+			r := &run{
+				Engine: &Engine{Client: client, Poll: poll.Strategy{Interval: time.Millisecond, MaxAttempts: tt.attempts}},
+				ctx:    context.Background(),
+				events: events,
+			}
+
+			resp, err := r.await("video_1")
+			close(events)
+			retries := 0
+			for event := range events {
+				if _, ok := event.(RetryingStatus); ok {
+					retries++
+				}
+			}
+			if retries != tt.retries {
+				t.Errorf("%d RetryingStatus events, want %d", retries, tt.retries)
+			}
+
+			if tt.want == "" {
+				if err != nil || resp == nil || resp.Status != "completed" {
+					t.Errorf("await() = %v, %v, want the completed job", resp, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("await() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAwaitTimeoutIsTyped(t *testing.T) {
+	client, checks := statusServer(t, []interface{}{502})
+	r := &run{
+		Engine: &Engine{Client: client, Poll: poll.Strategy{Interval: time.Millisecond, MaxAttempts: 4}},
+		ctx:    context.Background(),
+		events: make(chan Event, 100),
+	}
+	if _, err := r.await("video_1"); !errors.Is(err, poll.ErrTimeout) {
+		t.Errorf("await() error = %v, want poll.ErrTimeout", err)
+	}
+	if *checks != 4 {
+		t.Errorf("%d status checks, want 4", *checks)
+	}
+}
+
+// fakeProvider answers status checks with statuses in turn and downloads
+// with downloads in turn, recording the calls made
+type fakeProvider struct {
+	createErr error
+	statuses  []api.VideoResponse
+	downloads []error
+	calls     []string
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) CreateVideo(req api.CreateVideoRequest) (*api.CreateVideoResponse, error) {
+	p.calls = append(p.calls, "create")
+	if p.createErr != nil {
+		return nil, p.createErr
+	}
+	return &api.CreateVideoResponse{ID: "video_1", Status: "queued"}, nil
+}
+
+func (p *fakeProvider) GetVideo(videoID string) (*api.VideoResponse, error) {
+	p.calls = append(p.calls, "get")
+	resp := p.statuses[0]
+	if len(p.statuses) > 1 {
+		p.statuses = p.statuses[1:]
+	}
+	resp.ID = videoID
+	return &resp, nil
+}
+
+func (p *fakeProvider) DownloadVideoContent(videoID, outputPath string, progress api.ProgressFunc) error {
+	p.calls = append(p.calls, "download")
+	var err error
+	if len(p.downloads) > 0 {
+		err, p.downloads = p.downloads[0], p.downloads[1:]
+	}
+	return err
+}
+
+func (p *fakeProvider) DeleteVideo(videoID string) error {
+	p.calls = append(p.calls, "delete")
+	return nil
+}
+
+// runJob runs job with e and returns the names of its events and the final Done
+func runJob(t *testing.T, e *Engine, job Job) ([]string, Done) {
+	t.Helper()
+	if e.Poll.Interval == 0 {
+		e.Poll.Interval = time.Millisecond
+	}
+	if job.Output == nil {
+		dir := t.TempDir()
+		job.Output = func(videoID string) string { return filepath.Join(dir, videoID+".mp4") }
+	}
+	saved := poll.ContentBackoff
+	poll.ContentBackoff = backoff.Policy{Base: time.Millisecond, Max: time.Millisecond}
+	t.Cleanup(func() { poll.ContentBackoff = saved })
+
+	events := make(chan Event)
+	go e.Run(context.Background(), job, events)
+	var names []string
+	var done Done
+	for event := range events {
+		names = append(names, strings.TrimPrefix(fmt.Sprintf("%T", event), "engine."))
+		if d, ok := event.(Done); ok {
+			done = d
+		}
+	}
+	return names, done
+}
+
+func TestRun(t *testing.T) {
+	completed := api.VideoResponse{Status: "completed", Progress: 100}
+	notReady := fmt.Errorf("download: %w", api.ErrContentNotReady)
+
+	tests := []struct {
+		name       string
+		provider   *fakeProvider
+		keepRemote bool
+		job        Job
+		events     []string
+		calls      []string
+		stage      Stage // Stage of the error; "" for a saved video
+	}{
+		{
+			name:     "completes",
+			provider: &fakeProvider{statuses: []api.VideoResponse{{Status: "in_progress", Progress: 40}, completed}},
+			events:   []string{"Created", "Polled", "Polled", "Downloaded", "Deleted", "Done"},
+			calls:    []string{"create", "get", "get", "download", "delete"},
+		},
+		{
+			name:       "keeps the remote video",
+			provider:   &fakeProvider{statuses: []api.VideoResponse{completed}},
+			keepRemote: true,
+			events:     []string{"Created", "Polled", "Downloaded", "Kept", "Done"},
+			calls:      []string{"create", "get", "download"},
+		},
+		{
+			name:     "resumes an existing job",
+			provider: &fakeProvider{statuses: []api.VideoResponse{completed}},
+			job:      Job{VideoID: "video_1"},
+			events:   []string{"Polled", "Downloaded", "Deleted", "Done"},
+			calls:    []string{"get", "download", "delete"},
+		},
+		{
+			name:     "create fails",
+			provider: &fakeProvider{createErr: errors.New("invalid size")},
+			events:   []string{"Done"},
+			calls:    []string{"create"},
+			stage:    StageCreate,
+		},
+		{
+			name:     "job fails",
+			provider: &fakeProvider{statuses: []api.VideoResponse{{Status: "failed", Error: &api.ErrorObject{Message: "internal error"}}}},
+			events:   []string{"Created", "Polled", "Done"},
+			calls:    []string{"create", "get"},
+			stage:    StagePoll,
+		},
+		{
+			name:     "content not ready yet",
+			provider: &fakeProvider{statuses: []api.VideoResponse{completed}, downloads: []error{notReady, notReady}},
+			events:   []string{"Created", "Polled", "RetryingDownload", "RetryingDownload", "Downloaded", "Deleted", "Done"},
+			calls:    []string{"create", "get", "download", "download", "download", "delete"},
+		},
+		{
+			name:     "download fails",
+			provider: &fakeProvider{statuses: []api.VideoResponse{completed}, downloads: []error{errors.New("disk full")}},
+			events:   []string{"Created", "Polled", "Done"},
+			calls:    []string{"create", "get", "download"},
+			stage:    StageDownload,
+		},
+		{
+			name:     "finish fails and keeps the video on the service",
+			provider: &fakeProvider{statuses: []api.VideoResponse{completed}},
+			job: Job{Finish: func(path string, resp *api.VideoResponse) (string, error) {
+				return "", errors.New("upload failed")
+			}},
+			events: []string{"Created", "Polled", "Downloaded", "Done"},
+			calls:  []string{"create", "get", "download"},
+			stage:  StageDownload,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Engine{Client: tt.provider, KeepRemote: tt.keepRemote}
+			events, done := runJob(t, e, tt.job)
+			if !reflect.DeepEqual(events, tt.events) {
+				t.Errorf("events = %v, want %v", events, tt.events)
+			}
+			if !reflect.DeepEqual(tt.provider.calls, tt.calls) {
+				t.Errorf("calls = %v, want %v", tt.provider.calls, tt.calls)
+			}
+			if done.Stage != tt.stage || (done.Err == nil) != (tt.stage == "") {
+				t.Errorf("Done stage, error = %q, %v, want stage %q", done.Stage, done.Err, tt.stage)
+			}
+			if tt.stage == "" && done.Path == "" {
+				t.Error("Done has no path for a saved video")
+			}
+		})
+	}
+}
+
+func TestRunFailedJobIsTyped(t *testing.T) {
+	reason := &api.ErrorObject{Code: "moderation_blocked", Message: "blocked"}
+	provider := &fakeProvider{statuses: []api.VideoResponse{{Status: "failed", Error: reason}}}
+	_, done := runJob(t, &Engine{Client: provider}, Job{})
+
+	var jobErr *poll.JobError
+	if !errors.As(done.Err, &jobErr) || jobErr.Reason != reason {
+		t.Fatalf("Done error = %#v, want a *poll.JobError with the service's reason", done.Err)
+	}
+	if done.Response == nil || done.Response.Status != "failed" {
+		t.Errorf("Done response = %+v, want the failed status", done.Response)
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	provider := &fakeProvider{statuses: []api.VideoResponse{{Status: "in_progress"}}}
+	_, done := runJob(t, &Engine{Client: provider, Poll: poll.Strategy{MaxAttempts: 3}}, Job{})
+	if !errors.Is(done.Err, poll.ErrTimeout) || done.Stage != StagePoll {
+		t.Errorf("Done = %q, %v, want a poll timeout", done.Stage, done.Err)
+	}
+	if gets := strings.Count(strings.Join(provider.calls, " "), "get"); gets != 3 {
+		t.Errorf("%d status checks, want 3", gets)
+	}
+}
+
+func TestRunSubmittedBeforeCreated(t *testing.T) {
+	provider := &fakeProvider{statuses: []api.VideoResponse{{Status: "completed"}}}
+	var submitted string
+	var calls []string
+	job := Job{Submitted: func(videoID string) {
+		submitted = videoID
+		calls = append([]string(nil), provider.calls...)
+	}}
+	events, _ := runJob(t, &Engine{Client: provider}, job)
+	if submitted != "video_1" {
+		t.Errorf("Submitted() got %q, want video_1", submitted)
+	}
+	if !reflect.DeepEqual(calls, []string{"create"}) {
+		t.Errorf("calls when Submitted() ran = %v, want only the create", calls)
+	}
+	if len(events) == 0 || events[0] != "Created" {
+		t.Errorf("events = %v, want Created first", events)
+	}
+}
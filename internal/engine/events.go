@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+)
+
+// Stage is the step of a job that an error happened in
+type Stage string
+
+const (
+	StageCreate   Stage = "create"
+	StagePoll     Stage = "poll"
+	StageDownload Stage = "download"
+)
+
+// Event is a step of a running job. It is one of the types below.
+type Event interface {
+	event()
+}
+
+// Uploading reports the upload of a reference file with the create request
+type Uploading struct {
+	Reference string
+	Written   int64
+	Total     int64
+}
+
+// Created reports that the job was submitted
+type Created struct {
+	VideoID string
+	Status  string
+}
+
+// Polled carries a status check of the job
+type Polled struct {
+	VideoID  string
+	Response *api.VideoResponse
+	Attempt  int           // 1 for the first check
+	Elapsed  time.Duration // Since the job started
+}
+
+// RetryingStatus reports that a status check was rate limited or hit a
+// server or network error, and the job is checked again after Wait. The
+// failed check counts against the poll limit and timeout.
+type RetryingStatus struct {
+	VideoID string
+	Attempt int // The check that failed, 1 for the first
+	Wait    time.Duration
+	Err     error
+}
+
+// RetryingDownload reports that the finished video is not served yet and is
+// requested again after Wait
+type RetryingDownload struct {
+	VideoID string
+	Attempt int // 2 for the first retry
+	Wait    time.Duration
+}
+
+// Downloading reports the download of the finished video
+type Downloading struct {
+	VideoID string
+	Written int64
+	Total   int64
+}
+
+// Downloaded reports that the video was saved to Path
+type Downloaded struct {
+	VideoID string
+	Path    string
+}
+
+// Kept reports that the video was left on the service, as KeepRemote asks
+type Kept struct {
+	VideoID string
+}
+
+// Deleted reports the deletion of the video from the service after it was
+// saved. Err is only worth a warning, since the video is saved.
+type Deleted struct {
+	VideoID string
+	Err     error
+}
+
+// Done is the last event of a job. Err is nil when the video was saved to
+// Path; otherwise Stage tells where the job stopped. Response is the last
+// status of the job, if it got that far, e.g. to tell a job the service
+// failed from one that could not be checked.
+type Done struct {
+	VideoID  string
+	Path     string
+	Response *api.VideoResponse
+	Stage    Stage
+	Err      error
+}
+
+func (Uploading) event()        {}
+func (Created) event()          {}
+func (Polled) event()           {}
+func (RetryingStatus) event()   {}
+func (RetryingDownload) event() {}
+func (Downloading) event()      {}
+func (Downloaded) event()       {}
+func (Kept) event()             {}
+func (Deleted) event()          {}
+func (Done) event()             {}
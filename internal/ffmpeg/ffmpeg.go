@@ -0,0 +1,120 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Available reports whether the ffmpeg binary can be found on PATH
+func Available() bool {
+	_, err := exec.LookPath("ffmpeg")
+	return err == nil
+}
+
+// run executes ffmpeg with the given arguments, including its error output on failure
+func run(args ...string) error {
+	if !Available() {
+		return fmt.Errorf("ffmpeg not found on PATH; install it from https://ffmpeg.org")
+	}
+
+	cmd := exec.Command("ffmpeg", append([]string{"-hide_banner", "-loglevel", "error", "-y"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Concat joins clips that share the same encoding into a single file without re-encoding
+func Concat(inputs []string, output string) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no clips to concatenate")
+	}
+
+	// The concat demuxer reads its inputs from a list file
+	list, err := os.CreateTemp("", "video-gen-concat-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create concat list: %w", err)
+	}
+	defer os.Remove(list.Name())
+
+	for _, input := range inputs {
+		absPath, err := filepath.Abs(input)
+		if err != nil {
+			list.Close()
+			return fmt.Errorf("failed to resolve clip path: %w", err)
+		}
+		escaped := strings.ReplaceAll(absPath, "'", `'\''`)
+		if _, err := fmt.Fprintf(list, "file '%s'\n", escaped); err != nil {
+			list.Close()
+			return fmt.Errorf("failed to write concat list: %w", err)
+		}
+	}
+	if err := list.Close(); err != nil {
+		return fmt.Errorf("failed to write concat list: %w", err)
+	}
+
+	return run("-f", "concat", "-safe", "0", "-i", list.Name(), "-c", "copy", output)
+}
+
+// ConcatScaled joins clips of different sizes by scaling and padding each to
+// width x height and re-encoding. Only the video streams are kept, since clips
+// without an audio track cannot be joined with ones that have one.
+func ConcatScaled(inputs []string, output string, width, height int) error {
+	if len(inputs) == 0 {
+		return fmt.Errorf("no clips to concatenate")
+	}
+	return run(concatScaledArgs(inputs, output, width, height)...)
+}
+
+// concatScaledArgs returns the ffmpeg arguments of ConcatScaled
+func concatScaledArgs(inputs []string, output string, width, height int) []string {
+	var args []string
+	var filter strings.Builder
+	for i, input := range inputs {
+		args = append(args, "-i", input)
+		fmt.Fprintf(&filter, "[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1[v%d];", i, width, height, width, height, i)
+	}
+	for i := range inputs {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=1:a=0[v]", len(inputs))
+
+	return append(args, "-filter_complex", filter.String(), "-map", "[v]", "-c:v", "libx264", "-pix_fmt", "yuv420p", output)
+}
+
+// Poster extracts a single frame at the given offset (in seconds) as an image
+func Poster(input, output string, at float64) error {
+	return run("-ss", fmt.Sprintf("%.3f", at), "-i", input, "-frames:v", "1", "-q:v", "2", output)
+}
+
+// LastFrame extracts the final frame of input as an image. Every frame of the
+// last second is decoded and written over output, so the image left behind is
+// the last one.
+func LastFrame(input, output string) error {
+	return run("-sseof", "-1", "-i", input, "-update", "1", "-q:v", "2", output)
+}
+
+// GIF renders an animated GIF preview scaled to width pixels at the given frame rate,
+// using a generated palette for better color quality
+func GIF(input, output string, width, fps int) error {
+	return run(gifArgs(input, output, width, fps)...)
+}
+
+// gifArgs returns the ffmpeg arguments of GIF
+func gifArgs(input, output string, width, fps int) []string {
+	filter := fmt.Sprintf("fps=%d,scale=%d:-1:flags=lanczos,split[a][b];[a]palettegen[p];[b][p]paletteuse", fps, width)
+	return []string{"-i", input, "-vf", filter, "-loop", "0", output}
+}
+
+// SideBySide places two clips next to each other at a common height, ending with
+// the shorter clip. Audio is taken from the left clip when it has any.
+func SideBySide(left, right, output string, height int) error {
+	filter := fmt.Sprintf("[0:v]scale=-2:%d,setsar=1[l];[1:v]scale=-2:%d,setsar=1[r];[l][r]hstack=inputs=2:shortest=1[v]", height, height)
+	return run("-i", left, "-i", right, "-filter_complex", filter, "-map", "[v]", "-map", "0:a?", "-shortest", "-c:v", "libx264", "-pix_fmt", "yuv420p", output)
+}
@@ -0,0 +1,80 @@
+package ffmpeg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConcatScaledArgs(t *testing.T) {
+	got := concatScaledArgs([]string{"a.mp4", "b.mp4"}, "out.mp4", 1280, 720)
+	expected := []string{
+		"-i", "a.mp4", "-i", "b.mp4",
+		"-filter_complex",
+		"[0:v]scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2,setsar=1[v0];" +
+			"[1:v]scale=1280:720:force_original_aspect_ratio=decrease,pad=1280:720:(ow-iw)/2:(oh-ih)/2,setsar=1[v1];" +
+			"[v0][v1]concat=n=2:v=1:a=0[v]",
+		"-map", "[v]", "-c:v", "libx264", "-pix_fmt", "yuv420p", "out.mp4",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("concatScaledArgs() =\n  %q\nwant\n  %q", got, expected)
+	}
+}
+
+func TestGIFArgs(t *testing.T) {
+	got := gifArgs("in.mp4", "out.gif", 320, 12)
+	expected := []string{"-i", "in.mp4", "-vf", "fps=12,scale=320:-1:flags=lanczos,split[a][b];[a]palettegen[p];[b][p]paletteuse", "-loop", "0", "out.gif"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("gifArgs() =\n  %q\nwant\n  %q", got, expected)
+	}
+}
+
+func TestConcatNoClips(t *testing.T) {
+	if err := Concat(nil, "out.mp4"); err == nil {
+		t.Error("Concat() with no clips = nil, want an error")
+	}
+	if err := ConcatScaled(nil, "out.mp4", 1280, 720); err == nil {
+		t.Error("ConcatScaled() with no clips = nil, want an error")
+	}
+}
+
+func TestConcat(t *testing.T) {
+	requireFFmpeg(t)
+	dir := t.TempDir()
+	// A quote in the name exercises the concat list escaping
+	first := testClip(t, dir, "it's one.mp4", "160x90")
+	second := testClip(t, dir, "two.mp4", "160x90")
+	output := filepath.Join(dir, "joined.mp4")
+	if err := Concat([]string{first, second}, output); err != nil {
+		t.Fatalf("Concat() = %v", err)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("expected %s to exist: %v", output, err)
+	}
+}
+
+func TestConcatScaledAndFrames(t *testing.T) {
+	requireFFmpeg(t)
+	dir := t.TempDir()
+	wide := testClip(t, dir, "wide.mp4", "160x90")
+	tall := testClip(t, dir, "tall.mp4", "90x160")
+
+	outputs := map[string]func(string) error{
+		"joined.mp4": func(out string) error { return ConcatScaled([]string{wide, tall}, out, 160, 90) },
+		"poster.jpg": func(out string) error { return Poster(wide, out, 0.5) },
+		"last.jpg":   func(out string) error { return LastFrame(wide, out) },
+		"pair.mp4":   func(out string) error { return SideBySide(wide, tall, out, 90) },
+	}
+	for name, render := range outputs {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(dir, name)
+			if err := render(output); err != nil {
+				t.Fatalf("render %s: %v", name, err)
+			}
+			if info, err := os.Stat(output); err != nil || info.Size() == 0 {
+				t.Errorf("expected %s to be written: %v", output, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultGIFWidth is the width of GIF previews when the spec does not give one
+const defaultGIFWidth = 480
+
+// encoders maps the codec names accepted in a post-processing spec to ffmpeg encoders
+var encoders = map[string]string{
+	"h264":   "libx264",
+	"h265":   "libx265",
+	"hevc":   "libx265",
+	"vp9":    "libvpx-vp9",
+	"av1":    "libaom-av1",
+	"prores": "prores_ks",
+}
+
+// watermarkPositions maps overlay positions to overlay filter coordinates
+var watermarkPositions = map[string]string{
+	"top-left":     "20:20",
+	"top-right":    "W-w-20:20",
+	"bottom-left":  "20:H-h-20",
+	"bottom-right": "W-w-20:H-h-20",
+	"center":       "(W-w)/2:(H-h)/2",
+}
+
+// Pipeline is a post-processing stage applied to a downloaded video
+type Pipeline struct {
+	Codec     string // Target video codec (h264, h265, vp9, av1, prores); empty keeps the original
+	Bitrate   string // Target video bitrate, e.g. "4M"
+	Watermark string // Image overlaid on the video
+	Position  string // Watermark position (default bottom-right)
+	Format    string // Target container (mp4, mov, mkv, webm); empty keeps the original
+	GIFWidth  int    // Width of a GIF preview to extract, 0 for none
+}
+
+// ParsePipeline parses a comma-separated post-processing spec such as
+// "codec=h265,bitrate=4M,watermark=logo.png,position=top-right,format=mov,gif=320".
+// A bare "gif" extracts a preview at the default width.
+func ParsePipeline(spec string) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "codec":
+			if _, ok := encoders[strings.ToLower(value)]; !ok {
+				return nil, fmt.Errorf("unsupported codec %q (use h264, h265, vp9, av1, or prores)", value)
+			}
+			p.Codec = strings.ToLower(value)
+		case "bitrate":
+			p.Bitrate = value
+		case "watermark":
+			if _, err := os.Stat(value); err != nil {
+				return nil, fmt.Errorf("watermark image: %w", err)
+			}
+			p.Watermark = value
+		case "position":
+			if _, ok := watermarkPositions[value]; !ok {
+				return nil, fmt.Errorf("unsupported watermark position %q (use top-left, top-right, bottom-left, bottom-right, or center)", value)
+			}
+			p.Position = value
+		case "format":
+			switch strings.ToLower(value) {
+			case "mp4", "mov", "mkv", "webm":
+				p.Format = strings.ToLower(value)
+			default:
+				return nil, fmt.Errorf("unsupported format %q (use mp4, mov, mkv, or webm)", value)
+			}
+		case "gif":
+			p.GIFWidth = defaultGIFWidth
+			if value != "" {
+				width, err := strconv.Atoi(value)
+				if err != nil || width <= 0 {
+					return nil, fmt.Errorf("invalid GIF width %q", value)
+				}
+				p.GIFWidth = width
+			}
+		default:
+			return nil, fmt.Errorf("unknown post-processing option %q (use codec, bitrate, watermark, position, format, or gif)", key)
+		}
+	}
+
+	if p.Format == "webm" && p.Codec != "" && p.Codec != "vp9" && p.Codec != "av1" {
+		return nil, fmt.Errorf("webm only supports the vp9 and av1 codecs")
+	}
+	if *p == (Pipeline{}) {
+		return nil, fmt.Errorf("empty post-processing spec")
+	}
+	return p, nil
+}
+
+// reencodes reports whether the video stream has to be re-encoded
+func (p *Pipeline) reencodes() bool {
+	return p.Codec != "" || p.Bitrate != "" || p.Watermark != "" || p.Format == "webm"
+}
+
+// Apply post-processes input. A transcoded or remuxed video replaces input
+// (with the new extension when the format changes); a GIF preview is saved
+// next to it as NAME_preview.gif. It returns the path of the processed video
+// and of the GIF, which is empty when none was requested.
+func (p *Pipeline) Apply(input string) (string, string, error) {
+	if !Available() {
+		return input, "", fmt.Errorf("ffmpeg not found on PATH; install it from https://ffmpeg.org")
+	}
+
+	output := input
+	if p.reencodes() || p.Format != "" {
+		var err error
+		output, err = p.transcode(input)
+		if err != nil {
+			return input, "", err
+		}
+	}
+
+	var gif string
+	if p.GIFWidth > 0 {
+		gif = strings.TrimSuffix(output, filepath.Ext(output)) + "_preview.gif"
+		if err := GIF(output, gif, p.GIFWidth, 12); err != nil {
+			return output, "", err
+		}
+	}
+	return output, gif, nil
+}
+
+// transcode rewrites input with the pipeline's codec, bitrate, watermark,
+// and container, replacing the original file
+func (p *Pipeline) transcode(input string) (string, error) {
+	ext := filepath.Ext(input)
+	format := p.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	output := strings.TrimSuffix(input, ext) + "." + format
+
+	// Write next to the input first so a failed run never leaves a partial file in its place
+	tmp := strings.TrimSuffix(input, ext) + ".processing." + format
+
+	if err := run(p.transcodeArgs(input, tmp, format)...); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		return "", fmt.Errorf("failed to replace video: %w", err)
+	}
+	if output != input {
+		if err := os.Remove(input); err != nil {
+			return output, fmt.Errorf("failed to remove original video: %w", err)
+		}
+	}
+	return output, nil
+}
+
+// transcodeArgs returns the ffmpeg arguments that write input to output in
+// the given container format
+func (p *Pipeline) transcodeArgs(input, output, format string) []string {
+	args := []string{"-i", input}
+	if p.Watermark != "" {
+		position := watermarkPositions[p.Position]
+		if position == "" {
+			position = watermarkPositions["bottom-right"]
+		}
+		args = append(args, "-i", p.Watermark, "-filter_complex", "[0:v][1:v]overlay="+position+"[v]", "-map", "[v]", "-map", "0:a?")
+	}
+
+	if p.reencodes() {
+		codec := p.Codec
+		if codec == "" {
+			codec = "h264"
+			if format == "webm" {
+				codec = "vp9"
+			}
+		}
+		args = append(args, "-c:v", encoders[codec])
+		if p.Bitrate != "" {
+			args = append(args, "-b:v", p.Bitrate)
+		}
+		if codec == "h264" || codec == "h265" || codec == "hevc" {
+			args = append(args, "-pix_fmt", "yuv420p")
+		}
+		if codec == "h265" || codec == "hevc" {
+			// QuickTime and Safari only play HEVC tagged as hvc1
+			args = append(args, "-tag:v", "hvc1")
+		}
+		if format == "webm" {
+			args = append(args, "-c:a", "libopus")
+		} else {
+			args = append(args, "-c:a", "aac")
+		}
+	} else {
+		// Container change only: copy the streams as they are
+		args = append(args, "-c", "copy")
+	}
+	if format == "mp4" || format == "mov" {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return append(args, output)
+}
@@ -0,0 +1,141 @@
+package ffmpeg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePipeline(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(logo, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected Pipeline
+		message  string // Expected error text, empty for no error
+	}{
+		{"codec and bitrate", "codec=H265, bitrate=4M", Pipeline{Codec: "h265", Bitrate: "4M"}, ""},
+		{"watermark", "watermark=" + logo + ",position=top-right", Pipeline{Watermark: logo, Position: "top-right"}, ""},
+		{"format", "format=MOV", Pipeline{Format: "mov"}, ""},
+		{"default gif", "gif", Pipeline{GIFWidth: defaultGIFWidth}, ""},
+		{"gif width", "gif=320", Pipeline{GIFWidth: 320}, ""},
+		{"empty parts skipped", ",codec=vp9,,format=webm,", Pipeline{Codec: "vp9", Format: "webm"}, ""},
+		{"empty", " , ", Pipeline{}, "empty post-processing spec"},
+		{"unknown codec", "codec=mpeg2", Pipeline{}, `unsupported codec "mpeg2"`},
+		{"unknown position", "position=middle", Pipeline{}, `unsupported watermark position "middle"`},
+		{"unknown format", "format=avi", Pipeline{}, `unsupported format "avi"`},
+		{"invalid gif width", "gif=0", Pipeline{}, `invalid GIF width "0"`},
+		{"missing watermark", "watermark=" + filepath.Join(t.TempDir(), "missing.png"), Pipeline{}, "watermark image"},
+		{"unknown option", "speed=2", Pipeline{}, `unknown post-processing option "speed"`},
+		{"webm with h264", "codec=h264,format=webm", Pipeline{}, "webm only supports the vp9 and av1 codecs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePipeline(tt.spec)
+			if tt.message != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.message) {
+					t.Fatalf("ParsePipeline(%q) error = %v, want it to mention %q", tt.spec, err, tt.message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePipeline(%q) = %v", tt.spec, err)
+			}
+			if *p != tt.expected {
+				t.Errorf("ParsePipeline(%q) = %+v, want %+v", tt.spec, *p, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranscodeArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+		format   string
+		expected []string
+	}{
+		{"remux only", Pipeline{Format: "mkv"}, "mkv",
+			[]string{"-i", "in.mp4", "-c", "copy", "out.mkv"}},
+		{"remux to mov", Pipeline{Format: "mov"}, "mov",
+			[]string{"-i", "in.mp4", "-c", "copy", "-movflags", "+faststart", "out.mov"}},
+		{"h264 with bitrate", Pipeline{Codec: "h264", Bitrate: "4M"}, "mp4",
+			[]string{"-i", "in.mp4", "-c:v", "libx264", "-b:v", "4M", "-pix_fmt", "yuv420p", "-c:a", "aac", "-movflags", "+faststart", "out.mp4"}},
+		{"h265 tagged hvc1", Pipeline{Codec: "h265"}, "mp4",
+			[]string{"-i", "in.mp4", "-c:v", "libx265", "-pix_fmt", "yuv420p", "-tag:v", "hvc1", "-c:a", "aac", "-movflags", "+faststart", "out.mp4"}},
+		{"webm defaults to vp9", Pipeline{Format: "webm"}, "webm",
+			[]string{"-i", "in.mp4", "-c:v", "libvpx-vp9", "-c:a", "libopus", "out.webm"}},
+		{"watermark defaults to bottom-right", Pipeline{Watermark: "logo.png"}, "mp4",
+			[]string{"-i", "in.mp4", "-i", "logo.png", "-filter_complex", "[0:v][1:v]overlay=W-w-20:H-h-20[v]", "-map", "[v]", "-map", "0:a?",
+				"-c:v", "libx264", "-pix_fmt", "yuv420p", "-c:a", "aac", "-movflags", "+faststart", "out.mp4"}},
+		{"watermark position", Pipeline{Watermark: "logo.png", Position: "center", Codec: "prores"}, "mov",
+			[]string{"-i", "in.mp4", "-i", "logo.png", "-filter_complex", "[0:v][1:v]overlay=(W-w)/2:(H-h)/2[v]", "-map", "[v]", "-map", "0:a?",
+				"-c:v", "prores_ks", "-c:a", "aac", "-movflags", "+faststart", "out.mov"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.pipeline.transcodeArgs("in.mp4", "out."+tt.format, tt.format)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("transcodeArgs() =\n  %q\nwant\n  %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// requireFFmpeg skips tests that run the ffmpeg binary when it is not installed
+func requireFFmpeg(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not found on PATH")
+	}
+}
+
+// testClip renders a short synthetic clip with ffmpeg's test source
+func testClip(t *testing.T, dir, name, size string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := run("-f", "lavfi", "-i", "testsrc=duration=1:size="+size+":rate=12", "-pix_fmt", "yuv420p", path); err != nil {
+		t.Fatalf("failed to render test clip: %v", err)
+	}
+	return path
+}
+
+func TestApply(t *testing.T) {
+	requireFFmpeg(t)
+	dir := t.TempDir()
+	input := testClip(t, dir, "clip.mp4", "160x90")
+
+	p, err := ParsePipeline("format=mkv,gif=80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, gif, err := p.Apply(input)
+	if err != nil {
+		t.Fatalf("Apply() = %v", err)
+	}
+	if want := filepath.Join(dir, "clip.mkv"); output != want {
+		t.Errorf("Apply() output = %s, want %s", output, want)
+	}
+	if want := filepath.Join(dir, "clip_preview.gif"); gif != want {
+		t.Errorf("Apply() gif = %s, want %s", gif, want)
+	}
+	for _, path := range []string{output, gif} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Errorf("expected the original %s to be removed", input)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.processing.*"))
+	if len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,74 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DeadLetterFileName is the file queued jobs that failed for good are added
+// to, next to the ledger
+const DeadLetterFileName = "dead-letter.jsonl"
+
+// DeadLetter is a queued job that failed for good: its settings, with the
+// fields of a batch file item so -f can run the file again, and why it failed
+type DeadLetter struct {
+	Prompt    string    `json:"prompt"`
+	Model     string    `json:"model,omitempty"`
+	Size      string    `json:"size,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	Reference string    `json:"reference,omitempty"`
+	Filename  string    `json:"filename,omitempty"`
+	Priority  string    `json:"priority,omitempty"`
+	Error     string    `json:"error"`
+	Class     string    `json:"class"`    // queue.ErrorClass of the last error
+	Attempts  int       `json:"attempts"` // Tries made, the first included
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// DeadLetterPath returns the dead-letter file
+func DeadLetterPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), DeadLetterFileName), nil
+}
+
+// AddDeadLetter appends a job to the dead-letter file, one JSON object a line
+func AddDeadLetter(d DeadLetter) error {
+	if d.FailedAt.IsZero() {
+		d.FailedAt = time.Now().UTC()
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %w", err)
+	}
+	path, err := DeadLetterPath()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	unlock, err := lockLedger(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,454 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+)
+
+// Entry is a single generation job recorded in the ledger
+type Entry struct {
+	ID         string `json:"id"`
+	Provider   string `json:"provider"`
+	Prompt     string `json:"prompt,omitempty"`
+	Model      string `json:"model,omitempty"`
+	Size       string `json:"size,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	Status     string `json:"status"`
+	OutputPath string `json:"output_path,omitempty"`
+	RemixOf    string `json:"remix_of,omitempty"`
+	KeptRemote bool   `json:"kept_remote,omitempty"` // Downloaded but still stored on the service
+	Error      string `json:"error,omitempty"`
+	// NegativePrompt is the exclusions submitted with the prompt, already folded into it
+	NegativePrompt string `json:"negative_prompt,omitempty"`
+	// Parent is the job whose prompt this one revised, linking prompt versions for history tree
+	Parent string `json:"parent,omitempty"`
+	// FallbackFrom is the model that refused the job for quota or tier before -fallback-model resubmitted it
+	FallbackFrom string `json:"fallback_from,omitempty"`
+	// Cost is the estimated list price of the render in USD, set once it completes
+	Cost float64 `json:"cost_usd,omitempty"`
+	// SHA256 and Bytes identify the saved file, to spot archived copies that changed or broke
+	SHA256 string `json:"sha256,omitempty"`
+	Bytes  int64  `json:"bytes,omitempty"`
+	// Profile and Config are the -profile and -config the job was started
+	// with, so it is resumed with the same settings
+	Profile string `json:"profile,omitempty"`
+	Config  string `json:"config,omitempty"`
+	// GenerationSeconds is how long the job took to render, for ETA estimates
+	GenerationSeconds int       `json:"generation_seconds,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// mu serializes ledger updates from concurrent jobs in the same process;
+// lockLedger does the same for other video-gen processes
+var mu sync.Mutex
+
+// Path returns the ledger file, video-gen/history.json in the user data
+// directory: $XDG_DATA_HOME when set, else ~/.local/share on Linux,
+// ~/Library/Application Support on macOS, and %LocalAppData% on Windows.
+// A ledger at ~/.local/share from an earlier version is used while there is
+// none in the platform directory.
+func Path() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	legacy := filepath.Join(homeDir, ".local", "share", "video-gen", "history.json")
+
+	dataDir := os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataDir) {
+		switch runtime.GOOS {
+		case "darwin":
+			dataDir = filepath.Join(homeDir, "Library", "Application Support")
+		case "windows":
+			if dataDir = os.Getenv("LocalAppData"); dataDir == "" {
+				return "", fmt.Errorf("%%LocalAppData%% is not defined")
+			}
+		default:
+			return legacy, nil
+		}
+	}
+	path := filepath.Join(dataDir, "video-gen", "history.json")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, nil
+		}
+	}
+	return path, nil
+}
+
+// Load returns all recorded jobs, newest first
+func Load() ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	unlock, err := lockLedger(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	entries, err := load()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// Find returns the recorded job with the given ID
+func Find(id string) (*Entry, error) {
+	entries, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("job %s not found in history", id)
+}
+
+// Expired returns downloaded jobs that were kept on the service for longer than age
+func Expired(age time.Duration) ([]Entry, error) {
+	entries, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []Entry
+	cutoff := time.Now().Add(-age)
+	for _, entry := range entries {
+		if entry.KeptRemote && entry.UpdatedAt.Before(cutoff) {
+			expired = append(expired, entry)
+		}
+	}
+	return expired, nil
+}
+
+// SetGenerationTime records how long the job took to render, from the
+// service's Unix timestamps when it reports them, otherwise since the job
+// was first recorded
+func (e *Entry) SetGenerationTime(createdAt, completedAt int64) {
+	switch {
+	case createdAt > 0 && completedAt >= createdAt:
+		e.GenerationSeconds = int(completedAt - createdAt)
+	case !e.CreatedAt.IsZero():
+		e.GenerationSeconds = int(time.Since(e.CreatedAt).Seconds())
+	}
+}
+
+// SetCost records the estimated cost of a finished job, taking the settings
+// of remixes and resumed jobs, which the ledger does not know yet, from the
+// job's status
+func (e *Entry) SetCost(resp *api.VideoResponse) {
+	if e.Model == "" {
+		e.Model, e.Size, e.Duration = resp.Model, resp.Size, resp.Seconds
+	}
+	if cost, ok := api.EstimateCost(e.Model, e.Size, e.Duration); ok {
+		e.Cost = cost
+	}
+}
+
+// Checksum returns the hex SHA-256 and size of a saved video, for the
+// ledger's SHA256 and Bytes
+func Checksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to checksum video: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to checksum video: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
+
+// EstimatedCost returns what a job costs: the estimate recorded when it
+// finished or, for jobs still rendering and ones recorded before costs were
+// tracked, the list price of its settings. Failed and cancelled jobs are not
+// billed, and false is returned when the price is unknown.
+func (e Entry) EstimatedCost() (float64, bool) {
+	if e.Cost > 0 {
+		return e.Cost, true
+	}
+	if e.Status == "failed" || e.Status == "cancelled" {
+		return 0, false
+	}
+	return api.EstimateCost(e.Model, e.Size, e.Duration)
+}
+
+// MonthSpend returns the estimated cost of the jobs created in the calendar
+// month of t
+func MonthSpend(t time.Time) (float64, error) {
+	entries, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	end := start.AddDate(0, 1, 0)
+
+	var total float64
+	for _, entry := range entries {
+		if entry.CreatedAt.Before(start) || !entry.CreatedAt.Before(end) {
+			continue
+		}
+		if cost, ok := entry.EstimatedCost(); ok {
+			total += cost
+		}
+	}
+	return total, nil
+}
+
+// TypicalDuration returns the median time past jobs with the same model,
+// size, and duration took to render, falling back to jobs with the same
+// model and duration, or 0 when the ledger has no comparable jobs
+func TypicalDuration(model, size, duration string) time.Duration {
+	entries, err := Load()
+	if err != nil {
+		return 0
+	}
+
+	var exact, similar []int
+	for _, entry := range entries {
+		if entry.GenerationSeconds <= 0 || entry.Model != model || entry.Duration != duration {
+			continue
+		}
+		similar = append(similar, entry.GenerationSeconds)
+		if entry.Size == size {
+			exact = append(exact, entry.GenerationSeconds)
+		}
+	}
+	if len(exact) == 0 {
+		exact = similar
+	}
+	if len(exact) == 0 {
+		return 0
+	}
+	sort.Ints(exact)
+	return time.Duration(exact[len(exact)/2]) * time.Second
+}
+
+// Remaining estimates the time left for a job that has been running for
+// elapsed: from the typical duration of past jobs, from the progress made so
+// far, or the average of both when both are known. It returns 0 when there
+// is nothing to estimate from or the job is already overdue.
+func Remaining(typical, elapsed time.Duration, progress int) time.Duration {
+	var estimates []time.Duration
+	if typical > 0 {
+		estimates = append(estimates, typical-elapsed)
+	}
+	if progress > 0 && progress < 100 {
+		estimates = append(estimates, time.Duration(float64(elapsed)*float64(100-progress)/float64(progress)))
+	}
+	if len(estimates) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, estimate := range estimates {
+		total += estimate
+	}
+	if left := total / time.Duration(len(estimates)); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Record adds a job to the ledger, or updates it when the ID is already recorded.
+// update is applied to the stored entry, so callers only set the fields they know.
+func Record(id string, update func(e *Entry)) error {
+	mu.Lock()
+	defer mu.Unlock()
+	// Held from load to save, so a job recorded by another process in between
+	// is not lost
+	unlock, err := lockLedger(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := load()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	index := -1
+	for i := range entries {
+		if entries[i].ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		entries = append(entries, Entry{ID: id, CreatedAt: now})
+		index = len(entries) - 1
+	}
+	update(&entries[index])
+	entries[index].UpdatedAt = now
+
+	return save(entries)
+}
+
+// StatusInterrupted marks a job that was still rendering when the program
+// was stopped. It keeps running on the service until it is resumed.
+const StatusInterrupted = "interrupted"
+
+// MarkInterrupted records job as interrupted with its provider, prompt, and
+// settings, keeping what the ledger already has for fields job leaves empty
+// (a resumed job only knows its ID and provider)
+func MarkInterrupted(job Entry) error {
+	return Record(job.ID, func(e *Entry) {
+		e.Provider = job.Provider
+		e.Status = StatusInterrupted
+		if job.Prompt != "" {
+			e.Prompt = job.Prompt
+		}
+		if job.Model != "" {
+			e.Model, e.Size, e.Duration = job.Model, job.Size, job.Duration
+		}
+		if job.Profile != "" {
+			e.Profile = job.Profile
+		}
+		if job.Config != "" {
+			e.Config = job.Config
+		}
+	})
+}
+
+// ResumeCommand returns the command that polls and downloads job e into
+// outputDir, with the config and profile it was started with, for a job left
+// behind by an interrupted run
+func ResumeCommand(e Entry, outputDir string) string {
+	args := []string{"video-gen"}
+	if e.Config != "" {
+		args = append(args, "-config", shellQuote(e.Config))
+	}
+	if e.Profile != "" {
+		args = append(args, "-profile", shellQuote(e.Profile))
+	}
+	if e.Provider != "" && e.Provider != "sora" {
+		args = append(args, "-provider", e.Provider)
+	}
+	if outputDir != "" {
+		args = append(args, "-o", shellQuote(outputDir))
+	}
+	args = append(args, "-resume", e.ID)
+	return strings.Join(args, " ")
+}
+
+// shellQuote single-quotes arg for a POSIX shell when it contains anything
+// besides safe path characters
+func shellQuote(arg string) string {
+	if strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789/._-~+") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// load reads the ledger file; a missing file is an empty ledger
+func load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// lockLedger takes an advisory lock shared by every video-gen process on
+// the ledger, exclusive for writers, until the returned function is called.
+// Readers of a ledger that does not exist yet need no lock.
+func lockLedger(exclusive bool) (func(), error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !exclusive {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// save writes the ledger atomically so an interrupted run cannot corrupt it.
+// Each write goes through its own temporary file, so writers never share one.
+func save(entries []Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "history-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
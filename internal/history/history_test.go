@@ -0,0 +1,261 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useTempLedger points the ledger at a temporary data directory
+func useTempLedger(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+}
+
+// recordAll records entries as they are, timestamps included
+func recordAll(t *testing.T, entries ...Entry) {
+	t.Helper()
+	for _, entry := range entries {
+		entry := entry
+		if err := Record(entry.ID, func(e *Entry) { *e = entry }); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecordMergesUpdates(t *testing.T) {
+	useTempLedger(t)
+
+	if err := Record("video_1", func(e *Entry) {
+		e.Provider, e.Prompt, e.Model, e.Status = "sora", "a lighthouse", "sora-2", "queued"
+	}); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	first, err := Find("video_1")
+	if err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+
+	if err := Record("video_1", func(e *Entry) {
+		e.Status, e.OutputPath = "completed", "/videos/lighthouse.mp4"
+	}); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	entries, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Load() = %d entries, want 1", len(entries))
+	}
+	got := entries[0]
+	if got.Prompt != "a lighthouse" || got.Model != "sora-2" || got.Status != "completed" || got.OutputPath != "/videos/lighthouse.mp4" {
+		t.Errorf("merged entry = %+v", got)
+	}
+	if !got.CreatedAt.Equal(first.CreatedAt) || got.UpdatedAt.Before(first.UpdatedAt) {
+		t.Errorf("timestamps = %s, %s; want the first CreatedAt and a later UpdatedAt", got.CreatedAt, got.UpdatedAt)
+	}
+
+	// Every write goes through its own temporary file, which is gone afterwards
+	path, _ := Path()
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestRecordConcurrent(t *testing.T) {
+	useTempLedger(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Record(fmt.Sprintf("video_%d", i), func(e *Entry) { e.Status = "completed" }); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(entries) != 20 {
+		t.Errorf("Load() = %d entries, want 20", len(entries))
+	}
+}
+
+func TestLoadWithoutLedger(t *testing.T) {
+	useTempLedger(t)
+
+	entries, err := Load()
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Load() = %v, %v, want no entries", entries, err)
+	}
+	path, _ := Path()
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("Load() created %s", filepath.Dir(path))
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	tests := []struct {
+		name     string
+		typical  time.Duration
+		elapsed  time.Duration
+		progress int
+		want     time.Duration
+	}{
+		{"nothing to go on", 0, time.Minute, 0, 0},
+		{"typical duration", 3 * time.Minute, time.Minute, 0, 2 * time.Minute},
+		{"progress", 0, time.Minute, 25, 3 * time.Minute},
+		{"average of both", 3 * time.Minute, time.Minute, 25, 150 * time.Second},
+		{"overdue", 2 * time.Minute, 5 * time.Minute, 0, 0},
+		{"finished", 0, time.Minute, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Remaining(tt.typical, tt.elapsed, tt.progress); got != tt.want {
+				t.Errorf("Remaining(%s, %s, %d) = %s, want %s", tt.typical, tt.elapsed, tt.progress, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypicalDuration(t *testing.T) {
+	useTempLedger(t)
+	recordAll(t,
+		Entry{ID: "a", Model: "sora-2", Size: "1280x720", Duration: "8", GenerationSeconds: 100},
+		Entry{ID: "b", Model: "sora-2", Size: "1280x720", Duration: "8", GenerationSeconds: 300},
+		Entry{ID: "c", Model: "sora-2", Size: "1280x720", Duration: "8", GenerationSeconds: 200},
+		Entry{ID: "d", Model: "sora-2", Size: "720x1280", Duration: "8", GenerationSeconds: 900},
+		Entry{ID: "e", Model: "sora-2", Size: "720x1280", Duration: "4"},
+	)
+
+	tests := []struct {
+		name                  string
+		model, size, duration string
+		want                  time.Duration
+	}{
+		{"median of the same settings", "sora-2", "1280x720", "8", 200 * time.Second},
+		{"same model and duration", "sora-2", "1792x1024", "8", 300 * time.Second},
+		{"only jobs with a time count", "sora-2", "720x1280", "4", 0},
+		{"other model", "sora-2-pro", "1280x720", "8", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TypicalDuration(tt.model, tt.size, tt.duration); got != tt.want {
+				t.Errorf("TypicalDuration() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthSpend(t *testing.T) {
+	useTempLedger(t)
+	march := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	recordAll(t,
+		Entry{ID: "recorded", Status: "completed", Cost: 1.5, CreatedAt: march},
+		Entry{ID: "rendering", Status: "in_progress", Model: "sora-2", Size: "1280x720", Duration: "4", CreatedAt: march},
+		Entry{ID: "failed", Status: "failed", Model: "sora-2", Size: "1280x720", Duration: "4", CreatedAt: march},
+		Entry{ID: "last day", Status: "completed", Cost: 0.25, CreatedAt: time.Date(2026, time.March, 31, 23, 59, 0, 0, time.UTC)},
+		Entry{ID: "next month", Status: "completed", Cost: 9, CreatedAt: time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+	)
+
+	got, err := MonthSpend(march)
+	if err != nil {
+		t.Fatalf("MonthSpend() error: %v", err)
+	}
+	// sora-2 is $0.10 a second
+	if want := 1.5 + 0.4 + 0.25; fmt.Sprintf("%.2f", got) != fmt.Sprintf("%.2f", want) {
+		t.Errorf("MonthSpend() = %.2f, want %.2f", got, want)
+	}
+}
+
+func TestResumeCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		entry     Entry
+		outputDir string
+		want      string
+	}{
+		{"sora", Entry{ID: "video_1", Provider: "sora"}, "/videos", "video-gen -o /videos -resume video_1"},
+		{"runway", Entry{ID: "task_1", Provider: "runway"}, "", "video-gen -provider runway -resume task_1"},
+		{"profile and config", Entry{ID: "video_1", Provider: "sora", Profile: "client", Config: "/etc/video gen.toml"}, "/videos",
+			"video-gen -config '/etc/video gen.toml' -profile client -o /videos -resume video_1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResumeCommand(tt.entry, tt.outputDir); got != tt.want {
+				t.Errorf("ResumeCommand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"/Users/me/Movies", "/Users/me/Movies"},
+		{"~/videos_2.0+draft", "~/videos_2.0+draft"},
+		{"/Users/me/My Videos", "'/Users/me/My Videos'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			if got := shellQuote(tt.arg); got != tt.want {
+				t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDeadLetter(t *testing.T) {
+	useTempLedger(t)
+
+	letters := []DeadLetter{
+		{Prompt: "a lighthouse", Model: "sora-2", Error: "Video generation failed", Class: "failed", Attempts: 3},
+		{Prompt: "a harbor", Priority: "urgent", Error: "timeout waiting for video generation", Class: "timeout", Attempts: 1},
+	}
+	for _, d := range letters {
+		if err := AddDeadLetter(d); err != nil {
+			t.Fatalf("AddDeadLetter() error: %v", err)
+		}
+	}
+
+	path, _ := DeadLetterPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != len(letters) {
+		t.Fatalf("%s has %d lines, want %d:\n%s", path, len(lines), len(letters), data)
+	}
+	for i, line := range lines {
+		var got DeadLetter
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if got.FailedAt.IsZero() {
+			t.Errorf("line %d has no failed_at", i+1)
+		}
+		got.FailedAt = time.Time{}
+		if got != letters[i] {
+			t.Errorf("line %d = %+v, want %+v", i+1, got, letters[i])
+		}
+	}
+}
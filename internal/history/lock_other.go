@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package history
+
+import "os"
+
+// lockFile does nothing where there are no file locks; the ledger is then
+// only protected within one process
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// unlockFile does nothing, like lockFile
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,91 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// placeholder matches {name} in a hook command
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Vars describes a downloaded video to a hook command
+type Vars struct {
+	Path     string
+	ID       string
+	Prompt   string
+	Model    string
+	Provider string
+	Size     string
+	Duration string
+}
+
+// fields returns the placeholder values by name
+func (v Vars) fields() map[string]string {
+	return map[string]string{
+		"path":     v.Path,
+		"id":       v.ID,
+		"prompt":   v.Prompt,
+		"model":    v.Model,
+		"provider": v.Provider,
+		"size":     v.Size,
+		"duration": v.Duration,
+	}
+}
+
+// Expand replaces the {path}, {id}, {prompt}, {model}, {provider}, {size},
+// and {duration} placeholders in command with shell-quoted values. Unknown
+// placeholders are left as they are.
+func Expand(command string, vars Vars) string {
+	fields := vars.fields()
+	return placeholder.ReplaceAllStringFunc(command, func(match string) string {
+		value, ok := fields[match[1:len(match)-1]]
+		if !ok {
+			return match
+		}
+		return quote(value)
+	})
+}
+
+// Run expands and runs command through the shell (cmd on Windows). The values
+// are also available as VIDEO_GEN_PATH, VIDEO_GEN_ID, VIDEO_GEN_PROMPT, and
+// so on. It returns the command's combined output.
+func Run(command string, vars Vars) (string, error) {
+	expanded := Expand(command, vars)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", expanded)
+	} else {
+		cmd = exec.Command("sh", "-c", expanded)
+	}
+	cmd.Env = os.Environ()
+	for name, value := range vars.fields() {
+		cmd.Env = append(cmd.Env, "VIDEO_GEN_"+strings.ToUpper(name)+"="+value)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	text := strings.TrimSpace(output.String())
+	if err != nil {
+		if text != "" {
+			return text, fmt.Errorf("on_complete hook failed: %w: %s", err, text)
+		}
+		return text, fmt.Errorf("on_complete hook failed: %w", err)
+	}
+	return text, nil
+}
+
+// quote makes value safe to splice into a shell command as a single word
+func quote(value string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,110 @@
+// Package manifest writes the JSON sidecar saved next to each video when
+// sidecar_manifest is set, describing how the video was made for asset
+// ingestion pipelines
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// Manifest describes a saved video
+type Manifest struct {
+	Video    string             `json:"video"` // File name of the video, next to the manifest
+	SHA256   string             `json:"sha256,omitempty"`
+	Bytes    int64              `json:"bytes,omitempty"`
+	Provider string             `json:"provider"`
+	Request  Request            `json:"request"`
+	Response *api.VideoResponse `json:"response,omitempty"` // The job's final status as the service reported it
+	Timings  Timings            `json:"timings"`
+}
+
+// Request is the job as it was submitted
+type Request struct {
+	Prompt               string `json:"prompt"`
+	Model                string `json:"model,omitempty"`
+	Seconds              string `json:"seconds,omitempty"`
+	Size                 string `json:"size,omitempty"`
+	InputReference       string `json:"input_reference,omitempty"`
+	InputReferenceSHA256 string `json:"input_reference_sha256,omitempty"`
+	CropAnchor           string `json:"crop_anchor,omitempty"`
+	NegativePrompt       string `json:"negative_prompt,omitempty"` // Exclusions, also folded into the prompt
+	FallbackFrom         string `json:"fallback_from,omitempty"`   // Model that refused the job before it fell back to Model
+}
+
+// Timings is when the job was started, rendered, and saved
+type Timings struct {
+	StartedAt      *time.Time `json:"started_at,omitempty"` // When this tool submitted or resumed the job
+	CreatedAt      *time.Time `json:"created_at,omitempty"` // When the service accepted it
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	SavedAt        time.Time  `json:"saved_at"`
+	RenderSeconds  int64      `json:"render_seconds,omitempty"`  // From created_at to completed_at
+	ElapsedSeconds int64      `json:"elapsed_seconds,omitempty"` // From started_at to saved_at
+}
+
+// Path returns the manifest file of a video: its path with a .json extension
+func Path(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".json"
+}
+
+// Write saves the manifest of the video at videoPath, made from the request
+// it was submitted with and its final status. started is when the job was
+// submitted or resumed, zero when unknown.
+func Write(videoPath, provider string, req api.CreateVideoRequest, resp *api.VideoResponse, started time.Time) (string, error) {
+	m := Manifest{
+		Video:    filepath.Base(videoPath),
+		Provider: provider,
+		Request: Request{
+			Prompt:         req.Prompt,
+			Model:          req.Model,
+			Seconds:        req.Seconds,
+			Size:           req.Size,
+			InputReference: req.InputReference,
+			CropAnchor:     req.CropAnchor,
+			NegativePrompt: req.Negative,
+			FallbackFrom:   req.FallbackFrom,
+		},
+		Response: resp,
+		Timings:  Timings{SavedAt: time.Now().UTC()},
+	}
+
+	var err error
+	if m.SHA256, m.Bytes, err = history.Checksum(videoPath); err != nil {
+		return "", err
+	}
+	// The reference may have been a temporary file that is gone by now
+	if req.InputReference != "" {
+		m.Request.InputReferenceSHA256, _, _ = history.Checksum(req.InputReference)
+	}
+	if resp != nil && resp.CreatedAt > 0 {
+		created := time.Unix(resp.CreatedAt, 0).UTC()
+		m.Timings.CreatedAt = &created
+		if resp.CompletedAt >= resp.CreatedAt {
+			completed := time.Unix(resp.CompletedAt, 0).UTC()
+			m.Timings.CompletedAt = &completed
+			m.Timings.RenderSeconds = resp.CompletedAt - resp.CreatedAt
+		}
+	}
+	if !started.IsZero() {
+		started = started.UTC()
+		m.Timings.StartedAt = &started
+		m.Timings.ElapsedSeconds = int64(m.Timings.SavedAt.Sub(started).Seconds())
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	path := Path(videoPath)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,205 @@
+// Package mcp serves tools over the Model Context Protocol's stdio transport:
+// newline-delimited JSON-RPC 2.0 messages on stdin and stdout. Only the tools
+// capability is offered, which is all video-gen mcp needs for agents to
+// generate videos through it.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first.
+// Tools and text content are unchanged across them.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// maxMessageSize bounds a single JSON-RPC message read from the client
+const maxMessageSize = 4 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Tool is a function the client can call. Handler receives the call's
+// arguments and returns the text handed back to the model; an error is
+// reported to the model as a failed call rather than a protocol error.
+type Tool struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{} // JSON Schema of the arguments object
+	Handler     func(args json.RawMessage) (string, error)
+}
+
+// Server answers MCP requests with a fixed set of tools
+type Server struct {
+	name    string
+	version string
+	tools   []Tool
+	mu      sync.Mutex // Serializes writes to the client
+}
+
+// NewServer returns a server that introduces itself as name and version
+func NewServer(name, version string, tools ...Tool) *Server {
+	return &Server{name: name, version: version, tools: tools}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// content is a text block of a tool result
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type toolResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Serve reads requests from r and writes responses to w until r is closed.
+// Requests are handled one at a time, in the order they arrive.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.write(w, response{ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "invalid JSON: " + err.Error()}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			if req.ID != nil {
+				s.write(w, response{ID: req.ID, Error: &rpcError{codeInvalidRequest, "not a JSON-RPC 2.0 request"}})
+			}
+			continue
+		}
+
+		result, rerr := s.handle(req)
+		// Notifications get no response
+		if req.ID == nil {
+			continue
+		}
+		s.write(w, response{ID: req.ID, Result: result, Error: rerr})
+	}
+	return scanner.Err()
+}
+
+// handle dispatches a request to its method
+func (s *Server) handle(req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		return map[string]interface{}{
+			"protocolVersion": negotiate(params.ProtocolVersion),
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		}, nil
+
+	case "ping":
+		return struct{}{}, nil
+
+	case "tools/list":
+		tools := make([]map[string]interface{}, 0, len(s.tools))
+		for _, tool := range s.tools {
+			tools = append(tools, map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"inputSchema": tool.InputSchema,
+			})
+		}
+		return map[string]interface{}{"tools": tools}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, "invalid tools/call params: " + err.Error()}
+		}
+		tool := s.tool(params.Name)
+		if tool == nil {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		text, err := tool.Handler(params.Arguments)
+		if err != nil {
+			return toolResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		return toolResult{Content: []content{{Type: "text", Text: text}}}, nil
+	}
+
+	if req.ID == nil {
+		// Unknown notifications, such as notifications/initialized, need no handling
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+}
+
+// tool returns the tool with the given name, or nil
+func (s *Server) tool(name string) *Tool {
+	for i := range s.tools {
+		if s.tools[i].Name == name {
+			return &s.tools[i]
+		}
+	}
+	return nil
+}
+
+// write sends a response as one line
+func (s *Server) write(w io.Writer, resp response) {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{codeInvalidRequest, err.Error()}})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Write(append(data, '\n'))
+}
+
+// negotiate returns the protocol version to use: the client's when the
+// server speaks it, otherwise the newest the server knows
+func negotiate(requested string) string {
+	for _, version := range protocolVersions {
+		if version == requested {
+			return version
+		}
+	}
+	return protocolVersions[0]
+}
@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// serve runs the server over the given request lines and returns the decoded responses
+func serve(t *testing.T, s *Server, lines ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+
+	var responses []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response %q is not JSON: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func echoServer() *Server {
+	return NewServer("test", "1.2.3", Tool{
+		Name:        "echo",
+		Description: "Echo the text argument",
+		InputSchema: map[string]interface{}{"type": "object"},
+		Handler: func(args json.RawMessage) (string, error) {
+			var params struct {
+				Text string `json:"text"`
+			}
+			json.Unmarshal(args, &params)
+			if params.Text == "" {
+				return "", errors.New("text is required")
+			}
+			return params.Text, nil
+		},
+	})
+}
+
+func TestInitialize(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		want      string
+	}{
+		{"supported version", "2024-11-05", "2024-11-05"},
+		{"unknown version", "1999-01-01", protocolVersions[0]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := serve(t, echoServer(),
+				`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"`+tt.requested+`"}}`,
+				`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			)
+			if len(responses) != 1 {
+				t.Fatalf("got %d responses, want 1 (notifications get none)", len(responses))
+			}
+			result := responses[0]["result"].(map[string]interface{})
+			if got := result["protocolVersion"]; got != tt.want {
+				t.Errorf("protocolVersion = %v, want %s", got, tt.want)
+			}
+			if info := result["serverInfo"].(map[string]interface{}); info["name"] != "test" || info["version"] != "1.2.3" {
+				t.Errorf("serverInfo = %v", info)
+			}
+		})
+	}
+}
+
+func TestTools(t *testing.T) {
+	responses := serve(t, echoServer(),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hello"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{}}}`,
+	)
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want 3", len(responses))
+	}
+
+	tools := responses[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["name"] != "echo" {
+		t.Errorf("tools/list = %v, want the echo tool", tools)
+	}
+
+	tests := []struct {
+		name    string
+		resp    map[string]interface{}
+		text    string
+		isError bool
+	}{
+		{"success", responses[1], "hello", false},
+		{"handler error", responses[2], "text is required", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.resp["result"].(map[string]interface{})
+			text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+			if text != tt.text {
+				t.Errorf("text = %v, want %q", text, tt.text)
+			}
+			if isError, _ := result["isError"].(bool); isError != tt.isError {
+				t.Errorf("isError = %t, want %t", isError, tt.isError)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		code    float64
+	}{
+		{"invalid JSON", `{"jsonrpc":`, codeParseError},
+		{"not JSON-RPC 2.0", `{"id":1,"method":"ping"}`, codeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, codeMethodNotFound},
+		{"unknown tool", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, codeInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := serve(t, echoServer(), tt.request)
+			if len(responses) != 1 {
+				t.Fatalf("got %d responses, want 1", len(responses))
+			}
+			rpcErr, ok := responses[0]["error"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("response %v has no error", responses[0])
+			}
+			if rpcErr["code"] != tt.code {
+				t.Errorf("code = %v, want %v", rpcErr["code"], tt.code)
+			}
+		})
+	}
+}
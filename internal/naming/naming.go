@@ -0,0 +1,172 @@
+// Package naming renders the filenames videos are saved under from
+// -name-template (name_template in the config), for the CLI and the TUI
+// alike, and keeps the videos of a run from overwriting each other
+package naming
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// placeholder matches {name} and {name:N} in filename templates
+var placeholder = regexp.MustCompile(`\{(\w+)(?::(\d+))?\}`)
+
+// defaultPromptLength caps {prompt} when the template gives no length
+const defaultPromptLength = 60
+
+// Job is what a filename template can refer to
+type Job struct {
+	Provider string
+	Prompt   string
+	Model    string
+	Size     string
+	Seconds  string
+	Index    int // Position in a batch or queue, 0 otherwise
+}
+
+// Render returns the filename for a generated video: template rendered for
+// the job, or defaultName when template is empty. {id} is left in place for
+// FillID once the job has been created.
+func Render(template, defaultName string, job Job) (string, error) {
+	if template == "" {
+		return defaultName, nil
+	}
+
+	now := time.Now()
+	fields := map[string]string{
+		"date":      now.Format("20060102"),
+		"time":      now.Format("150405"),
+		"timestamp": now.Format("20060102_150405"),
+		"provider":  job.Provider,
+		"model":     job.Model,
+		"size":      job.Size,
+		"duration":  job.Seconds,
+		"n":         fmt.Sprintf("%02d", job.Index),
+	}
+
+	var unknown string
+	name := placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		parts := placeholder.FindStringSubmatch(match)
+		key, length := parts[1], 0
+		if parts[2] != "" {
+			length, _ = strconv.Atoi(parts[2])
+		}
+
+		switch key {
+		case "id":
+			return "{id}"
+		case "prompt":
+			if length == 0 {
+				length = defaultPromptLength
+			}
+			return Slugify(job.Prompt, length)
+		}
+
+		value, ok := fields[key]
+		if !ok {
+			unknown = match
+			return match
+		}
+		value = Sanitize(value)
+		if length > 0 && len([]rune(value)) > length {
+			value = string([]rune(value)[:length])
+		}
+		return value
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("unknown placeholder %s in name template %q", unknown, template)
+	}
+
+	name = Sanitize(name)
+	if name == "" {
+		return "", fmt.Errorf("name template %q produced an empty filename", template)
+	}
+	if filepath.Ext(name) == "" {
+		name += ".mp4"
+	}
+	return name, nil
+}
+
+// FillID replaces the {id} placeholder Render leaves in a path with a job's ID
+func FillID(path, videoID string) string {
+	return strings.ReplaceAll(path, "{id}", Sanitize(videoID))
+}
+
+// Variation returns path with _vN before the extension, naming take n of
+// several generated from the same prompt
+func Variation(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_v%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// claimed holds the paths Unique has handed out, so concurrent jobs that
+// render the same name before either video is saved still get their own
+var claimed = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: map[string]bool{}}
+
+// Unique returns path, or when a file already exists there or another job of
+// this run was given it, the path with -2, -3, and so on before the extension
+func Unique(path string) string {
+	claimed.Lock()
+	defer claimed.Unlock()
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for n := 2; taken(candidate); n++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	claimed.paths[candidate] = true
+	return candidate
+}
+
+// taken reports whether a path was handed out or is already on disk
+func taken(path string) bool {
+	if claimed.paths[path] {
+		return true
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Slugify turns text into a lowercase, hyphen-separated filename fragment of at most max runes
+func Slugify(text string, max int) string {
+	var sb strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && sb.Len() > 0 {
+			sb.WriteRune('-')
+			hyphen = true
+		}
+	}
+
+	slug := []rune(strings.TrimRight(sb.String(), "-"))
+	if len(slug) > max {
+		slug = []rune(strings.TrimRight(string(slug[:max]), "-"))
+	}
+	return string(slug)
+}
+
+// Sanitize replaces characters that are unsafe in filenames on common
+// filesystems (path separators, Windows reserved characters, control characters)
+func Sanitize(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	return strings.Trim(name, " .")
+}
@@ -0,0 +1,144 @@
+// Package poll decides when to check on a generation job and when to stop
+// waiting for it. The CLI and the TUI share it so both poll the same way.
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/backoff"
+)
+
+const (
+	// DefaultMaxAttempts is how many status checks are made before giving up
+	DefaultMaxAttempts = 200
+	// ContentRetries is how many times a finished job's video is requested
+	// while the service still reports it as not ready
+	ContentRetries = 12
+
+	// fixedJitter randomizes a fixed poll interval, so jobs started together
+	// drift apart
+	fixedJitter = 0.1
+)
+
+// statusBackoff is the adaptive wait between status checks: 10s, growing by
+// half with each check up to 30s. A job reporting 100% is checked at the
+// base rate again, since it is about to finish.
+var statusBackoff = backoff.Policy{Base: 10 * time.Second, Max: 30 * time.Second, Factor: 1.5, Jitter: 0.2}
+
+// ContentBackoff spaces out the requests for a finished job's video
+var ContentBackoff = backoff.Policy{Base: 5 * time.Second, Max: 20 * time.Second, Factor: 1.5, Jitter: 0.2}
+
+// ErrorBackoff spaces out status checks after ones that were rate limited or
+// hit a server or network error, doubling from 5s up to 2 minutes
+var ErrorBackoff = backoff.Policy{Base: 5 * time.Second, Max: 2 * time.Minute, Jitter: 0.2}
+
+// ErrTimeout is returned once a job has used up its status checks or its time
+var ErrTimeout = errors.New("timeout waiting for video generation")
+
+// Strategy is how often a job is checked and how long it is waited for.
+// The zero value polls adaptively with 200 attempts and no time limit.
+type Strategy struct {
+	Interval    time.Duration // Fixed wait between status checks (±10%); 0 backs off from 10s to 30s
+	MaxAttempts int           // Status checks before giving up; 0 for DefaultMaxAttempts
+	Timeout     time.Duration // Limit on the whole wait, 0 for none
+}
+
+// Validate rejects negative settings
+func (s Strategy) Validate() error {
+	if s.Interval < 0 {
+		return fmt.Errorf("invalid poll interval %s", s.Interval)
+	}
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("invalid maximum poll attempts %d", s.MaxAttempts)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("invalid poll timeout %s", s.Timeout)
+	}
+	return nil
+}
+
+// Attempts returns how many status checks are made before giving up
+func (s Strategy) Attempts() int {
+	if s.MaxAttempts > 0 {
+		return s.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// Nominal returns the typical wait after attempts status checks of a job
+// that last reported progress, without jitter, for display
+func (s Strategy) Nominal(attempts, progress int) time.Duration {
+	if s.Interval > 0 {
+		return s.Interval
+	}
+	if progress >= 100 {
+		return statusBackoff.Step(1)
+	}
+	return statusBackoff.Step(attempts)
+}
+
+// Wait returns the wait before the next status check of a job that has had
+// attempts checks, has been rendering for elapsed, and last reported
+// progress. It is jittered and cut short at the timeout.
+func (s Strategy) Wait(attempts int, elapsed time.Duration, progress int) time.Duration {
+	jitter := statusBackoff.Jitter
+	if s.Interval > 0 {
+		jitter = fixedJitter
+	}
+	return s.Limit(backoff.Jitter(s.Nominal(attempts, progress), jitter), elapsed)
+}
+
+// Limit shortens wait so it does not run past the timeout
+func (s Strategy) Limit(wait, elapsed time.Duration) time.Duration {
+	if s.Timeout > 0 && elapsed+wait > s.Timeout {
+		wait = s.Timeout - elapsed
+		if wait < 0 {
+			wait = 0
+		}
+	}
+	return wait
+}
+
+// Check returns an error wrapping ErrTimeout once attempts status checks
+// have been made or elapsed has reached the timeout, and nil otherwise
+func (s Strategy) Check(attempts int, elapsed time.Duration) error {
+	if attempts >= s.Attempts() {
+		return fmt.Errorf("%w after %d status checks", ErrTimeout, attempts)
+	}
+	if s.Timeout > 0 && elapsed >= s.Timeout {
+		return fmt.Errorf("%w after %s", ErrTimeout, s.Timeout)
+	}
+	return nil
+}
+
+// JobError is the error of a job the service failed or cancelled
+type JobError struct {
+	Status string           // "failed" or "cancelled"
+	Reason *api.ErrorObject // The service's reason, nil when it gave none
+}
+
+func (e *JobError) Error() string {
+	if e.Status == "cancelled" {
+		return "Video generation was cancelled"
+	}
+	if e.Reason != nil && e.Reason.Message != "" {
+		return "Video generation failed: " + e.Reason.Message
+	}
+	return "Video generation failed"
+}
+
+// Failed returns a *JobError for a job whose status is "failed", with the
+// service's reason when it gave one, or "cancelled", and nil for any other
+// status
+func Failed(resp *api.VideoResponse) error {
+	switch resp.Status {
+	case "cancelled":
+		return &JobError{Status: resp.Status}
+	case "failed":
+		return &JobError{Status: resp.Status, Reason: resp.Error}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package poll
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+)
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		attempts int
+		elapsed  time.Duration
+		progress int
+		nominal  time.Duration // Expected wait before jitter
+		jitter   float64
+	}{
+		{"first check", Strategy{}, 1, 0, 0, 10 * time.Second, 0.2},
+		{"grows by half", Strategy{}, 2, 0, 40, 15 * time.Second, 0.2},
+		{"grows again", Strategy{}, 3, 0, 60, 22500 * time.Millisecond, 0.2},
+		{"capped", Strategy{}, 10, 0, 80, 30 * time.Second, 0.2},
+		{"finishing job checked at base rate", Strategy{}, 10, 0, 100, 10 * time.Second, 0.2},
+		{"fixed interval", Strategy{Interval: 5 * time.Second}, 7, 0, 50, 5 * time.Second, 0.1},
+		{"fixed interval ignores progress", Strategy{Interval: 5 * time.Second}, 7, 0, 100, 5 * time.Second, 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.strategy.Nominal(tt.attempts, tt.progress); got != tt.nominal {
+				t.Errorf("Nominal() = %s, want %s", got, tt.nominal)
+			}
+			low := time.Duration(float64(tt.nominal) * (1 - tt.jitter))
+			high := time.Duration(float64(tt.nominal) * (1 + tt.jitter))
+			for i := 0; i < 50; i++ {
+				if got := tt.strategy.Wait(tt.attempts, tt.elapsed, tt.progress); got < low || got > high {
+					t.Fatalf("Wait() = %s, want between %s and %s", got, low, high)
+				}
+			}
+		})
+	}
+}
+
+func TestWaitStopsAtTimeout(t *testing.T) {
+	s := Strategy{Interval: 10 * time.Second, Timeout: time.Minute}
+	if got := s.Wait(1, 55*time.Second, 0); got > 5*time.Second {
+		t.Errorf("Wait() = %s, want at most the 5s left", got)
+	}
+	if got := s.Wait(1, 2*time.Minute, 0); got != 0 {
+		t.Errorf("Wait() past the timeout = %s, want 0", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		wait     time.Duration
+		elapsed  time.Duration
+		expected time.Duration
+	}{
+		{"no timeout", 0, 30 * time.Second, time.Hour, 30 * time.Second},
+		{"well within", time.Minute, 10 * time.Second, 10 * time.Second, 10 * time.Second},
+		{"ends at timeout", time.Minute, 10 * time.Second, 55 * time.Second, 5 * time.Second},
+		{"exactly at timeout", time.Minute, 10 * time.Second, 50 * time.Second, 10 * time.Second},
+		{"past timeout", time.Minute, 10 * time.Second, 90 * time.Second, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Strategy{Timeout: tt.timeout}
+			if got := s.Limit(tt.wait, tt.elapsed); got != tt.expected {
+				t.Errorf("Limit(%s, %s) = %s, want %s", tt.wait, tt.elapsed, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		attempts int
+		elapsed  time.Duration
+		message  string // Expected error text, empty for no error
+	}{
+		{"keeps polling", Strategy{}, 1, time.Hour, ""},
+		{"default attempts used up", Strategy{}, DefaultMaxAttempts, 0, "after 200 status checks"},
+		{"one short of the limit", Strategy{MaxAttempts: 5}, 4, 0, ""},
+		{"max attempts used up", Strategy{MaxAttempts: 5}, 5, 0, "after 5 status checks"},
+		{"before timeout", Strategy{Timeout: time.Minute}, 3, 59 * time.Second, ""},
+		{"timed out", Strategy{Timeout: time.Minute}, 3, time.Minute, "after 1m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.strategy.Check(tt.attempts, tt.elapsed)
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrTimeout) {
+				t.Fatalf("Check() = %v, want ErrTimeout", err)
+			}
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Check() = %q, want it to mention %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []Strategy{{}, {Interval: time.Second, MaxAttempts: 3, Timeout: time.Minute}}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", s, err)
+		}
+	}
+	invalid := []Strategy{{Interval: -time.Second}, {MaxAttempts: -1}, {Timeout: -time.Minute}}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", s)
+		}
+	}
+}
+
+func TestFailed(t *testing.T) {
+	tests := []struct {
+		name          string
+		resp          api.VideoResponse
+		message       string // Expected error text, empty for no error
+		contentPolicy bool
+	}{
+		{"queued", api.VideoResponse{Status: "queued"}, "", false},
+		{"in progress", api.VideoResponse{Status: "in_progress", Progress: 50}, "", false},
+		{"completed", api.VideoResponse{Status: "completed"}, "", false},
+		{"failed without reason", api.VideoResponse{Status: "failed"}, "Video generation failed", false},
+		{"failed with reason", api.VideoResponse{Status: "failed", Error: &api.ErrorObject{Message: "internal error"}},
+			"Video generation failed: internal error", false},
+		{"cancelled", api.VideoResponse{Status: "cancelled"}, "Video generation was cancelled", false},
+		{"content policy", api.VideoResponse{Status: "failed", Error: &api.ErrorObject{Code: "moderation_blocked", Message: "Your request was blocked by our moderation system."}},
+			"Video generation failed: Your request was blocked by our moderation system.", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Failed(&tt.resp)
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("Failed() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.message {
+				t.Fatalf("Failed() = %v, want %q", err, tt.message)
+			}
+			var jobErr *JobError
+			if !errors.As(err, &jobErr) || jobErr.Status != tt.resp.Status {
+				t.Errorf("Failed() = %#v, want a *JobError with status %s", err, tt.resp.Status)
+			}
+			if got := api.IsContentPolicyError(tt.resp.Error); got != tt.contentPolicy {
+				t.Errorf("IsContentPolicyError() = %t, want %t", got, tt.contentPolicy)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// placeholderPattern matches {name} template placeholders. {a|b} wildcard
+// choices and {{.var}} Go template actions do not match.
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z0-9_\-]+)\}`)
+
+// Template is a named, reusable prompt with {placeholders}
+type Template struct {
+	Prompt      string            `toml:"prompt"`
+	Description string            `toml:"description"`
+	Defaults    map[string]string `toml:"defaults"` // Values used when a placeholder is not given
+}
+
+// LoadTemplates reads prompt templates from a TOML file of [name] tables.
+// A missing file means no templates.
+func LoadTemplates(file string) (map[string]Template, error) {
+	templates := make(map[string]Template)
+	if file == "" {
+		return templates, nil
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return templates, nil
+	}
+	if _, err := toml.DecodeFile(file, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode templates file: %w", err)
+	}
+	return templates, nil
+}
+
+// LoadTemplate returns the named template from file
+func LoadTemplate(name, file string) (*Template, error) {
+	templates, err := LoadTemplates(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return FindTemplate(templates, name, file)
+}
+
+// FindTemplate returns the named template, or an error listing the ones
+// there are; source names where they are defined
+func FindTemplate(templates map[string]Template, name, source string) (*Template, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		if len(templates) == 0 {
+			return nil, fmt.Errorf("unknown template '%s': no templates defined in %s", name, source)
+		}
+		return nil, fmt.Errorf("unknown template '%s'. Available templates: %s", name, strings.Join(TemplateNames(templates), ", "))
+	}
+	return &tmpl, nil
+}
+
+// TemplateNames lists the template names in sorted order
+func TemplateNames(templates map[string]Template) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Placeholders returns the names of the template's placeholders in order of
+// first appearance
+func (t Template) Placeholders() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(t.Prompt, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Fill replaces the placeholders with values from vars, falling back to the
+// template's defaults. Every placeholder must have a value.
+func (t Template) Fill(vars map[string]string) (string, error) {
+	var missing []string
+	for _, name := range t.Placeholders() {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+		if _, ok := t.Defaults[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template needs values for: %s (pass them with -var key=value)", strings.Join(missing, ", "))
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(t.Prompt, func(match string) string {
+		name := match[1 : len(match)-1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return t.Defaults[name]
+	}), nil
+}
@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPromptLength is the longest prompt each model accepts, in characters.
+// Sora has no published limit, so its value is a conservative guide.
+var maxPromptLength = map[string]int{
+	"sora-2":      2000,
+	"sora-2-pro":  2000,
+	"gen3a_turbo": 1000,
+	"gen4_turbo":  1000,
+}
+
+// maxOnScreenWords is the longest quoted on-screen text that video models render legibly
+const maxOnScreenWords = 4
+
+// realPersonTerms suggest the prompt depicts a real, identifiable person
+var realPersonTerms = []string{
+	"celebrity", "celebrities", "famous person", "real person", "politician",
+	"president", "prime minister", "deepfake", "lookalike", "look-alike", "in the likeness of",
+}
+
+// copyrightedTerms are characters and franchises that are commonly rejected
+var copyrightedTerms = []string{
+	"mickey mouse", "minnie mouse", "donald duck", "spider-man", "spiderman", "batman",
+	"superman", "iron man", "darth vader", "star wars", "harry potter", "pikachu",
+	"pokemon", "pokémon", "super mario", "sonic the hedgehog", "shrek",
+	"homer simpson", "the simpsons", "hello kitty", "buzz lightyear",
+	"disney", "marvel", "pixar",
+}
+
+var quotedText = regexp.MustCompile(`["“]([^"”]+)["”]`)
+
+// Lint checks a prompt for common causes of rejected or poor generations and
+// returns human-readable warnings. It never blocks submission.
+func Lint(text, model string) []string {
+	var warnings []string
+	lower := strings.ToLower(text)
+
+	if limit, ok := maxPromptLength[model]; ok {
+		if length := utf8.RuneCountInString(text); length > limit {
+			warnings = append(warnings, fmt.Sprintf("prompt is %d characters; %s accepts at most %d", length, model, limit))
+		}
+	}
+
+	if words := len(strings.Fields(text)); words > 0 && words < 4 {
+		warnings = append(warnings, "prompt is very short; describe the subject, setting, and camera for better results")
+	}
+
+	if term := findTerm(lower, realPersonTerms); term != "" {
+		warnings = append(warnings, fmt.Sprintf("prompt mentions %q; videos of real people are usually rejected", term))
+	}
+
+	if term := findTerm(lower, copyrightedTerms); term != "" {
+		warnings = append(warnings, fmt.Sprintf("prompt mentions %q; copyrighted characters are usually rejected", term))
+	}
+
+	for _, match := range quotedText.FindAllStringSubmatch(text, -1) {
+		if len(strings.Fields(match[1])) > maxOnScreenWords {
+			warnings = append(warnings, fmt.Sprintf("on-screen text %q is long; video models rarely render more than %d words legibly", match[1], maxOnScreenWords))
+		}
+	}
+
+	return warnings
+}
+
+// PolicyTerms returns every real-person and copyrighted term in a prompt,
+// the phrases most likely to have caused a content policy rejection
+func PolicyTerms(text string) []string {
+	lower := strings.ToLower(text)
+	var found []string
+	for _, terms := range [][]string{realPersonTerms, copyrightedTerms} {
+		for _, term := range terms {
+			if findTerm(lower, []string{term}) != "" {
+				found = append(found, term)
+			}
+		}
+	}
+	return found
+}
+
+// findTerm returns the first term that appears in text as a whole word or phrase
+func findTerm(text string, terms []string) string {
+	for _, term := range terms {
+		pattern := `(^|[^\pL\pN])` + regexp.QuoteMeta(term) + `($|[^\pL\pN])`
+		if regexp.MustCompile(pattern).MatchString(text) {
+			return term
+		}
+	}
+	return ""
+}
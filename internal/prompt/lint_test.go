@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		model string
+		want  []string // Substrings of the warnings, in order
+	}{
+		{"clean", "A lighthouse on a rocky coast at dusk, slow dolly in", "sora-2", nil},
+		{"too long", strings.Repeat("wave ", 250), "gen4_turbo", []string{"prompt is 1250 characters; gen4_turbo accepts at most 1000"}},
+		{"unknown model has no length limit", strings.Repeat("wave ", 500), "custom-model", nil},
+		{"very short", "a lighthouse", "sora-2", []string{"prompt is very short"}},
+		{"real person", "A politician giving a speech in the rain", "sora-2", []string{`mentions "politician"`}},
+		{"copyrighted character", "Batman standing on a rooftop at night", "sora-2", []string{`mentions "batman"; copyrighted characters`}},
+		{"term inside a word", "A batmanesque cape blowing on a rooftop", "sora-2", nil},
+		{"long on-screen text", `A neon sign reading "open all night every day" over a diner`, "sora-2", []string{"on-screen text \"open all night every day\" is long"}},
+		{"short on-screen text", `A neon sign reading "open late" over a quiet diner`, "sora-2", nil},
+		{"several findings", "Pikachu with a celebrity", "sora-2", []string{`mentions "celebrity"`, `mentions "pikachu"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Lint(tt.text, tt.model)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Lint() = %q, want %d warnings", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("warning %d = %q, want one containing %q", i+1, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"A lighthouse at dusk", nil},
+		{"A celebrity lookalike meets Darth Vader and Mickey Mouse", []string{"celebrity", "lookalike", "mickey mouse", "darth vader"}},
+		{"Supermarket shelves", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := PolicyTerms(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PolicyTerms(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithNegative folds exclusions such as "text, logos" into the prompt as an
+// "Avoid:" clause, since neither Sora nor Runway accepts a separate negative prompt
+func WithNegative(text, negative string) string {
+	negative = strings.TrimRight(strings.TrimSpace(negative), ".")
+	if negative == "" {
+		return text
+	}
+
+	text = strings.TrimSpace(text)
+	if !strings.HasSuffix(text, ".") && !strings.HasSuffix(text, "!") && !strings.HasSuffix(text, "?") {
+		text += "."
+	}
+
+	return fmt.Sprintf("%s Avoid: %s.", text, negative)
+}
@@ -0,0 +1,101 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Style is a named preset of prompt directives and default settings
+type Style struct {
+	Directive string `toml:"directive"`
+	Size      string `toml:"size"`
+	Duration  string `toml:"duration"`
+}
+
+// builtinStyles ship with the tool; user presets with the same name replace them
+var builtinStyles = map[string]Style{
+	"cinematic": {
+		Directive: "cinematic film look, anamorphic lens, shallow depth of field, dramatic lighting, subtle film grain, smooth dolly movement",
+		Size:      "1280x720",
+	},
+	"product-showcase": {
+		Directive: "clean studio product shot, seamless backdrop, soft diffused key light with crisp reflections, slow turntable rotation, sharp focus on the product",
+		Size:      "1280x720",
+		Duration:  "8",
+	},
+	"retro-vhs": {
+		Directive: "1980s VHS home video, washed-out colors, scan lines, tracking noise, slight chromatic aberration, handheld camcorder framing",
+	},
+	"drone-aerial": {
+		Directive: "aerial drone footage, smooth high-altitude glide, wide establishing view, golden hour light, stabilized gimbal movement",
+		Size:      "1280x720",
+		Duration:  "8",
+	},
+}
+
+// loadUserStyles reads user-defined presets from a TOML file of [name] tables.
+// A missing file means no user presets.
+func loadUserStyles(file string) (map[string]Style, error) {
+	styles := make(map[string]Style)
+	if file == "" {
+		return styles, nil
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return styles, nil
+	}
+	if _, err := toml.DecodeFile(file, &styles); err != nil {
+		return nil, fmt.Errorf("failed to decode styles file: %w", err)
+	}
+	return styles, nil
+}
+
+// LoadStyle returns the named preset, checking user presets in userFile before the built-ins
+func LoadStyle(name, userFile string) (*Style, error) {
+	styles, err := loadUserStyles(userFile)
+	if err != nil {
+		return nil, err
+	}
+
+	style, ok := styles[name]
+	if !ok {
+		style, ok = builtinStyles[name]
+	}
+	if !ok {
+		names, _ := StyleNames(userFile)
+		return nil, fmt.Errorf("unknown style '%s'. Available styles: %s", name, strings.Join(names, ", "))
+	}
+
+	return &style, nil
+}
+
+// StyleNames lists the built-in and user-defined preset names in sorted order
+func StyleNames(userFile string) ([]string, error) {
+	styles, err := loadUserStyles(userFile)
+	if err != nil {
+		return nil, err
+	}
+	for name, style := range builtinStyles {
+		if _, ok := styles[name]; !ok {
+			styles[name] = style
+		}
+	}
+
+	names := make([]string, 0, len(styles))
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// WithStyle appends a style directive to the prompt
+func WithStyle(text, directive string) string {
+	if directive == "" {
+		return text
+	}
+	return fmt.Sprintf("%s Style: %s", text, directive)
+}
@@ -0,0 +1,61 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LoadVars reads template variables from an optional TOML vars file and
+// applies overrides (from --var flags) on top
+func LoadVars(file string, overrides map[string]string) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	if file != "" {
+		var raw map[string]interface{}
+		if _, err := toml.DecodeFile(file, &raw); err != nil {
+			return nil, fmt.Errorf("failed to decode vars file: %w", err)
+		}
+		for key, value := range raw {
+			vars[key] = fmt.Sprint(value)
+		}
+	}
+
+	for key, value := range overrides {
+		vars[key] = value
+	}
+
+	return vars, nil
+}
+
+// ParseVar splits a "key=value" argument into its parts
+func ParseVar(arg string) (string, string, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("variable must be in format key=value, got %q", arg)
+	}
+	return key, value, nil
+}
+
+// Render expands Go template placeholders such as {{.season}} in a prompt.
+// Prompts without template actions are returned unchanged.
+func Render(text string, vars map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+
+	return sb.String(), nil
+}
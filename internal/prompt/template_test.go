@@ -0,0 +1,82 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	vars := map[string]string{"season": "winter", "product": "lamp"}
+	tests := []struct {
+		name string
+		text string
+		want string
+		err  string // Substring of the error; "" for none
+	}{
+		{"no template", "A lighthouse at dusk", "A lighthouse at dusk", ""},
+		{"braces without actions", "A {red|blue} car", "A {red|blue} car", ""},
+		{"variables", "A {{.product}} in {{.season}} light", "A lamp in winter light", ""},
+		{"missing variable", "A {{.product}} in {{.city}}", "", `map has no entry for key "city"`},
+		{"invalid template", "A {{.product", "", "invalid prompt template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.text, vars)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Render(%q) error = %v, want one containing %q", tt.text, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Render(%q) = %q, %v, want %q", tt.text, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVar(t *testing.T) {
+	tests := []struct {
+		arg        string
+		key, value string
+		ok         bool
+	}{
+		{"season=winter", "season", "winter", true},
+		{" season =late winter", "season", "late winter", true},
+		{"formula=a=b", "formula", "a=b", true},
+		{"empty=", "empty", "", true},
+		{"season", "", "", false},
+		{"=winter", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			key, value, err := ParseVar(tt.arg)
+			if (err == nil) != tt.ok || key != tt.key || value != tt.value {
+				t.Errorf("ParseVar(%q) = %q, %q, %v", tt.arg, key, value, err)
+			}
+		})
+	}
+}
+
+func TestLoadVars(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "vars.toml")
+	if err := os.WriteFile(file, []byte("season = \"winter\"\nyear = 2026\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadVars(file, map[string]string{"season": "summer", "city": "Oslo"})
+	if err != nil {
+		t.Fatalf("LoadVars() error: %v", err)
+	}
+	want := map[string]string{"season": "summer", "year": "2026", "city": "Oslo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadVars() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadVars(filepath.Join(t.TempDir(), "missing.toml"), nil); err == nil {
+		t.Error("LoadVars() of a missing file = nil error, want one")
+	}
+}
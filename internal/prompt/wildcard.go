@@ -0,0 +1,91 @@
+package prompt
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxExpandDepth bounds nested expansion, since wildcard files may reference other wildcards
+const maxExpandDepth = 10
+
+var (
+	// choicePattern matches inline alternatives such as {red|blue|green}
+	choicePattern = regexp.MustCompile(`\{([^{}]*\|[^{}]*)\}`)
+
+	// wildcardPattern matches wildcard file references such as __styles__
+	wildcardPattern = regexp.MustCompile(`__([A-Za-z0-9][A-Za-z0-9_\-/]*?)__`)
+)
+
+// Expand resolves {a|b|c} alternatives and __name__ wildcards (a random line from
+// name.txt in wildcardDir) into a single randomized prompt variant
+func Expand(text, wildcardDir string) (string, error) {
+	cache := make(map[string][]string)
+
+	for depth := 0; depth < maxExpandDepth; depth++ {
+		if !choicePattern.MatchString(text) && !wildcardPattern.MatchString(text) {
+			return text, nil
+		}
+
+		text = choicePattern.ReplaceAllStringFunc(text, func(match string) string {
+			options := strings.Split(match[1:len(match)-1], "|")
+			return strings.TrimSpace(options[rand.Intn(len(options))])
+		})
+
+		var expandErr error
+		text = wildcardPattern.ReplaceAllStringFunc(text, func(match string) string {
+			name := match[2 : len(match)-2]
+			lines, ok := cache[name]
+			if !ok {
+				var err error
+				lines, err = readWildcardFile(wildcardDir, name)
+				if err != nil {
+					if expandErr == nil {
+						expandErr = err
+					}
+					return match
+				}
+				cache[name] = lines
+			}
+			return lines[rand.Intn(len(lines))]
+		})
+		if expandErr != nil {
+			return "", expandErr
+		}
+	}
+
+	return "", fmt.Errorf("wildcards nested more than %d levels deep", maxExpandDepth)
+}
+
+// readWildcardFile returns the non-empty, non-comment lines of name.txt
+func readWildcardFile(dir, name string) ([]string, error) {
+	path := filepath.Join(dir, name+".txt")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wildcard file for __%s__: %w", name, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read wildcard file %s: %w", path, err)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("wildcard file %s has no entries", path)
+	}
+
+	return lines, nil
+}
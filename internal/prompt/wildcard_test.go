@@ -0,0 +1,72 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeWildcards writes name.txt files with the given contents to a new directory
+func writeWildcards(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name+".txt")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpand(t *testing.T) {
+	dir := writeWildcards(t, map[string]string{
+		"weather":        "# One line is picked\n\nfoggy\n",
+		"scene":          "a __weather__ {harbor|harbor}",
+		"styles/film":    "35mm film",
+		"loop":           "__loop__",
+		"empty":          "# nothing yet\n",
+		"lighting_setup": "soft key light",
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want []string // Every acceptable result; none when an error is expected
+		err  string
+	}{
+		{"plain text", "A lighthouse", []string{"A lighthouse"}, ""},
+		{"alternatives", "A {red | blue} car", []string{"A red car", "A blue car"}, ""},
+		{"wildcard", "A __weather__ morning", []string{"A foggy morning"}, ""},
+		{"nested wildcard", "__scene__ at dawn", []string{"a foggy harbor at dawn"}, ""},
+		{"wildcard in a subdirectory", "Shot on __styles/film__", []string{"Shot on 35mm film"}, ""},
+		{"underscores in the name", "__lighting_setup__", []string{"soft key light"}, ""},
+		{"missing wildcard file", "A __season__ morning", nil, "failed to open wildcard file for __season__"},
+		{"empty wildcard file", "__empty__", nil, "has no entries"},
+		{"self-referencing wildcard", "__loop__", nil, "nested more than 10 levels deep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.text, dir)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expand(%q) = %q, %v, want an error containing %q", tt.text, got, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expand(%q) error: %v", tt.text, err)
+			}
+			for _, want := range tt.want {
+				if got == want {
+					return
+				}
+			}
+			t.Errorf("Expand(%q) = %q, want one of %q", tt.text, got, tt.want)
+		})
+	}
+}
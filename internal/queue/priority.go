@@ -0,0 +1,69 @@
+// Package queue decides the order the jobs of a queue start in and which
+// failed jobs are tried again. The TUI queue and the CLI's batch worker pool
+// share it, so a job marked urgent jumps ahead of the rest in both without
+// cancelling anything, and both retry the same errors.
+package queue
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Priority is how soon a queued job starts relative to the others. The zero
+// value is Normal.
+type Priority int
+
+const (
+	Urgent Priority = -1 // Starts before every other pending job
+	Normal Priority = 0
+	Batch  Priority = 1 // Starts only when no urgent or normal job is pending
+)
+
+// String returns the name of the priority, as accepted by ParsePriority
+func (p Priority) String() string {
+	switch {
+	case p < Normal:
+		return "urgent"
+	case p > Normal:
+		return "batch"
+	}
+	return "normal"
+}
+
+// ParsePriority reads a priority name; an empty one is Normal
+func ParsePriority(name string) (Priority, error) {
+	switch name {
+	case "urgent":
+		return Urgent, nil
+	case "", "normal":
+		return Normal, nil
+	case "batch":
+		return Batch, nil
+	}
+	return Normal, fmt.Errorf("unknown priority '%s'; use urgent, normal, or batch", name)
+}
+
+// Next returns the priority after p, cycling normal → urgent → batch, for
+// a key that switches between them
+func (p Priority) Next() Priority {
+	switch p {
+	case Normal:
+		return Urgent
+	case Urgent:
+		return Batch
+	}
+	return Normal
+}
+
+// Order returns the positions of jobs with the given priorities in the
+// order they start: by priority, then in the order they were queued
+func Order(priorities []Priority) []int {
+	order := make([]int, len(priorities))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return priorities[order[a]] < priorities[order[b]]
+	})
+	return order
+}
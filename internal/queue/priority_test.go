@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Priority
+		wantErr bool
+	}{
+		{"", Normal, false},
+		{"normal", Normal, false},
+		{"urgent", Urgent, false},
+		{"batch", Batch, false},
+		{"high", Normal, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePriority(tt.name)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ParsePriority(%q) = %s, %v", tt.name, got, err)
+			}
+			if err == nil && tt.name != "" && got.String() != tt.name {
+				t.Errorf("String() = %s, want %s", got, tt.name)
+			}
+		})
+	}
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		priorities []Priority
+		want       []int
+	}{
+		{"all normal keep their order", []Priority{Normal, Normal, Normal}, []int{0, 1, 2}},
+		{"urgent jumps ahead", []Priority{Batch, Batch, Normal, Urgent, Batch}, []int{3, 2, 0, 1, 4}},
+		{"ties keep their order", []Priority{Urgent, Normal, Urgent}, []int{0, 2, 1}},
+		{"empty", nil, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Order(tt.priorities); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
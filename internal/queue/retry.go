@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/backoff"
+	"github.com/telemetry/video-gen/internal/poll"
+)
+
+// ErrorClass groups the errors a queued job fails with, so a retry policy
+// can name the ones worth another attempt
+type ErrorClass string
+
+const (
+	Transient  ErrorClass = "transient"  // Rate limits, server errors, and network errors
+	Failed     ErrorClass = "failed"     // The service failed the job, for a reason other than its content
+	Moderation ErrorClass = "moderation" // The prompt or reference was refused under the content policy
+	Timeout    ErrorClass = "timeout"    // The job outlasted its status checks or the poll timeout
+	Other      ErrorClass = "other"      // Anything else, such as a bad setting or key; never retried
+)
+
+// retryableClasses are the classes a retry policy can name
+var retryableClasses = []ErrorClass{Transient, Failed, Moderation, Timeout}
+
+// DefaultRetryOn are the classes retried when a policy names none: the
+// ones where the same request can succeed the next time
+var DefaultRetryOn = []ErrorClass{Transient, Failed}
+
+// DefaultRetryBackoff is the wait before each retry when a policy sets
+// none: 30s, doubling up to 10 minutes
+var DefaultRetryBackoff = backoff.Policy{Base: 30 * time.Second, Max: 10 * time.Minute, Jitter: 0.2}
+
+// Classify returns the class of the error a job failed with
+func Classify(err error) ErrorClass {
+	var jobErr *poll.JobError
+	isJobErr := errors.As(err, &jobErr)
+	switch {
+	case api.IsContentPolicy(err), isJobErr && api.IsContentPolicyError(jobErr.Reason):
+		return Moderation
+	case isJobErr && jobErr.Status == "failed":
+		return Failed
+	case errors.Is(err, poll.ErrTimeout):
+		return Timeout
+	case !isJobErr && api.IsTransient(err):
+		return Transient
+	}
+	return Other
+}
+
+// ParseErrorClass reads the name of a class a retry policy can name
+func ParseErrorClass(name string) (ErrorClass, error) {
+	names := make([]string, len(retryableClasses))
+	for i, class := range retryableClasses {
+		if string(class) == name {
+			return class, nil
+		}
+		names[i] = string(class)
+	}
+	return "", fmt.Errorf("unknown error class '%s'; use %s", name, strings.Join(names, ", "))
+}
+
+// RetryPolicy is how a queued job that failed is tried again. The zero
+// value never retries: each attempt is a new job, billed again.
+type RetryPolicy struct {
+	MaxAttempts int            // Tries per job, the first included; 0 or 1 for no retries
+	Backoff     backoff.Policy // Wait before each retry; the zero value for DefaultRetryBackoff
+	On          []ErrorClass   // Classes retried; nil for DefaultRetryOn
+}
+
+// Retry reports whether a job whose attempt-th try (1 for the first)
+// failed with err is tried again, and the wait before it
+func (p RetryPolicy) Retry(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !p.retries(Classify(err)) {
+		return 0, false
+	}
+	policy := p.Backoff
+	if policy.Base == 0 {
+		policy = DefaultRetryBackoff
+	}
+	return policy.Delay(attempt), true
+}
+
+// retries reports whether the policy retries errors of class
+func (p RetryPolicy) retries(class ErrorClass) bool {
+	on := p.On
+	if on == nil {
+		on = DefaultRetryOn
+	}
+	for _, c := range on {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
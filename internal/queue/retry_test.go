@@ -0,0 +1,87 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/backoff"
+	"github.com/telemetry/video-gen/internal/poll"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"network error", fmt.Errorf("failed to create video: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), Transient},
+		{"failed job", &poll.JobError{Status: "failed", Reason: &api.ErrorObject{Message: "internal error"}}, Failed},
+		{"failed job without reason", &poll.JobError{Status: "failed"}, Failed},
+		{"moderated job", &poll.JobError{Status: "failed", Reason: &api.ErrorObject{Code: "moderation_blocked"}}, Moderation},
+		{"cancelled job", &poll.JobError{Status: "cancelled"}, Other},
+		{"timeout", fmt.Errorf("%w after 200 status checks", poll.ErrTimeout), Timeout},
+		{"local error", errors.New("reference image not found"), Other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorClass(t *testing.T) {
+	for _, name := range []string{"transient", "failed", "moderation", "timeout"} {
+		if class, err := ParseErrorClass(name); err != nil || string(class) != name {
+			t.Errorf("ParseErrorClass(%q) = %s, %v", name, class, err)
+		}
+	}
+	for _, name := range []string{"other", "5xx", ""} {
+		if _, err := ParseErrorClass(name); err == nil {
+			t.Errorf("ParseErrorClass(%q) = nil error, want one", name)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	failed := &poll.JobError{Status: "failed"}
+	moderated := &poll.JobError{Status: "failed", Reason: &api.ErrorObject{Code: "moderation_blocked"}}
+	fixed := backoff.Policy{Base: time.Minute, Max: 4 * time.Minute}
+
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		err     error
+		want    time.Duration
+		retry   bool
+	}{
+		{"zero policy never retries", RetryPolicy{}, 1, failed, 0, false},
+		{"first retry", RetryPolicy{MaxAttempts: 3, Backoff: fixed}, 1, failed, time.Minute, true},
+		{"backoff grows", RetryPolicy{MaxAttempts: 3, Backoff: fixed}, 2, failed, 2 * time.Minute, true},
+		{"attempts used up", RetryPolicy{MaxAttempts: 3, Backoff: fixed}, 3, failed, 0, false},
+		{"moderation not retried by default", RetryPolicy{MaxAttempts: 3, Backoff: fixed}, 1, moderated, 0, false},
+		{"moderation named", RetryPolicy{MaxAttempts: 3, Backoff: fixed, On: []ErrorClass{Moderation}}, 1, moderated, time.Minute, true},
+		{"class not named", RetryPolicy{MaxAttempts: 3, Backoff: fixed, On: []ErrorClass{Transient}}, 1, failed, 0, false},
+		{"other errors never retried", RetryPolicy{MaxAttempts: 3, Backoff: fixed}, 1, errors.New("bad key"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.policy.Retry(tt.attempt, tt.err)
+			if got != tt.want || ok != tt.retry {
+				t.Errorf("Retry(%d, %v) = %s, %t, want %s, %t", tt.attempt, tt.err, got, ok, tt.want, tt.retry)
+			}
+		})
+	}
+
+	// Without a backoff, retries wait DefaultRetryBackoff
+	got, ok := RetryPolicy{MaxAttempts: 2}.Retry(1, failed)
+	if low, high := time.Duration(float64(DefaultRetryBackoff.Base)*0.8), time.Duration(float64(DefaultRetryBackoff.Base)*1.2); !ok || got < low || got > high {
+		t.Errorf("Retry() with the default backoff = %s, %t, want about %s", got, ok, DefaultRetryBackoff.Base)
+	}
+}
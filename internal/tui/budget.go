@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/engine"
+)
+
+// reserveBudget commits the estimated cost of a new job to the session's
+// budget, returning the amount reserved. Its warnings are left to the review
+// screen. Remixes and resumed jobs are not counted, as their price is not
+// known up front.
+func (m Model) reserveBudget(job engine.Job) (float64, error) {
+	if job.VideoID != "" || job.RemixOf != "" {
+		return 0, nil
+	}
+	cost, ok := api.EstimateJobCost(job.Request, nil)
+	if !ok {
+		return 0, nil
+	}
+	if _, err := m.budget.Reserve(cost, m.force); err != nil {
+		return 0, fmt.Errorf("%w (restart with -force to submit anyway)", err)
+	}
+	return cost, nil
+}
+
+// releaseBudget returns the reserved cost of a job that ended without being
+// billed: it was refused on submission or failed on the service
+func (m Model) releaseBudget(cost float64, done engine.Done) {
+	if done.Stage == engine.StageCreate || (done.Response != nil && done.Response.Status == "failed") {
+		m.budget.Release(cost)
+	}
+}
+
+// viewBudget shows the estimated cost of the job being reviewed and what it
+// would do to the budget
+func (m Model) viewBudget() string {
+	cost, ok := api.EstimateCost(m.model, m.size, m.duration)
+	if !ok {
+		return ""
+	}
+	var sb strings.Builder
+	if m.takes > 1 {
+		sb.WriteString(infoStyle.Render(fmt.Sprintf("Estimated cost: ~$%.2f (%d takes at ~$%.2f)", cost*float64(m.takes), m.takes, cost)))
+		cost *= float64(m.takes)
+	} else {
+		sb.WriteString(infoStyle.Render(fmt.Sprintf("Estimated cost: ~$%.2f", cost)))
+	}
+	warning, err := m.budget.Check(cost)
+	switch {
+	case err != nil && m.force:
+		sb.WriteString("\n")
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("⚠ %v (forced)", err)))
+	case err != nil:
+		sb.WriteString("\n")
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("✗ %v", err)))
+	case warning != "":
+		sb.WriteString("\n")
+		sb.WriteString(warningStyle.Render("⚠ " + warning))
+	}
+	return sb.String()
+}
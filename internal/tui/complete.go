@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxCompletions is the number of candidates listed under an input when a
+// completion is ambiguous
+const maxCompletions = 8
+
+// completePath completes the last element of a typed path, shell-style: a
+// single match is filled in (with a trailing separator for directories) and
+// several matches are extended to their longest common prefix. It returns
+// the new value and, when the completion is ambiguous, the candidate names.
+// Only directories match when dirsOnly is set; otherwise files must have one
+// of the extensions in types.
+func completePath(value string, dirsOnly bool, types []string) (string, []string) {
+	// Split off the element being typed, keeping the directory as written
+	dir, base := "", value
+	if i := strings.LastIndexAny(value, `/`+string(filepath.Separator)); i >= 0 {
+		dir, base = value[:i+1], value[i+1:]
+	} else if value == "~" {
+		return "~" + string(filepath.Separator), nil
+	}
+
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	} else if strings.HasPrefix(readDir, "~") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			readDir = homeDir + readDir[1:]
+		}
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return value, nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		// Stat follows symlinks so linked directories complete as directories
+		isDir := entry.IsDir()
+		if info, err := os.Stat(filepath.Join(readDir, name)); err == nil {
+			isDir = info.IsDir()
+		}
+		switch {
+		case isDir:
+			name += string(filepath.Separator)
+		case dirsOnly || !hasType(name, types):
+			continue
+		}
+		matches = append(matches, name)
+	}
+	sort.Strings(matches)
+
+	switch len(matches) {
+	case 0:
+		return value, nil
+	case 1:
+		return dir + matches[0], nil
+	}
+
+	prefix := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(matches) > maxCompletions {
+		matches = append(matches[:maxCompletions], "...")
+	}
+	return dir + prefix, matches
+}
+
+// hasType reports whether name has one of the extensions in types, ignoring case
+func hasType(name string, types []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, t := range types {
+		if ext == t {
+			return true
+		}
+	}
+	return false
+}
+
+// completeInput completes the path in the text input of the reference image
+// or output directory step
+func (m Model) completeInput() Model {
+	value, candidates := completePath(cleanDroppedPath(m.textInput.Value()), m.state == stateOutputDir, referenceTypes)
+	m.textInput.SetValue(value)
+	m.textInput.CursorEnd()
+	m.completions = candidates
+	return m
+}
+
+// canComplete reports whether Tab should complete the output directory rather
+// than queue the prompt: the typed path must not already be an existing
+// directory
+func (m Model) canComplete() bool {
+	value := normalizePath(m.textInput.Value())
+	if value == "" {
+		return false
+	}
+	info, err := os.Stat(value)
+	return err != nil || !info.IsDir()
+}
+
+// viewCompletions lists the candidates of an ambiguous completion
+func (m Model) viewCompletions() string {
+	if len(m.completions) == 0 {
+		return ""
+	}
+	return "\n" + promptStyle.Render(strings.Join(m.completions, "  "))
+}
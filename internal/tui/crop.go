@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
+)
+
+// cropChoices lists the crop anchors offered for each cropped axis
+var cropChoices = map[string][]string{
+	"x": {"left", "center", "right"},
+	"y": {"top", "center", "bottom"},
+}
+
+// significantCrop is the share of a reference image's width or height that
+// can be cropped away before the crop step warns about it
+const significantCrop = 0.1
+
+// askCropAnchor asks which part of the reference image to keep when its
+// aspect ratio does not match the selected size, and otherwise moves on to
+// the output directory
+func (m Model) askCropAnchor() Model {
+	if m.referenceImg == "" || m.skipReference {
+		return m.askOutputDir()
+	}
+	axis, fraction, err := api.ReferenceCrop(m.referenceImg, m.size)
+	if err != nil || axis == "" {
+		// Unreadable images are reported when the job is submitted
+		return m.askOutputDir()
+	}
+
+	m.cropAxis = axis
+	m.cropFraction = fraction
+	m.cropSelection = 0
+	for i, choice := range m.cropOptions() {
+		if choice == m.cropAnchor || (m.cropAnchor == "" && choice == "center") {
+			m.cropSelection = i
+		}
+	}
+	m.state = stateCropAnchor
+	m.message = ""
+	return m
+}
+
+// cropOptions lists the anchors for the cropped axis, plus a -crop-anchor
+// value that is not one of them
+func (m Model) cropOptions() []string {
+	choices := cropChoices[m.cropAxis]
+	if m.cropAnchor == "" {
+		return choices
+	}
+	for _, choice := range choices {
+		if choice == m.cropAnchor {
+			return choices
+		}
+	}
+	return append(append([]string{}, choices...), m.cropAnchor)
+}
+
+// askOutputDir moves on to the output directory input
+func (m Model) askOutputDir() Model {
+	m.state = stateOutputDir
+	m.textInput.SetValue(m.outputDir)
+	m.textInput.Placeholder = "Output directory..."
+	m.message = ""
+	return m
+}
+
+// updateCropAnchor handles keys while choosing the crop anchor
+func (m Model) updateCropAnchor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	options := m.cropOptions()
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyLeft:
+		m.cropSelection = (m.cropSelection - 1 + len(options)) % len(options)
+	case tea.KeyDown, tea.KeyRight:
+		m.cropSelection = (m.cropSelection + 1) % len(options)
+	case tea.KeyEnter:
+		m.cropAnchor = options[m.cropSelection]
+		return m.askOutputDir(), nil
+	case tea.KeyEsc:
+		return m.goBack(), nil
+	}
+	return m, nil
+}
+
+// viewCropAnchor lists the crop anchors for the cropped axis
+func (m Model) viewCropAnchor() string {
+	var sb strings.Builder
+	edges := "left and right"
+	if m.cropAxis == "y" {
+		edges = "top and bottom"
+	}
+	sb.WriteString(promptStyle.Render(fmt.Sprintf("The reference image is cropped at the %s to fit %s. Keep which part? (use arrow keys)", edges, m.size)))
+	sb.WriteString("\n")
+	if m.cropFraction >= significantCrop {
+		dimension := "width"
+		if m.cropAxis == "y" {
+			dimension = "height"
+		}
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("⚠ %.0f%% of the image's %s is cut away; go back and choose \"match\" for the closest size", m.cropFraction*100, dimension)))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	for i, choice := range m.cropOptions() {
+		if i == m.cropSelection {
+			sb.WriteString(successStyle.Render("▶ " + choice))
+		} else {
+			sb.WriteString(promptStyle.Render("  " + choice))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render("Press Enter to confirm, Esc to go back"))
+	return sb.String()
+}
@@ -0,0 +1,259 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/clipboard"
+)
+
+const (
+	// debugPaneEntries is how many of the latest API calls the debug pane scrolls through
+	debugPaneEntries = 50
+	// debugPaneChrome is the number of lines around the debug viewport
+	debugPaneChrome = 8
+)
+
+// debugKeyFree reports whether a plain d can open the debug pane on this
+// screen: not while typing, and not where d downloads a video
+func (m Model) debugKeyFree() bool {
+	switch m.state {
+	case stateAPIKey, statePrompt, stateReferenceImage, stateOutputDir, stateRemixPrompt, stateSetupOutputDir,
+		stateTemplateVars, stateContentPolicy, stateListVideos, stateVideoDetails:
+		return false
+	}
+	return true
+}
+
+// toggleDebugPane opens the debug pane scrolled to the latest call, or closes it
+func (m Model) toggleDebugPane() Model {
+	m.debugOpen = !m.debugOpen
+	m.debugSearching = false
+	m.debugMessage = ""
+	if m.debugOpen {
+		m.debugView = viewport.New(m.debugPaneSize())
+		m.debugView.SetContent(m.debugContent())
+		m.debugView.GotoBottom()
+		m.debugFollow = true
+	}
+	return m
+}
+
+// debugPaneSize returns the viewport size for the terminal, with defaults
+// until the first window size message arrives
+func (m Model) debugPaneSize() (int, int) {
+	width, height := m.width, m.height-debugPaneChrome
+	if width <= 0 {
+		width = 100
+	}
+	if m.height <= 0 {
+		height = 20
+	}
+	if height < 5 {
+		height = 5
+	}
+	return width, height
+}
+
+// updateDebugPane handles keys while the debug pane is open: scrolling,
+// searching with / (n and N for the next and previous match), c to copy the
+// latest call, and d, Ctrl+G, or Esc to close
+func (m Model) updateDebugPane(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.debugView.SetContent(m.debugContent())
+
+	if m.debugSearching {
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.debugSearching = false
+			m.debugQuery = strings.TrimSpace(m.debugSearch.Value())
+			m.debugMatch = m.debugView.YOffset - 1
+			return m.nextDebugMatch(1), nil
+		case tea.KeyEsc:
+			m.debugSearching = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.debugSearch, cmd = m.debugSearch.Update(msg)
+		return m, cmd
+	}
+
+	m.debugMessage = ""
+	switch {
+	case msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlG || msg.String() == "d":
+		return m.toggleDebugPane(), nil
+	case msg.String() == "/":
+		m.debugSearching = true
+		m.debugSearch = textinput.New()
+		m.debugSearch.Prompt = "/"
+		m.debugSearch.SetValue(m.debugQuery)
+		m.debugSearch.Focus()
+		return m, textinput.Blink
+	case msg.String() == "n":
+		return m.nextDebugMatch(1), nil
+	case msg.String() == "N":
+		return m.nextDebugMatch(-1), nil
+	case msg.String() == "c":
+		return m.copyLastCall(), nil
+	}
+
+	var cmd tea.Cmd
+	m.debugView, cmd = m.debugView.Update(msg)
+	m.debugFollow = m.debugView.AtBottom()
+	return m, cmd
+}
+
+// nextDebugMatch scrolls to the next line containing the search query in
+// the given direction, wrapping around at either end
+func (m Model) nextDebugMatch(direction int) Model {
+	if m.debugQuery == "" {
+		return m
+	}
+	lines := m.debugLines()
+	query := strings.ToLower(m.debugQuery)
+	for i := 1; i <= len(lines); i++ {
+		line := ((m.debugMatch+direction*i)%len(lines) + len(lines)) % len(lines)
+		if strings.Contains(strings.ToLower(lines[line]), query) {
+			m.debugMatch = line
+			m.debugView.SetYOffset(line)
+			m.debugFollow = m.debugView.AtBottom()
+			return m
+		}
+	}
+	m.debugMessage = fmt.Sprintf("No match for %q", m.debugQuery)
+	return m
+}
+
+// copyLastCall puts the latest call, request and response, on the clipboard
+// as JSON with credentials already redacted
+func (m Model) copyLastCall() Model {
+	entries := m.debugLog.Recent(1)
+	if len(entries) == 0 {
+		m.debugMessage = "No API calls yet"
+		return m
+	}
+	data, err := json.MarshalIndent(entries[0], "", "  ")
+	if err != nil {
+		m.debugMessage = "Could not copy the call: " + err.Error()
+		return m
+	}
+	if err := clipboard.CopyText(string(data)); err != nil {
+		m.debugMessage = "Could not copy the call: " + err.Error()
+		return m
+	}
+	m.debugMessage = fmt.Sprintf("Copied %s %s to the clipboard", entries[0].Method, entries[0].URL)
+	return m
+}
+
+// debugLines renders the latest API calls as plain lines wrapped to the
+// pane: the request, its body, the outcome with its timing, and the response
+// body. Wrapping here keeps search matches on the lines the viewport shows.
+func (m Model) debugLines() []string {
+	var text []string
+	for _, entry := range m.debugLog.Recent(debugPaneEntries) {
+		text = append(text, fmt.Sprintf("→ %s %s", entry.Method, entry.URL))
+		if len(entry.RequestBody) > 0 {
+			text = append(text, strings.Split(indentJSON(entry.RequestBody), "\n")...)
+		}
+		text = append(text, "← "+debugOutcome(entry))
+		if len(entry.ResponseBody) > 0 {
+			text = append(text, strings.Split(indentJSON(entry.ResponseBody), "\n")...)
+		}
+		text = append(text, "")
+	}
+
+	width, _ := m.debugPaneSize()
+	var lines []string
+	for _, line := range text {
+		runes := []rune(line)
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+// debugContent styles the debug lines for the viewport, highlighting the
+// current search match
+func (m Model) debugContent() string {
+	lines := m.debugLines()
+	styled := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case m.debugQuery != "" && i == m.debugMatch:
+			styled[i] = warningStyle.Render(line)
+		case strings.HasPrefix(line, "→ "):
+			styled[i] = debugRequestStyle.Render(line)
+		case strings.HasPrefix(line, "← "):
+			styled[i] = debugResponseStyle.Render(line)
+		default:
+			styled[i] = debugJSONStyle.Render(line)
+		}
+	}
+	return strings.Join(styled, "\n")
+}
+
+// viewDebugPane shows the scrollable debug log, following new calls while
+// scrolled to the bottom
+func (m Model) viewDebugPane() string {
+	view := m.debugView
+	view.SetContent(m.debugContent())
+	if m.debugFollow {
+		view.GotoBottom()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(debugRequestStyle.Render(fmt.Sprintf("DEBUG LOG (last %d API calls)", len(m.debugLog.Recent(debugPaneEntries)))))
+	sb.WriteString("\n")
+	sb.WriteString(strings.Repeat("─", view.Width))
+	sb.WriteString("\n")
+	sb.WriteString(view.View())
+	sb.WriteString("\n")
+	sb.WriteString(strings.Repeat("─", view.Width))
+	sb.WriteString("\n")
+	if m.debugSearching {
+		sb.WriteString(m.debugSearch.View())
+	} else if m.debugMessage != "" {
+		sb.WriteString(infoStyle.Render(m.debugMessage))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render("↑/↓ PgUp/PgDn scroll · / search · n/N next match · c copy last call · d/Esc close"))
+	return sb.String()
+}
+
+// viewDebugHint points to the debug pane while it is closed
+func (m Model) viewDebugHint() string {
+	calls := len(m.debugLog.Recent(debugPaneEntries))
+	if calls == 0 {
+		return ""
+	}
+	key := "Ctrl+G"
+	if m.debugKeyFree() {
+		key = "d"
+	}
+	return "\n\n" + debugRequestStyle.Render(fmt.Sprintf("DEBUG: %d API calls logged, press %s to view", calls, key))
+}
+
+// debugOutcome summarizes the response to a logged call, or its error
+func debugOutcome(entry api.DebugEntry) string {
+	if entry.Error != "" {
+		return fmt.Sprintf("%s (%s)", entry.Error, entry.Timing)
+	}
+	return fmt.Sprintf("%d (%s)", entry.Status, entry.Timing)
+}
+
+// indentJSON pretty-prints a logged body
+func indentJSON(body json.RawMessage) string {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return string(body)
+	}
+	return indented.String()
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/prompt"
+)
+
+// promptEnhancedMsg carries the chat model's rewrite of the prompt, or the error
+type promptEnhancedMsg struct {
+	original string
+	enhanced string
+	err      error
+}
+
+// enhancePrompt sends the typed prompt to the chat model for a more detailed
+// rewrite. Variables and wildcards are resolved first so the model sees the
+// actual prompt; the style and negative prompt are still added on submission.
+func (m Model) enhancePrompt() (tea.Model, tea.Cmd) {
+	value := strings.TrimSpace(m.textInput.Value())
+	if value == "" {
+		m.message = "Type a prompt to enhance"
+		return m, nil
+	}
+	text, err := prompt.Render(value, m.vars)
+	if err == nil {
+		text, err = prompt.Expand(text, m.wildcardsDir)
+	}
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+
+	m.message = ""
+	m.state = stateEnhancing
+	client := m.client
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		enhanced, err := client.EnhancePrompt(text)
+		return promptEnhancedMsg{original: value, enhanced: enhanced, err: err}
+	})
+}
+
+// updateEnhanceReview handles keys while the enhanced prompt awaits approval:
+// Enter puts it in the prompt input for a final edit, Esc keeps the original
+func (m Model) updateEnhanceReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.state = statePrompt
+		m.textInput.SetValue(m.enhancedPrompt)
+		m.textInput.CursorEnd()
+	case tea.KeyEsc:
+		m.state = statePrompt
+		m.textInput.SetValue(m.promptDraft)
+		m.textInput.CursorEnd()
+	}
+	return m, nil
+}
+
+// viewEnhanceReview shows the original and enhanced prompts side by side for approval
+func (m Model) viewEnhanceReview() string {
+	var sb strings.Builder
+	sb.WriteString(promptStyle.Render("Original:"))
+	sb.WriteString("\n")
+	sb.WriteString(m.promptDraft)
+	sb.WriteString("\n\n")
+	sb.WriteString(successStyle.Render("Enhanced:"))
+	sb.WriteString("\n")
+	sb.WriteString(infoStyle.Render(m.enhancedPrompt))
+	sb.WriteString("\n\n")
+	sb.WriteString(promptStyle.Render("Press Enter to use the enhanced prompt, Esc to keep the original"))
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/filepicker"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filePickerHeight is the number of entries the reference file picker shows at once
+const filePickerHeight = 12
+
+// referenceTypes are the extensions the reference file picker can select
+var referenceTypes = []string{".jpg", ".jpeg", ".png", ".gif", ".mp4", ".mov"}
+
+// openFilePicker browses for a reference image or video, starting in the
+// directory of the typed path or the home directory
+func (m Model) openFilePicker() (tea.Model, tea.Cmd) {
+	fp := filepicker.New()
+	for _, ext := range referenceTypes {
+		fp.AllowedTypes = append(fp.AllowedTypes, ext, strings.ToUpper(ext))
+	}
+	fp.AutoHeight = false
+	fp.Height = filePickerHeight
+	fp.ShowPermissions = false
+	fp.CurrentDirectory = pickerDirectory(m.textInput.Value())
+
+	m.filePicker = fp
+	m.message = ""
+	m.state = stateReferencePicker
+	return m, fp.Init()
+}
+
+// pickerDirectory returns the directory to start browsing from for a typed
+// path: the path itself when it is a directory, its parent when that exists,
+// and the home directory otherwise
+func pickerDirectory(path string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	path = normalizePath(path)
+	if path == "" {
+		return homeDir
+	}
+
+	// Stat follows symlinks, so a linked directory opens as a directory
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err == nil && info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return homeDir
+}
+
+// updateFilePicker handles keys and directory listings in the file picker.
+// Choosing a file fills it into the reference input for confirmation.
+func (m Model) updateFilePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEsc {
+		m.state = stateReferenceImage
+		m.message = ""
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.filePicker, cmd = m.filePicker.Update(msg)
+
+	if ok, path := m.filePicker.DidSelectFile(msg); ok {
+		m.state = stateReferenceImage
+		m.textInput.SetValue(path)
+		m.textInput.CursorEnd()
+		m.message = ""
+		return m, nil
+	}
+	if ok, path := m.filePicker.DidSelectDisabledFile(msg); ok {
+		m.message = filepath.Base(path) + " is not an image or video (" + strings.Join(referenceTypes, ", ") + ")"
+		return m, cmd
+	}
+	return m, cmd
+}
+
+// viewFilePicker shows the directory being browsed and its entries
+func (m Model) viewFilePicker() string {
+	var sb strings.Builder
+	sb.WriteString(promptStyle.Render("Select a reference image or video:"))
+	sb.WriteString("\n")
+	sb.WriteString(infoStyle.Render(m.filePicker.CurrentDirectory))
+	sb.WriteString("\n\n")
+	sb.WriteString(m.filePicker.View())
+	if m.message != "" {
+		sb.WriteString("\n")
+		sb.WriteString(errorStyle.Render(m.message))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(promptStyle.Render("↑/↓ to move, → or Enter to open, ← to go up, Enter to select, Esc to type a path"))
+	return sb.String()
+}
@@ -0,0 +1,298 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/engine"
+	"github.com/telemetry/video-gen/internal/history"
+	"github.com/telemetry/video-gen/internal/hook"
+	"github.com/telemetry/video-gen/internal/manifest"
+	"github.com/telemetry/video-gen/internal/naming"
+)
+
+// startJobMsg asks for a job to be run with the engine
+type startJobMsg struct {
+	job engine.Job
+}
+
+// jobEventMsg carries an event of the running job. events identifies the run,
+// so events still in flight for a cancelled job are dropped.
+type jobEventMsg struct {
+	events <-chan engine.Event
+	event  engine.Event
+}
+
+// errModerationBlocked marks prompts refused by the -strict pre-flight check
+var errModerationBlocked = errors.New("prompt blocked by pre-flight moderation")
+
+// newEngine returns an engine for the TUI's client and settings
+func (m Model) newEngine() *engine.Engine {
+	return &engine.Engine{Client: m.client, Poll: m.poll, KeepRemote: m.keepRemote}
+}
+
+// nextEvent delivers the next event of a run, and nothing once it has ended
+func nextEvent(events <-chan engine.Event) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return jobEventMsg{events: events, event: event}
+	}
+}
+
+// moderate runs the -strict pre-flight check on a new prompt, returning
+// errModerationBlocked when it is flagged. Checks that fail are let through,
+// since Sora still moderates on submission.
+func (m Model) moderate(job engine.Job) error {
+	if !m.strict || !m.moderation || job.VideoID != "" || job.RemixOf != "" {
+		return nil
+	}
+	result, err := m.client.ModeratePrompt(job.Request.Prompt)
+	if err == nil && result.Flagged {
+		return fmt.Errorf("%w (%s)", errModerationBlocked, result)
+	}
+	return nil
+}
+
+// startJob runs a job with the engine, saving the video to the output
+// directory. A job with a VideoID is picked up where it is.
+func (m Model) startJob(job engine.Job) (Model, tea.Cmd) {
+	reserved, err := m.reserveBudget(job)
+	if err != nil {
+		m.err = err
+		m.state = stateError
+		return m, nil
+	}
+	m.reserved = reserved
+	m.jobRequest, m.jobStarted = job.Request, time.Now()
+
+	ctx, stop := context.WithCancel(context.Background())
+	events := make(chan engine.Event)
+	m.events, m.stopJob = events, stop
+
+	job.Output = m.saveAs(m.outputDir, fmt.Sprintf("sora_video_%s.mp4", time.Now().Format("20060102_150405")), job.Request, 0, 0)
+
+	run := func() tea.Msg {
+		if err := m.moderate(job); err != nil {
+			stop()
+			m.budget.Release(reserved)
+			return contentPolicyMsg{err: err}
+		}
+		go m.newEngine().Run(ctx, job, events)
+		return nextEvent(events)()
+	}
+
+	if job.VideoID == "" {
+		m.state = stateGenerating
+		return m, run
+	}
+	m = m.startPolling(job.VideoID)
+	return m, tea.Batch(run, tick())
+}
+
+// saveAs returns the job Output function saving a video in dir under the
+// name template rendered for req, or defaultName without a template, with a
+// -2 style suffix when another video already has that name. index numbers
+// queued jobs and is 0 otherwise; take adds a _vN suffix to takes of a prompt.
+func (m Model) saveAs(dir, defaultName string, req api.CreateVideoRequest, index, take int) func(videoID string) string {
+	template := m.nameTemplate
+	job := naming.Job{
+		Provider: m.client.Name(),
+		Prompt:   req.Prompt,
+		Model:    req.Model,
+		Size:     req.Size,
+		Seconds:  req.Seconds,
+		Index:    index,
+	}
+	return func(videoID string) string {
+		// The template was checked when the TUI started
+		name, err := naming.Render(template, defaultName, job)
+		if err != nil {
+			name = defaultName
+		}
+		if take > 0 {
+			name = naming.Variation(name, take)
+		}
+		return naming.Unique(filepath.Join(dir, naming.FillID(name, videoID)))
+	}
+}
+
+// startPolling shows a job as rendering from its first status check on
+func (m Model) startPolling(videoID string) Model {
+	m.videoID = videoID
+	m.state = statePolling
+	m.message = ""
+	m.pollAttempts = 0
+	m.elapsedSeconds = 0
+	m.progress = 0
+	m.videoStatus = ""
+	m.typicalDuration = m.estimateDuration(m.model, m.size, m.duration)
+	return m
+}
+
+// stopRun stops the engine running the current job and drops its remaining events
+func (m Model) stopRun() Model {
+	if m.stopJob != nil {
+		m.stopJob()
+	}
+	m.events, m.stopJob = nil, nil
+	return m
+}
+
+// updateJob applies an event of the running job
+func (m Model) updateJob(event engine.Event) (tea.Model, tea.Cmd) {
+	next := nextEvent(m.events)
+
+	switch event := event.(type) {
+	case engine.Created:
+		m.recordCreated(event.VideoID, event.Status, api.CreateVideoRequest{
+			Prompt:   m.prompt,
+			Model:    m.model,
+			Size:     m.size,
+			Seconds:  m.duration,
+			Negative: m.negative,
+			Parent:   m.parentJob,
+		}, m.remixID)
+		m = m.startPolling(event.VideoID)
+		m.createdJob = event.VideoID
+		return m, tea.Batch(next, tick())
+
+	case engine.Polled:
+		m.pollAttempts = event.Attempt
+		m.progress = event.Response.Progress
+		m.videoStatus = event.Response.Status
+		if event.Response.Status == "completed" {
+			m.recordCompleted(event.Response)
+			if m.createdJob == event.VideoID {
+				req := api.CreateVideoRequest{Model: m.model, Size: m.size, Seconds: m.duration}
+				if m.remixID != "" {
+					req = api.CreateVideoRequest{} // A remix keeps its source's settings
+				}
+				m = m.countCost(req, event.Response)
+			}
+			m.state = stateDownloading
+			m.downloadPercent = -1
+		}
+
+	case engine.RetryingStatus:
+		// The check still counts toward the poll limit shown
+		m.pollAttempts = event.Attempt
+
+	case engine.Downloading:
+		m.downloadPercent = float64(event.Written) / float64(event.Total)
+
+	case engine.Deleted:
+		warnDeleteFailed(event.Err)
+
+	case engine.Done:
+		m = m.stopRun()
+		if err := m.jobFailed(event); err != nil {
+			m.releaseBudget(m.reserved, event)
+			if api.IsContentPolicy(event.Err) || (event.Response != nil && api.IsContentPolicyError(event.Response.Error)) {
+				return m.Update(contentPolicyMsg{err: err})
+			}
+			return m.Update(errorMsg{err: err})
+		}
+		m.recordSaved(event.VideoID, event.Path)
+		return m, m.finishVideo(event.Path, event.Response)
+	}
+	return m, next
+}
+
+// jobFailed returns the error a job ended with, recording jobs the service
+// failed in the ledger
+func (m Model) jobFailed(done engine.Done) error {
+	if done.Err == nil {
+		return nil
+	}
+	if done.Response != nil && done.Response.Status == "failed" {
+		m.record(done.VideoID, func(e *history.Entry) {
+			e.Provider = m.client.Name()
+			e.Status = "failed"
+			e.Error = done.Err.Error()
+		})
+	}
+	return done.Err
+}
+
+// countCost adds a render this session created to the session's video count
+// and estimated cost
+func (m Model) countCost(req api.CreateVideoRequest, resp *api.VideoResponse) Model {
+	if cost, ok := api.EstimateJobCost(req, resp); ok {
+		m.sessionCost += cost
+	}
+	m.sessionVideos++
+	return m
+}
+
+// finishVideo writes the manifest of a saved video and runs the on_complete hook
+func (m Model) finishVideo(path string, resp *api.VideoResponse) tea.Cmd {
+	vars := hook.Vars{
+		Path:     path,
+		ID:       m.videoID,
+		Prompt:   m.prompt,
+		Model:    m.model,
+		Provider: m.client.Name(),
+		Size:     m.size,
+		Duration: m.duration,
+	}
+	return func() tea.Msg {
+		manifestErr := m.writeManifest(path, m.jobRequest, resp, m.jobStarted)
+		return videoDownloadedMsg{path: path, finishErr: errors.Join(manifestErr, m.runHook(vars))}
+	}
+}
+
+// writeManifest saves the JSON manifest of a video when sidecar_manifest is set
+func (m Model) writeManifest(path string, req api.CreateVideoRequest, resp *api.VideoResponse, started time.Time) error {
+	if !m.sidecar {
+		return nil
+	}
+	_, err := manifest.Write(path, m.client.Name(), req, resp, started)
+	return err
+}
+
+// recordCreated records a submitted job in the ledger
+func (m Model) recordCreated(videoID, status string, req api.CreateVideoRequest, remixOf string) {
+	m.record(videoID, func(e *history.Entry) {
+		e.Provider = m.client.Name()
+		e.Prompt = req.Prompt
+		e.NegativePrompt = req.Negative
+		e.RemixOf = remixOf
+		e.Parent = req.Parent
+		// A remix keeps its source's settings, which the TUI does not know
+		if remixOf == "" {
+			e.Model = req.Model
+			e.Size = req.Size
+			e.Duration = req.Seconds
+		}
+		e.Status = status
+	})
+}
+
+// recordSaved records a downloaded video in the ledger
+func (m Model) recordSaved(videoID, path string) {
+	m.record(videoID, func(e *history.Entry) {
+		e.Provider = m.client.Name()
+		e.Status = "downloaded"
+		e.OutputPath = path
+		e.KeptRemote = m.keepRemote
+		// The checksum is left out if the file cannot be read back
+		e.SHA256, e.Bytes, _ = history.Checksum(path)
+	})
+}
+
+// warnDeleteFailed reports a saved video that could not be deleted from the
+// service. It is only a warning, since the user has the file.
+func warnDeleteFailed(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to delete video from service: %v\n", err)
+	}
+}
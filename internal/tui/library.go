@@ -0,0 +1,485 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/clipboard"
+	"github.com/telemetry/video-gen/internal/engine"
+	"github.com/telemetry/video-gen/internal/history"
+)
+
+// libraryPageSize is the number of videos fetched per page of the library
+const libraryPageSize = 20
+
+// videoItem is a remote video shown in the library
+type videoItem struct {
+	video    api.VideoResponse
+	selected bool // Marked with space for deletion
+}
+
+func (i videoItem) Title() string {
+	mark := "[ ]"
+	if i.selected {
+		mark = "[x]"
+	}
+	return fmt.Sprintf("%s %s  %s", mark, i.video.ID, i.video.Status)
+}
+
+func (i videoItem) Description() string {
+	parts := []string{i.video.Model, i.video.Size}
+	if i.video.Seconds != "" {
+		parts = append(parts, i.video.Seconds+"s")
+	}
+	parts = append(parts, time.Unix(i.video.CreatedAt, 0).Format("Jan 2, 15:04"))
+	if i.video.Prompt != "" {
+		parts = append(parts, i.video.Prompt)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (i videoItem) FilterValue() string {
+	return i.video.ID + " " + i.video.Status + " " + i.video.Prompt
+}
+
+// libraryKeyMap holds the per-video actions of the library
+type libraryKeyMap struct {
+	toggle   key.Binding
+	details  key.Binding
+	download key.Binding
+	remix    key.Binding
+	delete   key.Binding
+	newVideo key.Binding
+}
+
+var libraryKeys = libraryKeyMap{
+	toggle:   key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "select")),
+	details:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "details")),
+	download: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "download/resume selected")),
+	remix:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "remix")),
+	delete:   key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "delete selected")),
+	newVideo: key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "new video")),
+}
+
+func (k libraryKeyMap) bindings() []key.Binding {
+	return []key.Binding{k.toggle, k.details, k.download, k.remix, k.delete, k.newVideo}
+}
+
+// newLibrary returns the list used to browse remote videos
+func newLibrary() list.Model {
+	l := list.New(nil, list.NewDefaultDelegate(), 80, 20)
+	l.Title = "Video library"
+	l.SetStatusBarItemName("video", "videos")
+	// Esc and q are handled by the model so quitting works the same on every screen
+	l.DisableQuitKeybindings()
+	// d downloads the selected video rather than paging
+	l.KeyMap.NextPage.SetKeys("right", "l", "pgdown", "f")
+	l.AdditionalShortHelpKeys = libraryKeys.bindings
+	l.AdditionalFullHelpKeys = libraryKeys.bindings
+	return l
+}
+
+// libraryHeight leaves room for the title and footer around the list
+func libraryHeight(windowHeight int) int {
+	if windowHeight-6 < 8 {
+		return 8
+	}
+	return windowHeight - 6
+}
+
+// listVideos fetches the page of the library after the video with ID after
+// (the first page when empty). Prompts missing from the API response are
+// filled in from the history ledger.
+func (m Model) listVideos(after string) tea.Cmd {
+	return func() tea.Msg {
+		resp, err := m.client.ListVideosAfter(libraryPageSize, after)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+
+		prompts := ledgerPrompts()
+		for i := range resp.Data {
+			if resp.Data[i].Prompt == "" {
+				resp.Data[i].Prompt = prompts[resp.Data[i].ID]
+			}
+		}
+
+		lastID := resp.LastID
+		if lastID == "" && len(resp.Data) > 0 {
+			lastID = resp.Data[len(resp.Data)-1].ID
+		}
+		return videosListedMsg{videos: resp.Data, after: after, lastID: lastID, hasMore: resp.HasMore}
+	}
+}
+
+// ledgerPrompts maps recorded job IDs to their prompts
+func ledgerPrompts() map[string]string {
+	prompts := make(map[string]string)
+	entries, err := history.Load()
+	if err != nil {
+		return prompts
+	}
+	for _, entry := range entries {
+		prompts[entry.ID] = entry.Prompt
+	}
+	return prompts
+}
+
+// deleteVideos deletes videos from the service, reporting the ones that were deleted
+func (m Model) deleteVideos(ids []string) tea.Cmd {
+	return func() tea.Msg {
+		var deleted []string
+		for _, id := range ids {
+			if err := m.client.DeleteVideo(id); err == nil {
+				deleted = append(deleted, id)
+			}
+		}
+		return videosDeletedMsg{ids: deleted, failed: len(ids) - len(deleted)}
+	}
+}
+
+// updateLibrary handles keys on the library screen
+func (m Model) updateLibrary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+
+	// While a filter is being typed every key belongs to the list
+	if m.library.SettingFilter() {
+		return m.updateLibraryList(msg)
+	}
+	if msg.Type == tea.KeyEsc {
+		if m.library.IsFiltered() {
+			return m.updateLibraryList(msg)
+		}
+		// Clear a selection before quitting
+		if len(m.selectedVideos()) > 0 {
+			return m, m.setSelected(func(videoItem) bool { return false })
+		}
+		return m, tea.Quit
+	}
+	if !m.libraryLoaded {
+		return m, nil
+	}
+
+	item, ok := m.library.SelectedItem().(videoItem)
+	switch {
+	case key.Matches(msg, libraryKeys.newVideo), !ok && key.Matches(msg, libraryKeys.details):
+		return m.startPrompt(), nil
+	case !ok:
+		return m.updateLibraryList(msg)
+	case key.Matches(msg, libraryKeys.details):
+		m.detailVideo = item.video
+		m.detailEntry, _ = history.Find(item.video.ID)
+		m.state = stateVideoDetails
+		return m, nil
+	case key.Matches(msg, libraryKeys.toggle):
+		id := item.video.ID
+		cmd := m.setSelected(func(i videoItem) bool {
+			if i.video.ID == id {
+				return !i.selected
+			}
+			return i.selected
+		})
+		// Move on so a run of videos can be selected by holding space
+		model, listCmd := m.updateLibraryList(tea.KeyMsg{Type: tea.KeyDown})
+		return model, tea.Batch(cmd, listCmd)
+	case key.Matches(msg, libraryKeys.delete):
+		// Delete the selection, or the highlighted video when nothing is selected
+		if selected := m.selectedVideos(); len(selected) > 0 {
+			m.deleteIDs = selected
+			m.state = stateConfirmDelete
+			return m, nil
+		}
+	case key.Matches(msg, libraryKeys.download):
+		// Download or resume the whole selection side by side on the dashboard
+		if selected := m.selectedVideos(); len(selected) > 0 {
+			var videos []api.VideoResponse
+			for _, id := range selected {
+				video, _ := m.libraryVideo(id)
+				videos = append(videos, video)
+			}
+			return m.watchVideos(videos)
+		}
+	}
+	if model, cmd, ok := m.videoAction(msg, item.video); ok {
+		return model, cmd
+	}
+	return m.updateLibraryList(msg)
+}
+
+// updateLibraryList passes a message to the list, fetching the next page once
+// the cursor reaches the last loaded video
+func (m Model) updateLibraryList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.library, cmd = m.library.Update(msg)
+
+	if m.libraryMore && !m.libraryLoading && !m.library.IsFiltered() && m.library.Index() >= len(m.library.Items())-1 {
+		m.libraryLoading = true
+		return m, tea.Batch(cmd, m.library.StartSpinner(), m.listVideos(m.libraryLastID))
+	}
+	return m, cmd
+}
+
+// updateVideoDetails handles keys on the details screen
+func (m Model) updateVideoDetails(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	if msg.Type == tea.KeyEsc || msg.Type == tea.KeyBackspace {
+		m.state = stateListVideos
+		return m, nil
+	}
+	if msg.String() == "c" {
+		if err := clipboard.CopyText(m.detailVideo.ID); err != nil {
+			m.message = "Could not copy the ID: " + err.Error()
+		} else {
+			m.message = "Copied " + m.detailVideo.ID + " to the clipboard"
+		}
+		return m, nil
+	}
+	if model, cmd, ok := m.videoAction(msg, m.detailVideo); ok {
+		return model, cmd
+	}
+	return m, nil
+}
+
+// videoAction runs the download, remix, or delete action bound to msg, if any
+func (m Model) videoAction(msg tea.KeyMsg, video api.VideoResponse) (tea.Model, tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, libraryKeys.download):
+		if video.Status == "failed" {
+			m.message = "Failed videos have nothing to download"
+			return m, nil, true
+		}
+		// Finished videos are downloaded; unfinished ones are picked up
+		// where polling left off
+		job := engine.Job{VideoID: video.ID}
+		return m, func() tea.Msg {
+			return startJobMsg{job: job}
+		}, true
+
+	case key.Matches(msg, libraryKeys.remix):
+		if video.Status != "completed" {
+			m.message = "Only completed videos can be remixed"
+			return m, nil, true
+		}
+		m.remixID = video.ID
+		m.state = stateRemixPrompt
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "Describe the change (e.g. make it night time)..."
+		m.textInput.Focus()
+		return m, nil, true
+
+	case key.Matches(msg, libraryKeys.delete):
+		m.deleteIDs = []string{video.ID}
+		m.state = stateConfirmDelete
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// updateConfirmDelete handles the yes/no answer to a delete confirmation
+func (m Model) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch strings.ToLower(msg.String()) {
+	case "y":
+		m.state = stateDeletingVideos
+		return m, tea.Batch(m.deleteVideos(m.deleteIDs), m.spinner.Tick)
+	case "n", "esc":
+		m.deleteIDs = nil
+		m.state = stateListVideos
+	}
+	return m, nil
+}
+
+// selectedVideos returns the IDs of the videos marked for deletion, in list order
+func (m Model) selectedVideos() []string {
+	var ids []string
+	for _, item := range m.library.Items() {
+		if video := item.(videoItem); video.selected {
+			ids = append(ids, video.video.ID)
+		}
+	}
+	return ids
+}
+
+// setSelected marks each video as selected or not according to selected,
+// and shows the selection count in the list title
+func (m *Model) setSelected(selected func(videoItem) bool) tea.Cmd {
+	items := m.library.Items()
+	updated := make([]list.Item, len(items))
+	count := 0
+	for i, item := range items {
+		video := item.(videoItem)
+		video.selected = selected(video)
+		if video.selected {
+			count++
+		}
+		updated[i] = video
+	}
+
+	m.library.Title = "Video library"
+	if count > 0 {
+		m.library.Title = fmt.Sprintf("Video library (%d selected)", count)
+	}
+	return m.library.SetItems(updated)
+}
+
+// libraryVideo returns a loaded video by ID
+func (m Model) libraryVideo(id string) (api.VideoResponse, bool) {
+	for _, item := range m.library.Items() {
+		if video := item.(videoItem).video; video.ID == id {
+			return video, true
+		}
+	}
+	return api.VideoResponse{}, false
+}
+
+// removeFromLibrary drops deleted videos from the list
+func (m *Model) removeFromLibrary(ids []string) tea.Cmd {
+	deleted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	var items []list.Item
+	for _, item := range m.library.Items() {
+		if !deleted[item.(videoItem).video.ID] {
+			items = append(items, item)
+		}
+	}
+	cmd := m.library.SetItems(items)
+	return tea.Batch(cmd, m.setSelected(func(i videoItem) bool { return i.selected }))
+}
+
+// viewLibrary renders the library screen
+func (m Model) viewLibrary() string {
+	if !m.libraryLoaded {
+		return fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render("Loading videos..."))
+	}
+	view := m.library.View()
+	if m.message != "" {
+		view += "\n" + warningStyle.Render(m.message)
+	}
+	return view
+}
+
+// viewVideoDetails renders everything known about the selected video: the
+// service's job record, its remix lineage, and where the ledger says it was saved
+func (m Model) viewVideoDetails() string {
+	var sb strings.Builder
+	video := m.detailVideo
+
+	sb.WriteString(promptStyle.Render("Video details:"))
+	sb.WriteString("\n\n")
+
+	fields := [][2]string{
+		{"ID", video.ID},
+		{"Status", video.Status},
+		{"Model", video.Model},
+		{"Size", video.Size},
+	}
+	if video.Seconds != "" {
+		fields = append(fields, [2]string{"Duration", video.Seconds + "s"})
+	}
+	if video.Status != "completed" && video.Status != "failed" {
+		fields = append(fields, [2]string{"Progress", fmt.Sprintf("%d%%", video.Progress)})
+	}
+	fields = append(fields, [2]string{"Created", formatTimestamp(video.CreatedAt)})
+	if video.CompletedAt > 0 {
+		took := time.Duration(video.CompletedAt-video.CreatedAt) * time.Second
+		fields = append(fields, [2]string{"Completed", fmt.Sprintf("%s (took %s)", formatTimestamp(video.CompletedAt), took)})
+	}
+	if video.ExpiresAt > 0 {
+		expires := formatTimestamp(video.ExpiresAt)
+		if left := time.Until(time.Unix(video.ExpiresAt, 0)); left > 0 {
+			expires += fmt.Sprintf(" (in %s)", left.Round(time.Minute))
+		} else {
+			expires += " (expired)"
+		}
+		fields = append(fields, [2]string{"Expires", expires})
+	}
+	fields = append(fields,
+		[2]string{"Remix of", video.RemixedFromVideoID},
+		[2]string{"Remixes", strings.Join(m.remixesOf(video.ID), ", ")},
+	)
+	if m.detailEntry != nil {
+		fields = append(fields, [2]string{"Saved to", m.detailEntry.OutputPath})
+	}
+	fields = append(fields, [2]string{"Prompt", video.Prompt})
+
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s %s\n", promptStyle.Render(fmt.Sprintf("%-10s", field[0]+":")), infoStyle.Render(field[1])))
+	}
+
+	if video.Error != nil {
+		sb.WriteString("\n")
+		detail := video.Error.Message
+		if video.Error.Code != "" {
+			detail = fmt.Sprintf("[%s] %s", video.Error.Code, detail)
+		}
+		sb.WriteString(errorStyle.Render("Error: " + detail))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render("c copy ID · d download/resume · r remix · x delete · esc back"))
+	if m.message != "" {
+		sb.WriteString("\n")
+		sb.WriteString(warningStyle.Render(m.message))
+	}
+	return sb.String()
+}
+
+// remixesOf returns the loaded videos remixed from the video with the given ID
+func (m Model) remixesOf(id string) []string {
+	var remixes []string
+	for _, item := range m.library.Items() {
+		if video := item.(videoItem).video; video.RemixedFromVideoID == id {
+			remixes = append(remixes, video.ID)
+		}
+	}
+	return remixes
+}
+
+// formatTimestamp renders a Unix timestamp from the API in local time
+func formatTimestamp(unix int64) string {
+	return time.Unix(unix, 0).Format("Jan 2 2006, 15:04:05")
+}
+
+// viewConfirmDelete renders the delete confirmation with counts by status,
+// calling out jobs that are still rendering
+func (m Model) viewConfirmDelete() string {
+	var sb strings.Builder
+	if len(m.deleteIDs) == 1 {
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("Delete %s from the service?", m.deleteIDs[0])))
+	} else {
+		sb.WriteString(warningStyle.Render(fmt.Sprintf("Delete %d videos from the service?", len(m.deleteIDs))))
+	}
+	sb.WriteString("\n\n")
+
+	counts := make(map[string]int)
+	var statuses []string
+	for _, id := range m.deleteIDs {
+		video, _ := m.libraryVideo(id)
+		if counts[video.Status] == 0 {
+			statuses = append(statuses, video.Status)
+		}
+		counts[video.Status]++
+	}
+	for _, status := range statuses {
+		line := fmt.Sprintf("  %d %s", counts[status], status)
+		if status == "queued" || status == "in_progress" {
+			sb.WriteString(errorStyle.Render(line + " (still rendering, possibly started elsewhere)"))
+		} else {
+			sb.WriteString(promptStyle.Render(line))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render("This cannot be undone. Press y to delete or n to cancel"))
+	return sb.String()
+}
@@ -1,20 +1,40 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/filepicker"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/budget"
+	"github.com/telemetry/video-gen/internal/clipboard"
 	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/engine"
+	"github.com/telemetry/video-gen/internal/history"
+	"github.com/telemetry/video-gen/internal/hook"
+	"github.com/telemetry/video-gen/internal/naming"
+	"github.com/telemetry/video-gen/internal/poll"
+	"github.com/telemetry/video-gen/internal/prompt"
+	"github.com/telemetry/video-gen/internal/queue"
+	"github.com/telemetry/video-gen/internal/upload"
 )
 
+// remoteOutputMessage explains why an s3:// or gs:// output directory is refused
+const remoteOutputMessage = "Uploading to s3:// or gs:// is only supported by non-interactive runs (-p); enter a local directory"
+
 type state int
 
 const (
@@ -32,78 +52,183 @@ const (
 	stateDownloading
 	stateComplete
 	stateError
+	stateRemixPrompt
+	stateContentPolicy
+	stateVideoDetails
+	stateConfirmDelete
+	stateQueue
+	stateTemplateSelect
+	stateTemplateVars
+	stateEnhancing
+	stateEnhanceReview
+	stateCropAnchor
+	stateReferencePicker
+	stateProfileSelect
+	stateSetupOutputDir
+	stateSetupModel
+	stateSetupSize
+	stateSetupNotify
 )
 
-type videoCreatedMsg struct {
-	id string
-}
-
-type videoReadyMsg struct {
-	videoID string // Changed to use video ID instead of URL
-}
-
 type videoDownloadedMsg struct {
-	path string
+	path      string
+	finishErr error // Writing the manifest or running the on_complete hook failed; the video is still saved
 }
 
 type errorMsg struct {
 	err error
 }
 
-type pollMsg struct {
-	progress int    // Progress percentage from API
-	status   string // Status from API
+// contentPolicyMsg reports a prompt rejected by moderation, before or after submission
+type contentPolicyMsg struct {
+	err error
+}
+
+// videoCancelledMsg reports the outcome of cancelling a job with c
+type videoCancelledMsg struct {
+	videoID string
+	err     error
 }
 
 type debugMsg struct {
 	entry string
 }
 
+// videosListedMsg carries a page of the library; after is the cursor it was
+// requested with, empty for the first page
 type videosListedMsg struct {
-	videos []api.VideoResponse
+	videos  []api.VideoResponse
+	after   string
+	lastID  string
+	hasMore bool
 }
 
-type videoDeletedMsg struct {
-	videoID string
-	current int
-	total   int
+// videosDeletedMsg reports the videos removed from the service
+type videosDeletedMsg struct {
+	ids    []string
+	failed int
 }
 
-type videosDeletedMsg struct{}
+type moderationMsg struct {
+	prompt string
+	result *api.ModerationResult
+}
 
 type tickMsg time.Time
 
 type Model struct {
-	state          state
-	textInput      textinput.Model
-	spinner        spinner.Model
-	cfg            *config.Config
-	client         *api.SoraClient
-	prompt         string
-	model          string
-	modelSelection int // 0 = sora-2, 1 = sora-2-pro
-	referenceImg   string
+	state             state
+	textInput         textinput.Model
+	spinner           spinner.Model
+	cfg               *config.Config
+	client            *api.SoraClient
+	cancelling        bool          // c was pressed while polling; waiting for the job to be cancelled
+	poll              poll.Strategy // How jobs are polled and how long they are waited for
+	resumeCommands    []string      // How to resume the jobs still rendering when the TUI was quit
+	prompt            string
+	model             string
+	modelSelection    int // Index into api.Models("sora")
+	referenceImg      string
+	fetchingReference string  // Reference URL or clipboard being fetched, empty when none is
+	cropAnchor        string  // Part of the reference image kept when cropping
+	cropAxis          string  // Axis the reference image is cropped on: "x" or "y"
+	cropFraction      float64 // Share of the reference image's width or height cropped away
+	cropSelection     int
+	filePicker        filepicker.Model // Reference file browser (Ctrl+F)
+	completions       []string         // Candidates of an ambiguous Tab path completion
 	duration          string
-	durationSelection int // 0 = 4s, 1 = 8s, 2 = 12s
+	durationSelection int // Index into the durations of the model
 	size              string
-	sizeSelection     int // 0 = 1280x720, 1 = 720x1280, 2 = 1792x1024, 3 = 1024x1792
-	outputDir      string
-	videoID        string
-	outputPath     string
-	err            error
-	message        string
-	pollAttempts   int
-	elapsedSeconds int
-	progress       int    // Video generation progress percentage (0-100)
-	videoStatus    string // Current video status from API
-	skipReference  bool
-	debug          bool
-	debugLogs           []string
-	recentVideos        []api.VideoResponse
-	deleteVideos        bool // Whether to delete listed videos
-	deletingVideoID     string
-	deletingVideoIndex  int
-	deletingVideoTotal  int
+	sizeSelection     int // Index into sizeOptions: the sizes of the model, then match
+	outputDir         string
+	videoID           string
+	outputPath        string
+	err               error
+	message           string
+	pollAttempts      int
+	elapsedSeconds    int
+	progress          int           // Video generation progress percentage (0-100)
+	videoStatus       string        // Current video status from API
+	typicalDuration   time.Duration // How long past jobs with these settings took, for the ETA
+	skipReference     bool
+	debug             bool
+	debugLog          *api.DebugLog   // API calls for the debug pane and -debug-log
+	debugOpen         bool            // The debug pane covers the screen
+	debugView         viewport.Model  // Scrollback of the debug pane
+	debugFollow       bool            // Keep the debug pane scrolled to the latest call
+	debugSearching    bool            // Typing a debug pane search
+	debugSearch       textinput.Model // Debug pane search input
+	debugQuery        string          // Last debug pane search
+	debugMatch        int             // Line of the current search match
+	debugMessage      string          // Debug pane status, e.g. after copying a call
+	width             int             // Terminal size from the last window size message
+	height            int
+	library           list.Model        // Remote videos
+	libraryLoaded     bool              // The first page has arrived
+	libraryLoading    bool              // A further page is being fetched
+	libraryMore       bool              // More pages are available
+	libraryLastID     string            // Cursor for the next page
+	detailVideo       api.VideoResponse // Video shown in stateVideoDetails
+	detailEntry       *history.Entry    // Ledger record of detailVideo, nil when not recorded
+	deleteIDs         []string          // Videos awaiting delete confirmation
+	transport         http.RoundTripper // Record/replay or proxy transport, nil for the default
+	baseURL           string            // OpenAI-compatible API endpoint, empty for the default
+	organization      string            // OpenAI organization billed, empty for the key's default
+	project           string            // OpenAI project billed, empty for the key's default
+	opts              CLIOptions        // Options to start over with once a profile is picked or setup is done
+	setup             bool              // Running the first-run setup
+	setupSteps        []state           // Steps of the setup, in order
+	setupSelection    int               // Highlighted notification choice
+	setupCreateDir    string            // Missing output directory Enter again creates
+	notifyOptions     []notifyOption    // Notification choices offered by the setup
+	checkingKey       bool              // The API key that was entered is being checked
+	keyUnchecked      string            // Key that could not be checked, which Enter again accepts
+	profileNames      []string          // Profiles offered at startup
+	profileSelection  int               // Highlighted profile
+	strict            bool              // Block prompts flagged by the pre-flight moderation check
+	moderationWarning string
+	lintWarnings      []string                   // Local prompt lint warnings
+	vars              map[string]string          // Prompt template variables
+	templates         map[string]prompt.Template // Named templates from templates.toml
+	templateNames     []string
+	templateSelection int
+	templateName      string            // Template being filled in
+	templateVars      map[string]string // Placeholder values entered so far
+	templateVarIndex  int               // Placeholder being asked for
+	promptDraft       string            // Prompt input restored when the template picker or enhancement is cancelled
+	enhancedPrompt    string            // Chat model rewrite awaiting approval
+	wildcardsDir      string
+	promptInput       string // Prompt as typed, before template and wildcard expansion
+	negative          string // Exclusions folded into every prompt
+	styleDirective    string // Style preset directive appended to every prompt
+	remixID           string // Video being remixed, empty for new videos
+	ledger            bool   // Record jobs in the local history ledger
+	moderation        bool   // Check prompts with the moderation endpoint; replayed and simulated sessions make no real requests
+	downloadBar       progress.Model
+	downloadPercent   float64             // Fraction of the video downloaded, -1 when the size is unknown
+	events            <-chan engine.Event // Events of the running job, nil when none is running
+	stopJob           context.CancelFunc  // Stops the engine running the current job
+	keepRemote        bool                // Leave videos on the service after download
+	onComplete        string              // Hook command run after each download
+	nameTemplate      string              // Filename template for saved videos, empty for the default names
+	retentionAge      time.Duration       // Age at which kept videos are deleted, 0 to keep them
+	policyTerms       []string            // Phrases in a rejected prompt that likely triggered content policy
+	queue             []queuedJob         // Prompts added with Tab, run with Ctrl+R, and jobs resumed from the library
+	queueStarted      time.Time
+	concurrency       int               // Queued jobs rendering at once
+	takes             int               // Videos generated per prompt; more than one are queued and saved with _vN suffixes
+	priority          queue.Priority    // Given to prompts queued next; Ctrl+P switches it
+	retry             queue.RetryPolicy // How failed queued jobs are tried again
+	createdJob        string            // Current job when this session created it, so its render counts toward the session cost
+	parentJob         string            // Finished job whose prompt was pre-filled for editing, recorded as the next version's parent
+	sessionCost       float64           // Estimated price of the videos rendered this session, in USD
+	sessionVideos     int
+	budget            *budget.Tracker        // Estimated spend of the session against the configured budget
+	reserved          float64                // Estimated cost of the current job committed to the budget
+	force             bool                   // Submit jobs past the budget's hard limits
+	sidecar           bool                   // Write a JSON manifest next to each saved video
+	jobRequest        api.CreateVideoRequest // Current job as submitted, for its manifest
+	jobStarted        time.Time              // When the current job was submitted or resumed
 }
 
 var (
@@ -126,6 +251,9 @@ var (
 	infoStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("86"))
 
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
 	debugRequestStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("33")).
 				Bold(true)
@@ -147,9 +275,36 @@ type CLIOptions struct {
 	Duration       string
 	Size           string
 	OutputDir      string
+	RecordPath     string
+	ReplayPath     string
+	Simulate       bool // Use the built-in fake Sora backend instead of the API
+	Force          bool // Submit jobs past the budget's hard limits
+	Strict         bool
+	Vars           map[string]string
+	VarsFile       string
+	Negative       string
+	Style          string
+	APIKey         string // Overrides OPENAI_API_KEY and the config file
+	KeepRemote     bool   // Keep videos on the service after download
+	Concurrency    int    // Queued jobs rendering at once; values below 2 use the default
+	Count          int    // Takes generated per prompt, saved with _v1.._vN suffixes; values below 2 generate one
+	OnComplete     string // Hook command run after each download, overriding on_complete in the config
+	NameTemplate   string // Output filename template, overriding name_template in the config
+	CropAnchor     string // Part of the reference image kept when cropping
+	BaseURL        string // OpenAI-compatible API endpoint overriding OPENAI_BASE_URL and api_base_url
+	Organization   string // OpenAI organization overriding OPENAI_ORG_ID and organization
+	Project        string // OpenAI project overriding OPENAI_PROJECT_ID and project
+	Profile        string // Config profile to use; empty shows the profile picker when profiles are defined
+	ConfigPath     string // Config file to use instead of the default one; the first-run setup creates it when missing
+	DebugLog       string // File the API calls are logged to as JSON lines
+
+	PollInterval time.Duration // Fixed wait between status checks, overriding poll_interval; 0 polls adaptively
+	MaxPolls     int           // Status checks before giving up, overriding poll_max_attempts
+	Timeout      time.Duration // Limit on waiting for each job, overriding poll_timeout
 }
 
 func NewModel(opts CLIOptions) (*Model, error) {
+	config.SetPath(opts.ConfigPath)
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
@@ -165,30 +320,145 @@ func NewModel(opts CLIOptions) (*Model, error) {
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	m := &Model{
-		textInput: ti,
-		spinner:   s,
-		cfg:       cfg,
-		debug:     opts.Debug,
-		debugLogs: make([]string, 0),
+		textInput:  ti,
+		spinner:    s,
+		cfg:        cfg,
+		debug:      opts.Debug,
+		strict:     opts.Strict,
+		negative:   opts.Negative,
+		ledger:     opts.ReplayPath == "" && !opts.Simulate,
+		moderation: opts.ReplayPath == "" && !opts.Simulate,
+
+		concurrency: defaultQueueConcurrency,
+		takes:       1,
+
+		downloadBar: progress.New(progress.WithDefaultGradient(), progress.WithWidth(60)),
+		library:     newLibrary(),
 	}
 
-	// Check API key first
-	if cfg.OpenAIAPIKey == "" {
-		m.state = stateAPIKey
-		m.textInput.Placeholder = "sk-..."
+	// Pick a profile first when there are some to choose from
+	if opts.Profile == "" && opts.Prompt == "" && len(cfg.Profiles) > 0 {
+		m.askProfile(opts)
 		return m, nil
 	}
+	if err := cfg.UseProfile(opts.Profile); err != nil {
+		return nil, err
+	}
 
-	// Create debug callback that appends directly to the slice
-	debugCallback := func(entry string) {
-		if m.debug {
-			m.debugLogs = append(m.debugLogs, entry)
-			if len(m.debugLogs) > 50 {
-				m.debugLogs = m.debugLogs[len(m.debugLogs)-50:]
-			}
+	if m.negative == "" {
+		m.negative = cfg.NegativePrompt
+	}
+	if opts.Concurrency > 1 {
+		m.concurrency = opts.Concurrency
+	}
+	if opts.Count > 1 {
+		m.takes = opts.Count
+	}
+
+	policy, age, err := cfg.RetentionPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if opts.KeepRemote {
+		policy = config.RetentionKeep
+	}
+	m.keepRemote = policy != config.RetentionDelete
+	m.onComplete = opts.OnComplete
+	if m.onComplete == "" {
+		m.onComplete = cfg.OnComplete
+	}
+	m.nameTemplate = opts.NameTemplate
+	if m.nameTemplate == "" {
+		m.nameTemplate = cfg.NameTemplate
+	}
+	// Check the template now rather than when the first video is saved
+	if _, err := naming.Render(m.nameTemplate, "", naming.Job{}); err != nil {
+		return nil, err
+	}
+	if policy == config.RetentionDeleteAfterDays {
+		m.retentionAge = age
+	}
+	pollInterval := opts.PollInterval
+	if pollInterval == 0 && opts.ReplayPath != "" {
+		pollInterval = api.ReplayPollInterval
+	}
+	m.poll, err = cfg.PollStrategy(pollInterval, opts.Timeout, opts.MaxPolls)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.retry, err = cfg.RetryPolicy(); err != nil {
+		return nil, err
+	}
+
+	limits, err := cfg.Budget()
+	if err != nil {
+		return nil, err
+	}
+	var monthToDate float64
+	if limits.Set() {
+		if monthToDate, err = history.MonthSpend(time.Now()); err != nil {
+			return nil, fmt.Errorf("failed to read this month's spend from history: %w", err)
 		}
 	}
-	m.client = api.NewClient(cfg.OpenAIAPIKey, m.debug, debugCallback)
+	m.budget = budget.NewTracker(limits, monthToDate)
+	m.force = opts.Force
+	m.sidecar = cfg.SidecarManifest
+
+	m.vars, err = prompt.LoadVars(opts.VarsFile, opts.Vars)
+	if err != nil {
+		return nil, err
+	}
+	m.wildcardsDir, err = cfg.WildcardsDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Send requests through the configured proxy, or the simulated backend, recording or replaying them if requested
+	proxy, err := api.NewProxyTransport(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Simulate {
+		if opts.ReplayPath != "" {
+			return nil, fmt.Errorf("cannot simulate and replay at the same time")
+		}
+		proxy = api.NewSimulatedTransport()
+	}
+	m.transport, err = api.NewSessionTransport(opts.RecordPath, opts.ReplayPath, proxy)
+	if err != nil {
+		return nil, err
+	}
+	// The debug pane shows the API calls as they are logged
+	m.debugLog, err = api.OpenDebugLog(opts.DebugLog)
+	if err != nil {
+		return nil, err
+	}
+	if m.debug || opts.DebugLog != "" {
+		m.transport = m.debugLog.Transport(m.transport)
+	}
+	m.baseURL = cfg.BaseURL(opts.BaseURL)
+	m.organization = cfg.OrganizationID(opts.Organization)
+	m.project = cfg.ProjectID(opts.Project)
+
+	// Check API key first (not needed when replaying a recorded session or simulating)
+	apiKey := cfg.APIKey(opts.APIKey)
+	needsKey := apiKey == "" && opts.ReplayPath == "" && !opts.Simulate
+	// A first launch walks through the setup, which writes the config
+	if !config.Exists() && opts.Prompt == "" && opts.ReplayPath == "" {
+		*m = m.startSetup(opts, needsKey)
+		return m, nil
+	}
+	if needsKey {
+		m.opts = opts
+		m.state = stateAPIKey
+		m.textInput.Placeholder = "sk-..."
+		return m, nil
+	}
+
+	m.debugLog.Redact(apiKey)
+	m.client = api.NewClient(apiKey, false, nil)
+	m.configureClient(m.client)
 
 	// Determine initial state based on CLI options
 	if opts.Prompt != "" {
@@ -196,17 +466,16 @@ func NewModel(opts CLIOptions) (*Model, error) {
 		m.prompt = opts.Prompt
 		m.state = stateGenerating
 	} else {
-		// Interactive mode: start by listing recent videos
+		// Interactive mode: start in the video library
 		m.state = stateListVideos
-		m.deleteVideos = true // Default to yes for deletion
 		m.textInput.Placeholder = ""
 	}
 
 	// Apply CLI options or fall back to config/defaults
-	// Output directory
-	if opts.OutputDir != "" {
+	// Output directory; s3:// and gs:// destinations are only uploaded to by non-interactive runs
+	if opts.OutputDir != "" && !upload.IsRemote(opts.OutputDir) {
 		m.outputDir = opts.OutputDir
-	} else if cfg.OutputDir != "" {
+	} else if cfg.OutputDir != "" && !upload.IsRemote(cfg.OutputDir) {
 		m.outputDir = cfg.OutputDir
 	} else {
 		homeDir, _ := os.UserHomeDir()
@@ -215,99 +484,148 @@ func NewModel(opts CLIOptions) (*Model, error) {
 
 	// Model
 	if opts.Model != "" {
-		modelName := opts.Model
-		if modelName == "sora" {
-			modelName = "sora-2"
-		} else if modelName == "sora-pro" {
-			modelName = "sora-2-pro"
-		}
-		m.model = modelName
-		if modelName == "sora-2" {
-			m.modelSelection = 0
-		} else {
-			m.modelSelection = 1
-		}
+		m.model = api.ModelName(opts.Model)
 	} else if cfg.Model != "" {
 		m.model = cfg.Model
-		if cfg.Model == "sora-2" {
-			m.modelSelection = 0
-		} else {
-			m.modelSelection = 1
-		}
 	} else {
-		m.model = "sora-2"
-		m.modelSelection = 0
+		m.model = api.DefaultModel("sora").Name
 	}
+	m.modelSelection = m.modelIndex()
 
 	// Duration
+	// A style preset supplies defaults between the flags and the config
+	if opts.Style != "" {
+		stylesPath, err := config.StylesPath()
+		if err != nil {
+			return nil, err
+		}
+		style, err := prompt.LoadStyle(opts.Style, stylesPath)
+		if err != nil {
+			return nil, err
+		}
+		m.styleDirective = style.Directive
+		if opts.Duration == "" {
+			opts.Duration = style.Duration
+		}
+		if opts.Size == "" {
+			opts.Size = style.Size
+		}
+	}
+
 	if opts.Duration != "" {
 		m.duration = opts.Duration
-		m.durationSelection = getDurationSelection(opts.Duration)
 	} else if cfg.Duration != "" {
 		m.duration = cfg.Duration
-		m.durationSelection = getDurationSelection(cfg.Duration)
 	} else {
-		m.duration = "4"
-		m.durationSelection = 0
+		m.duration = api.ModelDurations(m.model)[0]
 	}
+	m.durationSelection = indexOf(api.ModelDurations(m.model), m.duration)
 
 	// Size
 	if opts.Size != "" {
 		m.size = opts.Size
-		m.sizeSelection = getSizeSelection(opts.Size)
 	} else if cfg.Size != "" {
 		m.size = cfg.Size
-		m.sizeSelection = getSizeSelection(cfg.Size)
 	} else {
-		m.size = "1280x720"
-		m.sizeSelection = 0
+		m.size = api.ModelSizes(m.model)[0]
 	}
+	m.size = api.ResolveSize(m.size)
 
-	// Reference image
-	if opts.ReferenceImage != "" {
+	// Reference image; a URL or the clipboard is saved to the output directory up front
+	if api.IsReferenceURL(opts.ReferenceImage) || clipboard.IsReference(opts.ReferenceImage) {
+		msg := m.fetchReference(opts.ReferenceImage)().(referenceFetchedMsg)
+		if msg.err != nil {
+			return nil, msg.err
+		}
+		m.referenceImg = msg.path
+	} else if opts.ReferenceImage != "" {
 		m.referenceImg = opts.ReferenceImage
 	}
 
+	// A matched size is picked once the reference is known
+	if m.size == api.SizeMatchReference {
+		if m.referenceImg == "" {
+			if opts.Size == api.SizeMatchReference {
+				return nil, fmt.Errorf("-s %s needs a reference image (-r)", api.SizeMatchReference)
+			}
+			m.size = api.ModelSizes(m.model)[0]
+		} else {
+			size, err := api.MatchReferenceSize(m.referenceImg, api.ModelSizes(m.model))
+			if err != nil {
+				return nil, err
+			}
+			m.size = size
+		}
+	}
+	m.sizeSelection = m.sizeIndex(m.size)
+	if _, err := api.ParseCropAnchor(opts.CropAnchor); err != nil {
+		return nil, err
+	}
+	m.cropAnchor = opts.CropAnchor
+
 	return m, nil
 }
 
-// Helper function to get size selection index
-func getDurationSelection(duration string) int {
-	switch duration {
-	case "4":
-		return 0
-	case "8":
-		return 1
-	case "12":
-		return 2
-	default:
-		return 0
+// configureClient points an OpenAI client at Azure OpenAI or a custom base
+// URL, bills the configured organization and project, and sets the
+// record/replay or proxy transport
+func (m Model) configureClient(client *api.SoraClient) {
+	if m.cfg.UsesAzure() {
+		client.SetAzure(m.cfg.Azure.Endpoint, m.cfg.Azure.APIVersion, m.cfg.Azure.Deployments)
+	}
+	client.SetBaseURL(m.baseURL)
+	client.SetOrganization(m.organization, m.project)
+	if m.transport != nil {
+		client.SetTransport(m.transport)
 	}
 }
 
-func getSizeSelection(size string) int {
-	switch size {
-	case "1280x720":
-		return 0
-	case "720x1280":
-		return 1
-	case "1792x1024":
-		return 2
-	case "1024x1792":
-		return 3
-	default:
-		return 0
+// indexOf returns the position of value in options, or 0 when it is not one of them
+func indexOf(options []string, value string) int {
+	for i, option := range options {
+		if option == value {
+			return i
+		}
 	}
+	return 0
 }
 
-func (m *Model) addDebugLog(entry string) {
-	if m.debug {
-		m.debugLogs = append(m.debugLogs, entry)
-		// Keep last 50 entries
-		if len(m.debugLogs) > 50 {
-			m.debugLogs = m.debugLogs[len(m.debugLogs)-50:]
-		}
+// modelIndex returns the position of the current model on the model step
+func (m Model) modelIndex() int {
+	var names []string
+	for _, model := range api.Models("sora") {
+		names = append(names, model.Name)
+	}
+	return indexOf(names, m.model)
+}
+
+// sizeIndex returns the position of size on the size step, the first size
+// when the model does not render it
+func (m Model) sizeIndex(size string) int {
+	var sizes []string
+	for _, option := range m.sizeOptions() {
+		sizes = append(sizes, option.size)
 	}
+	return indexOf(sizes, size)
+}
+
+// sizeOption is a choice on the size step
+type sizeOption struct {
+	size string
+	desc string
+}
+
+// sizeOptions lists the sizes to choose from, with matching the reference
+// image last when one is set
+func (m Model) sizeOptions() []sizeOption {
+	var options []sizeOption
+	for _, size := range api.ModelSizes(m.model) {
+		options = append(options, sizeOption{size, api.SizeLabel(size)})
+	}
+	if m.referenceImg != "" && !m.skipReference && !api.IsVideoReference(m.referenceImg) {
+		options = append(options, sizeOption{api.SizeMatchReference, "Closest to the reference image"})
+	}
+	return options
 }
 
 func (m Model) Init() tea.Cmd {
@@ -322,7 +640,7 @@ func (m Model) Init() tea.Cmd {
 	}
 	// If in interactive mode, list recent videos
 	if m.state == stateListVideos {
-		return tea.Batch(clearScreen, textinput.Blink, m.spinner.Tick, m.listVideos())
+		return tea.Batch(clearScreen, textinput.Blink, m.spinner.Tick, m.deleteExpired(), m.listVideos(""))
 	}
 	return tea.Batch(clearScreen, textinput.Blink, m.spinner.Tick)
 }
@@ -332,14 +650,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case spinner.TickMsg:
+		// The library has its own spinner for loading further pages
+		var libraryCmd tea.Cmd
+		m.library, libraryCmd = m.library.Update(msg)
+		// Each dashboard row has its own spinner
+		jobsCmd := m.updateJobSpinners(msg)
 		m.spinner, cmd = m.spinner.Update(msg)
 		// Continue ticking during deleting state
 		if m.state == stateDeletingVideos {
-			return m, tea.Batch(cmd, m.spinner.Tick)
+			return m, tea.Batch(cmd, libraryCmd, jobsCmd, m.spinner.Tick)
+		}
+		return m, tea.Batch(cmd, libraryCmd, jobsCmd)
+
+	case tea.WindowSizeMsg:
+		m.library.SetSize(msg.Width, libraryHeight(msg.Height))
+		m.width, m.height = msg.Width, msg.Height
+		if m.debugOpen {
+			m.debugView.Width, m.debugView.Height = m.debugPaneSize()
 		}
-		return m, cmd
+		return m, nil
 
 	case tickMsg:
+		if m.state == stateQueue && !m.queueFinished() {
+			// Refresh the per-job elapsed times, and start retries whose wait is over
+			cmd := m.startJobs()
+			return m, tea.Batch(cmd, tick())
+		}
 		if m.state == statePolling || m.state == stateGenerating {
 			m.elapsedSeconds++
 			return m, tick()
@@ -347,34 +683,138 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// The debug pane takes over the keys while it is open
+		if m.debug && (msg.Type == tea.KeyCtrlG || (msg.String() == "d" && !m.debugOpen && m.debugKeyFree())) {
+			return m.toggleDebugPane(), nil
+		}
+		if m.debugOpen && msg.Type != tea.KeyCtrlC {
+			return m.updateDebugPane(msg)
+		}
+
+		// Library screens handle their own keys, including Esc
+		if msg.Type != tea.KeyCtrlC {
+			switch m.state {
+			case stateListVideos:
+				return m.updateLibrary(msg)
+			case stateVideoDetails:
+				return m.updateVideoDetails(msg)
+			case stateConfirmDelete:
+				return m.updateConfirmDelete(msg)
+			case stateTemplateSelect:
+				return m.updateTemplateSelect(msg)
+			case stateTemplateVars:
+				return m.updateTemplateVars(msg)
+			case stateEnhanceReview:
+				return m.updateEnhanceReview(msg)
+			case stateCropAnchor:
+				return m.updateCropAnchor(msg)
+			case stateReferencePicker:
+				return m.updateFilePicker(msg)
+			case stateProfileSelect:
+				return m.updateProfileSelect(msg)
+			case stateSetupOutputDir, stateSetupModel, stateSetupSize, stateSetupNotify:
+				return m.updateSetup(msg)
+			}
+		}
+
+		if m.state == statePolling && msg.String() == "c" && !m.cancelling {
+			return m.cancelGeneration()
+		}
+		if m.state == stateSize && (msg.String() == "+" || msg.String() == "-") {
+			return m.changeTakes(msg.String() == "+"), nil
+		}
+
+		if msg.Type != tea.KeyTab {
+			m.completions = nil
+		}
+
 		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
-			return m, tea.Quit
+		case tea.KeyEsc:
+			if m.inWizard() {
+				return m.goBack(), nil
+			}
+			return m.quit()
+
+		case tea.KeyCtrlC:
+			return m.quit()
 
 		case tea.KeyCtrlU:
 			// Clear the input field
 			m.textInput.SetValue("")
 			return m, nil
 
-		case tea.KeyEnter:
-			if m.state == stateListVideos {
-				// User confirmed deletion choice
-				if m.deleteVideos && len(m.recentVideos) > 0 {
-					// Transition to deleting state
-					m.state = stateDeletingVideos
-					return m, tea.Batch(m.deleteAllVideos(), m.spinner.Tick)
-				} else {
-					// Skip deletion, go to prompt
-					m.state = statePrompt
-					m.textInput.SetValue(m.cfg.LastPrompt)
-					m.textInput.Placeholder = "Describe the video you want to generate..."
-					m.textInput.Focus()
+		case tea.KeyTab:
+			// Complete paths in the reference and output directory inputs
+			if m.state == stateReferenceImage || (m.state == stateOutputDir && m.canComplete()) {
+				return m.completeInput(), nil
+			}
+			// Add to the queue instead of generating now
+			if m.state == statePrompt {
+				return m.queuePrompt()
+			}
+			if m.state == stateOutputDir {
+				value := strings.TrimSpace(m.textInput.Value())
+				if upload.IsRemote(value) {
+					m.message = remoteOutputMessage
 					return m, nil
 				}
+				if value != "" {
+					m.outputDir = normalizePath(value)
+				}
+				m.cfg.OutputDir = m.outputDir
+				m.enqueue(m.prompt)
+				next := m.startPrompt()
+				if err := config.Save(m.cfg); err != nil {
+					next.message = fmt.Sprintf("failed to save config: %v", err)
+				}
+				return next, nil
+			}
+
+		case tea.KeyCtrlR:
+			if m.state == statePrompt && len(m.queue) > 0 {
+				return m.runQueue()
+			}
+
+		case tea.KeyCtrlT:
+			if m.state == statePrompt {
+				return m.openTemplates()
+			}
+
+		case tea.KeyCtrlP:
+			if m.state == statePrompt {
+				m.priority = m.priority.Next()
+				return m, nil
+			}
+
+		case tea.KeyCtrlE:
+			if m.state == statePrompt {
+				return m.enhancePrompt()
+			}
+
+		case tea.KeyCtrlF:
+			if m.state == stateReferenceImage {
+				return m.openFilePicker()
+			}
+
+		case tea.KeyCtrlV:
+			if m.state == stateReferenceImage {
+				return m.startFetchReference(clipboard.Reference)
+			}
+
+		case tea.KeyEnter:
+			if m.state == stateQueue {
+				if m.queueFinished() {
+					m.queue = nil
+					return m.startPrompt(), nil
+				}
+				return m, nil
 			}
 			if m.state == stateComplete {
 				// Restart after completion - preserve prompt and reference image
-				previousPrompt := m.prompt
+				previousPrompt := m.promptInput
+				if m.videoID != "" && m.remixID == "" {
+					m.parentJob = m.videoID
+				}
 				m.state = statePrompt
 				m.videoID = ""
 				m.outputPath = ""
@@ -384,6 +824,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.elapsedSeconds = 0
 				m.progress = 0
 				m.skipReference = false
+				m.remixID = ""
 				// Keep referenceImg set so it becomes the default
 				m.textInput.SetValue(previousPrompt)
 				m.textInput.Placeholder = "Describe the video you want to generate..."
@@ -392,7 +833,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if m.state == stateError {
 				// Retry after error - preserve prompt and allow editing
-				previousPrompt := m.prompt
+				previousPrompt := m.promptInput
+				if m.videoID != "" && m.remixID == "" {
+					m.parentJob = m.videoID
+				}
 				m.state = statePrompt
 				m.videoID = ""
 				m.outputPath = ""
@@ -402,6 +846,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.elapsedSeconds = 0
 				m.progress = 0
 				m.skipReference = false
+				m.remixID = ""
 				// Pre-fill with previous prompt for easy editing
 				m.textInput.SetValue(previousPrompt)
 				m.textInput.Placeholder = "Describe the video you want to generate..."
@@ -410,126 +855,180 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if m.state == stateModel {
 				// Handle model selection with Enter
-				if m.modelSelection == 0 {
-					m.model = "sora-2"
-				} else {
-					m.model = "sora-2-pro"
-				}
+				m.model = api.Models("sora")[m.modelSelection].Name
 				m.cfg.Model = m.model
+				// The duration and size steps list what this model renders
+				m.durationSelection = indexOf(api.ModelDurations(m.model), m.duration)
+				m.sizeSelection = m.sizeIndex(m.size)
 				m.state = stateReferenceImage
 				// Set previous reference image as default (if it exists)
 				m.textInput.SetValue(m.referenceImg)
-				m.textInput.Placeholder = "Path to reference image (or press Enter to skip)..."
+				m.textInput.Placeholder = "Path to reference image or video (or press Enter to skip)..."
 				m.message = ""
 				return m, nil
 			}
 			if m.state == stateSize {
 				// Handle size selection with Enter
-				sizes := []string{"1280x720", "720x1280", "1792x1024", "1024x1792"}
-				m.size = sizes[m.sizeSelection]
+				size := m.sizeOptions()[m.sizeSelection].size
+				if size == api.SizeMatchReference {
+					matched, err := api.MatchReferenceSize(m.referenceImg, api.ModelSizes(m.model))
+					if err != nil {
+						m.message = err.Error()
+						return m, nil
+					}
+					size = matched
+				}
+				m.size = size
 				m.cfg.Size = m.size
-				m.state = stateOutputDir
-				m.textInput.SetValue(m.outputDir)
-				m.textInput.Placeholder = "Output directory..."
-				m.message = ""
-				return m, nil
+				return m.askCropAnchor(), nil
 			}
 			return m.handleEnter()
 
-		case tea.KeyUp, tea.KeyLeft:
-			if m.state == stateListVideos {
-				m.deleteVideos = !m.deleteVideos
-				return m, nil
+		case tea.KeyLeft:
+			// On the selection steps, left and right move between steps
+			if m.state == stateModel || m.state == stateDuration || m.state == stateSize {
+				return m.goBack(), nil
 			}
+
+		case tea.KeyRight:
+			if m.state == stateModel || m.state == stateDuration || m.state == stateSize {
+				return m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			}
+
+		case tea.KeyUp:
 			if m.state == stateModel {
-				m.modelSelection = (m.modelSelection - 1 + 2) % 2
+				count := len(api.Models("sora"))
+				m.modelSelection = (m.modelSelection - 1 + count) % count
 				return m, nil
 			}
 			if m.state == stateDuration {
-				m.durationSelection = (m.durationSelection - 1 + 3) % 3
+				count := len(api.ModelDurations(m.model))
+				m.durationSelection = (m.durationSelection - 1 + count) % count
 				return m, nil
 			}
 			if m.state == stateSize {
-				m.sizeSelection = (m.sizeSelection - 1 + 4) % 4
+				count := len(m.sizeOptions())
+				m.sizeSelection = (m.sizeSelection - 1 + count) % count
 				return m, nil
 			}
 
-		case tea.KeyDown, tea.KeyRight:
-			if m.state == stateListVideos {
-				m.deleteVideos = !m.deleteVideos
-				return m, nil
-			}
+		case tea.KeyDown:
 			if m.state == stateModel {
-				m.modelSelection = (m.modelSelection + 1) % 2
+				m.modelSelection = (m.modelSelection + 1) % len(api.Models("sora"))
 				return m, nil
 			}
 			if m.state == stateDuration {
-				m.durationSelection = (m.durationSelection + 1) % 3
+				m.durationSelection = (m.durationSelection + 1) % len(api.ModelDurations(m.model))
 				return m, nil
 			}
 			if m.state == stateSize {
-				m.sizeSelection = (m.sizeSelection + 1) % 4
+				m.sizeSelection = (m.sizeSelection + 1) % len(m.sizeOptions())
 				return m, nil
 			}
 		}
 
-	case videoCreatedMsg:
-		m.videoID = msg.id
-		m.state = statePolling
-		m.pollAttempts = 0
-		m.elapsedSeconds = 0
-		m.progress = 0
-		return m, tea.Batch(m.checkVideoStatus(), tick())
+	case startJobMsg:
+		return m.startJob(msg.job)
 
-	case pollMsg:
-		if m.state != statePolling {
+	case jobEventMsg:
+		if msg.events != m.events {
 			return m, nil
 		}
-		m.pollAttempts++
-		m.progress = msg.progress   // Update progress from API
-		m.videoStatus = msg.status  // Update status from API
-		if m.pollAttempts > 200 {
-			return m, func() tea.Msg {
-				return errorMsg{err: fmt.Errorf("timeout waiting for video generation")}
-			}
-		}
-		return m, m.pollVideo()
+		return m.updateJob(msg.event)
 
-	case videoReadyMsg:
-		m.state = stateDownloading
-		return m, m.downloadVideo()
+	case videoCancelledMsg:
+		return m.updateCancelled(msg)
 
 	case videoDownloadedMsg:
 		m.outputPath = msg.path
 		m.state = stateComplete
+		if msg.finishErr != nil {
+			m.message = msg.finishErr.Error()
+		}
 		return m, nil
 
 	case videosListedMsg:
-		m.recentVideos = msg.videos
-		// Stay in stateListVideos to show the list
-		return m, nil
-
-	case videoDeletedMsg:
-		m.deletingVideoID = msg.videoID
-		m.deletingVideoIndex = msg.current
-		m.deletingVideoTotal = msg.total
-		return m, nil
+		m.library.StopSpinner()
+		m.libraryLoading = false
+		m.libraryLoaded = true
+		m.libraryMore = msg.hasMore
+		m.libraryLastID = msg.lastID
+		var items []list.Item
+		if msg.after != "" {
+			items = m.library.Items()
+		}
+		for _, video := range msg.videos {
+			items = append(items, videoItem{video: video})
+		}
+		return m, m.library.SetItems(items)
 
 	case videosDeletedMsg:
-		m.recentVideos = nil
-		m.deletingVideoID = ""
-		m.deletingVideoIndex = 0
-		m.deletingVideoTotal = 0
+		m.deleteIDs = nil
+		m.state = stateListVideos
+		m.message = fmt.Sprintf("Deleted %d video(s)", len(msg.ids))
+		if msg.failed > 0 {
+			m.message += fmt.Sprintf(", %d could not be deleted", msg.failed)
+		}
+		return m, m.removeFromLibrary(msg.ids)
+
+	case referenceFetchedMsg:
+		return m.referenceFetched(msg)
+
+	case keyCheckedMsg:
+		return m.keyChecked(msg)
+
+	case promptEnhancedMsg:
+		if m.state != stateEnhancing {
+			return m, nil
+		}
 		m.state = statePrompt
-		m.textInput.SetValue(m.cfg.LastPrompt)
-		m.textInput.Placeholder = "Describe the video you want to generate..."
-		m.textInput.Focus()
+		if msg.err != nil {
+			m.message = msg.err.Error()
+			return m, nil
+		}
+		m.promptDraft = msg.original
+		m.enhancedPrompt = msg.enhanced
+		m.state = stateEnhanceReview
 		return m, nil
 
+	case moderationMsg:
+		// Ignore results for a prompt that has since been replaced
+		if msg.prompt == m.prompt && msg.result.Flagged {
+			m.moderationWarning = fmt.Sprintf("⚠ Prompt may be rejected by content moderation (%s)", msg.result)
+		}
+		return m, nil
+
+	case queueEventMsg, queueDoneMsg:
+		return m.updateQueue(msg)
+
 	case errorMsg:
 		m.err = msg.err
 		m.state = stateError
 		return m, nil
+
+	case contentPolicyMsg:
+		// A resumed job has no prompt to revise
+		if m.prompt == "" {
+			m.err = msg.err
+			m.state = stateError
+			return m, nil
+		}
+		// Let the prompt be revised in place; settings are kept for the resubmission
+		m.err = msg.err
+		m.state = stateContentPolicy
+		m.message = ""
+		m.moderationWarning = ""
+		m.policyTerms = prompt.PolicyTerms(m.prompt)
+		m.textInput.SetValue(m.promptInput)
+		m.textInput.Placeholder = "Revise the prompt..."
+		m.textInput.Focus()
+		// Ask the moderation endpoint which categories the prompt falls into
+		return m, m.checkModeration(m.prompt)
+	}
+
+	// The file picker lists directories with its own messages
+	if m.state == stateReferencePicker {
+		return m.updateFilePicker(msg)
 	}
 
 	m.textInput, cmd = m.textInput.Update(msg)
@@ -541,53 +1040,86 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 
 	switch m.state {
 	case stateAPIKey:
-		if value == "" {
-			m.message = "API key cannot be empty"
+		if m.checkingKey {
 			return m, nil
 		}
-		m.cfg.OpenAIAPIKey = value
-		if err := config.Save(m.cfg); err != nil {
-			m.err = err
-			m.state = stateError
-			return m, nil
-		}
-		// Create debug callback that appends directly to the slice
-		debugCallback := func(entry string) {
-			if m.debug {
-				m.debugLogs = append(m.debugLogs, entry)
-				if len(m.debugLogs) > 50 {
-					m.debugLogs = m.debugLogs[len(m.debugLogs)-50:]
-				}
-			}
-		}
-		m.client = api.NewClient(value, m.debug, debugCallback)
-		m.state = statePrompt
-		m.textInput.SetValue("")
-		m.textInput.Placeholder = "Describe the video you want to generate..."
-		m.message = ""
-		return m, nil
+		return m.submitAPIKey(value)
 
 	case statePrompt:
 		if value == "" {
 			// Empty prompt means exit
 			return m, tea.Quit
 		}
-		m.prompt = value
+		rendered, err := m.resolvePrompt(value)
+		if err != nil {
+			m.message = err.Error()
+			return m, nil
+		}
+		m.prompt = rendered
+		m.promptInput = value
 		m.cfg.LastPrompt = value
 		m.state = stateModel
 		// Model selection is now handled by arrow keys, not text input
 		m.message = ""
-		return m, nil
+		m.moderationWarning = ""
+		m.lintWarnings = prompt.Lint(rendered, m.cfg.Model)
+		// Check moderation in the background while the user picks settings
+		return m, m.checkModeration(rendered)
+
+	case stateContentPolicy:
+		if value == "" {
+			m.message = "Enter a revised prompt"
+			return m, nil
+		}
+		rendered, err := m.resolvePrompt(value)
+		if err != nil {
+			m.message = err.Error()
+			return m, nil
+		}
+		m.prompt = rendered
+		m.promptInput = value
+		m.err = nil
+		m.message = ""
+		m.moderationWarning = ""
+		m.policyTerms = nil
+		if m.videoID != "" && m.remixID == "" {
+			m.parentJob = m.videoID
+		}
+		m.videoID = ""
+		m.pollAttempts = 0
+		m.elapsedSeconds = 0
+		m.progress = 0
+		m.state = stateGenerating
+		if m.remixID != "" {
+			return m, m.remixVideo()
+		}
+		m.cfg.LastPrompt = value
+		m.lintWarnings = prompt.Lint(rendered, m.model)
+		return m, m.createVideo()
+
+	case stateRemixPrompt:
+		if value == "" {
+			m.message = "Describe the change to make"
+			return m, nil
+		}
+		rendered, err := m.resolvePrompt(value)
+		if err != nil {
+			m.message = err.Error()
+			return m, nil
+		}
+		m.prompt = rendered
+		m.promptInput = value
+		m.message = ""
+		m.state = stateGenerating
+		return m, m.remixVideo()
 
 	case stateReferenceImage:
+		if api.IsReferenceURL(value) || clipboard.IsReference(value) {
+			return m.startFetchReference(value)
+		}
 		if value != "" {
-			// Expand tilde to home directory
-			if strings.HasPrefix(value, "~/") {
-				homeDir, err := os.UserHomeDir()
-				if err == nil {
-					value = filepath.Join(homeDir, value[2:])
-				}
-			}
+			// Expand tilde and undo the quoting of dragged-in files
+			value = normalizePath(value)
 			// Validate file exists
 			if _, err := os.Stat(value); os.IsNotExist(err) {
 				m.message = "File does not exist"
@@ -597,33 +1129,47 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		} else {
 			m.skipReference = true
 		}
-		m.state = stateDuration
-		m.textInput.SetValue(m.duration)
-		m.textInput.Placeholder = m.duration
-		m.message = ""
-		return m, nil
+		return m.referenceChosen(), nil
 
 	case stateDuration:
 		// Duration selection is confirmed, save and move to size
-		durations := []string{"4", "8", "12"}
-		m.duration = durations[m.durationSelection]
+		m.duration = api.ModelDurations(m.model)[m.durationSelection]
 		m.cfg.Duration = m.duration
 		m.state = stateSize
+		// Matching the reference is only offered while there is an image to match
+		if m.sizeSelection >= len(m.sizeOptions()) {
+			m.sizeSelection = 0
+		}
 		// Size selection is handled by arrow keys, not text input
 		m.message = ""
 		return m, nil
 
 	case stateOutputDir:
+		if upload.IsRemote(value) {
+			m.message = remoteOutputMessage
+			return m, nil
+		}
 		if value != "" {
-			m.outputDir = value
+			m.outputDir = normalizePath(value)
 		}
 		m.cfg.OutputDir = m.outputDir
+		if cost, ok := api.EstimateCost(m.model, m.size, m.duration); ok && !m.force {
+			if _, err := m.budget.Check(cost * float64(m.takes)); err != nil {
+				m.message = fmt.Sprintf("%v (restart with -force to submit anyway)", err)
+				return m, nil
+			}
+		}
 		// Save config with all updates
 		if err := config.Save(m.cfg); err != nil {
 			m.err = fmt.Errorf("failed to save config: %w", err)
 			m.state = stateError
 			return m, nil
 		}
+		// Several takes render side by side on the queue dashboard
+		if m.takes > 1 {
+			m.enqueue(m.prompt)
+			return m.runQueue()
+		}
 		m.state = stateGenerating
 		return m, m.createVideo()
 	}
@@ -631,221 +1177,415 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func tick() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+// cancelGeneration cancels the job being polled so it stops using quota
+func (m Model) cancelGeneration() (tea.Model, tea.Cmd) {
+	m.cancelling = true
+	m.message = ""
+	client, videoID := m.client, m.videoID
+	return m, func() tea.Msg {
+		return videoCancelledMsg{videoID: videoID, err: client.CancelVideo(videoID)}
+	}
 }
 
-func (m Model) createVideo() tea.Cmd {
-	return func() tea.Msg {
-		req := api.CreateVideoRequest{
-			Prompt:         m.prompt,
-			Model:          m.model,
-			InputReference: m.referenceImg,
-			Seconds:        m.duration,
-			Size:           m.size,
+// quit exits the TUI. Jobs still rendering keep running on the service, so
+// they are recorded in the ledger as interrupted and ResumeCommands tells
+// how to pick them up.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	type orphan struct {
+		entry     history.Entry
+		outputDir string
+	}
+	var orphans []orphan
+	if m.videoID != "" && (m.state == statePolling || m.state == stateDownloading) {
+		entry := history.Entry{ID: m.videoID, Provider: m.client.Name(), Prompt: m.prompt}
+		// A remix keeps its source's settings, which the TUI does not know
+		if m.remixID == "" {
+			entry.Model, entry.Size, entry.Duration = m.model, m.size, m.duration
 		}
+		orphans = append(orphans, orphan{entry, m.outputDir})
+	}
+	for _, job := range m.queue {
+		if job.videoID != "" && job.active() {
+			entry := history.Entry{ID: job.videoID, Provider: m.client.Name(), Prompt: job.req.Prompt,
+				Model: job.req.Model, Size: job.req.Size, Duration: job.req.Seconds}
+			orphans = append(orphans, orphan{entry, job.outputDir})
+		}
+	}
 
-		resp, err := m.client.CreateVideo(req)
-		if err != nil {
-			return errorMsg{err: err}
+	for _, o := range orphans {
+		// Resumed with the same config and profile, from any directory
+		o.entry.Profile = m.cfg.ActiveProfile()
+		if m.opts.ConfigPath != "" {
+			o.entry.Config, _ = filepath.Abs(m.opts.ConfigPath)
+		}
+		if m.ledger {
+			_ = history.MarkInterrupted(o.entry)
 		}
+		m.resumeCommands = append(m.resumeCommands, history.ResumeCommand(o.entry, o.outputDir))
+	}
+	return m, tea.Quit
+}
+
+// ResumeCommands returns the commands that resume the jobs still rendering
+// when the TUI was quit
+func (m Model) ResumeCommands() []string {
+	return m.resumeCommands
+}
 
-		return videoCreatedMsg{id: resp.ID}
+// updateCancelled returns to the prompt, with the cancelled prompt filled in,
+// once the job is cancelled. If it could not be cancelled, polling goes on.
+func (m Model) updateCancelled(msg videoCancelledMsg) (tea.Model, tea.Cmd) {
+	m.cancelling = false
+	if msg.videoID != m.videoID || m.state != statePolling {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.message = msg.err.Error()
+		return m, nil
+	}
+
+	m.record(msg.videoID, func(e *history.Entry) {
+		e.Provider = m.client.Name()
+		e.Status = "cancelled"
+	})
+	next := m.stopRun().startPrompt()
+	if m.remixID == "" {
+		next.parentJob = msg.videoID
 	}
+	next.videoID = ""
+	next.remixID = ""
+	next.pollAttempts = 0
+	next.elapsedSeconds = 0
+	next.progress = 0
+	next.videoStatus = ""
+	if m.promptInput != "" {
+		next.textInput.SetValue(m.promptInput)
+		next.textInput.CursorEnd()
+	}
+	next.message = fmt.Sprintf("Cancelled video job %s", msg.videoID)
+	return next, nil
 }
 
-func (m Model) pollVideo() tea.Cmd {
-	return func() tea.Msg {
-		// Dynamic polling: 10s for first 2 minutes, 10s when at 100%, 30s thereafter
-		var pollInterval time.Duration
-		if m.progress >= 100 {
-			// Poll every 10s when at 100% waiting for completion
-			pollInterval = 10 * time.Second
-		} else if m.elapsedSeconds < 120 {
-			pollInterval = 10 * time.Second
-		} else {
-			pollInterval = 30 * time.Second
-		}
-		time.Sleep(pollInterval)
+// queuePrompt adds the typed prompt to the queue with the current settings
+// and clears the input for the next one
+func (m Model) queuePrompt() (tea.Model, tea.Cmd) {
+	value := strings.TrimSpace(m.textInput.Value())
+	if value == "" {
+		m.message = "Type a prompt to add it to the queue"
+		return m, nil
+	}
+	rendered, err := m.resolvePrompt(value)
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+	m.enqueue(rendered)
+	m.cfg.LastPrompt = value
+	m.message = ""
+	m.textInput.SetValue("")
+	return m, nil
+}
 
-		// Check video status after sleep
-		resp, err := m.client.GetVideo(m.videoID)
-		if err != nil {
-			return errorMsg{err: err}
-		}
+// startPrompt moves to the prompt screen with the last prompt pre-filled
+func (m Model) startPrompt() Model {
+	m.state = statePrompt
+	m.message = ""
+	m.remixID = ""
+	m.parentJob = ""
+	m.textInput.SetValue(m.cfg.LastPrompt)
+	m.textInput.Placeholder = "Describe the video you want to generate..."
+	m.textInput.Focus()
+	return m
+}
 
-		// Only download when status is "completed"
-		if resp.Status == "completed" {
-			return videoReadyMsg{videoID: m.videoID}
-		}
+// inWizard reports whether the model is on a generation step after the
+// prompt, where Esc goes back a step instead of quitting
+func (m Model) inWizard() bool {
+	switch m.state {
+	case stateModel, stateReferenceImage, stateDuration, stateSize, stateCropAnchor, stateOutputDir:
+		return true
+	}
+	return false
+}
 
-		if resp.Status == "failed" {
-			errMsg := "Video generation failed"
-			if resp.Error != nil && resp.Error.Message != "" {
-				errMsg += ": " + resp.Error.Message
-			}
-			return errorMsg{err: fmt.Errorf(errMsg)}
+// goBack returns to the previous generation step with its answer filled in,
+// so a typo in the prompt or a wrong setting can be fixed without starting
+// over: output directory → crop anchor → size → duration → reference → model → prompt
+func (m Model) goBack() Model {
+	m.message = ""
+	m.completions = nil
+	switch m.state {
+	case stateModel:
+		m.state = statePrompt
+		m.textInput.SetValue(m.promptInput)
+		m.textInput.Placeholder = "Describe the video you want to generate..."
+		m.textInput.CursorEnd()
+		m.textInput.Focus()
+	case stateReferenceImage:
+		m.state = stateModel
+	case stateDuration:
+		m.state = stateReferenceImage
+		if m.skipReference {
+			m.textInput.SetValue("")
+		} else {
+			m.textInput.SetValue(m.referenceImg)
+		}
+		m.skipReference = false
+		m.textInput.Placeholder = "Path to reference image or video (or press Enter to skip)..."
+		m.textInput.CursorEnd()
+	case stateSize:
+		m.state = stateDuration
+	case stateCropAnchor:
+		m.state = stateSize
+	case stateOutputDir:
+		// The crop anchor step is only shown for mismatched reference images
+		if back := m.askCropAnchor(); back.state == stateCropAnchor {
+			return back
 		}
+		m.state = stateSize
+	}
+	return m
+}
 
-		// Continue polling with progress and status update
-		return pollMsg{progress: resp.Progress, status: resp.Status}
+// resolvePrompt expands templates and wildcards and applies the style and negative prompt
+func (m Model) resolvePrompt(value string) (string, error) {
+	rendered, err := prompt.Render(value, m.vars)
+	if err != nil {
+		return "", err
 	}
+	rendered, err = prompt.Expand(rendered, m.wildcardsDir)
+	if err != nil {
+		return "", err
+	}
+	rendered = prompt.WithStyle(rendered, m.styleDirective)
+	return prompt.WithNegative(rendered, m.negative), nil
 }
 
-func (m Model) checkVideoStatus() tea.Cmd {
-	return func() tea.Msg {
-		resp, err := m.client.GetVideo(m.videoID)
-		if err != nil {
-			return errorMsg{err: err}
-		}
+// record updates a job in the history ledger. Failures are ignored so a
+// ledger problem never interrupts a generation.
+func (m Model) record(id string, update func(e *history.Entry)) {
+	if m.ledger {
+		_ = history.Record(id, update)
+	}
+}
 
-		// Only download when status is "completed"
-		if resp.Status == "completed" {
-			return videoReadyMsg{videoID: m.videoID}
-		}
+// runHook runs the on_complete hook for a downloaded video, if one is set.
+// Its output is discarded since it would garble the TUI.
+func (m Model) runHook(vars hook.Vars) error {
+	if m.onComplete == "" {
+		return nil
+	}
+	_, err := hook.Run(m.onComplete, vars)
+	return err
+}
 
-		if resp.Status == "failed" {
-			errMsg := "Video generation failed"
-			if resp.Error != nil && resp.Error.Message != "" {
-				errMsg += ": " + resp.Error.Message
-			}
-			return errorMsg{err: fmt.Errorf(errMsg)}
-		}
+// recordCompleted records how long a finished job took to render and its estimated cost
+func (m Model) recordCompleted(resp *api.VideoResponse) {
+	m.record(resp.ID, func(e *history.Entry) {
+		e.SetGenerationTime(resp.CreatedAt, resp.CompletedAt)
+		e.SetCost(resp)
+	})
+}
 
-		// Continue polling with progress and status update
-		return pollMsg{progress: resp.Progress, status: resp.Status}
+// estimateDuration returns how long past jobs with these settings took to
+// render, or 0 when the ledger is off or has no comparable jobs
+func (m Model) estimateDuration(model, size, duration string) time.Duration {
+	if !m.ledger || model == "" {
+		return 0
 	}
+	return history.TypicalDuration(model, size, duration)
 }
 
-func (m Model) listVideos() tea.Cmd {
-	return func() tea.Msg {
-		resp, err := m.client.ListVideos(10)
-		if err != nil {
-			return errorMsg{err: err}
-		}
-		return videosListedMsg{videos: resp.Data}
+// highlightTerms renders text with every occurrence of terms (matched case-insensitively) in the error style
+func highlightTerms(text string, terms []string) string {
+	if len(terms) == 0 {
+		return text
 	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	pattern := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+	return pattern.ReplaceAllStringFunc(text, func(match string) string {
+		return errorStyle.Render(match)
+	})
 }
 
-func (m Model) deleteAllVideos() tea.Cmd {
-	videos := m.recentVideos
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
 
+// checkModeration asks the moderation endpoint about a prompt in the
+// background, for the warning shown while settings are picked
+func (m Model) checkModeration(prompt string) tea.Cmd {
+	if !m.moderation {
+		return nil
+	}
 	return func() tea.Msg {
-		// Delete all videos
-		for _, video := range videos {
-			// Ignore errors and continue
-			_ = m.client.DeleteVideo(video.ID)
+		result, err := m.client.ModeratePrompt(prompt)
+		if err != nil {
+			// The check is advisory; Sora still moderates on submission
+			return nil
 		}
+		return moderationMsg{prompt: prompt, result: result}
+	}
+}
 
-		// All done
-		return videosDeletedMsg{}
+// createVideo starts a job for the prompt and settings chosen
+func (m Model) createVideo() tea.Cmd {
+	job := engine.Job{Request: api.CreateVideoRequest{
+		Prompt:         m.prompt,
+		Model:          m.model,
+		InputReference: m.referenceImg,
+		CropAnchor:     m.cropAnchor,
+		Seconds:        m.duration,
+		Size:           m.size,
+		Negative:       m.negative,
+		Parent:         m.parentJob,
+	}}
+	return func() tea.Msg {
+		return startJobMsg{job: job}
 	}
 }
 
-func (m Model) downloadVideo() tea.Cmd {
+// remixVideo starts a job remixing m.remixID with the prompt
+func (m Model) remixVideo() tea.Cmd {
+	job := engine.Job{Request: api.CreateVideoRequest{Prompt: m.prompt, Negative: m.negative}, RemixOf: m.remixID}
 	return func() tea.Msg {
-		timestamp := time.Now().Format("20060102_150405")
-		filename := fmt.Sprintf("sora_video_%s.mp4", timestamp)
-		outputPath := filepath.Join(m.outputDir, filename)
-
-		// Retry download up to 12 times (2 minutes with 10s intervals)
-		maxRetries := 12
-		for attempt := 0; attempt < maxRetries; attempt++ {
-			if attempt > 0 {
-				time.Sleep(10 * time.Second)
-			}
+		return startJobMsg{job: job}
+	}
+}
 
-			err := m.client.DownloadVideoContent(m.videoID, outputPath)
-			if err == nil {
-				// Download successful, now delete the video from the service
-				if deleteErr := m.client.DeleteVideo(m.videoID); deleteErr != nil {
-					// Log error but don't fail the operation since download succeeded
-					// The video will remain on the service but user has their file
-					fmt.Fprintf(os.Stderr, "Warning: failed to delete video from service: %v\n", deleteErr)
-				}
-				return videoDownloadedMsg{path: outputPath}
-			}
+// deleteExpired deletes Sora videos this tool kept on the service once they
+// pass the retention age (retention = "delete-after-days")
+func (m Model) deleteExpired() tea.Cmd {
+	if m.retentionAge == 0 || !m.ledger {
+		return nil
+	}
 
-			// Check if it's a 404 (not ready yet) - if so, retry
-			if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "not ready") {
+	return func() tea.Msg {
+		expired, err := history.Expired(m.retentionAge)
+		if err != nil {
+			return nil
+		}
+		for _, entry := range expired {
+			if entry.Provider != m.client.Name() {
 				continue
 			}
-
-			// Other errors, fail immediately
-			return errorMsg{err: err}
+			if err := m.client.DeleteVideo(entry.ID); err == nil {
+				m.record(entry.ID, func(e *history.Entry) {
+					e.KeptRemote = false
+				})
+			}
 		}
+		return nil
+	}
+}
+
+// billingLabel names the organization and project requests are billed to,
+// or returns "" when both are the API key's defaults
+func (m Model) billingLabel() string {
+	var parts []string
+	if m.organization != "" {
+		parts = append(parts, "org: "+m.organization)
+	}
+	if m.project != "" {
+		parts = append(parts, "project: "+m.project)
+	}
+	return strings.Join(parts, " · ")
+}
 
-		return errorMsg{err: fmt.Errorf("video content not available after %d attempts (2 minutes)", maxRetries)}
+// serviceLabel summarizes the request quota and the jobs rendering on the
+// account as the API last reported them, or returns "" before it has
+func (m Model) serviceLabel() string {
+	var parts []string
+	if limits := api.CurrentRateLimits(); limits.Paused() > 0 {
+		parts = append(parts, fmt.Sprintf("rate limited %s", limits.Paused().Round(time.Second)))
+	} else if limits.Known() {
+		parts = append(parts, fmt.Sprintf("%d/%d requests", limits.Remaining, limits.Limit))
 	}
+	queued, inProgress := api.ActiveJobs()
+	if active := queued + inProgress; m.cfg.ConcurrentJobs > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d jobs running", active, m.cfg.ConcurrentJobs))
+	} else if active > 0 {
+		parts = append(parts, fmt.Sprintf("%d job(s) running", active))
+	}
+	return strings.Join(parts, " · ")
 }
 
 func (m Model) View() string {
 	var sb strings.Builder
 
-	sb.WriteString(titleStyle.Render("Video Generator (Sora)"))
+	title := titleStyle.Render("Video Generator (Sora)")
+	if billing := m.billingLabel(); billing != "" {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, "  ", infoStyle.Render(billing))
+	}
+	if service := m.serviceLabel(); service != "" {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, "  ", promptStyle.Render(service))
+	}
+	if local := m.cfg.LocalPath(); local != "" {
+		title = lipgloss.JoinHorizontal(lipgloss.Top, title, "  ", promptStyle.Render(filepath.Join(filepath.Base(filepath.Dir(local)), config.LocalFileName)))
+	}
+	sb.WriteString(title)
 	sb.WriteString("\n\n")
 
+	if m.debugOpen {
+		sb.WriteString(m.viewDebugPane())
+		return sb.String()
+	}
+
+	// Keep the moderation and lint warnings visible through the settings steps
+	if m.state >= stateModel && m.state <= stateOutputDir {
+		if m.moderationWarning != "" {
+			sb.WriteString(warningStyle.Render(m.moderationWarning))
+			sb.WriteString("\n\n")
+		}
+		for _, warning := range m.lintWarnings {
+			sb.WriteString(warningStyle.Render("⚠ " + warning))
+			sb.WriteString("\n")
+		}
+		if len(m.lintWarnings) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+
 	switch m.state {
 	case stateAPIKey:
+		sb.WriteString(m.setupHeader())
 		sb.WriteString(promptStyle.Render("Enter your OpenAI API key:"))
 		sb.WriteString("\n")
 		sb.WriteString(m.textInput.View())
+		if m.checkingKey {
+			sb.WriteString("\n")
+			sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render("Checking the key...")))
+		}
 		if m.message != "" {
 			sb.WriteString("\n")
 			sb.WriteString(errorStyle.Render(m.message))
 		}
 
 	case stateListVideos:
-		if m.recentVideos == nil {
-			sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render("Loading recent videos...")))
-		} else if len(m.recentVideos) == 0 {
-			sb.WriteString(promptStyle.Render("No recent videos found."))
-			sb.WriteString("\n\n")
-			sb.WriteString(promptStyle.Render("Press Enter to continue..."))
-		} else {
-			sb.WriteString(promptStyle.Render(fmt.Sprintf("Recent videos (%d found):", len(m.recentVideos))))
-			sb.WriteString("\n\n")
-
-			for i, video := range m.recentVideos {
-				if i >= 10 {
-					break
-				}
-				createdTime := time.Unix(video.CreatedAt, 0).Format("Jan 2, 15:04")
-				statusColor := promptStyle
-				if video.Status == "completed" {
-					statusColor = successStyle
-				} else if video.Status == "failed" {
-					statusColor = errorStyle
-				}
-				sb.WriteString(fmt.Sprintf("  %s - %s (%s) - %s\n",
-					promptStyle.Render(video.ID[:20]+"..."),
-					statusColor.Render(video.Status),
-					infoStyle.Render(video.Model),
-					promptStyle.Render(createdTime)))
-			}
+		sb.WriteString(m.viewLibrary())
 
-			sb.WriteString("\n")
-			sb.WriteString(promptStyle.Render("Delete all listed videos? (use arrow keys to toggle)"))
-			sb.WriteString("\n")
+	case stateVideoDetails:
+		sb.WriteString(m.viewVideoDetails())
 
-			if m.deleteVideos {
-				sb.WriteString(successStyle.Render("▶ Yes"))
-				sb.WriteString("  ")
-				sb.WriteString(promptStyle.Render("No"))
-			} else {
-				sb.WriteString(promptStyle.Render("  Yes"))
-				sb.WriteString("  ")
-				sb.WriteString(successStyle.Render("▶ No"))
-			}
+	case stateConfirmDelete:
+		sb.WriteString(m.viewConfirmDelete())
 
-			sb.WriteString("\n\n")
-			sb.WriteString(promptStyle.Render("Press Enter to confirm"))
+	case stateRemixPrompt:
+		sb.WriteString(promptStyle.Render(fmt.Sprintf("Remix %s - describe the change:", m.remixID)))
+		sb.WriteString("\n")
+		sb.WriteString(m.textInput.View())
+		if m.message != "" {
+			sb.WriteString("\n")
+			sb.WriteString(errorStyle.Render(m.message))
 		}
 
 	case stateDeletingVideos:
-		sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render(fmt.Sprintf("Deleting %d videos...", len(m.recentVideos)))))
+		sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render(fmt.Sprintf("Deleting %d video(s)...", len(m.deleteIDs)))))
 		sb.WriteString("\n")
 		sb.WriteString(promptStyle.Render("This may take a moment..."))
 
@@ -857,94 +1597,117 @@ func (m Model) View() string {
 			sb.WriteString("\n")
 			sb.WriteString(errorStyle.Render(m.message))
 		}
+		sb.WriteString("\n\n")
+		if len(m.queue) > 0 {
+			sb.WriteString(m.viewQueuePending())
+		} else {
+			settings := fmt.Sprintf("%s, %s, %ss", m.model, m.size, m.duration)
+			if m.takes > 1 {
+				settings += fmt.Sprintf(", %d takes", m.takes)
+			}
+			sb.WriteString(promptStyle.Render(fmt.Sprintf("Press Tab to queue the prompt with the current settings (%s)", settings)))
+		}
+		sb.WriteString("\n")
+		sb.WriteString(promptStyle.Render(fmt.Sprintf("Queued prompts get %s priority; press Ctrl+P to change it", m.priority)))
+		sb.WriteString("\n")
+		sb.WriteString(promptStyle.Render("Press Ctrl+T to start from a prompt template, Ctrl+E to enhance the prompt"))
+
+	case stateQueue:
+		sb.WriteString(m.viewQueue())
+
+	case stateTemplateSelect:
+		sb.WriteString(m.viewTemplateSelect())
+
+	case stateEnhancing:
+		sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render("Enhancing prompt...")))
+
+	case stateEnhanceReview:
+		sb.WriteString(m.viewEnhanceReview())
+
+	case stateCropAnchor:
+		sb.WriteString(m.viewCropAnchor())
+
+	case stateProfileSelect:
+		sb.WriteString(m.viewProfileSelect())
+
+	case stateSetupOutputDir, stateSetupModel, stateSetupSize, stateSetupNotify:
+		sb.WriteString(m.viewSetup())
+
+	case stateTemplateVars:
+		sb.WriteString(m.viewTemplateVars())
 
 	case stateModel:
 		sb.WriteString(promptStyle.Render("Select model (use arrow keys):"))
 		sb.WriteString("\n\n")
 
-		// Option 1: sora-2
-		if m.modelSelection == 0 {
-			sb.WriteString(successStyle.Render("▶ sora-2"))
-		} else {
-			sb.WriteString(promptStyle.Render("  sora-2"))
+		for i, model := range api.Models("sora") {
+			name := fmt.Sprintf("%-12s", model.Name)
+			if m.modelSelection == i {
+				sb.WriteString(successStyle.Render("▶ " + name))
+			} else {
+				sb.WriteString(promptStyle.Render("  " + name))
+			}
+			sb.WriteString(promptStyle.Render(" - " + model.Description))
+			sb.WriteString("\n")
 		}
-		sb.WriteString(promptStyle.Render("       - Fast generation, good quality"))
 		sb.WriteString("\n")
-
-		// Option 2: sora-2-pro
-		if m.modelSelection == 1 {
-			sb.WriteString(successStyle.Render("▶ sora-2-pro"))
-		} else {
-			sb.WriteString(promptStyle.Render("  sora-2-pro"))
-		}
-		sb.WriteString(promptStyle.Render("   - Superior quality, slower"))
-		sb.WriteString("\n\n")
-		sb.WriteString(promptStyle.Render("Press Enter to confirm"))
+		sb.WriteString(promptStyle.Render("Press Enter to confirm, Esc to go back to the prompt"))
 		if m.message != "" {
 			sb.WriteString("\n")
 			sb.WriteString(errorStyle.Render(m.message))
 		}
 
 	case stateReferenceImage:
-		sb.WriteString(promptStyle.Render("Reference image path (optional):"))
+		sb.WriteString(promptStyle.Render("Reference image or video path, or image URL (optional):"))
 		sb.WriteString("\n")
 		sb.WriteString(m.textInput.View())
+		sb.WriteString(m.viewCompletions())
 		if m.message != "" {
 			sb.WriteString("\n")
 			sb.WriteString(errorStyle.Render(m.message))
 		}
+		sb.WriteString("\n\n")
+		sb.WriteString(promptStyle.Render("Press Tab to complete the path, Ctrl+F to browse for a file, Ctrl+V to paste an image, Esc to go back"))
+
+	case stateReferencePicker:
+		sb.WriteString(m.viewFilePicker())
 
 	case stateDuration:
 		sb.WriteString(promptStyle.Render("Select video duration (use arrow keys):"))
 		sb.WriteString("\n\n")
 
-		durations := []struct {
-			duration string
-			desc     string
-		}{
-			{"4", "4 seconds"},
-			{"8", "8 seconds"},
-			{"12", "12 seconds"},
-		}
-
-		for i, dur := range durations {
+		for i, duration := range api.ModelDurations(m.model) {
 			if i == m.durationSelection {
-				sb.WriteString(successStyle.Render(fmt.Sprintf("→ %s - %s", dur.duration, dur.desc)))
+				sb.WriteString(successStyle.Render(fmt.Sprintf("→ %s - %s seconds", duration, duration)))
 			} else {
-				sb.WriteString(fmt.Sprintf("  %s - %s", dur.duration, dur.desc))
+				sb.WriteString(fmt.Sprintf("  %s - %s seconds", duration, duration))
 			}
 			sb.WriteString("\n")
 		}
 
 		sb.WriteString("\n")
-		sb.WriteString(promptStyle.Render("Press Enter to confirm"))
+		sb.WriteString(promptStyle.Render("Press Enter to confirm, Esc to go back"))
 
 	case stateSize:
 		sb.WriteString(promptStyle.Render("Select video size (use arrow keys):"))
 		sb.WriteString("\n\n")
 
-		sizes := []struct {
-			size string
-			desc string
-		}{
-			{"1280x720", "Landscape (HD)"},
-			{"720x1280", "Portrait (HD)"},
-			{"1792x1024", "Landscape (Wide)"},
-			{"1024x1792", "Portrait (Wide)"},
-		}
-
-		for i, s := range sizes {
+		for i, s := range m.sizeOptions() {
 			if m.sizeSelection == i {
-				sb.WriteString(successStyle.Render("▶ " + s.size))
+				sb.WriteString(successStyle.Render("▶ " + fmt.Sprintf("%-9s", s.size)))
 			} else {
-				sb.WriteString(promptStyle.Render("  " + s.size))
+				sb.WriteString(promptStyle.Render("  " + fmt.Sprintf("%-9s", s.size)))
 			}
-			sb.WriteString(promptStyle.Render("   - " + s.desc))
+			sb.WriteString(infoStyle.Render(fmt.Sprintf("   %-15s", strings.Join(api.SizeAliases(s.size), ", "))))
+			sb.WriteString(promptStyle.Render(" - " + s.desc))
 			sb.WriteString("\n")
 		}
 
 		sb.WriteString("\n")
-		sb.WriteString(promptStyle.Render("Press Enter to confirm"))
+		sb.WriteString(infoStyle.Render(fmt.Sprintf("Takes: %d", m.takes)))
+		sb.WriteString(promptStyle.Render("   (+/- to change; several takes are saved as _v1, _v2, ...)"))
+		sb.WriteString("\n\n")
+		sb.WriteString(promptStyle.Render("Press Enter to confirm, Esc to go back"))
 		if m.message != "" {
 			sb.WriteString("\n")
 			sb.WriteString(errorStyle.Render(m.message))
@@ -954,6 +1717,17 @@ func (m Model) View() string {
 		sb.WriteString(promptStyle.Render("Output directory:"))
 		sb.WriteString("\n")
 		sb.WriteString(m.textInput.View())
+		sb.WriteString(m.viewCompletions())
+		sb.WriteString("\n\n")
+		if budget := m.viewBudget(); budget != "" {
+			sb.WriteString(budget)
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(promptStyle.Render("Press Tab to complete the path, or on an existing directory to queue the prompt; Esc to go back"))
+		if m.message != "" {
+			sb.WriteString("\n")
+			sb.WriteString(errorStyle.Render(m.message))
+		}
 
 	case stateGenerating:
 		sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render(fmt.Sprintf("Creating video generation job... (%ds)", m.elapsedSeconds))))
@@ -972,22 +1746,80 @@ func (m Model) View() string {
 		}
 		sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render(fmt.Sprintf("Generating video (%ds) %s%s", m.elapsedSeconds, statusDisplay, progressStr))))
 		sb.WriteString("\n")
-		pollInterval := "10s"
-		if m.elapsedSeconds >= 120 {
-			pollInterval = "30s"
+		if left := history.Remaining(m.typicalDuration, time.Duration(m.elapsedSeconds)*time.Second, m.progress); left > 0 {
+			sb.WriteString(infoStyle.Render(fmt.Sprintf("Estimated time remaining: ~%s", left.Round(time.Second))))
+			sb.WriteString("\n")
+		} else if m.typicalDuration > 0 {
+			sb.WriteString(infoStyle.Render(fmt.Sprintf("Taking longer than usual (typically %s)", m.typicalDuration)))
+			sb.WriteString("\n")
+		}
+		sb.WriteString(promptStyle.Render(fmt.Sprintf("Polling API every ~%s (attempt %d/%d)", m.poll.Nominal(m.pollAttempts, m.progress).Round(time.Second), m.pollAttempts, m.poll.Attempts())))
+		sb.WriteString("\n")
+		if m.cancelling {
+			sb.WriteString(warningStyle.Render(fmt.Sprintf("Cancelling %s...", m.videoID)))
+		} else {
+			if m.message != "" {
+				sb.WriteString(errorStyle.Render(m.message))
+				sb.WriteString("\n")
+			}
+			sb.WriteString(promptStyle.Render("Press c to cancel the job"))
 		}
-		sb.WriteString(promptStyle.Render(fmt.Sprintf("Polling API every %s (attempt %d/200)", pollInterval, m.pollAttempts)))
 
 	case stateDownloading:
 		sb.WriteString(fmt.Sprintf("%s %s", m.spinner.View(), infoStyle.Render("Downloading video...")))
+		if m.downloadPercent >= 0 {
+			sb.WriteString("\n\n")
+			sb.WriteString(m.downloadBar.ViewAs(m.downloadPercent))
+		}
 
 	case stateComplete:
 		sb.WriteString(successStyle.Render("✓ Video generated successfully!"))
 		sb.WriteString("\n\n")
 		sb.WriteString(infoStyle.Render(fmt.Sprintf("Saved to: %s", m.outputPath)))
+		if m.message != "" {
+			sb.WriteString("\n")
+			sb.WriteString(warningStyle.Render(m.message))
+		}
+		if m.prompt != m.promptInput {
+			// Show the resolved variant when templates or wildcards were expanded
+			sb.WriteString("\n")
+			sb.WriteString(promptStyle.Render(fmt.Sprintf("Prompt: %s", m.prompt)))
+		}
 		sb.WriteString("\n\n")
 		sb.WriteString(promptStyle.Render("Press Enter to generate another video..."))
 
+	case stateContentPolicy:
+		sb.WriteString(errorStyle.Render("✗ Rejected by content policy:"))
+		sb.WriteString("\n")
+		sb.WriteString(errorStyle.Render(m.err.Error()))
+		sb.WriteString("\n\n")
+		sb.WriteString(promptStyle.Render("Prompt sent: "))
+		sb.WriteString(highlightTerms(m.prompt, m.policyTerms))
+		sb.WriteString("\n\n")
+		if len(m.policyTerms) > 0 || m.moderationWarning != "" {
+			sb.WriteString(promptStyle.Render("Likely triggers:"))
+			sb.WriteString("\n")
+			for _, term := range m.policyTerms {
+				sb.WriteString(warningStyle.Render(fmt.Sprintf("  • %q (real people and copyrighted characters are usually rejected)", term)))
+				sb.WriteString("\n")
+			}
+			if m.moderationWarning != "" {
+				sb.WriteString(warningStyle.Render("  • " + strings.TrimPrefix(m.moderationWarning, "⚠ ")))
+				sb.WriteString("\n")
+			}
+		} else {
+			sb.WriteString(promptStyle.Render("No obvious trigger found; try describing people, brands, and violence more generically."))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+		sb.WriteString(promptStyle.Render("Edit the prompt and press Enter to resubmit with the same settings:"))
+		sb.WriteString("\n")
+		sb.WriteString(m.textInput.View())
+		if m.message != "" {
+			sb.WriteString("\n")
+			sb.WriteString(errorStyle.Render(m.message))
+		}
+
 	case stateError:
 		sb.WriteString(errorStyle.Render("✗ Error occurred:"))
 		sb.WriteString("\n")
@@ -998,34 +1830,13 @@ func (m Model) View() string {
 
 	sb.WriteString("\n\n")
 	sb.WriteString(promptStyle.Render("Press Ctrl+C to quit"))
+	if m.sessionVideos > 0 {
+		sb.WriteString(promptStyle.Render(fmt.Sprintf(" · Session: %d video(s), ~$%.2f", m.sessionVideos, m.sessionCost)))
+	}
 
-	// Debug logs at the bottom
-	if m.debug && len(m.debugLogs) > 0 {
-		sb.WriteString("\n\n")
-		sb.WriteString(strings.Repeat("─", 80))
-		sb.WriteString("\n")
-		sb.WriteString(debugRequestStyle.Render("DEBUG MODE"))
-		sb.WriteString("\n")
-		sb.WriteString(strings.Repeat("─", 80))
-		sb.WriteString("\n\n")
-
-		// Show last 10 log entries
-		start := 0
-		if len(m.debugLogs) > 10 {
-			start = len(m.debugLogs) - 10
-		}
-
-		for i := start; i < len(m.debugLogs); i++ {
-			entry := m.debugLogs[i]
-			if strings.HasPrefix(entry, "REQUEST:") {
-				sb.WriteString(debugRequestStyle.Render("→ "))
-				sb.WriteString(debugJSONStyle.Render(entry))
-			} else {
-				sb.WriteString(debugResponseStyle.Render("← "))
-				sb.WriteString(debugJSONStyle.Render(entry))
-			}
-			sb.WriteString("\n\n")
-		}
+	// Point to the debug pane (the profile picker runs before the log is open)
+	if m.debug && m.debugLog != nil {
+		sb.WriteString(m.viewDebugHint())
 	}
 
 	return sb.String()
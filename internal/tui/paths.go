@@ -0,0 +1,63 @@
+package tui
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// cleanDroppedPath undoes the quoting terminals apply to files dragged into
+// them: surrounding quotes, backslash-escaped spaces and symbols, and
+// file:// URLs. A "~" is left for expandHome.
+func cleanDroppedPath(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	if strings.HasPrefix(strings.ToLower(value), "file://") {
+		if u, err := url.Parse(value); err == nil && u.Path != "" {
+			path := u.Path
+			// file:///C:/Users/... keeps a slash before the drive letter
+			if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+				path = path[1:]
+			}
+			return filepath.FromSlash(path)
+		}
+	}
+
+	// Backslashes separate Windows paths, so only unescape elsewhere
+	if runtime.GOOS != "windows" && strings.Contains(value, `\`) {
+		var sb strings.Builder
+		escaped := false
+		for _, r := range value {
+			if r == '\\' && !escaped {
+				escaped = true
+				continue
+			}
+			escaped = false
+			sb.WriteRune(r)
+		}
+		return sb.String()
+	}
+	return value
+}
+
+// expandHome replaces a leading "~" with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[1:])
+}
+
+// normalizePath turns a typed or dropped path into a usable file path
+func normalizePath(value string) string {
+	return expandHome(cleanDroppedPath(value))
+}
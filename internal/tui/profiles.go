@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/config"
+)
+
+// askProfile shows the profile picker before anything else, listing the
+// top-level settings first unless a profile takes the default name
+func (m *Model) askProfile(opts CLIOptions) {
+	m.opts = opts
+	m.profileNames = m.cfg.ProfileNames()
+	if _, ok := m.cfg.Profiles[config.DefaultProfile]; !ok {
+		m.profileNames = append([]string{config.DefaultProfile}, m.profileNames...)
+	}
+	m.state = stateProfileSelect
+}
+
+// updateProfileSelect handles keys in the profile picker. Choosing a profile
+// starts over with it applied, as if it had been passed with -profile.
+func (m Model) updateProfileSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyLeft:
+		m.profileSelection = (m.profileSelection - 1 + len(m.profileNames)) % len(m.profileNames)
+	case tea.KeyDown, tea.KeyRight:
+		m.profileSelection = (m.profileSelection + 1) % len(m.profileNames)
+	case tea.KeyEnter:
+		opts := m.opts
+		opts.Profile = m.profileNames[m.profileSelection]
+		next, err := NewModel(opts)
+		if err != nil {
+			m.message = err.Error()
+			return m, nil
+		}
+		return *next, next.Init()
+	case tea.KeyEsc:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// viewProfileSelect lists the profiles with the account and defaults each uses
+func (m Model) viewProfileSelect() string {
+	var sb strings.Builder
+	sb.WriteString(promptStyle.Render("Select a profile (use arrow keys):"))
+	sb.WriteString("\n\n")
+	for i, name := range m.profileNames {
+		line := name
+		if details := m.profileDetails(name); details != "" {
+			line += " - " + details
+		}
+		if i == m.profileSelection {
+			sb.WriteString(successStyle.Render("▶ " + line))
+		} else {
+			sb.WriteString(promptStyle.Render("  " + line))
+		}
+		sb.WriteString("\n")
+	}
+	if m.message != "" {
+		sb.WriteString("\n")
+		sb.WriteString(errorStyle.Render(m.message))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render("Press Enter to use the profile, Esc to quit"))
+	return sb.String()
+}
+
+// profileDetails summarizes what a profile changes, or describes the
+// top-level settings for the default entry
+func (m Model) profileDetails(name string) string {
+	profile, ok := m.cfg.Profiles[name]
+	if !ok {
+		return "top-level settings"
+	}
+	if profile == nil {
+		return ""
+	}
+	var parts []string
+	if profile.Organization != "" {
+		parts = append(parts, "org "+profile.Organization)
+	}
+	if profile.Project != "" {
+		parts = append(parts, "project "+profile.Project)
+	}
+	if profile.Provider != "" {
+		parts = append(parts, profile.Provider)
+	}
+	if profile.Model != "" {
+		parts = append(parts, profile.Model)
+	}
+	if profile.OutputDir != "" {
+		parts = append(parts, profile.OutputDir)
+	}
+	return strings.Join(parts, ", ")
+}
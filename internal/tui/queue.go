@@ -0,0 +1,484 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/engine"
+	"github.com/telemetry/video-gen/internal/history"
+	"github.com/telemetry/video-gen/internal/hook"
+	"github.com/telemetry/video-gen/internal/queue"
+)
+
+// defaultQueueConcurrency is the number of queued jobs rendering at once unless -concurrency is set
+const defaultQueueConcurrency = 3
+
+// maxTakes caps the takes per prompt set with + on the size step
+const maxTakes = 8
+
+// queuedJob is a prompt waiting in, or running from, the TUI queue, or a
+// resumed job from the library
+type queuedJob struct {
+	req        api.CreateVideoRequest
+	outputDir  string
+	status     string // pending, submitting, the API status while rendering, downloading, retrying, done, or failed
+	videoID    string
+	progress   int
+	events     <-chan engine.Event // Events of the running job
+	started    time.Time
+	outputPath string
+	err        error
+	finishErr  error         // Writing the manifest or running the on_complete hook failed after the video was saved
+	spinner    spinner.Model // Ticks only while the job is active
+	typical    time.Duration // How long past jobs with these settings took
+	created    bool          // Submitted by this session rather than resumed, so its render counts toward the session cost
+	reserved   float64       // Estimated cost committed to the budget on submission
+	take       int           // Number of this take among several of the same prompt, 0 for a single take
+	priority   queue.Priority
+	attempts   int       // Tries started, 0 for a job resumed from the library
+	retryAt    time.Time // When a retrying job starts again
+}
+
+// active reports whether the job has started and not yet finished
+func (j queuedJob) active() bool {
+	return j.status != "pending" && j.status != "retrying" && j.status != "done" && j.status != "failed"
+}
+
+// ready reports whether the job can start: it is pending, or retrying and
+// its wait is over
+func (j queuedJob) ready() bool {
+	return j.status == "pending" || j.status == "retrying" && !time.Now().Before(j.retryAt)
+}
+
+// label is the job's prompt cut to max runes, led by its take number when
+// there are several takes of it
+func (j queuedJob) label(max int) string {
+	if j.take == 0 {
+		return truncate(j.req.Prompt, max)
+	}
+	tag := fmt.Sprintf("v%d ", j.take)
+	return tag + truncate(j.req.Prompt, max-len(tag))
+}
+
+// eta estimates the time left from past jobs and the progress made so far,
+// or returns 0 when there is nothing to estimate from
+func (j queuedJob) eta() time.Duration {
+	return history.Remaining(j.typical, time.Since(j.started), j.progress)
+}
+
+// newJobSpinner returns a spinner for one dashboard row
+func newJobSpinner() spinner.Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	return s
+}
+
+// queueEventMsg carries an event of a running queued job
+type queueEventMsg struct {
+	index int
+	event engine.Event
+}
+
+// queueDoneMsg reports that a queued job was saved, or failed with err
+type queueDoneMsg struct {
+	index     int
+	path      string
+	err       error
+	finishErr error
+	rendered  bool // The video was rendered and billed before err, so it is not tried again
+}
+
+// enqueue adds a resolved prompt to the queue with the current settings,
+// once for every take
+func (m *Model) enqueue(rendered string) {
+	reference := m.referenceImg
+	if m.skipReference {
+		reference = ""
+	}
+	for take := 1; take <= m.takes; take++ {
+		job := queuedJob{
+			req: api.CreateVideoRequest{
+				Prompt:         rendered,
+				Model:          m.model,
+				InputReference: reference,
+				CropAnchor:     m.cropAnchor,
+				Seconds:        m.duration,
+				Size:           m.size,
+				Negative:       m.negative,
+			},
+			outputDir: m.outputDir,
+			status:    "pending",
+			spinner:   newJobSpinner(),
+			typical:   m.estimateDuration(m.model, m.size, m.duration),
+			priority:  m.priority,
+		}
+		if m.takes > 1 {
+			job.take = take
+		}
+		m.queue = append(m.queue, job)
+	}
+}
+
+// changeTakes adds or removes a take of each prompt, between 1 and maxTakes
+func (m Model) changeTakes(more bool) Model {
+	switch {
+	case more && m.takes < maxTakes:
+		m.takes++
+	case !more && m.takes > 1:
+		m.takes--
+	}
+	return m
+}
+
+// watchVideos adds existing jobs from the library to the dashboard: finished
+// videos are downloaded and unfinished ones are polled until they finish
+func (m Model) watchVideos(videos []api.VideoResponse) (tea.Model, tea.Cmd) {
+	// Output names are numbered from when the dashboard started
+	if m.queueStarted.IsZero() {
+		m.queueStarted = time.Now()
+	}
+
+	var cmds []tea.Cmd
+	for _, video := range videos {
+		if video.Status == "failed" {
+			continue
+		}
+		job := queuedJob{
+			req: api.CreateVideoRequest{
+				Prompt:  video.Prompt,
+				Model:   video.Model,
+				Seconds: video.Seconds,
+				Size:    video.Size,
+			},
+			outputDir: m.outputDir,
+			status:    video.Status,
+			videoID:   video.ID,
+			progress:  video.Progress,
+			started:   time.Now(),
+			spinner:   newJobSpinner(),
+			typical:   m.estimateDuration(video.Model, video.Size, video.Seconds),
+		}
+		if video.CreatedAt > 0 {
+			job.started = time.Unix(video.CreatedAt, 0)
+		}
+		m.queue = append(m.queue, job)
+		cmds = append(cmds, m.runQueueJob(len(m.queue)-1), job.spinner.Tick)
+	}
+	if len(cmds) == 0 {
+		m.message = "Failed videos have nothing to download"
+		return m, nil
+	}
+
+	m.state = stateQueue
+	return m, tea.Batch(append(cmds, tick())...)
+}
+
+// runQueue switches to the dashboard and starts the first jobs
+func (m Model) runQueue() (tea.Model, tea.Cmd) {
+	m.state = stateQueue
+	m.queueStarted = time.Now()
+	m.message = ""
+	return m, tea.Batch(m.startJobs(), tick())
+}
+
+// startJobs submits pending jobs, urgent ones first, until m.concurrency
+// jobs are active
+func (m *Model) startJobs() tea.Cmd {
+	running := 0
+	priorities := make([]queue.Priority, len(m.queue))
+	for i, job := range m.queue {
+		if job.active() {
+			running++
+		}
+		priorities[i] = job.priority
+	}
+
+	var cmds []tea.Cmd
+	for _, i := range queue.Order(priorities) {
+		if running >= m.concurrency {
+			break
+		}
+		if m.queue[i].ready() {
+			reserved, err := m.reserveBudget(engine.Job{Request: m.queue[i].req})
+			if err != nil {
+				m.queue[i].status = "failed"
+				m.queue[i].err = err
+				continue
+			}
+			m.queue[i].reserved = reserved
+			m.queue[i].status = "submitting"
+			m.queue[i].started = time.Now()
+			m.queue[i].attempts++
+			cmds = append(cmds, m.runQueueJob(i), m.queue[i].spinner.Tick)
+			running++
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// updateJobSpinners advances the spinners of active jobs; finished jobs stop ticking
+func (m *Model) updateJobSpinners(msg spinner.TickMsg) tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range m.queue {
+		if m.queue[i].active() {
+			var cmd tea.Cmd
+			m.queue[i].spinner, cmd = m.queue[i].spinner.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// queueFinished reports whether every queued job has been saved or has failed
+func (m Model) queueFinished() bool {
+	for _, job := range m.queue {
+		if job.status != "done" && job.status != "failed" {
+			return false
+		}
+	}
+	return true
+}
+
+// runQueueJob runs a queued job with the engine, creating it unless it was
+// resumed from the library
+func (m *Model) runQueueJob(index int) tea.Cmd {
+	events := make(chan engine.Event)
+	m.queue[index].events = events
+
+	queued := m.queue[index]
+	job := engine.Job{
+		Request: queued.req,
+		VideoID: queued.videoID,
+		Started: queued.started,
+		Output:  m.saveAs(queued.outputDir, fmt.Sprintf("sora_queue_%s_%02d.mp4", m.queueStarted.Format("20060102_150405"), index+1), queued.req, index+1, queued.take),
+	}
+
+	model := *m
+	return func() tea.Msg {
+		if err := model.moderate(job); err != nil {
+			model.budget.Release(queued.reserved)
+			return queueDoneMsg{index: index, err: err}
+		}
+		go model.newEngine().Run(context.Background(), job, events)
+		return nextQueueEvent(index, events)()
+	}
+}
+
+// nextQueueEvent delivers the next event of a queued job, and nothing once it has ended
+func nextQueueEvent(index int, events <-chan engine.Event) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return queueEventMsg{index: index, event: event}
+	}
+}
+
+// finishQueueJob writes the manifest of a saved queued job and runs the on_complete hook
+func (m Model) finishQueueJob(index int, job queuedJob, path string, resp *api.VideoResponse) tea.Cmd {
+	vars := hook.Vars{
+		Path:     path,
+		ID:       job.videoID,
+		Prompt:   job.req.Prompt,
+		Model:    job.req.Model,
+		Provider: m.client.Name(),
+		Size:     job.req.Size,
+		Duration: job.req.Seconds,
+	}
+	return func() tea.Msg {
+		manifestErr := m.writeManifest(path, job.req, resp, job.started)
+		return queueDoneMsg{index: index, path: path, finishErr: errors.Join(manifestErr, m.runHook(vars))}
+	}
+}
+
+// updateQueue applies a queue message to its job and issues the job's next step
+func (m Model) updateQueue(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case queueEventMsg:
+		job := &m.queue[msg.index]
+		next := nextQueueEvent(msg.index, job.events)
+		switch event := msg.event.(type) {
+		case engine.Created:
+			job.videoID = event.VideoID
+			job.status = event.Status
+			job.created = true
+			m.recordCreated(event.VideoID, event.Status, job.req, "")
+
+		case engine.Polled:
+			job.status = event.Response.Status
+			job.progress = event.Response.Progress
+			if event.Response.Status == "completed" {
+				job.status = "downloading"
+				m.recordCompleted(event.Response)
+				if job.created {
+					m = m.countCost(job.req, event.Response)
+				}
+			}
+
+		case engine.Deleted:
+			warnDeleteFailed(event.Err)
+
+		case engine.Done:
+			if err := m.jobFailed(event); err != nil {
+				m.releaseBudget(job.reserved, event)
+				return m.updateQueue(queueDoneMsg{index: msg.index, err: err, rendered: event.Stage == engine.StageDownload})
+			}
+			m.recordSaved(event.VideoID, event.Path)
+			return m, m.finishQueueJob(msg.index, *job, event.Path, event.Response)
+		}
+		return m, next
+
+	case queueDoneMsg:
+		job := &m.queue[msg.index]
+		job.outputPath = msg.path
+		job.err = msg.err
+		job.finishErr = msg.finishErr
+		job.status = "done"
+		if msg.err != nil {
+			job.status = "failed"
+			m.retryJob(job, msg.rendered)
+		}
+		return m, m.startJobs()
+	}
+	return m, nil
+}
+
+// retryJob schedules another try of a failed job when the retry policy
+// allows it, and otherwise adds the job to the dead-letter file. Jobs
+// resumed from the library and videos that failed to download after
+// rendering are not tried again.
+func (m Model) retryJob(job *queuedJob, rendered bool) {
+	if job.attempts == 0 {
+		return
+	}
+	if !rendered {
+		if wait, ok := m.retry.Retry(job.attempts, job.err); ok {
+			job.status = "retrying"
+			job.retryAt = time.Now().Add(wait)
+			job.videoID, job.progress, job.created = "", 0, false
+			return
+		}
+	}
+	if m.ledger {
+		_ = history.AddDeadLetter(history.DeadLetter{
+			Prompt:    job.req.Prompt,
+			Model:     job.req.Model,
+			Size:      job.req.Size,
+			Duration:  job.req.Seconds,
+			Reference: job.req.InputReference,
+			Priority:  job.priority.String(),
+			Error:     job.err.Error(),
+			Class:     string(queue.Classify(job.err)),
+			Attempts:  job.attempts,
+		})
+	}
+}
+
+// viewQueuePending lists the prompts waiting to run, below the prompt input
+func (m Model) viewQueuePending() string {
+	var sb strings.Builder
+	var cost float64
+	for _, job := range m.queue {
+		if estimate, ok := api.EstimateJobCost(job.req, nil); ok {
+			cost += estimate
+		}
+	}
+	sb.WriteString(infoStyle.Render(fmt.Sprintf("Queue (%d, ~$%.2f):", len(m.queue), cost)))
+	sb.WriteString("\n")
+	for i, job := range m.queue {
+		tag := ""
+		if job.priority != queue.Normal {
+			tag = fmt.Sprintf(" [%s]", job.priority)
+		}
+		sb.WriteString(promptStyle.Render(fmt.Sprintf("  %d. %s · %s · %ss · %s%s", i+1, job.req.Model, job.req.Size, job.req.Seconds, job.label(60), tag)))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(promptStyle.Render("Press Ctrl+R to run the queue"))
+	return sb.String()
+}
+
+// viewQueue renders the job dashboard: one row per job with its own
+// spinner, status, progress, elapsed time, and estimated time remaining
+func (m Model) viewQueue() string {
+	var sb strings.Builder
+	done, failed, running := 0, 0, 0
+	for _, job := range m.queue {
+		switch {
+		case job.status == "done":
+			done++
+		case job.status == "failed":
+			failed++
+		case job.active():
+			running++
+		}
+	}
+	sb.WriteString(promptStyle.Render(fmt.Sprintf("Jobs: %d running, %d finished, %d failed, %d waiting",
+		running, done, failed, len(m.queue)-running-done-failed)))
+	sb.WriteString("\n\n")
+	sb.WriteString(promptStyle.Render(fmt.Sprintf("     %-3s %-12s %5s %8s %9s  %s", "#", "STATUS", "PROG", "ELAPSED", "ETA", "PROMPT")))
+	sb.WriteString("\n")
+
+	for i, job := range m.queue {
+		text := job.label(50)
+		if text == "" {
+			text = job.videoID
+		}
+		switch job.status {
+		case "pending":
+			sb.WriteString(promptStyle.Render(fmt.Sprintf("  ·  %-3d %-12s %5s %8s %9s  %s", i+1, "pending", "", "", "", text)))
+		case "retrying":
+			wait := time.Until(job.retryAt).Round(time.Second)
+			sb.WriteString(warningStyle.Render(fmt.Sprintf("  ↻  %-3d %-12s %5s %8s %9s  %s", i+1, "retrying", "", "", max(wait, 0), text)))
+			sb.WriteString("\n")
+			sb.WriteString(promptStyle.Render(fmt.Sprintf("           attempt %d failed: %v", job.attempts, job.err)))
+		case "done":
+			sb.WriteString(successStyle.Render(fmt.Sprintf("  ✓  %-3d ", i+1)))
+			sb.WriteString(infoStyle.Render(fmt.Sprintf("%s → %s", text, job.outputPath)))
+			if job.finishErr != nil {
+				sb.WriteString(" ")
+				sb.WriteString(warningStyle.Render(job.finishErr.Error()))
+			}
+		case "failed":
+			sb.WriteString(errorStyle.Render(fmt.Sprintf("  ✗  %-3d %s: %v", i+1, text, job.err)))
+		default:
+			elapsed := time.Since(job.started).Round(time.Second)
+			eta := "-"
+			if left := job.eta(); left > 0 {
+				eta = left.Round(time.Second).String()
+			}
+			sb.WriteString(fmt.Sprintf("  %s ", job.spinner.View()))
+			sb.WriteString(infoStyle.Render(fmt.Sprintf("%-3d %-12s %4d%% %8s %9s  %s", i+1, job.status, job.progress, elapsed, eta, text)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if m.queueFinished() {
+		sb.WriteString("\n")
+		sb.WriteString(successStyle.Render(fmt.Sprintf("All jobs finished: %d generated, %d failed", done, failed)))
+		sb.WriteString("\n")
+		if path, err := history.DeadLetterPath(); failed > 0 && m.ledger && err == nil {
+			sb.WriteString(promptStyle.Render(fmt.Sprintf("Failed jobs were added to %s; video-gen -f runs them again", path)))
+			sb.WriteString("\n")
+		}
+		sb.WriteString(promptStyle.Render("Press Enter to continue..."))
+	}
+	return sb.String()
+}
+
+// truncate shortens text to at most max runes, marking the cut with an ellipsis
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
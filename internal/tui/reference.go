@@ -0,0 +1,74 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/clipboard"
+)
+
+// referenceFetchedMsg carries the file a reference URL or the clipboard
+// image was saved to, or the error
+type referenceFetchedMsg struct {
+	ref  string
+	path string
+	err  error
+}
+
+// startFetchReference fetches a reference URL, or pastes the clipboard
+// image, while the reference field waits
+func (m Model) startFetchReference(ref string) (tea.Model, tea.Cmd) {
+	m.fetchingReference = ref
+	if clipboard.IsReference(ref) {
+		m.message = "Pasting image from the clipboard..."
+	} else {
+		m.message = "Fetching reference image..."
+	}
+	return m, m.fetchReference(ref)
+}
+
+// fetchReference saves an http(s) or data: URL reference, fetched through the
+// configured proxy, or the clipboard image to the output directory
+func (m Model) fetchReference(ref string) tea.Cmd {
+	dir, proxy := m.outputDir, m.cfg.Proxy
+	return func() tea.Msg {
+		if clipboard.IsReference(ref) {
+			data, err := clipboard.PasteImage()
+			if err != nil {
+				return referenceFetchedMsg{ref: ref, err: err}
+			}
+			path, err := api.SaveReferenceImage(data, dir, "clipboard", "from the clipboard")
+			return referenceFetchedMsg{ref: ref, path: path, err: err}
+		}
+
+		transport, err := api.NewProxyTransport(proxy)
+		if err != nil {
+			return referenceFetchedMsg{ref: ref, err: err}
+		}
+		path, err := api.FetchReference(ref, dir, transport)
+		return referenceFetchedMsg{ref: ref, path: path, err: err}
+	}
+}
+
+// referenceFetched uses a fetched reference and moves on to the duration,
+// unless the reference field was left while it was being fetched
+func (m Model) referenceFetched(msg referenceFetchedMsg) (tea.Model, tea.Cmd) {
+	if m.state != stateReferenceImage || m.fetchingReference != msg.ref {
+		return m, nil
+	}
+	m.fetchingReference = ""
+	if msg.err != nil {
+		m.message = msg.err.Error()
+		return m, nil
+	}
+	m.referenceImg = msg.path
+	return m.referenceChosen(), nil
+}
+
+// referenceChosen moves on from the reference field to the duration
+func (m Model) referenceChosen() Model {
+	m.state = stateDuration
+	m.textInput.SetValue(m.duration)
+	m.textInput.Placeholder = m.duration
+	m.message = ""
+	return m
+}
@@ -0,0 +1,372 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/config"
+	"github.com/telemetry/video-gen/internal/upload"
+)
+
+// notifyOption is a choice on the notification step of the setup, with the
+// on_complete command it saves
+type notifyOption struct {
+	label   string
+	command string
+}
+
+// keyCheckedMsg reports whether the API key that was entered was accepted
+type keyCheckedMsg struct {
+	key string
+	err error
+}
+
+// startSetup begins the first-run setup, which asks for the API key when
+// there is none, the output directory, the default model and size, and
+// whether to be notified of saved videos, then saves the config and starts
+// over with it
+func (m Model) startSetup(opts CLIOptions, needsKey bool) Model {
+	m.opts = opts
+	m.setup = true
+	m.setupSteps = nil
+	if needsKey {
+		m.setupSteps = append(m.setupSteps, stateAPIKey)
+	}
+	m.setupSteps = append(m.setupSteps, stateSetupOutputDir, stateSetupModel, stateSetupSize)
+	if m.notifyOptions = notifyOptions(); len(m.notifyOptions) > 1 {
+		m.setupSteps = append(m.setupSteps, stateSetupNotify)
+	}
+
+	m.outputDir = opts.OutputDir
+	if m.outputDir == "" || upload.IsRemote(m.outputDir) {
+		homeDir, _ := os.UserHomeDir()
+		m.outputDir = filepath.Join(homeDir, "Desktop")
+	}
+	m.model = api.ModelName(opts.Model)
+	if _, ok := api.LookupModel(m.model); !ok {
+		m.model = api.DefaultModel("sora").Name
+	}
+	m.size = api.ResolveSize(opts.Size)
+	return m.setupStep(m.setupSteps[0])
+}
+
+// notifyOptions lists the notification choices: none, and a desktop
+// notification where the platform has a notifier
+func notifyOptions() []notifyOption {
+	options := []notifyOption{{"No notifications", ""}}
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("osascript"); err == nil {
+			options = append(options, notifyOption{"Desktop notification when a video is saved", `osascript -e 'display notification "Video saved" with title "video-gen"'`})
+		}
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err == nil {
+			options = append(options, notifyOption{"Desktop notification when a video is saved", "notify-send 'Video saved' {path}"})
+		}
+	}
+	return options
+}
+
+// setupStep shows a step of the setup with its current answer selected
+func (m Model) setupStep(step state) Model {
+	m.state = step
+	m.message = ""
+	m.setupCreateDir = ""
+	switch step {
+	case stateAPIKey:
+		m.textInput.SetValue("")
+		m.textInput.Placeholder = "sk-..."
+	case stateSetupOutputDir:
+		m.textInput.SetValue(m.outputDir)
+		m.textInput.Placeholder = ""
+		m.textInput.CursorEnd()
+	case stateSetupModel:
+		m.modelSelection = m.modelIndex()
+	case stateSetupSize:
+		m.sizeSelection = indexOf(api.ModelSizes(m.model), m.size)
+	case stateSetupNotify:
+		m.setupSelection = 0
+	}
+	return m
+}
+
+// nextSetupStep moves on from step, saving the config after the last one
+func (m Model) nextSetupStep() (tea.Model, tea.Cmd) {
+	for i, step := range m.setupSteps {
+		if step == m.state && i+1 < len(m.setupSteps) {
+			return m.setupStep(m.setupSteps[i+1]), nil
+		}
+	}
+	return m.finishSetup()
+}
+
+// finishSetup saves the answers and starts over as on any later launch
+func (m Model) finishSetup() (tea.Model, tea.Cmd) {
+	m.cfg.OutputDir = m.outputDir
+	m.cfg.Model = m.model
+	m.cfg.Size = m.size
+	if m.state == stateSetupNotify {
+		m.cfg.OnComplete = m.notifyOptions[m.setupSelection].command
+	}
+	if err := config.Save(m.cfg); err != nil {
+		m.message = fmt.Sprintf("failed to save config: %v", err)
+		return m, nil
+	}
+	next, err := NewModel(m.opts)
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+	return *next, next.Init()
+}
+
+// updateSetup handles keys on the setup steps. Esc goes back a step, or
+// quits on the first one.
+func (m Model) updateSetup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		for i, step := range m.setupSteps {
+			if step == m.state && i > 0 {
+				return m.setupStep(m.setupSteps[i-1]), nil
+			}
+		}
+		return m.quit()
+	case tea.KeyCtrlC:
+		return m.quit()
+	case tea.KeyEnter:
+		return m.submitSetupStep()
+	}
+
+	switch m.state {
+	case stateSetupModel:
+		count := len(api.Models("sora"))
+		switch msg.Type {
+		case tea.KeyUp:
+			m.modelSelection = (m.modelSelection - 1 + count) % count
+		case tea.KeyDown:
+			m.modelSelection = (m.modelSelection + 1) % count
+		}
+	case stateSetupSize:
+		count := len(api.ModelSizes(m.model))
+		switch msg.Type {
+		case tea.KeyUp:
+			m.sizeSelection = (m.sizeSelection - 1 + count) % count
+		case tea.KeyDown:
+			m.sizeSelection = (m.sizeSelection + 1) % count
+		}
+	case stateSetupNotify:
+		count := len(m.notifyOptions)
+		switch msg.Type {
+		case tea.KeyUp:
+			m.setupSelection = (m.setupSelection - 1 + count) % count
+		case tea.KeyDown:
+			m.setupSelection = (m.setupSelection + 1) % count
+		}
+	case stateSetupOutputDir:
+		var cmd tea.Cmd
+		m.setupCreateDir = ""
+		m.textInput, cmd = m.textInput.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// submitSetupStep takes the answer of a setup step other than the API key
+func (m Model) submitSetupStep() (tea.Model, tea.Cmd) {
+	switch m.state {
+	case stateSetupOutputDir:
+		return m.submitSetupOutputDir()
+	case stateSetupModel:
+		m.model = api.Models("sora")[m.modelSelection].Name
+		// The size is re-asked for the model; keep it when the model renders it
+		if !contains(api.ModelSizes(m.model), m.size) {
+			m.size = api.ModelSizes(m.model)[0]
+		}
+	case stateSetupSize:
+		m.size = api.ModelSizes(m.model)[m.sizeSelection]
+	}
+	return m.nextSetupStep()
+}
+
+// submitSetupOutputDir checks the output directory, asking before creating
+// one that does not exist
+func (m Model) submitSetupOutputDir() (tea.Model, tea.Cmd) {
+	value := strings.TrimSpace(m.textInput.Value())
+	if value == "" {
+		m.message = "Enter the directory videos are saved to"
+		return m, nil
+	}
+	if upload.IsRemote(value) {
+		m.message = remoteOutputMessage
+		return m, nil
+	}
+	dir := normalizePath(value)
+	info, err := os.Stat(dir)
+	switch {
+	case err == nil && !info.IsDir():
+		m.message = fmt.Sprintf("%s is a file, not a directory", dir)
+		return m, nil
+	case os.IsNotExist(err):
+		if m.setupCreateDir != dir {
+			m.setupCreateDir = dir
+			m.message = fmt.Sprintf("%s does not exist; press Enter again to create it", dir)
+			return m, nil
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			m.message = fmt.Sprintf("failed to create %s: %v", dir, err)
+			return m, nil
+		}
+	case err != nil:
+		m.message = err.Error()
+		return m, nil
+	}
+	m.outputDir = dir
+	return m.nextSetupStep()
+}
+
+// submitAPIKey checks a key that was entered with a cheap authenticated
+// request before it is saved. A key that could not be checked, say while
+// offline, is used anyway when Enter is pressed again.
+func (m Model) submitAPIKey(key string) (tea.Model, tea.Cmd) {
+	if key == "" {
+		m.message = "API key cannot be empty"
+		return m, nil
+	}
+	if key == m.keyUnchecked {
+		return m.keyChecked(keyCheckedMsg{key: key})
+	}
+	m.checkingKey = true
+	m.message = ""
+	client := api.NewClient(key, false, nil)
+	m.configureClient(client)
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return keyCheckedMsg{key: key, err: client.CheckKey()}
+	})
+}
+
+// keyChecked saves an accepted key and moves on, or asks again with the error
+func (m Model) keyChecked(msg keyCheckedMsg) (tea.Model, tea.Cmd) {
+	if m.state != stateAPIKey || (!m.checkingKey && msg.key != m.keyUnchecked) {
+		return m, nil
+	}
+	m.checkingKey = false
+	m.keyUnchecked = ""
+	if api.IsKeyError(msg.err) {
+		m.message = fmt.Sprintf("The key was not accepted: %v", msg.err)
+		return m, nil
+	}
+	if msg.err != nil {
+		m.keyUnchecked = msg.key
+		m.message = fmt.Sprintf("Could not check the key: %v. Press Enter again to use it anyway", msg.err)
+		return m, nil
+	}
+	m.cfg.SetAPIKey(msg.key)
+	m.debugLog.Redact(msg.key)
+	if m.setup {
+		return m.nextSetupStep()
+	}
+	if err := config.Save(m.cfg); err != nil {
+		m.err = err
+		m.state = stateError
+		return m, nil
+	}
+	next, err := NewModel(m.opts)
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+	return *next, next.Init()
+}
+
+// viewSetup shows a setup step other than the API key
+func (m Model) viewSetup() string {
+	var sb strings.Builder
+	sb.WriteString(m.setupHeader())
+
+	switch m.state {
+	case stateSetupOutputDir:
+		sb.WriteString(promptStyle.Render("Where should videos be saved?"))
+		sb.WriteString("\n")
+		sb.WriteString(m.textInput.View())
+		sb.WriteString("\n")
+
+	case stateSetupModel:
+		sb.WriteString(promptStyle.Render("Default model (use arrow keys):"))
+		sb.WriteString("\n\n")
+		for i, model := range api.Models("sora") {
+			name := fmt.Sprintf("%-12s", model.Name)
+			if m.modelSelection == i {
+				sb.WriteString(successStyle.Render("▶ " + name))
+			} else {
+				sb.WriteString(promptStyle.Render("  " + name))
+			}
+			sb.WriteString(promptStyle.Render(" - " + model.Description))
+			sb.WriteString("\n")
+		}
+
+	case stateSetupSize:
+		sb.WriteString(promptStyle.Render("Default size (use arrow keys):"))
+		sb.WriteString("\n\n")
+		for i, size := range api.ModelSizes(m.model) {
+			if m.sizeSelection == i {
+				sb.WriteString(successStyle.Render("▶ " + fmt.Sprintf("%-9s", size)))
+			} else {
+				sb.WriteString(promptStyle.Render("  " + fmt.Sprintf("%-9s", size)))
+			}
+			sb.WriteString(infoStyle.Render(fmt.Sprintf("   %-15s", strings.Join(api.SizeAliases(size), ", "))))
+			sb.WriteString(promptStyle.Render(" - " + api.SizeLabel(size)))
+			sb.WriteString("\n")
+		}
+
+	case stateSetupNotify:
+		sb.WriteString(promptStyle.Render("Notifications (use arrow keys):"))
+		sb.WriteString("\n\n")
+		for i, option := range m.notifyOptions {
+			if m.setupSelection == i {
+				sb.WriteString(successStyle.Render("▶ " + option.label))
+			} else {
+				sb.WriteString(promptStyle.Render("  " + option.label))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if m.message != "" {
+		sb.WriteString("\n")
+		sb.WriteString(errorStyle.Render(m.message))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render("Press Enter to continue, Esc to go back; everything can be changed later in the config file"))
+	return sb.String()
+}
+
+// setupHeader introduces the setup and numbers its steps
+func (m Model) setupHeader() string {
+	if !m.setup {
+		return ""
+	}
+	step := 1
+	for i, s := range m.setupSteps {
+		if s == m.state {
+			step = i + 1
+		}
+	}
+	return infoStyle.Render(fmt.Sprintf("Welcome to video-gen! First-time setup, step %d of %d", step, len(m.setupSteps))) + "\n\n"
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
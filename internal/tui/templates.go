@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/prompt"
+)
+
+// openTemplates loads templates.toml and the project-local templates and
+// shows the template picker
+func (m Model) openTemplates() (tea.Model, tea.Cmd) {
+	templates, err := m.cfg.Templates()
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+	if len(templates) == 0 {
+		m.message = fmt.Sprintf("No templates found; define them in %s", m.cfg.TemplateSources())
+		return m, nil
+	}
+
+	m.templates = templates
+	m.templateNames = prompt.TemplateNames(templates)
+	if m.templateSelection >= len(m.templateNames) {
+		m.templateSelection = 0
+	}
+	m.promptDraft = m.textInput.Value()
+	m.message = ""
+	m.state = stateTemplateSelect
+	return m, nil
+}
+
+// updateTemplateSelect handles keys in the template picker
+func (m Model) updateTemplateSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		return m.closeTemplates(m.promptDraft), nil
+	case tea.KeyUp, tea.KeyLeft:
+		m.templateSelection = (m.templateSelection - 1 + len(m.templateNames)) % len(m.templateNames)
+	case tea.KeyDown, tea.KeyRight:
+		m.templateSelection = (m.templateSelection + 1) % len(m.templateNames)
+	case tea.KeyEnter:
+		m.templateName = m.templateNames[m.templateSelection]
+		m.templateVars = make(map[string]string)
+		m.templateVarIndex = 0
+		return m.nextTemplateVar()
+	}
+	return m, nil
+}
+
+// updateTemplateVars handles keys while filling in a template's placeholders
+func (m Model) updateTemplateVars(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.state = stateTemplateSelect
+		m.message = ""
+		return m, nil
+	case tea.KeyCtrlU:
+		m.textInput.SetValue("")
+		return m, nil
+	case tea.KeyEnter:
+		placeholders := m.templates[m.templateName].Placeholders()
+		name := placeholders[m.templateVarIndex]
+		value := strings.TrimSpace(m.textInput.Value())
+		if value == "" {
+			m.message = fmt.Sprintf("Enter a value for {%s}", name)
+			return m, nil
+		}
+		m.templateVars[name] = value
+		m.templateVarIndex++
+		return m.nextTemplateVar()
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// nextTemplateVar asks for the next unfilled placeholder, or fills the
+// template into the prompt input once every placeholder has a value
+func (m Model) nextTemplateVar() (tea.Model, tea.Cmd) {
+	tmpl := m.templates[m.templateName]
+	placeholders := tmpl.Placeholders()
+	m.message = ""
+
+	if m.templateVarIndex < len(placeholders) {
+		name := placeholders[m.templateVarIndex]
+		// Pre-fill with the -var value or the template default
+		value, ok := m.vars[name]
+		if !ok {
+			value = tmpl.Defaults[name]
+		}
+		m.state = stateTemplateVars
+		m.textInput.SetValue(value)
+		m.textInput.Placeholder = fmt.Sprintf("Value for {%s}...", name)
+		return m, nil
+	}
+
+	filled, err := tmpl.Fill(m.templateVars)
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+	return m.closeTemplates(filled), nil
+}
+
+// closeTemplates returns to the prompt screen with text in the prompt input
+func (m Model) closeTemplates(text string) Model {
+	m.state = statePrompt
+	m.textInput.SetValue(text)
+	m.textInput.Placeholder = "Describe the video you want to generate..."
+	m.textInput.CursorEnd()
+	return m
+}
+
+// viewTemplateSelect lists the templates with the selected one's prompt
+func (m Model) viewTemplateSelect() string {
+	var sb strings.Builder
+	sb.WriteString(promptStyle.Render("Select a prompt template (use arrow keys):"))
+	sb.WriteString("\n\n")
+	for i, name := range m.templateNames {
+		line := name
+		if description := m.templates[name].Description; description != "" {
+			line += " - " + description
+		}
+		if i == m.templateSelection {
+			sb.WriteString(successStyle.Render("▶ " + line))
+		} else {
+			sb.WriteString(promptStyle.Render("  " + line))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(infoStyle.Render(m.templates[m.templateNames[m.templateSelection]].Prompt))
+	sb.WriteString("\n\n")
+	sb.WriteString(promptStyle.Render("Press Enter to fill in the template, Esc to go back"))
+	return sb.String()
+}
+
+// viewTemplateVars shows the template with the placeholder being filled in
+func (m Model) viewTemplateVars() string {
+	tmpl := m.templates[m.templateName]
+	placeholders := tmpl.Placeholders()
+
+	var sb strings.Builder
+	sb.WriteString(infoStyle.Render(fmt.Sprintf("Template: %s", m.templateName)))
+	sb.WriteString("\n")
+	sb.WriteString(promptStyle.Render(tmpl.Prompt))
+	sb.WriteString("\n\n")
+	sb.WriteString(promptStyle.Render(fmt.Sprintf("{%s} (%d/%d):", placeholders[m.templateVarIndex], m.templateVarIndex+1, len(placeholders))))
+	sb.WriteString("\n")
+	sb.WriteString(m.textInput.View())
+	if m.message != "" {
+		sb.WriteString("\n")
+		sb.WriteString(errorStyle.Render(m.message))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(promptStyle.Render("Press Enter for the next value, Esc to pick another template"))
+	return sb.String()
+}
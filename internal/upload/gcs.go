@@ -0,0 +1,67 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// gcsStore puts objects with the Cloud Storage client library
+type gcsStore struct {
+	bucket *storage.BucketHandle
+	client *storage.Client
+}
+
+// newGCSStore creates a Cloud Storage client. Credentials come from
+// opts.CredentialsFile, or else Google's application default credentials:
+// GOOGLE_APPLICATION_CREDENTIALS, gcloud's login, or the GCP instance.
+func newGCSStore(ctx context.Context, bucket string, opts GCSOptions, transport http.RoundTripper) (*gcsStore, error) {
+	var clientOpts []option.ClientOption
+	if opts.CredentialsFile != "" {
+		clientOpts = append(clientOpts, option.WithCredentialsFile(opts.CredentialsFile))
+	}
+	if opts.Endpoint != "" {
+		clientOpts = append(clientOpts, option.WithEndpoint(opts.Endpoint))
+	}
+	// A configured proxy needs its own transport, with the credentials layered on top
+	if transport != nil {
+		authorized, err := htransport.NewTransport(ctx, transport, append(clientOpts, option.WithScopes(storage.ScopeReadWrite))...)
+		if err != nil {
+			return nil, fmt.Errorf("no Google credentials: set credentials_file under [gcs] or GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+		}
+		clientOpts = append(clientOpts, option.WithHTTPClient(&http.Client{Transport: authorized}))
+	}
+
+	client, err := storage.NewClient(ctx, clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("no Google credentials: set credentials_file under [gcs] or GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+	}
+	return &gcsStore{bucket: client.Bucket(bucket), client: client}, nil
+}
+
+func (s *gcsStore) put(ctx context.Context, key, path, contentType string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, file); err != nil {
+		w.Close()
+		return err
+	}
+	// The object is only stored once the writer closes
+	return w.Close()
+}
+
+func (s *gcsStore) close() error {
+	return s.client.Close()
+}
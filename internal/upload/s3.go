@@ -0,0 +1,83 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Store puts objects with the AWS SDK, which splits large videos into
+// multipart uploads
+type s3Store struct {
+	bucket   string
+	uploader *manager.Uploader
+}
+
+// newS3Store loads the AWS configuration, with opts taking precedence over
+// the SDK's usual sources, and checks that credentials are available
+func newS3Store(ctx context.Context, bucket string, opts S3Options, transport http.RoundTripper) (*s3Store, error) {
+	var load []func(*awsconfig.LoadOptions) error
+	// Keep the SDK's own client, which AWS_CA_BUNDLE configures, and only take the proxy
+	if t, ok := transport.(*http.Transport); ok {
+		load = append(load, awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+			tr.Proxy = t.Proxy
+		})))
+	}
+	if opts.Region != "" {
+		load = append(load, awsconfig.WithRegion(opts.Region))
+	}
+	if opts.Profile != "" {
+		load = append(load, awsconfig.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.AccessKeyID != "" || opts.SecretAccessKey != "" {
+		load = append(load, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.AccessKeyID, opts.SecretAccessKey, opts.SessionToken)))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, load...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("no AWS credentials: set access_key_id and secret_access_key or profile under [s3], or the AWS_* environment variables: %w", err)
+	}
+
+	client3 := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		// S3-compatible stores are addressed path-style
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Store{bucket: bucket, uploader: manager.NewUploader(client3)}, nil
+}
+
+func (s *s3Store) put(ctx context.Context, key, path, contentType string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func (s *s3Store) close() error {
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package upload stores saved videos in an S3 or Google Cloud Storage bucket
+// when -o names one (s3://bucket/prefix/ or gs://bucket/prefix/), for hosts
+// without persistent local disk. Uploads go through the AWS SDK and the Cloud
+// Storage client library, so credentials come from the config or from
+// wherever those SDKs usually find them.
+package upload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Destination is a bucket and key prefix that files are uploaded under
+type Destination struct {
+	Scheme string // "s3" or "gs"
+	Bucket string
+	Prefix string // Key prefix, empty or ending in "/"
+}
+
+// IsRemote reports whether an output directory names a bucket rather than a
+// local directory
+func IsRemote(dir string) bool {
+	return strings.HasPrefix(dir, "s3://") || strings.HasPrefix(dir, "gs://")
+}
+
+// ParseDestination parses an s3://bucket/prefix/ or gs://bucket/prefix/
+// output directory. The prefix is treated as a folder, whether or not it
+// ends in a slash.
+func ParseDestination(dir string) (Destination, error) {
+	scheme, rest, ok := strings.Cut(dir, "://")
+	if !ok || (scheme != "s3" && scheme != "gs") {
+		return Destination{}, fmt.Errorf("invalid output destination %q (use s3://bucket/prefix/ or gs://bucket/prefix/)", dir)
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return Destination{}, fmt.Errorf("invalid output destination %q: missing bucket name", dir)
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return Destination{Scheme: scheme, Bucket: bucket, Prefix: prefix}, nil
+}
+
+// String returns the destination as given to -o, e.g. s3://bucket/prefix/
+func (d Destination) String() string {
+	return fmt.Sprintf("%s://%s/%s", d.Scheme, d.Bucket, d.Prefix)
+}
+
+// URL returns the URL of the object a file named name is uploaded to
+func (d Destination) URL(name string) string {
+	return d.String() + name
+}
+
+// S3Options configures uploads to S3 and S3-compatible stores. Empty fields
+// are left to the AWS SDK's default chain: the AWS_* environment variables,
+// ~/.aws/config and ~/.aws/credentials, then the instance or task role.
+type S3Options struct {
+	Region          string // Bucket region; us-east-1 when the SDK finds none
+	Profile         string // Named profile in the shared AWS config files
+	AccessKeyID     string // Static credentials, used instead of the default chain
+	SecretAccessKey string
+	SessionToken    string // For temporary static credentials
+	Endpoint        string // S3-compatible endpoint (MinIO, R2); objects are then addressed path-style
+}
+
+// GCSOptions configures uploads to Google Cloud Storage. Without a
+// credentials file, Google's application default credentials are used.
+type GCSOptions struct {
+	CredentialsFile string // Service account or authorized user JSON key
+	Endpoint        string // Storage API endpoint, e.g. for an emulator
+}
+
+// Options holds the credentials of both stores; only the destination's is used
+type Options struct {
+	S3  S3Options
+	GCS GCSOptions
+}
+
+// store puts a local file at a key of a bucket
+type store interface {
+	put(ctx context.Context, key, path, contentType string) error
+	close() error
+}
+
+// Uploader uploads files under a destination
+type Uploader struct {
+	dest  Destination
+	store store
+}
+
+// New returns an uploader for dest, failing when no credentials are found.
+// transport carries the requests, nil for the default transport.
+func New(dest Destination, opts Options, transport http.RoundTripper) (*Uploader, error) {
+	ctx := context.Background()
+	u := &Uploader{dest: dest}
+	var err error
+	switch dest.Scheme {
+	case "s3":
+		u.store, err = newS3Store(ctx, dest.Bucket, opts.S3, transport)
+	case "gs":
+		u.store, err = newGCSStore(ctx, dest.Bucket, opts.GCS, transport)
+	default:
+		err = fmt.Errorf("unsupported output destination %q", dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Destination returns where the uploader puts files
+func (u *Uploader) Destination() Destination {
+	return u.dest
+}
+
+// Upload stores the file at path under the destination, named after the
+// file, and returns its URL. The local file is left in place.
+func (u *Uploader) Upload(path string) (string, error) {
+	name := filepath.Base(path)
+	if err := u.store.put(context.Background(), u.dest.Prefix+name, path, contentType(name)); err != nil {
+		return "", fmt.Errorf("failed to upload %s to %s: %w", name, u.dest, err)
+	}
+	return u.dest.URL(name), nil
+}
+
+// Close releases the uploader's connections
+func (u *Uploader) Close() error {
+	return u.store.close()
+}
+
+// contentType returns the MIME type an uploaded file is stored with
+func contentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp4":
+		return "video/mp4"
+	case ".json":
+		return "application/json"
+	case ".webp":
+		return "image/webp"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	}
+	return "application/octet-stream"
+}
+
+// StagingDir returns a new temporary directory to save videos in before they
+// are uploaded
+func StagingDir() (string, error) {
+	dir, err := os.MkdirTemp("", "video-gen-upload-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timestampTolerance rejects deliveries whose signature timestamp is too old or too far ahead
+const timestampTolerance = 5 * time.Minute
+
+// Events nobody is waiting on are kept for a job that starts waiting late,
+// up to receivedTTL and maxReceived, as the project's other jobs send them too
+const (
+	receivedTTL = time.Hour
+	maxReceived = 1000
+)
+
+// Event is a video job event delivered by an OpenAI project webhook
+type Event struct {
+	Type    string // e.g. "video.completed" or "video.failed"
+	VideoID string
+}
+
+// Server receives webhook deliveries and hands them to jobs waiting on a video ID
+type Server struct {
+	secret   []byte
+	listener net.Listener
+	server   *http.Server
+
+	mu       sync.Mutex
+	waiters  map[string]chan Event
+	received map[string]receivedEvent // Events that arrived before anyone waited on them
+}
+
+// receivedEvent is an event kept until someone waits on its video
+type receivedEvent struct {
+	event Event
+	at    time.Time
+}
+
+// Listen starts a webhook receiver on the given port. When secret is set
+// (the "whsec_..." signing secret), deliveries with an invalid signature are
+// rejected. Without one, deliveries cannot be verified, so the receiver only
+// listens on 127.0.0.1, e.g. behind a tunnel that checks them.
+func Listen(port int, secret string) (*Server, error) {
+	s := &Server{
+		waiters:  make(map[string]chan Event),
+		received: make(map[string]receivedEvent),
+	}
+
+	if secret != "" {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook secret: %w", err)
+		}
+		s.secret = key
+	}
+
+	addr := fmt.Sprintf(":%d", port)
+	if s.secret == nil {
+		addr = fmt.Sprintf("127.0.0.1:%d", port)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start webhook listener: %w", err)
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+
+	go s.server.Serve(listener)
+
+	return s, nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the listener
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// Wait returns a channel that receives the next event for a video, and a
+// function that stops waiting, which the caller must call when done
+func (s *Server) Wait(videoID string) (<-chan Event, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan Event, 1)
+	if received, ok := s.received[videoID]; ok {
+		delete(s.received, videoID)
+		ch <- received.event
+		return ch, func() {}
+	}
+	s.waiters[videoID] = ch
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.waiters[videoID] == ch {
+			delete(s.waiters, videoID)
+		}
+	}
+}
+
+// ServeHTTP verifies and dispatches a single webhook delivery
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if s.secret != nil {
+		if err := s.verify(r.Header, body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var payload struct {
+		Type string `json:"type"`
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid event payload", http.StatusBadRequest)
+		return
+	}
+
+	if strings.HasPrefix(payload.Type, "video.") && payload.Data.ID != "" {
+		s.dispatch(Event{Type: payload.Type, VideoID: payload.Data.ID})
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) dispatch(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ch, ok := s.waiters[event.VideoID]; ok {
+		delete(s.waiters, event.VideoID)
+		ch <- event
+		return
+	}
+
+	now := time.Now()
+	var oldest string
+	for id, received := range s.received {
+		if now.Sub(received.at) > receivedTTL {
+			delete(s.received, id)
+		} else if oldest == "" || received.at.Before(s.received[oldest].at) {
+			oldest = id
+		}
+	}
+	if len(s.received) >= maxReceived {
+		delete(s.received, oldest)
+	}
+	s.received[event.VideoID] = receivedEvent{event: event, at: now}
+}
+
+// verify checks a Standard Webhooks signature: an HMAC-SHA256 over
+// "id.timestamp.body", sent as one or more space-separated "v1,<base64>" values
+func (s *Server) verify(header http.Header, body []byte) error {
+	id := header.Get("webhook-id")
+	timestamp := header.Get("webhook-timestamp")
+	signatures := header.Get("webhook-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return errors.New("missing webhook signature headers")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > timestampTolerance || age < -timestampTolerance {
+		return errors.New("webhook timestamp outside tolerance")
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range strings.Fields(signatures) {
+		version, value, ok := strings.Cut(signature, ",")
+		if !ok || version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return errors.New("invalid webhook signature")
+}
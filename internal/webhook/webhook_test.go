@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test signing secret")
+
+// sign returns the Standard Webhooks headers for a delivery signed with secret
+func sign(secret []byte, id string, at time.Time, body string) http.Header {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id + "." + timestamp + "." + body))
+	header := http.Header{}
+	header.Set("webhook-id", id)
+	header.Set("webhook-timestamp", timestamp)
+	header.Set("webhook-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerify(t *testing.T) {
+	const body = `{"type": "video.completed", "data": {"id": "video_1"}}`
+	now := time.Now()
+	s := &Server{secret: testSecret}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   string // Substring of the error; "" for a valid delivery
+	}{
+		{"valid", sign(testSecret, "msg_1", now, body), body, ""},
+		{"valid among several signatures", func() http.Header {
+			h := sign(testSecret, "msg_1", now, body)
+			h.Set("webhook-signature", "v1,c2lnbmF0dXJl v2,other "+h.Get("webhook-signature"))
+			return h
+		}(), body, ""},
+		{"slightly ahead of the clock", sign(testSecret, "msg_1", now.Add(time.Minute), body), body, ""},
+		{"tampered body", sign(testSecret, "msg_1", now, body), strings.Replace(body, "video_1", "video_2", 1), "invalid webhook signature"},
+		{"tampered id", func() http.Header {
+			h := sign(testSecret, "msg_1", now, body)
+			h.Set("webhook-id", "msg_2")
+			return h
+		}(), body, "invalid webhook signature"},
+		{"other secret", sign([]byte("another secret"), "msg_1", now, body), body, "invalid webhook signature"},
+		{"expired", sign(testSecret, "msg_1", now.Add(-6*time.Minute), body), body, "outside tolerance"},
+		{"too far ahead", sign(testSecret, "msg_1", now.Add(6*time.Minute), body), body, "outside tolerance"},
+		{"malformed timestamp", func() http.Header {
+			h := sign(testSecret, "msg_1", now, body)
+			h.Set("webhook-timestamp", "yesterday")
+			return h
+		}(), body, "invalid webhook timestamp"},
+		{"unsigned", http.Header{}, body, "missing webhook signature headers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.verify(tt.header, []byte(tt.body))
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("verify() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("verify() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTPDispatches(t *testing.T) {
+	s := &Server{secret: testSecret, waiters: map[string]chan Event{}, received: map[string]receivedEvent{}}
+	deliver := func(header http.Header, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	waiting, stop := s.Wait("video_1")
+	defer stop()
+	body := `{"type": "video.completed", "data": {"id": "video_1"}}`
+	if code := deliver(sign(testSecret, "msg_1", time.Now(), body), body); code != http.StatusOK {
+		t.Fatalf("signed delivery = %d, want 200", code)
+	}
+	select {
+	case event := <-waiting:
+		if event != (Event{Type: "video.completed", VideoID: "video_1"}) {
+			t.Errorf("event = %+v", event)
+		}
+	default:
+		t.Fatal("no event for the waiting job")
+	}
+
+	// An event that arrives first is kept for the job that waits on it later
+	early := `{"type": "video.failed", "data": {"id": "video_2"}}`
+	deliver(sign(testSecret, "msg_2", time.Now(), early), early)
+	late, stopLate := s.Wait("video_2")
+	defer stopLate()
+	if event := <-late; event.Type != "video.failed" {
+		t.Errorf("late waiter got %+v, want the video.failed event", event)
+	}
+
+	// A forged delivery is refused and reaches no one
+	forged := `{"type": "video.completed", "data": {"id": "video_3"}}`
+	if code := deliver(sign([]byte("forged"), "msg_3", time.Now(), forged), forged); code != http.StatusUnauthorized {
+		t.Errorf("forged delivery = %d, want 401", code)
+	}
+	if _, ok := s.received["video_3"]; ok {
+		t.Error("forged event was kept")
+	}
+}
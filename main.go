@@ -4,63 +4,740 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/telemetry/video-gen/internal/api"
 	"github.com/telemetry/video-gen/internal/cli"
+	"github.com/telemetry/video-gen/internal/prompt"
 	"github.com/telemetry/video-gen/internal/tui"
 )
 
+// version is set at build time by the Makefile
+var version = "dev"
+
+// varFlags collects repeated -var key=value flags
+type varFlags map[string]string
+
+func (v varFlags) String() string {
+	pairs := make([]string, 0, len(v))
+	for key, value := range v {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v varFlags) Set(arg string) error {
+	key, value, err := prompt.ParseVar(arg)
+	if err != nil {
+		return err
+	}
+	v[key] = value
+	return nil
+}
+
 func main() {
-	// CLI flags
-	debug := flag.Bool("d", false, "Enable debug mode (show API requests/responses)")
+	// "help" alone shows the top-level help, which needs the flags below
+	topLevelHelp := len(os.Args) == 2 && os.Args[1] == "help"
+
+	// Subcommands
+	if len(os.Args) > 1 && !topLevelHelp {
+		if run, ok := subcommand(os.Args[1]); ok {
+			run(os.Args[2:])
+			return
+		}
+	}
+
+	// CLI flags; the generation flags are the ones the generate subcommand takes
+	generationOptions := addGenerationFlags(flag.CommandLine)
 	prompt := flag.String("p", "", "Video generation prompt (triggers non-interactive mode)")
-	model := flag.String("m", "", "Model: 'sora' or 'sora-pro'")
-	referenceImage := flag.String("r", "", "Path to reference image")
-	duration := flag.String("t", "", "Duration: 4, 8, or 12 seconds")
-	size := flag.String("s", "", "Size: '1280x720', '720x1280', '1792x1024', or '1024x1792'")
-	outputDir := flag.String("o", "", "Output directory")
+	templateName := flag.String("template", "", "Prompt template from templates.toml, filled with -var values (instead of -p)")
+	autoReview := flag.String("auto-review", "", "Acceptance criteria a vision model checks the video against, regenerating on failure (requires ffmpeg)")
+	reviewAttempts := flag.Int("review-attempts", 3, "Maximum generations when using -auto-review")
+	var batchFile string
+	flag.StringVar(&batchFile, "f", "", "Generate a video for every prompt in a file (text, JSON, or CSV)")
+	flag.StringVar(&batchFile, "batch", "", "Alias for -f")
+	concurrency := flag.Int("concurrency", 1, "Number of batch videos to generate in parallel")
+	count := flag.Int("count", 1, "Number of variations of the prompt to generate at once, saved with _v1.._vN suffixes")
+	remix := flag.String("remix", "", "ID of a completed Sora video to remix with the -p prompt")
+	resume := flag.String("resume", "", "ID of an existing job to poll and download instead of creating a new one")
+	revise := flag.String("revise", "", "ID of a past job whose prompt -p revises, recorded as its next version (see history tree)")
+	jsonOutput := flag.Bool("json", false, "Emit newline-delimited JSON events on stdout (progress moves to stderr)")
 
+	flag.Usage = func() { printUsage(flag.CommandLine.Output(), flag.CommandLine) }
+	if topLevelHelp {
+		printUsage(os.Stdout, flag.CommandLine)
+		return
+	}
 	flag.Parse()
 
-	// If prompt is provided via -p flag, run in non-interactive CLI mode
-	if *prompt != "" {
-		opts := cli.Options{
-			Debug:          *debug,
-			Prompt:         *prompt,
-			Model:          *model,
-			ReferenceImage: *referenceImage,
-			Duration:       *duration,
-			Size:           *size,
-			OutputDir:      *outputDir,
-		}
+	if *remix != "" && *prompt == "" && *templateName == "" {
+		fmt.Fprintf(os.Stderr, "Error: -remix requires a prompt (-p) describing the change\n")
+		os.Exit(2)
+	}
+	if *count < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -count must be at least 1\n")
+		os.Exit(2)
+	}
+
+	opts := generationOptions()
+
+	// If a prompt, template, batch file, or job to resume is provided, run in non-interactive CLI mode
+	if *prompt != "" || *templateName != "" || batchFile != "" || *resume != "" {
+		opts.Prompt = *prompt
+		opts.Template = *templateName
+		opts.AutoReview = *autoReview
+		opts.ReviewAttempts = *reviewAttempts
+		opts.BatchFile = batchFile
+		opts.Concurrency = *concurrency
+		opts.Count = *count
+		opts.RemixID = *remix
+		opts.ResumeID = *resume
+		opts.Revise = *revise
+		opts.JSON = *jsonOutput
 
 		if err := cli.RunNonInteractive(opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			os.Exit(cli.ExitCode(err))
 		}
 		return
 	}
 
 	// Otherwise run interactive TUI mode
-	opts := tui.CLIOptions{
-		Debug:          *debug,
-		Prompt:         *prompt,
-		Model:          *model,
-		ReferenceImage: *referenceImage,
-		Duration:       *duration,
-		Size:           *size,
-		OutputDir:      *outputDir,
+	tuiOpts := tui.CLIOptions{
+		Debug:          opts.Debug,
+		Model:          opts.Model,
+		ReferenceImage: opts.ReferenceImage,
+		Duration:       opts.Duration,
+		Size:           opts.Size,
+		OutputDir:      opts.OutputDir,
+		RecordPath:     opts.RecordPath,
+		ReplayPath:     opts.ReplayPath,
+		Simulate:       opts.Simulate,
+		Force:          opts.Force,
+		Strict:         opts.Strict,
+		Vars:           opts.Vars,
+		Negative:       opts.Negative,
+		Style:          opts.Style,
+		VarsFile:       opts.VarsFile,
+		APIKey:         opts.APIKey,
+		KeepRemote:     opts.KeepRemote,
+		Concurrency:    *concurrency,
+		Count:          *count,
+		OnComplete:     opts.OnComplete,
+		NameTemplate:   opts.NameTemplate,
+		CropAnchor:     opts.CropAnchor,
+		BaseURL:        opts.BaseURL,
+		Organization:   opts.Organization,
+		Project:        opts.Project,
+		Profile:        opts.Profile,
+		ConfigPath:     opts.ConfigPath,
+		DebugLog:       opts.DebugLog,
+		PollInterval:   opts.PollInterval,
+		MaxPolls:       opts.MaxPolls,
+		Timeout:        opts.Timeout,
 	}
 
-	tuiModel, err := tui.NewModel(opts)
+	tuiModel, err := tui.NewModel(tuiOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing: %v\n", err)
 		os.Exit(1)
 	}
 
 	p := tea.NewProgram(tuiModel)
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
+	// Quitting mid-generation leaves the job rendering on the service
+	if final, ok := finalModel.(tui.Model); ok {
+		cli.PrintResumeCommands(final.ResumeCommands())
+	}
+}
+
+// subcommand returns the function that runs the named subcommand
+func subcommand(name string) (func(args []string), bool) {
+	switch name {
+	case "storyboard":
+		return runStoryboard, true
+	case "run":
+		return runWorkflow, true
+	case "compare":
+		return runCompare, true
+	case "history":
+		return runHistory, true
+	case "templates":
+		return runTemplates, true
+	case "cost":
+		return runCost, true
+	case "stats":
+		return runStats, true
+	case "generate":
+		return runGenerate, true
+	case "list":
+		return runList, true
+	case "status":
+		return runStatus, true
+	case "download":
+		return runDownload, true
+	case "delete":
+		return runDelete, true
+	case "remix":
+		return runRemix, true
+	case "mcp":
+		return runMCP, true
+	case "help":
+		return runHelp, true
+	case "man":
+		return runMan, true
+	}
+	return nil, false
+}
+
+// addGenerationFlags registers the flags shared by generation subcommands and
+// returns a function that builds the options once the flags are parsed
+func addGenerationFlags(fs *flag.FlagSet) func() cli.Options {
+	debug := fs.Bool("d", false, "Enable debug mode (show API requests/responses)")
+	models, durations, sizes := soraChoices()
+	model := fs.String("m", "", "Model: "+orList(models, "'"))
+	var referenceImage string
+	fs.StringVar(&referenceImage, "r", "", "Path to reference image or video (mp4/mov), an https:// or data: image URL, or 'clipboard'")
+	fs.StringVar(&referenceImage, "reference", "", "Alias for -r")
+	duration := fs.String("t", "", "Duration: "+orList(durations, "")+" seconds")
+	size := fs.String("s", "", "Size: "+orList(append(sizes, api.SizeMatchReference), "'")+" (closest to the reference image), or an alias: "+strings.Join(api.SizeAliasNames(), ", "))
+	outputDir := fs.String("o", "", "Output directory")
+	recordPath := fs.String("record", "", "Record all API interactions to a session file")
+	replayPath := fs.String("replay", "", "Replay API interactions from a recorded session file (no network)")
+	simulate := fs.Bool("simulate", false, "Run against a built-in fake Sora backend (no API key or network, sample videos)")
+	var enhancePrompt bool
+	fs.BoolVar(&enhancePrompt, "enhance-prompt", false, "Rewrite prompts with a chat model before generation")
+	fs.BoolVar(&enhancePrompt, "enhance", false, "Alias for -enhance-prompt")
+	strict := fs.Bool("strict", false, "Refuse to submit prompts flagged by the pre-flight moderation check")
+	force := fs.Bool("force", false, "Submit jobs past the budget's hard limits")
+	vars := varFlags{}
+	fs.Var(vars, "var", "Prompt template variable as key=value (repeatable)")
+	varsFile := fs.String("vars", "", "TOML file of prompt template variables")
+	refPrompt := fs.String("ref-prompt", "", "Generate the reference image from this text prompt")
+	negative := fs.String("negative", "", "Things to keep out of the video, e.g. 'text, logos'")
+	style := fs.String("style", "", "Style preset: cinematic, product-showcase, retro-vhs, drone-aerial, or a user preset")
+	provider := fs.String("provider", "", "Video provider: 'sora' or 'runway'")
+	fallback := fs.String("fallback", "", "Provider to retry with when the primary provider rejects a job")
+	fallbackModel := fs.String("fallback-model", "", "Model to retry with when the account's quota or tier refuses a job (e.g. 'sora' for sora-pro jobs)")
+	webhookPort := fs.Int("webhook-port", 0, "Listen on this port for OpenAI webhook events instead of polling frequently")
+	apiKey := fs.String("api-key", "", "OpenAI API key (overrides OPENAI_API_KEY and the config file)")
+	baseURL := fs.String("base-url", "", "OpenAI-compatible API base URL (overrides OPENAI_BASE_URL and api_base_url)")
+	org := fs.String("org", "", "OpenAI organization ID to bill (overrides OPENAI_ORG_ID and organization)")
+	project := fs.String("project", "", "OpenAI project ID to bill (overrides OPENAI_PROJECT_ID and project)")
+	profile := fs.String("profile", "", "Config profile to use, e.g. 'work' for [profiles.work]")
+	configPath := fs.String("config", "", "Config file to use instead of the default, e.g. one per setup on a shared machine")
+	debugLog := fs.String("debug-log", "", "Append a JSON log of every API call (credentials redacted) to this file")
+	pollInterval := fs.Duration("poll-interval", 0, "Fixed wait between status checks, e.g. 15s (default: 10s, growing by half per check up to 30s, randomized ±20%)")
+	maxPolls := fs.Int("max-polls", 0, "Status checks before giving up on a job (default 200)")
+	timeout := fs.Duration("timeout", 0, "Give up waiting for a job after this long, e.g. 30m (default: no limit)")
+	thumbnail := fs.Bool("thumbnail", false, "Also download a thumbnail image of each video (Sora only)")
+	spritesheet := fs.Bool("spritesheet", false, "Also download a spritesheet of video frames (Sora only)")
+	nameTemplate := fs.String("name-template", "", "Output filename template, e.g. '{date}_{model}_{prompt:40}_{id}.mp4'")
+	keepRemote := fs.Bool("keep-remote", false, "Keep videos on the service after download (overrides retention in config)")
+	onComplete := fs.String("on-complete", "", "Shell command run after each download, e.g. 'aws s3 cp {path} s3://bucket/'")
+	postProcess := fs.String("postprocess", "", "ffmpeg post-processing, e.g. 'codec=h265,bitrate=4M,watermark=logo.png,format=mov,gif'")
+	cropAnchor := fs.String("crop-anchor", "", "Part of the reference image kept when cropping: center, top, bottom, left, right, a corner, or X,Y (0-1)")
+	continueFrom := fs.String("continue-from", "", "Use the last frame of this local video as the reference image (requires ffmpeg)")
+
+	return func() cli.Options {
+		return cli.Options{
+			Debug:            *debug,
+			Model:            *model,
+			ReferenceImage:   referenceImage,
+			Duration:         *duration,
+			Size:             *size,
+			OutputDir:        *outputDir,
+			RecordPath:       *recordPath,
+			ReplayPath:       *replayPath,
+			Simulate:         *simulate,
+			Force:            *force,
+			EnhancePrompt:    enhancePrompt,
+			Strict:           *strict,
+			Vars:             vars,
+			VarsFile:         *varsFile,
+			RefPrompt:        *refPrompt,
+			Negative:         *negative,
+			Style:            *style,
+			Provider:         *provider,
+			FallbackProvider: *fallback,
+			FallbackModel:    *fallbackModel,
+			WebhookPort:      *webhookPort,
+			APIKey:           *apiKey,
+			Thumbnail:        *thumbnail,
+			Spritesheet:      *spritesheet,
+			NameTemplate:     *nameTemplate,
+			KeepRemote:       *keepRemote,
+			OnComplete:       *onComplete,
+			PostProcess:      *postProcess,
+			ContinueFrom:     *continueFrom,
+			CropAnchor:       *cropAnchor,
+			BaseURL:          *baseURL,
+			Organization:     *org,
+			Project:          *project,
+			Profile:          *profile,
+			ConfigPath:       *configPath,
+			DebugLog:         *debugLog,
+			PollInterval:     *pollInterval,
+			MaxPolls:         *maxPolls,
+			Timeout:          *timeout,
+		}
+	}
+}
+
+// newSubcommandFlags creates a flag set for a subcommand with a usage line
+func newSubcommandFlags(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: video-gen %s\n", usage)
+		if summary := commandSummary(name); summary != "" {
+			fmt.Fprintf(fs.Output(), "\n%s\n", summary)
+		}
+		printFlags(fs.Output(), fs)
+	}
+	return fs
+}
+
+// runStoryboard parses flags for the storyboard subcommand and runs it
+func runStoryboard(args []string) {
+	fs := newSubcommandFlags("storyboard", "storyboard [flags] <script.md | storyboard.yaml>")
+	generationOptions := addGenerationFlags(fs)
+	directive := fs.String("directive", "", "Style directive appended to every scene (e.g. '35mm film, warm palette')")
+	llm := fs.Bool("llm", false, "Split the script into scenes with a chat model instead of by headings/paragraphs")
+	stitch := fs.Bool("stitch", false, "Concatenate the scene clips into a single video (requires ffmpeg)")
+	chain := fs.Bool("chain", false, "Start each scene from the last frame of the previous scene (requires ffmpeg)")
+	concurrency := fs.Int("concurrency", 1, "Number of scenes to generate in parallel")
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := cli.StoryboardOptions{
+		Options:      generationOptions(),
+		ScriptPath:   fs.Arg(0),
+		Directive:    *directive,
+		SplitWithLLM: *llm,
+		Stitch:       *stitch,
+		Chain:        *chain,
+	}
+	opts.Concurrency = *concurrency
+
+	if err := cli.RunStoryboard(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runWorkflow parses flags for the run subcommand and executes a workflow file
+func runWorkflow(args []string) {
+	fs := newSubcommandFlags("run", "run [flags] <workflow.yaml>")
+	generationOptions := addGenerationFlags(fs)
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := cli.WorkflowOptions{
+		Options:      generationOptions(),
+		WorkflowPath: fs.Arg(0),
+	}
+
+	if err := cli.RunWorkflow(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runCompare parses flags for the compare subcommand and generates an A/B comparison
+func runCompare(args []string) {
+	fs := newSubcommandFlags("compare", "compare [flags]")
+	generationOptions := addGenerationFlags(fs)
+	promptText := fs.String("p", "", "Prompt used for both variants")
+	promptA := fs.String("prompt-a", "", "Prompt for variant A")
+	promptB := fs.String("prompt-b", "", "Prompt for variant B")
+	providers := fs.String("providers", "", "Two providers to compare, e.g. 'sora,runway'")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := cli.CompareOptions{
+		Options: generationOptions(),
+		PromptA: *promptA,
+		PromptB: *promptB,
+	}
+	opts.Prompt = *promptText
+	if *providers != "" {
+		for _, name := range strings.Split(*providers, ",") {
+			opts.Providers = append(opts.Providers, strings.TrimSpace(name))
+		}
+	}
+
+	if err := cli.RunCompare(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runHistory parses flags for the history subcommand and lists or re-downloads past jobs
+func runHistory(args []string) {
+	if len(args) > 0 && args[0] == "export" {
+		runHistoryExport(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "tree" {
+		runHistoryTree(args[1:])
+		return
+	}
+
+	fs := newSubcommandFlags("history", "history [flags]")
+	generationOptions := addGenerationFlags(fs)
+	status := fs.String("status", "", "Only list jobs with this status (e.g. downloaded, failed, queued, interrupted)")
+	query := fs.String("q", "", "Only list jobs whose prompt contains this text")
+	limit := fs.Int("n", 20, "Maximum number of jobs to list (0 for all)")
+	download := fs.String("download", "", "ID of a past job to download again")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := cli.HistoryOptions{
+		Options:  generationOptions(),
+		Status:   *status,
+		Query:    *query,
+		Limit:    *limit,
+		Download: *download,
+	}
+
+	if err := cli.RunHistory(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runHistoryExport parses flags for history export and writes the ledger to stdout
+func runHistoryExport(args []string) {
+	fs := newSubcommandFlags("history export", "history export [-format csv|json] [-since 90d]")
+	format := fs.String("format", "csv", "Output format: csv or json")
+	since := fs.String("since", "", "Only export jobs created within this age, e.g. 90d, 12w, or 36h (default: all)")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunHistoryExport(cli.ExportOptions{Format: *format, Since: *since}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runHistoryTree prints the prompt versions and remixes descended from a job
+func runHistoryTree(args []string) {
+	fs := newSubcommandFlags("history tree", "history tree <id>")
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunHistoryTree(fs.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// soraChoices returns what the Sora models take for -m, -t, and -s, from the
+// capability table: the short name of each model, and every duration and size
+func soraChoices() (models, durations, sizes []string) {
+	seen := make(map[string]bool)
+	for _, model := range api.Models("sora") {
+		name := model.Name
+		if len(model.Aliases) > 0 {
+			name = model.Aliases[0]
+		}
+		models = append(models, name)
+		for _, duration := range model.Durations {
+			if !seen[duration] {
+				seen[duration] = true
+				durations = append(durations, duration)
+			}
+		}
+		for _, size := range model.Sizes {
+			if !seen[size] {
+				seen[size] = true
+				sizes = append(sizes, size)
+			}
+		}
+	}
+	return models, durations, sizes
+}
+
+// orList formats flag values as "a, b, or c", each wrapped in quote
+func orList(values []string, quote string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote + value + quote
+	}
+	if len(quoted) < 3 {
+		return strings.Join(quoted, " or ")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
+}
+
+// addClientFlags registers the flags needed to talk to a provider without generating
+func addClientFlags(fs *flag.FlagSet) func() cli.Options {
+	debug := fs.Bool("d", false, "Enable debug mode (show API requests/responses)")
+	recordPath := fs.String("record", "", "Record all API interactions to a session file")
+	replayPath := fs.String("replay", "", "Replay API interactions from a recorded session file (no network)")
+	simulate := fs.Bool("simulate", false, "Run against a built-in fake Sora backend (no API key or network, sample videos)")
+	provider := fs.String("provider", "", "Video provider: 'sora' or 'runway'")
+	apiKey := fs.String("api-key", "", "OpenAI API key (overrides OPENAI_API_KEY and the config file)")
+	baseURL := fs.String("base-url", "", "OpenAI-compatible API base URL (overrides OPENAI_BASE_URL and api_base_url)")
+	org := fs.String("org", "", "OpenAI organization ID to bill (overrides OPENAI_ORG_ID and organization)")
+	project := fs.String("project", "", "OpenAI project ID to bill (overrides OPENAI_PROJECT_ID and project)")
+	profile := fs.String("profile", "", "Config profile to use, e.g. 'work' for [profiles.work]")
+	configPath := fs.String("config", "", "Config file to use instead of the default, e.g. one per setup on a shared machine")
+	debugLog := fs.String("debug-log", "", "Append a JSON log of every API call (credentials redacted) to this file")
+
+	return func() cli.Options {
+		return cli.Options{
+			Debug:        *debug,
+			RecordPath:   *recordPath,
+			ReplayPath:   *replayPath,
+			Simulate:     *simulate,
+			Provider:     *provider,
+			APIKey:       *apiKey,
+			BaseURL:      *baseURL,
+			Organization: *org,
+			Project:      *project,
+			Profile:      *profile,
+			ConfigPath:   *configPath,
+			DebugLog:     *debugLog,
+		}
+	}
+}
+
+// runGenerate parses flags for the generate subcommand, the subcommand form of -p and -f
+func runGenerate(args []string) {
+	fs := newSubcommandFlags("generate", "generate [flags] -p <prompt> | -template <name> | -f <prompts file>")
+	generationOptions := addGenerationFlags(fs)
+	promptText := fs.String("p", "", "Video generation prompt")
+	templateName := fs.String("template", "", "Prompt template from templates.toml, filled with -var values (instead of -p)")
+	var batchFile string
+	fs.StringVar(&batchFile, "f", "", "Generate a video for every prompt in a file (text, JSON, or CSV)")
+	fs.StringVar(&batchFile, "batch", "", "Alias for -f")
+	concurrency := fs.Int("concurrency", 1, "Number of batch videos to generate in parallel")
+	autoReview := fs.String("auto-review", "", "Acceptance criteria a vision model checks the video against, regenerating on failure (requires ffmpeg)")
+	reviewAttempts := fs.Int("review-attempts", 3, "Maximum generations when using -auto-review")
+	jsonOutput := fs.Bool("json", false, "Emit newline-delimited JSON events on stdout (progress moves to stderr)")
+	revise := fs.String("revise", "", "ID of a past job whose prompt -p revises, recorded as its next version (see history tree)")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 || (*promptText == "" && *templateName == "" && batchFile == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := generationOptions()
+	opts.Prompt = *promptText
+	opts.Template = *templateName
+	opts.BatchFile = batchFile
+	opts.Concurrency = *concurrency
+	opts.AutoReview = *autoReview
+	opts.ReviewAttempts = *reviewAttempts
+	opts.JSON = *jsonOutput
+	opts.Revise = *revise
+
+	if err := cli.RunNonInteractive(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runTemplates lists the prompt templates in templates.toml and the project config
+func runTemplates(args []string) {
+	fs := newSubcommandFlags("templates", "templates")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunTemplates(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runCost parses flags for the cost subcommand and reports estimated spend
+func runCost(args []string) {
+	fs := newSubcommandFlags("cost", "cost [-month YYYY-MM | -all]")
+	month := fs.String("month", "", "Month to report as YYYY-MM (default: the current month)")
+	all := fs.Bool("all", false, "Report every recorded job instead of one month")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunCost(cli.CostOptions{Month: *month, All: *all}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runStats parses flags for the stats subcommand and summarizes usage from the ledger
+func runStats(args []string) {
+	fs := newSubcommandFlags("stats", "stats [-since 30d | -all] [-by day|week]")
+	since := fs.String("since", "30d", "Only count jobs created within this age, e.g. 7d, 12w, or 36h")
+	all := fs.Bool("all", false, "Count every recorded job instead of -since")
+	by := fs.String("by", "", "Count jobs per day or week (default: days for up to a month, otherwise weeks)")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunStats(cli.StatsOptions{Since: *since, All: *all, By: *by}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runList parses flags for the list subcommand and prints recent videos
+func runList(args []string) {
+	fs := newSubcommandFlags("list", "list [flags]")
+	clientOptions := addClientFlags(fs)
+	limit := fs.Int("n", 20, "Maximum number of videos to list")
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunList(clientOptions(), *limit); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runStatus parses flags for the status subcommand and reports the request
+// quota and the jobs rendering on the account
+func runStatus(args []string) {
+	fs := newSubcommandFlags("status", "status [flags]")
+	clientOptions := addClientFlags(fs)
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunStatus(clientOptions()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runDownload parses flags for the download subcommand and saves an existing job,
+// waiting for it to finish first if needed
+func runDownload(args []string) {
+	fs := newSubcommandFlags("download", "download [flags] <video-id>")
+	generationOptions := addGenerationFlags(fs)
+	jsonOutput := fs.Bool("json", false, "Emit newline-delimited JSON events on stdout (progress moves to stderr)")
+
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := generationOptions()
+	opts.ResumeID = fs.Arg(0)
+	opts.JSON = *jsonOutput
+
+	if err := cli.RunNonInteractive(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runDelete parses flags for the delete subcommand and removes videos from the service
+func runDelete(args []string) {
+	fs := newSubcommandFlags("delete", "delete [flags] <video-id>...")
+	clientOptions := addClientFlags(fs)
+
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunDelete(clientOptions(), fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runRemix parses flags for the remix subcommand and remixes a completed Sora video
+func runRemix(args []string) {
+	fs := newSubcommandFlags("remix", "remix [flags] -p <change> <video-id>")
+	generationOptions := addGenerationFlags(fs)
+	promptText := fs.String("p", "", "Prompt describing the change")
+	jsonOutput := fs.Bool("json", false, "Emit newline-delimited JSON events on stdout (progress moves to stderr)")
+
+	fs.Parse(args)
+	if fs.NArg() != 1 || *promptText == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := generationOptions()
+	opts.Prompt = *promptText
+	opts.RemixID = fs.Arg(0)
+	opts.JSON = *jsonOutput
+
+	if err := cli.RunNonInteractive(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
+
+// runMCP parses flags for the mcp subcommand and serves video generation to
+// agents over the Model Context Protocol on stdin and stdout
+func runMCP(args []string) {
+	fs := newSubcommandFlags("mcp", "mcp [flags]")
+	generationOptions := addGenerationFlags(fs)
+
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if err := cli.RunMCP(generationOptions(), version); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
 }
@@ -0,0 +1,249 @@
+// Package sora is a Go client for the OpenAI Sora video API, the same client
+// the video-gen binary uses. Every call takes a context, and Client is an
+// interface so programs that use it can substitute a fake in their tests.
+//
+//	client := sora.New(os.Getenv("OPENAI_API_KEY"))
+//	job, err := client.CreateVideo(ctx, sora.CreateVideoRequest{
+//		Prompt:  "A lighthouse on a cliff at dusk",
+//		Model:   "sora-2",
+//		Seconds: "8",
+//		Size:    "1280x720",
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	video, err := sora.Wait(ctx, client, job.ID)
+//	if err != nil {
+//		return err
+//	}
+//	return client.DownloadVideo(ctx, video.ID, "lighthouse.mp4", nil)
+//
+// Create requests are retried on network and server errors, and a 429
+// response pauses every request of the process for as long as the service
+// asks.
+package sora
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/telemetry/video-gen/internal/api"
+	"github.com/telemetry/video-gen/internal/poll"
+)
+
+// DefaultBaseURL is the OpenAI API endpoint used unless WithBaseURL is given
+const DefaultBaseURL = api.DefaultBaseURL
+
+// Preview variants downloadable with DownloadVariant
+const (
+	VariantThumbnail   = api.VariantThumbnail   // Still image (WebP)
+	VariantSpritesheet = api.VariantSpritesheet // Grid of frames (JPEG)
+)
+
+type (
+	// CreateVideoRequest describes a video to generate. InputReference is
+	// the path of a reference image or video, resized and cropped to Size.
+	CreateVideoRequest = api.CreateVideoRequest
+	// CreateVideoResponse is a newly created job
+	CreateVideoResponse = api.CreateVideoResponse
+	// Video is a job's status: queued, in_progress, completed, or failed
+	Video = api.VideoResponse
+	// VideoList is a page of jobs, newest first
+	VideoList = api.ListVideosResponse
+	// ErrorObject is the reason the service gives for a failed job
+	ErrorObject = api.ErrorObject
+	// ModerationResult reports whether a prompt is likely to be rejected
+	ModerationResult = api.ModerationResult
+	// ProgressFunc reports upload or download progress; total is -1 when unknown
+	ProgressFunc = api.ProgressFunc
+	// Doer sends HTTP requests, as *http.Client does
+	Doer = api.Doer
+)
+
+// Client is the Sora video API
+type Client interface {
+	// CreateVideo starts generating a video
+	CreateVideo(ctx context.Context, req CreateVideoRequest) (*CreateVideoResponse, error)
+	// RemixVideo starts a new video from a completed one, changed as prompt describes
+	RemixVideo(ctx context.Context, videoID, prompt string) (*CreateVideoResponse, error)
+	// GetVideo returns the status of a job
+	GetVideo(ctx context.Context, videoID string) (*Video, error)
+	// ListVideos returns up to limit jobs created before the job after, or
+	// the newest ones when after is empty
+	ListVideos(ctx context.Context, limit int, after string) (*VideoList, error)
+	// DownloadVideo saves a completed video to outputPath
+	DownloadVideo(ctx context.Context, videoID, outputPath string, progress ProgressFunc) error
+	// DownloadVariant saves a completed video's thumbnail or spritesheet to outputPath
+	DownloadVariant(ctx context.Context, videoID, variant, outputPath string) error
+	// DeleteVideo deletes a job and its video from the service, cancelling it if it is still rendering
+	DeleteVideo(ctx context.Context, videoID string) error
+	// ModeratePrompt checks a prompt against OpenAI's moderation endpoint
+	ModeratePrompt(ctx context.Context, prompt string) (*ModerationResult, error)
+}
+
+// Option configures the client returned by New
+type Option func(*options)
+
+type options struct {
+	baseURL      string
+	organization string
+	project      string
+	azure        string
+	azureVersion string
+	deployments  map[string]string
+	transport    http.RoundTripper
+	httpClient   Doer
+	debugLog     func(string)
+}
+
+// WithBaseURL points the client at an OpenAI-compatible API such as a gateway
+// proxy, e.g. "https://gateway.example.com/openai/v1"
+func WithBaseURL(url string) Option {
+	return func(o *options) { o.baseURL = url }
+}
+
+// WithOrganization bills requests to an OpenAI organization and project
+// instead of the API key's defaults; either may be empty
+func WithOrganization(organization, project string) Option {
+	return func(o *options) { o.organization, o.project = organization, project }
+}
+
+// WithAzure sends requests to an Azure OpenAI resource, e.g.
+// "https://myresource.openai.azure.com", with model names mapped to the
+// deployment names in deployments. An empty apiVersion uses the default.
+func WithAzure(endpoint, apiVersion string, deployments map[string]string) Option {
+	return func(o *options) { o.azure, o.azureVersion, o.deployments = endpoint, apiVersion, deployments }
+}
+
+// WithTransport replaces the HTTP transport, e.g. to add a proxy. A client
+// passed to WithHTTPClient is copied, not changed.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *options) { o.transport = rt }
+}
+
+// WithHTTPClient sends requests with client instead of an *http.Client with a
+// two-minute timeout, e.g. to serve canned responses in tests. WithTransport
+// has no effect alongside it unless client is an *http.Client.
+func WithHTTPClient(client Doer) Option {
+	return func(o *options) { o.httpClient = client }
+}
+
+// WithDebugLog passes the body of every request and response to log
+func WithDebugLog(log func(string)) Option {
+	return func(o *options) { o.debugLog = log }
+}
+
+// New returns a Client for the API key. Each Client backs off on its own
+// when rate limited, without pausing the program's other clients.
+func New(apiKey string, opts ...Option) Client {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := api.NewClient(apiKey, o.debugLog != nil, o.debugLog)
+	// Clients for different keys must not pause each other on a 429
+	c.Isolate()
+	if o.azure != "" {
+		c.SetAzure(o.azure, o.azureVersion, o.deployments)
+	} else {
+		c.SetBaseURL(o.baseURL)
+	}
+	c.SetOrganization(o.organization, o.project)
+	if o.httpClient != nil {
+		c.SetHTTPClient(o.httpClient)
+	}
+	if o.transport != nil {
+		c.SetTransport(o.transport)
+	}
+	return &client{sora: c}
+}
+
+// client implements Client with the API client of the video-gen binary
+type client struct {
+	sora *api.SoraClient
+}
+
+func (c *client) CreateVideo(ctx context.Context, req CreateVideoRequest) (*CreateVideoResponse, error) {
+	return c.sora.WithContext(ctx).CreateVideo(req)
+}
+
+func (c *client) RemixVideo(ctx context.Context, videoID, prompt string) (*CreateVideoResponse, error) {
+	return c.sora.WithContext(ctx).RemixVideo(videoID, prompt)
+}
+
+func (c *client) GetVideo(ctx context.Context, videoID string) (*Video, error) {
+	return c.sora.WithContext(ctx).GetVideo(videoID)
+}
+
+func (c *client) ListVideos(ctx context.Context, limit int, after string) (*VideoList, error) {
+	return c.sora.WithContext(ctx).ListVideosAfter(limit, after)
+}
+
+func (c *client) DownloadVideo(ctx context.Context, videoID, outputPath string, progress ProgressFunc) error {
+	return c.sora.WithContext(ctx).DownloadVideoContent(videoID, outputPath, progress)
+}
+
+func (c *client) DownloadVariant(ctx context.Context, videoID, variant, outputPath string) error {
+	return c.sora.WithContext(ctx).DownloadVideoVariant(videoID, variant, outputPath)
+}
+
+func (c *client) DeleteVideo(ctx context.Context, videoID string) error {
+	return c.sora.WithContext(ctx).DeleteVideo(videoID)
+}
+
+func (c *client) ModeratePrompt(ctx context.Context, prompt string) (*ModerationResult, error) {
+	return c.sora.WithContext(ctx).ModeratePrompt(prompt)
+}
+
+// Wait polls a job until it completes, backing off from 10s to 30s between
+// checks the way video-gen does, and returns its final status. A job that
+// fails returns an error with the service's reason; use IsContentPolicyError
+// on the returned Video's Error to tell content policy rejections apart.
+// Waiting ends with ctx, or after 200 checks.
+func Wait(ctx context.Context, c Client, videoID string) (*Video, error) {
+	var strategy poll.Strategy
+	started := time.Now()
+	progress := 0
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(strategy.Wait(attempt-1, time.Since(started), progress)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		video, err := c.GetVideo(ctx, videoID)
+		if err != nil {
+			return nil, err
+		}
+		progress = video.Progress
+		if video.Status == "completed" {
+			return video, nil
+		}
+		if err := poll.Failed(video); err != nil {
+			return video, err
+		}
+		if err := strategy.Check(attempt, time.Since(started)); err != nil {
+			return video, err
+		}
+	}
+}
+
+// StatusCode returns the HTTP status of an API error, or 0 when err did not
+// come from an API response
+func StatusCode(err error) int {
+	return api.StatusCode(err)
+}
+
+// IsContentPolicy reports whether an API error is a content policy rejection
+func IsContentPolicy(err error) bool {
+	return api.IsContentPolicy(err)
+}
+
+// IsContentPolicyError reports whether a failed job's error is a content policy rejection
+func IsContentPolicyError(e *ErrorObject) bool {
+	return api.IsContentPolicyError(e)
+}